@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// allBoards grants an access rule every exported board
+	allBoards = "*"
+)
+
+// accessRule authenticates serve requests with basic auth or a bearer token and limits the boards they are shown
+type accessRule struct {
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Token    string `yaml:"token"`
+	// Boards are the board ids or aliases the rule may see, * allows every board
+	Boards []string `yaml:"boards"`
+}
+
+func validateAccessRules(rules []accessRule) error {
+	for i, rule := range rules {
+		if (rule.User == "") == (rule.Token == "") {
+			return errors.Errorf("access rule %d needs either a user and password or a token", i+1)
+		}
+
+		if rule.User != "" && rule.Password == "" {
+			return errors.Errorf("access rule for %s has no password", rule.User)
+		}
+
+		if len(rule.Boards) == 0 {
+			return errors.Errorf("access rule %d allows no boards, use * to allow every board", i+1)
+		}
+	}
+
+	return nil
+}
+
+// authorize returns the access rule matching the request's credentials, nil when none match
+func (c *config) authorize(r *http.Request) *accessRule {
+	user, password, basic := r.BasicAuth()
+	token := ""
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+
+	for i := range c.Access {
+		rule := &c.Access[i]
+		if basic && rule.User != "" && secureEqual(rule.User, user) && secureEqual(rule.Password, password) {
+			return rule
+		}
+
+		if token != "" && rule.Token != "" && secureEqual(rule.Token, token) {
+			return rule
+		}
+	}
+
+	return nil
+}
+
+// allowedBoards resolves the boards of the rule to ids, nil when every board is allowed
+func (c *config) allowedBoards(rule *accessRule) []string {
+	var boardIds []string
+	for _, board := range rule.Boards {
+		if board == allBoards {
+			return nil
+		}
+
+		boardIds = append(boardIds, c.boardID(board))
+	}
+
+	return boardIds
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var (
+	agendaArgs = []cli.Flag{
+		cli.IntFlag{
+			Name:   "days",
+			Usage:  "how many days ahead to include cards due in",
+			EnvVar: "AGENDA_DAYS",
+			Value:  7,
+		},
+	}
+)
+
+// agendaCard is a card due within the agenda along with the board it's on
+type agendaCard struct {
+	card  Card
+	board Board
+	due   time.Time
+	// done is set once the due date was marked complete, the card is checked off
+	done bool
+}
+
+// agendaBoardCard is a card of a board along with whether its due date was marked complete, which go-trello doesn't
+// decode
+type agendaBoardCard struct {
+	trello.Card
+	DueComplete bool `json:"dueComplete"`
+}
+
+// agenda exports the open cards due in the coming days across every board as a checklist grouped by day
+func agenda(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	err = applyProfile(c, cfg)
+	if err != nil {
+		return err
+	}
+
+	clients := newBoardClients(c, cfg)
+	boards, err := getBoards(clients, c.StringSlice("board-id"))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	until := now.AddDate(0, 0, c.Int("days"))
+
+	var due []agendaCard
+	for _, board := range *boards {
+		cards, err := agendaBoardCards(clients, board)
+		if err != nil {
+			return err
+		}
+
+		for _, card := range cards {
+			if card.Closed || card.Due == "" {
+				continue
+			}
+
+			date := parseTrelloDate(card.Due, "card "+card.Name)
+			if date.IsZero() || date.Before(now) || date.After(until) {
+				continue
+			}
+
+			due = append(due, agendaCard{card: newCard(card.Card), board: newBoard(board), due: date, done: card.DueComplete})
+		}
+	}
+
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].due.Before(due[j].due)
+	})
+
+	printDate(os.Stdout, now)
+	fmt.Printf("### %s\n", tr("dueInNextDays", c.Int("days")))
+
+	day := ""
+	for _, item := range due {
+		local := item.due.Local()
+		if local.Format(dateFormat) != day {
+			day = local.Format(dateFormat)
+			fmt.Printf("#### %s\n", local.Format("Monday "+dateFormat))
+		}
+
+		check := " "
+		if item.done {
+			check = "x"
+		}
+
+		fmt.Printf("- [%s] %s [%s](%s) - %s\n", check, local.Format("15:04"), item.card.Name, item.card.Url, item.board.Name)
+	}
+
+	return nil
+}
+
+// agendaBoardCards returns the open cards of the board
+func agendaBoardCards(clients *boardClients, board trello.Board) ([]agendaBoardCard, error) {
+	client, err := clients.forBoard(board.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := client.Get("/boards/" + board.Id + "/cards")
+	if err != nil {
+		return nil, err
+	}
+
+	var cards []agendaBoardCard
+	err = json.Unmarshal(body, &cards)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to list the cards of %s", board.Name)
+	}
+
+	return cards, nil
+}
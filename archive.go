@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	archiveDocument = "board.md"
+	// archivedCardPrefix starts the heading of every card in the full fidelity layout
+	archivedCardPrefix = "#### ["
+)
+
+var (
+	archiveBoardArgs = []cli.Flag{
+		cli.StringFlag{
+			Name:   "archive-dir",
+			Usage:  "the directory the dated archive directory is created in",
+			EnvVar: "TRELLO2MD_ARCHIVE_DIR",
+			Value:  ".",
+		},
+		cli.BoolFlag{
+			Name:  "skip-attachments",
+			Usage: "archive the markdown without downloading the files uploaded to cards",
+		},
+		cli.IntFlag{
+			Name:   "download-concurrency",
+			Usage:  "the number of attachments downloaded in parallel, separately from --concurrency boards being fetched",
+			EnvVar: "TRELLO2MD_DOWNLOAD_CONCURRENCY",
+			Value:  4,
+		},
+		cli.StringFlag{
+			Name:   "bandwidth-limit",
+			Usage:  "the most bytes a second attachments are downloaded at between them, with an optional K, M or G suffix such as 2M, 0 for no limit",
+			EnvVar: "TRELLO2MD_BANDWIDTH_LIMIT",
+			Value:  "0",
+		},
+		cli.BoolFlag{
+			Name:  "close",
+			Usage: "close the board once its archive has been written and verified",
+		},
+	}
+)
+
+// archiveBoard exports everything on a board along with its uploaded attachments into a dated directory, checks
+// every open card made it into the archive and optionally closes the board
+func archiveBoard(c *cli.Context) error {
+	if len(c.StringSlice("board-id")) != 1 {
+		return errors.New("archive-board archives a single --board-id")
+	}
+
+	bandwidth, err := parseByteSize(c.String("bandwidth-limit"))
+	if err != nil {
+		return errors.Wrap(err, "invalid --bandwidth-limit")
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	clients := newBoardClients(c, cfg)
+	boards, err := getBoards(clients, c.StringSlice("board-id"))
+	if err != nil {
+		return err
+	}
+	board := (*boards)[0]
+
+	client, err := clients.forBoard(board.Id)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(c.String("archive-dir"), time.Now().Format(dateFormat)+"-"+slugify(board.Name))
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return errors.Wrap(err, "unable to create archive directory")
+	}
+
+	document := filepath.Join(dir, archiveDocument)
+	for name, value := range map[string]string{"fidelity": fidelityFull, "all-lists": "true", "output": document} {
+		err = c.Set(name, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = export(c, ioutil.Discard, exportOptions{})
+	if err != nil {
+		return err
+	}
+
+	body, err := client.Get("/boards/" + board.Id + "/cards")
+	if err != nil {
+		return errors.Wrap(err, "unable to list the cards of the board")
+	}
+
+	var cards []trello.Card
+	err = json.Unmarshal(body, &cards)
+	if err != nil {
+		return errors.Wrap(err, "unable to list the cards of the board")
+	}
+
+	archived, err := countArchivedCards(document)
+	if err != nil {
+		return err
+	}
+
+	if archived != len(cards) {
+		return errors.Errorf("the archive of %s holds %d cards but the board has %d open cards, the board was left open", board.Name, archived, len(cards))
+	}
+
+	if !c.Bool("skip-attachments") {
+		creds := clients.credentials(board.Id)
+		transport, err := newHTTPTransport(c, nil)
+		if err != nil {
+			return err
+		}
+
+		downloader := newAttachmentDownloader(newHTTPClient(c, transport), c.Int("download-concurrency"), bandwidth)
+		for _, card := range cards {
+			err = archiveAttachments(downloader, client, creds, &card, filepath.Join(dir, "attachments", card.ShortLink))
+			if err != nil {
+				downloader.wait()
+				return err
+			}
+		}
+
+		err = downloader.wait()
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Printf("archived %d cards of %s to %s", archived, board.Name, dir)
+
+	if !c.Bool("close") {
+		return nil
+	}
+
+	_, err = client.Put("/boards/"+board.Id, url.Values{"closed": {"true"}})
+	if err != nil {
+		return errors.Wrapf(err, "unable to close %s", board.Name)
+	}
+
+	log.Printf("closed %s", board.Name)
+
+	return nil
+}
+
+// countArchivedCards counts the card headings in the archived document
+func countArchivedCards(path string) (int, error) {
+	data, err := readOutput(path)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to read the archive")
+	}
+
+	count := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), archivedCardPrefix) {
+			count++
+		}
+	}
+
+	return count, scanner.Err()
+}
+
+// archiveAttachments starts downloading the files uploaded to a card, links to other sites are already in the
+// document
+func archiveAttachments(downloader *attachmentDownloader, client *trello.Client, creds credentials, card *trello.Card, dir string) error {
+	attachments, err := getCardAttachments(client, card)
+	if err != nil {
+		return err
+	}
+
+	for _, attachment := range *attachments {
+		if !attachment.IsUpload {
+			continue
+		}
+
+		err = os.MkdirAll(dir, 0755)
+		if err != nil {
+			return errors.Wrap(err, "unable to create attachment directory")
+		}
+
+		downloader.start(creds, card.Name, attachment, filepath.Join(dir, slugifyPath(attachment.Id+"-"+attachment.Name, slugStyleUnicode)))
+	}
+
+	return nil
+}
+
+// getAttachment requests an attachment from offset bytes in, trello only serves uploads to requests signed with the
+// key and token which are never sent to other hosts, such as the public bucket older uploads are stored in
+func getAttachment(httpClient *http.Client, creds credentials, rawURL string, offset int64) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	if host := req.URL.Hostname(); host == "trello.com" || strings.HasSuffix(host, ".trello.com") {
+		req.Header.Set("Authorization", fmt.Sprintf(`OAuth oauth_consumer_key="%s", oauth_token="%s"`, creds.Key, creds.Token))
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// servers which ignore the range send the whole attachment again
+	if resp.StatusCode != http.StatusOK && (offset == 0 || resp.StatusCode != http.StatusPartialContent) {
+		resp.Body.Close()
+		return nil, errors.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return resp, nil
+}
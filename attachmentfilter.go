@@ -0,0 +1,106 @@
+package main
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	attachmentSortTrello = "trello"
+	attachmentSortNewest = "newest"
+	attachmentSortOldest = "oldest"
+)
+
+// attachmentFilter orders the attachments of each card and picks which of them are rendered, so cards with dozens
+// of screenshots stay readable
+type attachmentFilter struct {
+	sort       string
+	imagesOnly bool
+	// extensions are the lower case extensions kept without their dot, every extension is kept when empty
+	extensions []string
+	// max is the most attachments rendered per card, zero renders them all
+	max int
+}
+
+// newAttachmentFilter reads the attachment flags, it's nil when they leave attachments as trello returns them
+func newAttachmentFilter(c *cli.Context) (*attachmentFilter, error) {
+	filter := &attachmentFilter{
+		sort:       c.String("attachment-sort"),
+		imagesOnly: c.Bool("images-only"),
+		max:        c.Int("max-attachments"),
+	}
+
+	if filter.sort != attachmentSortTrello && filter.sort != attachmentSortNewest && filter.sort != attachmentSortOldest {
+		return nil, errors.Errorf("unsupported attachment sort %q, expected trello, newest or oldest", filter.sort)
+	}
+
+	if filter.max < 0 {
+		return nil, errors.New("--max-attachments can't be negative")
+	}
+
+	for _, extension := range c.StringSlice("attachment-extension") {
+		filter.extensions = append(filter.extensions, strings.ToLower(strings.TrimPrefix(extension, ".")))
+	}
+
+	if filter.sort == attachmentSortTrello && !filter.imagesOnly && len(filter.extensions) == 0 && filter.max == 0 {
+		return nil, nil
+	}
+
+	return filter, nil
+}
+
+// apply returns the attachments to render in the order they're rendered
+func (f *attachmentFilter) apply(attachments []Attachment) []Attachment {
+	var kept []Attachment
+	for _, attachment := range attachments {
+		if f.imagesOnly && !isImageAttachment(attachment) {
+			continue
+		}
+
+		if len(f.extensions) > 0 && !containsString(f.extensions, attachmentExtension(attachment)) {
+			continue
+		}
+
+		kept = append(kept, attachment)
+	}
+
+	// trello dates are RFC3339 in utc so they sort as strings
+	switch f.sort {
+	case attachmentSortNewest:
+		sort.SliceStable(kept, func(i, j int) bool {
+			return kept[i].Date > kept[j].Date
+		})
+	case attachmentSortOldest:
+		sort.SliceStable(kept, func(i, j int) bool {
+			return kept[i].Date < kept[j].Date
+		})
+	}
+
+	if f.max > 0 && len(kept) > f.max {
+		kept = kept[:f.max]
+	}
+
+	return kept
+}
+
+// attachmentExtension is the lower case extension of the attachment's name, or of its url when the name has none
+func attachmentExtension(attachment Attachment) string {
+	extension := path.Ext(attachment.Name)
+	if extension == "" {
+		extension = path.Ext(strings.SplitN(attachment.Url, "?", 2)[0])
+	}
+
+	return strings.ToLower(strings.TrimPrefix(extension, "."))
+}
+
+func isImageAttachment(attachment Attachment) bool {
+	if attachment.MimeType != "" {
+		return strings.HasPrefix(attachment.MimeType, "image/")
+	}
+
+	return containsString([]string{"png", "jpg", "jpeg", "gif", "webp", "svg", "bmp"}, attachmentExtension(attachment))
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+)
+
+const (
+	// downloadingExtension is appended to attachments while they download, an interrupted download is resumed from it
+	downloadingExtension = ".part"
+)
+
+var (
+	// md5ETag matches the etag of an object uploaded to s3 in one part, which is the md5 of its content
+	md5ETag = regexp.MustCompile(`^"?([0-9a-f]{32})"?$`)
+
+	byteSizeUnits = map[string]int64{"": 1, "K": 1 << 10, "M": 1 << 20, "G": 1 << 30}
+)
+
+// parseByteSize reads a number of bytes with an optional K, M or G suffix such as 512K or 2M
+func parseByteSize(size string) (int64, error) {
+	number := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(size)), "B")
+	unit := strings.TrimLeft(number, "0123456789")
+	multiplier, ok := byteSizeUnits[unit]
+	n, err := strconv.ParseInt(strings.TrimSuffix(number, unit), 10, 64)
+	if !ok || err != nil || n < 0 {
+		return 0, errors.Errorf("invalid size %q, expected a number of bytes with an optional K, M or G suffix", size)
+	}
+
+	return n * multiplier, nil
+}
+
+// bandwidthLimiter spaces out reads so the downloads sharing it stay within rate bytes a second between them
+type bandwidthLimiter struct {
+	mu   sync.Mutex
+	rate int64
+	next time.Time
+}
+
+func (l *bandwidthLimiter) wait(n int) {
+	l.mu.Lock()
+	now := time.Now()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(time.Duration(int64(n) * int64(time.Second) / l.rate))
+	l.mu.Unlock()
+
+	time.Sleep(start.Sub(now))
+}
+
+// limitedReader reads at most a tenth of a second's worth of bytes at a time so the limit holds for slow and fast
+// connections alike
+type limitedReader struct {
+	r       io.Reader
+	limiter *bandwidthLimiter
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if max := int(r.limiter.rate/10) + 1; len(p) > max {
+		p = p[:max]
+	}
+
+	n, err := r.r.Read(p)
+	r.limiter.wait(n)
+
+	return n, err
+}
+
+// attachmentDownloader saves uploaded attachments with a pool of workers separate from the boards being fetched,
+// resuming downloads an interrupted run left behind, checking each against the size trello recorded and the md5 the
+// server reports where it does, and linking files identical to one already saved rather than storing them again
+type attachmentDownloader struct {
+	httpClient *http.Client
+	// limiter caps the bandwidth of every worker together, nil leaves downloads uncapped
+	limiter *bandwidthLimiter
+
+	wg  sync.WaitGroup
+	sem chan struct{}
+
+	mu    sync.Mutex
+	saved map[[sha256.Size]byte]string
+	err   error
+}
+
+// newAttachmentDownloader downloads at most concurrency attachments at a time within bandwidth bytes a second,
+// 0 leaves the bandwidth uncapped
+func newAttachmentDownloader(httpClient *http.Client, concurrency int, bandwidth int64) *attachmentDownloader {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	d := &attachmentDownloader{
+		httpClient: httpClient,
+		sem:        make(chan struct{}, concurrency),
+		saved:      map[[sha256.Size]byte]string{},
+	}
+	if bandwidth > 0 {
+		d.limiter = &bandwidthLimiter{rate: bandwidth}
+	}
+
+	return d
+}
+
+// start downloads the attachment of the card once a worker is free, errors are returned by wait
+func (d *attachmentDownloader) start(creds credentials, cardName string, attachment trello.Attachment, path string) {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		d.sem <- struct{}{}
+		defer func() { <-d.sem }()
+
+		err := d.download(creds, attachment, path)
+		if err != nil {
+			d.mu.Lock()
+			if d.err == nil {
+				d.err = errors.Wrapf(err, "unable to download %s from %s", attachment.Name, cardName)
+			}
+			d.mu.Unlock()
+		}
+	}()
+}
+
+// wait returns once every started download has finished along with the first which failed
+func (d *attachmentDownloader) wait() error {
+	d.wg.Wait()
+
+	return d.err
+}
+
+// download saves the attachment to path, encrypted when --encrypt-to is set, an attachment already saved by an
+// earlier run is kept
+func (d *attachmentDownloader) download(creds credentials, attachment trello.Attachment, path string) error {
+	final := path
+	if encryption != nil {
+		final += encryption.tool.extension
+	}
+
+	// encrypted attachments can't be checked without decrypting them so any already saved is kept
+	if info, err := os.Stat(final); err == nil && encryption != nil {
+		return nil
+	} else if err == nil && (attachment.Bytes == 0 || info.Size() == int64(attachment.Bytes)) {
+		sum, _, err := hashFile(final)
+		if err != nil {
+			return err
+		}
+		d.remember(sum, final)
+
+		return nil
+	}
+
+	partial := path + downloadingExtension
+	etag, err := d.fetch(creds, attachment, partial)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(partial)
+	if err != nil {
+		return err
+	}
+
+	if attachment.Bytes > 0 && info.Size() != int64(attachment.Bytes) {
+		os.Remove(partial)
+		return errors.Errorf("downloaded %d bytes but trello recorded %d", info.Size(), attachment.Bytes)
+	}
+
+	sum, md5Sum, err := hashFile(partial)
+	if err != nil {
+		return err
+	}
+
+	if m := md5ETag.FindStringSubmatch(etag); m != nil && m[1] != md5Sum {
+		os.Remove(partial)
+		return errors.Errorf("downloaded content has md5 %s but the server reported %s", md5Sum, m[1])
+	}
+
+	if existing, ok := d.savedAs(sum); ok {
+		os.Remove(final)
+		err = os.Link(existing, final)
+		if err == nil {
+			log.Printf("%s is identical to %s, linked it instead", final, existing)
+			return os.Remove(partial)
+		}
+	}
+
+	if encryption != nil {
+		err = encryptFile(partial, final)
+	} else {
+		err = os.Rename(partial, final)
+	}
+	if err != nil {
+		return err
+	}
+	d.remember(sum, final)
+
+	return nil
+}
+
+// fetch downloads the attachment into partial, carrying on from where an earlier download stopped when the server
+// supports ranges, and returns the etag the server sent
+func (d *attachmentDownloader) fetch(creds credentials, attachment trello.Attachment, partial string) (string, error) {
+	var offset int64
+	if info, err := os.Stat(partial); err == nil {
+		offset = info.Size()
+	}
+
+	if offset > 0 && offset == int64(attachment.Bytes) {
+		return "", nil
+	}
+
+	resp, err := getAttachment(d.httpClient, creds, attachment.Url, offset)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resp.StatusCode == http.StatusPartialContent {
+		log.Printf("resuming %s from %d bytes", attachment.Name, offset)
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(partial, flags, 0644)
+	if err != nil {
+		return "", err
+	}
+
+	var body io.Reader = resp.Body
+	if d.limiter != nil {
+		body = &limitedReader{r: resp.Body, limiter: d.limiter}
+	}
+
+	_, err = io.Copy(file, body)
+	if err != nil {
+		file.Close()
+		return "", err
+	}
+
+	return strings.ToLower(resp.Header.Get("ETag")), file.Close()
+}
+
+func (d *attachmentDownloader) savedAs(sum [sha256.Size]byte) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	path, ok := d.saved[sum]
+
+	return path, ok
+}
+
+func (d *attachmentDownloader) remember(sum [sha256.Size]byte, path string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.saved[sum]; !ok {
+		d.saved[sum] = path
+	}
+}
+
+// hashFile returns the sha256 files are deduplicated by and the hex md5 servers report as etags
+func hashFile(path string) ([sha256.Size]byte, string, error) {
+	var sum [sha256.Size]byte
+	file, err := os.Open(path)
+	if err != nil {
+		return sum, "", err
+	}
+	defer file.Close()
+
+	sha, md := sha256.New(), md5.New()
+	_, err = io.Copy(io.MultiWriter(sha, md), file)
+	if err != nil {
+		return sum, "", err
+	}
+	copy(sum[:], sha.Sum(nil))
+
+	return sum, hex.EncodeToString(md.Sum(nil)), nil
+}
+
+// encryptFile encrypts the downloaded attachment into path and removes the download
+func encryptFile(downloaded string, path string) error {
+	in, err := os.Open(downloaded)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		in.Close()
+		return err
+	}
+
+	err = encryption.encrypt(in, out)
+	in.Close()
+	if err != nil {
+		out.Close()
+		os.Remove(path)
+		return err
+	}
+
+	err = out.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(downloaded)
+}
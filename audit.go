@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+)
+
+const (
+	// cardCreationFilter are the actions which create a card, their member is the card's creator
+	cardCreationFilter = "createCard,copyCard,convertToCardFromCheckItem"
+)
+
+// boardAudit is who can see a board and the enterprise owning it, for compliance archives
+type boardAudit struct {
+	// Visibility is the board's permission level, private, org, enterprise or public
+	Visibility string `json:"visibility"`
+	// IdEnterprise is the trello enterprise the board belongs to, empty outside of enterprise workspaces
+	IdEnterprise string `json:"idEnterprise,omitempty"`
+	// EnterpriseOwned is set when the board is owned by the enterprise rather than its members
+	EnterpriseOwned bool `json:"enterpriseOwned"`
+}
+
+// cardCreator is when and by whom a card was created
+type cardCreator struct {
+	Date string
+	// By is the full name of the member who created the card, empty when trello no longer has the action creating it
+	By string
+	// username is the trello username of the member who created the card
+	username string
+}
+
+// getBoardAudit fetches the board's visibility and enterprise, which the board client leaves out
+func getBoardAudit(client *trello.Client, boardId string) (*boardAudit, error) {
+	var board struct {
+		Prefs struct {
+			PermissionLevel string `json:"permissionLevel"`
+		} `json:"prefs"`
+		IdEnterprise    string `json:"idEnterprise"`
+		EnterpriseOwned bool   `json:"enterpriseOwned"`
+	}
+	err := getJSON(client, "/boards/"+boardId+"?fields=prefs,idEnterprise,enterpriseOwned", &board)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to fetch the audit fields of board %s", boardId)
+	}
+
+	return &boardAudit{
+		Visibility:      board.Prefs.PermissionLevel,
+		IdEnterprise:    board.IdEnterprise,
+		EnterpriseOwned: board.EnterpriseOwned,
+	}, nil
+}
+
+// getCardCreator finds the action which created the card
+func getCardCreator(client *trello.Client, card *trello.Card) (*cardCreator, error) {
+	body, err := client.Get("/cards/" + card.Id + "/actions?filter=" + cardCreationFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []cardUpdateAction
+	err = json.Unmarshal(body, &actions)
+	if err != nil {
+		return nil, err
+	}
+
+	return cardCreatorOf(card, actions), nil
+}
+
+// cardCreatorOf finds the action creating the card among its actions, ordered newest first. when trello no longer
+// has it only the date the card was created is known, read from its id, and nil is returned when that isn't either
+func cardCreatorOf(card *trello.Card, actions []cardUpdateAction) *cardCreator {
+	for i := len(actions) - 1; i >= 0; i-- {
+		action := actions[i]
+		if action.Type == "createCard" || action.Type == "copyCard" || action.Type == "convertToCardFromCheckItem" {
+			return &cardCreator{Date: action.Date, By: action.MemberCreator.FullName, username: action.MemberCreator.Username}
+		}
+	}
+
+	created, err := cardCreated(card)
+	if err != nil {
+		return nil
+	}
+
+	return &cardCreator{Date: created.UTC().Format(time.RFC3339)}
+}
+
+// printBoardAudit writes who can see the board and the enterprise owning it under the board's heading
+func printBoardAudit(w io.Writer, audit *boardAudit) {
+	var fields []string
+	if audit.Visibility != "" {
+		fields = append(fields, tr("visibility", audit.Visibility))
+	}
+
+	if audit.IdEnterprise != "" {
+		if audit.EnterpriseOwned {
+			fields = append(fields, tr("enterpriseOwned", audit.IdEnterprise))
+		} else {
+			fields = append(fields, tr("enterprise", audit.IdEnterprise))
+		}
+	}
+
+	if len(fields) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "_%s_\n\n", strings.Join(fields, ", "))
+}
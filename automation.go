@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var (
+	exportAutomationArgs = []cli.Flag{
+		cli.StringSliceFlag{
+			Name:   "board-id",
+			Usage:  "the ids or config aliases of the boards to export the automation of",
+			EnvVar: "BOARD_ID",
+		},
+		cli.StringFlag{
+			Name:   "output",
+			Usage:  "the file to write the automation to instead of stdout",
+			EnvVar: "TRELLO2MD_AUTOMATION_OUTPUT",
+		},
+	}
+)
+
+// boardPlugin is a power-up enabled on a board
+type boardPlugin struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// exportAutomation renders the rules, buttons and scheduled commands power-ups such as butler keep on each board,
+// for handing a board over to another team. trello has no api for the automation built into boards so only the
+// commands power-ups store in the board's plugin data can be exported
+func exportAutomation(c *cli.Context) error {
+	if len(c.StringSlice("board-id")) == 0 {
+		return errors.New("export-automation needs at least one --board-id")
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	clients := newBoardClients(c, cfg)
+	boards, err := getBoards(clients, c.StringSlice("board-id"))
+	if err != nil {
+		return err
+	}
+
+	var automation bytes.Buffer
+	printDate(&automation, time.Now())
+
+	for _, board := range *boards {
+		client, err := clients.forBoard(board.Id)
+		if err != nil {
+			return err
+		}
+
+		err = printBoardAutomation(&automation, client, board)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.String("output") != "" {
+		return writeFileIfChanged(c.String("output"), automation.Bytes())
+	}
+
+	_, err = os.Stdout.Write(automation.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "unable to write the automation")
+	}
+
+	return nil
+}
+
+// printBoardAutomation writes the data each power-up keeps on the board under the power-up's name
+func printBoardAutomation(w io.Writer, client *trello.Client, board trello.Board) error {
+	var plugins []boardPlugin
+	err := getJSON(client, "/boards/"+board.Id+"/plugins?filter=enabled", &plugins)
+	if err != nil {
+		return errors.Wrapf(err, "unable to list the power-ups of %s", board.Name)
+	}
+
+	names := map[string]string{}
+	for _, plugin := range plugins {
+		names[plugin.Id] = plugin.Name
+	}
+
+	data, err := getPluginData(client, "/boards/"+board.Id)
+	if err != nil {
+		return err
+	}
+
+	printBoard(w, newBoard(board))
+
+	if len(data) == 0 {
+		fmt.Fprintf(w, "_%s_\n\n", tr("noAutomation"))
+		return nil
+	}
+
+	for _, value := range data {
+		name := names[value.IdPlugin]
+		if name == "" {
+			name = value.IdPlugin
+		}
+
+		fmt.Fprintf(w, "#### %s\n", name)
+		fmt.Fprintln(w, "```json")
+		fmt.Fprintln(w, value.Value)
+		fmt.Fprint(w, "```\n\n")
+	}
+
+	return nil
+}
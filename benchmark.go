@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var (
+	benchmarkArgs = []cli.Flag{
+		cli.IntFlag{
+			Name:   "runs",
+			Usage:  "how many times the fixtures are exported, the first run warms up and isn't counted",
+			EnvVar: "TRELLO2MD_BENCHMARK_RUNS",
+			Value:  10,
+		},
+	}
+)
+
+// benchmarkRun is what one export of the fixtures took
+type benchmarkRun struct {
+	duration    time.Duration
+	allocated   uint64
+	allocations uint64
+	bytes       int
+}
+
+// benchmark exports trello json exports recorded with --from-trello-json repeatedly and reports how long each export
+// took and what it allocated, so changes to the fetch and render pipeline can be compared without trello's latency
+// getting in the way. combine it with --cpuprofile or --memprofile to see where the time goes, go test -bench replays
+// the fixtures in testdata recorded with the record command through the fetch pipeline as well
+func benchmark(c *cli.Context) error {
+	if len(c.StringSlice("from-trello-json")) == 0 {
+		return errors.New("benchmark replays recorded boards so runs are comparable, pass trello json exports with --from-trello-json")
+	}
+
+	if c.String("output") != "" {
+		return errors.New("benchmark discards the document it renders and can't be combined with --output")
+	}
+
+	runs := c.Int("runs")
+	if runs < 1 {
+		return errors.Errorf("--runs must be at least 1, got %d", runs)
+	}
+
+	// the first run loads the templates and fills the caches, which only happens once in a real export
+	err := export(c, ioutil.Discard, exportOptions{})
+	if err != nil {
+		return err
+	}
+
+	var results []benchmarkRun
+	for i := 0; i < runs; i++ {
+		var document bytes.Buffer
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+
+		err = export(c, &document, exportOptions{})
+		if err != nil {
+			return err
+		}
+
+		duration := time.Since(start)
+		runtime.ReadMemStats(&after)
+		results = append(results, benchmarkRun{
+			duration:    duration,
+			allocated:   after.TotalAlloc - before.TotalAlloc,
+			allocations: after.Mallocs - before.Mallocs,
+			bytes:       document.Len(),
+		})
+	}
+
+	printBenchmark(results)
+
+	return nil
+}
+
+// printBenchmark writes a table of the runs followed by the fastest, median and slowest run
+func printBenchmark(results []benchmarkRun) {
+	fmt.Println("| run | duration | allocated | allocations | document |")
+	fmt.Println("| --- | --- | --- | --- | --- |")
+	for i, result := range results {
+		fmt.Printf("| %d | %s | %d B | %d | %d B |\n", i+1, result.duration.Round(time.Microsecond), result.allocated,
+			result.allocations, result.bytes)
+	}
+
+	var durations []float64
+	for _, result := range results {
+		durations = append(durations, float64(result.duration))
+	}
+	sort.Float64s(durations)
+
+	fmt.Println()
+	fmt.Printf("fastest %s, median %s, slowest %s over %d runs\n",
+		time.Duration(durations[0]).Round(time.Microsecond),
+		time.Duration(percentile(durations, 50)).Round(time.Microsecond),
+		time.Duration(durations[len(durations)-1]).Round(time.Microsecond),
+		len(durations))
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	// fixturesDir holds the requests and responses of an export recorded with the record command
+	fixturesDir = "testdata/fixtures"
+	// trelloJSONFixture is a board as trello's Export as JSON menu item writes it
+	trelloJSONFixture = "testdata/trello-board.json"
+)
+
+var (
+	// fixtureExport are the arguments of the export the fixtures were recorded with, testdata/export.md is what it
+	// rendered
+	fixtureExport = []string{"export-boards", "--board-id", "b1", "--board-id", "b2",
+		"--show-labels-and-members", "--show-description", "--show-checklists", "--show-comments",
+		"--show-attachments", "--show-completion"}
+)
+
+// fixtureKey identifies a recorded request, credentials in the query are redacted as they are when recording
+func fixtureKey(method string, path string, query string) string {
+	return method + " " + path + "?" + query
+}
+
+// replayFixtures serves the recorded responses in place of the trello api, a request which wasn't recorded fails the
+// benchmark so a change to the requests an export makes is noticed
+func replayFixtures(tb testing.TB) *httptest.Server {
+	tb.Helper()
+
+	paths, err := filepath.Glob(filepath.Join(fixturesDir, "*.json"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if len(paths) == 0 {
+		tb.Fatalf("no fixtures in %s, record some with the record command", fixturesDir)
+	}
+
+	recorded := map[string]fixture{}
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			tb.Fatal(err)
+		}
+
+		var f fixture
+		err = json.Unmarshal(data, &f)
+		if err != nil {
+			tb.Fatalf("unable to parse fixture %s: %v", path, err)
+		}
+
+		// the first response is kept when a request was made more than once
+		key := fixtureKey(f.Method, f.Path, f.Query)
+		if _, ok := recorded[key]; !ok {
+			recorded[key] = f
+		}
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		for _, param := range fixtureCredentialParams {
+			if _, ok := query[param]; ok {
+				query.Set(param, redacted)
+			}
+		}
+
+		f, ok := recorded[fixtureKey(r.Method, r.URL.Path, query.Encode())]
+		if !ok {
+			tb.Errorf("no fixture recorded for %s %s?%s", r.Method, r.URL.Path, query.Encode())
+			http.NotFound(w, r)
+			return
+		}
+
+		if f.ContentType != "" {
+			w.Header().Set("Content-Type", f.ContentType)
+		}
+		w.WriteHeader(f.Status)
+		if f.Body != nil {
+			_, _ = w.Write(f.Body)
+			return
+		}
+		_, _ = w.Write([]byte(f.BodyText))
+	}))
+}
+
+// benchmarkExport runs the command line with the arguments b.N times, writing the document to a temporary file
+func benchmarkExport(b *testing.B, args ...string) {
+	dir, err := ioutil.TempDir("", "trello2md-benchmark")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	output := filepath.Join(dir, "export.md")
+	args = append(args, "--output", output)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err = newApp().Run(args)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+
+	document, err := ioutil.ReadFile(output)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if len(document) == 0 {
+		b.Fatal("the export is empty")
+	}
+}
+
+// BenchmarkExport exports the recorded boards through the whole fetch and render pipeline
+func BenchmarkExport(b *testing.B) {
+	server := replayFixtures(b)
+	defer server.Close()
+
+	benchmarkExport(b, append([]string{appName, "--api-base-url", server.URL + "/1", "--key", "k", "--token", "t"}, fixtureExport...)...)
+}
+
+// BenchmarkExportTrelloJSON renders a board from a trello json export, the render pipeline without any fetching
+func BenchmarkExportTrelloJSON(b *testing.B) {
+	benchmarkExport(b, appName, "export-boards", "--from-trello-json", trelloJSONFixture, "--all-lists",
+		"--show-labels-and-members", "--show-description", "--show-checklists", "--show-comments")
+}
+
+func BenchmarkHTMLToMarkdown(b *testing.B) {
+	description := `<p>Steps from the <b>support</b> email:</p><ol><li>open <a href="https://example.com">the app</a></li>` +
+		`<li>sign in</li></ol><table><tr><th>browser</th><th>result</th></tr><tr><td>firefox</td><td>ok</td></tr>` +
+		`<tr><td>safari</td><td>fails</td></tr></table><pre><code>error: 500</code></pre>`
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		htmlToMarkdown(description)
+	}
+}
+
+// TestExportFixtures checks the recorded boards still render as they did when they were recorded
+func TestExportFixtures(t *testing.T) {
+	server := replayFixtures(t)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "trello2md-fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	output := filepath.Join(dir, "export.md")
+	args := append([]string{appName, "--api-base-url", server.URL + "/1", "--key", "k", "--token", "t"}, fixtureExport...)
+	err = newApp().Run(append(args, "--output", output))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadFile("testdata/export.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(withoutGenerationDate(got)) != string(withoutGenerationDate(want)) {
+		t.Errorf("the export of the fixtures changed, got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/jakekeeys/go-trello"
+)
+
+// botMembers recognises what automation did, actions butler and power-ups took along with those of the bot
+// accounts named by their username, e.g. a ci integration's member
+type botMembers struct {
+	usernames []string
+}
+
+// cardAction is any action taken on a card, with just enough of it to tell who took it
+type cardAction struct {
+	Date string `json:"date"`
+	Data struct {
+		Card struct {
+			Id string `json:"id"`
+		} `json:"card"`
+	} `json:"data"`
+	MemberCreator struct {
+		Username string `json:"username"`
+	} `json:"memberCreator"`
+	AppCreator *appCreator `json:"appCreator"`
+}
+
+func (b *botMembers) took(username string, app *appCreator) bool {
+	return app != nil || containsString(b.usernames, username)
+}
+
+// skipComments returns the comments members wrote themselves
+func (b *botMembers) skipComments(comments []Comment) []Comment {
+	var kept []Comment
+	for _, comment := range comments {
+		if b.took(comment.MemberCreator.Username, comment.AppCreator) {
+			continue
+		}
+
+		kept = append(kept, comment)
+	}
+
+	return kept
+}
+
+// lastHumanActivity returns the date of the newest of the card's actions which a member took themselves, empty
+// when automation took all of them
+func (b *botMembers) lastHumanActivity(actions []cardAction) (string, error) {
+	var last string
+	var lastDate time.Time
+	for _, action := range actions {
+		if b.took(action.MemberCreator.Username, action.AppCreator) {
+			continue
+		}
+
+		date := parseTrelloDate(action.Date, "an action of card "+action.Data.Card.Id)
+		if date.After(lastDate) {
+			last, lastDate = action.Date, date
+		}
+	}
+
+	return last, nil
+}
+
+// getCardLastHumanActivity returns when a member last did something to the card rather than automation, falling
+// back to the card's last activity when automation took every action trello returns
+func getCardLastHumanActivity(client *trello.Client, card *trello.Card, bots *botMembers) (string, error) {
+	body, err := client.Get("/cards/" + card.Id + "/actions?filter=all&limit=" + strconv.Itoa(cardActionsLimit))
+	if err != nil {
+		return "", err
+	}
+
+	var actions []cardAction
+	err = json.Unmarshal(body, &actions)
+	if err != nil {
+		return "", err
+	}
+
+	date, err := bots.lastHumanActivity(actions)
+	if err != nil || date != "" {
+		return date, err
+	}
+
+	return card.DateLastActivity, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// breaker is shared by every client of the process, so one outage stops every board rather than each finding
+	// out for itself
+	breaker = &circuitBreaker{}
+
+	// retryBudget caps the retries made by the whole process, set from --retry-budget
+	retryBudget = &budget{}
+)
+
+// circuitBreakerError fails requests straight away while the breaker is open
+type circuitBreakerError struct {
+	failures int
+	until    time.Time
+	cause    string
+}
+
+func (e *circuitBreakerError) Error() string {
+	return fmt.Sprintf("the trello api looks to be unavailable, %d requests failed in a row (last: %s), not sending any more until %s", e.failures, e.cause, e.until.Format(time.RFC3339))
+}
+
+// circuitBreaker opens after a number of requests in a row fail with a network error or a 5xx, once the cooldown
+// has passed a single request is let through to check whether trello has recovered
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	cause     string
+	openUntil time.Time
+	probing   bool
+}
+
+func (b *circuitBreaker) configure(threshold int, cooldown time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.threshold = threshold
+	b.cooldown = cooldown
+}
+
+// allow returns an error while the breaker is open, probe is true when the request is the one let through to check
+// whether trello has recovered
+func (b *circuitBreaker) allow() (probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return false, nil
+	}
+
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false, &circuitBreakerError{failures: b.failures, until: b.openUntil, cause: b.cause}
+	}
+	b.probing = true
+
+	return true, nil
+}
+
+// cancelProbe lets another request probe when the probe was cancelled before it could tell whether trello recovered
+func (b *circuitBreaker) cancelProbe() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+func (b *circuitBreaker) observe(resp *http.Response, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	switch {
+	case err != nil:
+		b.cause = err.Error()
+	case resp.StatusCode >= http.StatusInternalServerError:
+		b.cause = resp.Status
+	default:
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.threshold > 0 && b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// breakerTransport sends requests through the shared circuit breaker
+type breakerTransport struct {
+	delegate http.RoundTripper
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	probe, err := breaker.allow()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.delegate.RoundTrip(req)
+	switch {
+	case req.Context().Err() == nil:
+		breaker.observe(resp, err)
+	case probe:
+		breaker.cancelProbe()
+	}
+
+	return resp, err
+}
+
+// budget counts down the retries left, a negative budget is unlimited
+type budget struct {
+	mu        sync.Mutex
+	remaining int
+}
+
+func (b *budget) set(remaining int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remaining = remaining
+}
+
+// take uses one retry from the budget, false when it's spent
+func (b *budget) take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.remaining < 0 {
+		return true
+	}
+
+	if b.remaining == 0 {
+		return false
+	}
+	b.remaining--
+
+	return true
+}
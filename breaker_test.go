@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestBreakerCancelledProbe(t *testing.T) {
+	defer func(b *circuitBreaker) { breaker = b }(breaker)
+	breaker = &circuitBreaker{}
+	breaker.configure(1, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	transport := &breakerTransport{delegate: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Context() == ctx {
+			cancel()
+			return nil, req.Context().Err()
+		}
+
+		return nil, errors.New("connection refused")
+	})}
+
+	req, err := http.NewRequest(http.MethodGet, "https://api.trello.com/1/members/me", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// opens the breaker
+	_, err = transport.RoundTrip(req)
+	if _, ok := err.(*circuitBreakerError); ok || err == nil {
+		t.Fatalf("first request: got %v, want the delegate's error", err)
+	}
+
+	// the probe is cancelled before trello answers
+	_, err = transport.RoundTrip(req.WithContext(ctx))
+	if err != context.Canceled {
+		t.Fatalf("probe: got %v, want %v", err, context.Canceled)
+	}
+
+	// the next request probes rather than being rejected forever
+	_, err = transport.RoundTrip(req)
+	if _, ok := err.(*circuitBreakerError); ok || err == nil {
+		t.Fatalf("request after cancelled probe: got %v, want the delegate's error", err)
+	}
+}
@@ -0,0 +1,598 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	// browseMenuWidth is the widest the menu beside the preview gets, it takes a third of narrower terminals
+	browseMenuWidth = 40
+)
+
+var (
+	// errBrowseQuit unwinds the browser from any screen when the user quits
+	errBrowseQuit = errors.New("quit")
+)
+
+// browser is a terminal ui for navigating boards, lists and cards with a live preview of the highlighted one, the
+// selected cards can be exported as a single document
+type browser struct {
+	clients *boardClients
+	tmpl    *template.Template
+	show    showOptions
+	term    *terminal
+
+	// lists, cards and views cache what was fetched for the previews, keyed by board, list and card id
+	lists map[string][]trello.List
+	cards map[string][]trello.Card
+	views map[string]*cardView
+	// selected holds the cards to export in the order they were selected
+	selected []*cardView
+	// exported is the selection last exported without a file, it's written to stdout once the terminal is restored
+	exported []byte
+	// status replaces the help until the next key is pressed
+	status string
+}
+
+// browserPane is a screen of the browser, a menu with the preview of its highlighted item beside it
+type browserPane struct {
+	title string
+	help  string
+	items []string
+	// marked flags the items shown as selected
+	marked []bool
+	cursor int
+	// offset is the first item shown when the menu is longer than the terminal, scroll the first preview line
+	offset int
+	scroll int
+}
+
+func browse(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	err = applyProfile(c, cfg)
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := loadCardTemplate(c.String("template"), defaultCardTemplate)
+	if err != nil {
+		return err
+	}
+
+	clients := newBoardClients(c, cfg)
+	boards, err := browseBoardList(c, clients)
+	if err != nil {
+		return err
+	}
+	if len(boards) == 0 {
+		return errors.New("there are no open boards to browse")
+	}
+
+	term, err := openTerminal()
+	if err != nil {
+		return err
+	}
+
+	b := &browser{
+		clients: clients,
+		tmpl:    tmpl,
+		show: showOptions{
+			LabelsAndMembers: c.Bool("show-labels-and-members"),
+			Description:      c.Bool("show-description"),
+			Checklists:       c.Bool("show-checklists"),
+			Comments:         c.Bool("show-comments"),
+			Attachments:      c.Bool("show-attachments"),
+		},
+		term:  term,
+		lists: map[string][]trello.List{},
+		cards: map[string][]trello.Card{},
+		views: map[string]*cardView{},
+	}
+
+	err = b.boards(boards)
+	closeErr := term.close()
+	if err == errBrowseQuit {
+		err = nil
+	}
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil && b.exported != nil {
+		_, err = os.Stdout.Write(b.exported)
+	}
+
+	return err
+}
+
+// browseBoardList returns the boards given with --board-id, or every open board of the member when there are none
+func browseBoardList(c *cli.Context, clients *boardClients) ([]trello.Board, error) {
+	if len(c.StringSlice("board-id")) > 0 {
+		boards, err := getBoards(clients, c.StringSlice("board-id"))
+		if err != nil {
+			return nil, err
+		}
+
+		return *boards, nil
+	}
+
+	client, err := newClient(c)
+	if err != nil {
+		return nil, err
+	}
+
+	me, err := client.Member("me")
+	if err != nil {
+		return nil, err
+	}
+
+	boards, err := me.Boards()
+	if err != nil {
+		return nil, err
+	}
+
+	var open []trello.Board
+	for _, board := range boards {
+		if !board.Closed {
+			open = append(open, board)
+		}
+	}
+
+	return open, nil
+}
+
+// boards is the first screen, the preview lists the lists of the highlighted board
+func (b *browser) boards(boards []trello.Board) error {
+	pane := &browserPane{title: "Boards", help: "↑↓ move  enter open  e export the selection  q quit"}
+	for _, board := range boards {
+		pane.items = append(pane.items, board.Name)
+	}
+
+	for {
+		board := &boards[pane.cursor]
+		lists, err := b.boardLists(board)
+		preview := board.Desc
+		for _, list := range lists {
+			preview += "\n- " + list.Name
+		}
+
+		key, err := b.draw(pane, preview, err)
+		if err != nil {
+			return err
+		}
+
+		switch key {
+		case keyEnter, keyRight, "l":
+			err = b.listsOf(board)
+		case "e":
+			err = b.export()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// listsOf is the screen of a board's lists, the preview lists the cards of the highlighted list
+func (b *browser) listsOf(board *trello.Board) error {
+	lists, err := b.boardLists(board)
+	if err != nil {
+		b.status = err.Error()
+		return nil
+	}
+	if len(lists) == 0 {
+		b.status = fmt.Sprintf("%s has no open lists", board.Name)
+		return nil
+	}
+
+	pane := &browserPane{title: board.Name, help: "↑↓ move  enter open  ← back  e export the selection  q quit"}
+	for _, list := range lists {
+		pane.items = append(pane.items, list.Name)
+	}
+
+	for {
+		list := &lists[pane.cursor]
+		cards, err := b.listCards(board, list)
+		preview := ""
+		for _, card := range cards {
+			preview += "- " + card.Name + "\n"
+		}
+
+		key, err := b.draw(pane, preview, err)
+		if err != nil {
+			return err
+		}
+
+		switch key {
+		case keyLeft, keyBack, "h":
+			return nil
+		case keyEnter, keyRight, "l":
+			err = b.cardsOf(board, list)
+		case "e":
+			err = b.export()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// cardsOf is the screen of a list's cards, the preview is the highlighted card rendered with the card template
+func (b *browser) cardsOf(board *trello.Board, list *trello.List) error {
+	cards, err := b.listCards(board, list)
+	if err != nil {
+		b.status = err.Error()
+		return nil
+	}
+	if len(cards) == 0 {
+		b.status = fmt.Sprintf("%s has no cards", list.Name)
+		return nil
+	}
+
+	pane := &browserPane{
+		title: board.Name + " / " + list.Name,
+		help:  "↑↓ move  space select  a select all  pgup pgdn scroll  ← back  e export the selection  q quit",
+		items: make([]string, len(cards)),
+	}
+	for i, card := range cards {
+		pane.items[i] = card.Name
+	}
+
+	for {
+		pane.marked = make([]bool, len(cards))
+		for i, card := range cards {
+			pane.marked[i] = b.isSelected(card.Id)
+		}
+
+		preview := ""
+		view, err := b.view(board, &cards[pane.cursor])
+		if err == nil {
+			var document bytes.Buffer
+			err = b.tmpl.Execute(&document, view)
+			preview = document.String()
+		}
+
+		key, err := b.draw(pane, preview, err)
+		if err != nil {
+			return err
+		}
+
+		switch key {
+		case keyLeft, keyBack, "h":
+			return nil
+		case keySpace:
+			err = b.selectCard(board, &cards[pane.cursor], !pane.marked[pane.cursor])
+			if err == nil && pane.cursor < len(cards)-1 {
+				pane.cursor++
+				pane.scroll = 0
+			}
+		case "a":
+			for i := range cards {
+				err = b.selectCard(board, &cards[i], true)
+				if err != nil {
+					break
+				}
+			}
+		case "e":
+			err = b.export()
+		}
+		if err != nil {
+			b.status = err.Error()
+		}
+	}
+}
+
+// boardLists returns the open lists of the board, fetching them on first use
+func (b *browser) boardLists(board *trello.Board) ([]trello.List, error) {
+	if lists, ok := b.lists[board.Id]; ok {
+		return lists, nil
+	}
+
+	lists, err := getLists(board)
+	if err != nil {
+		return nil, err
+	}
+
+	b.lists[board.Id] = lists
+
+	return lists, nil
+}
+
+// listCards returns the open cards of the list, fetching them on first use
+func (b *browser) listCards(board *trello.Board, list *trello.List) ([]trello.Card, error) {
+	if cards, ok := b.cards[list.Id]; ok {
+		return cards, nil
+	}
+
+	client, err := b.clients.forBoard(board.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	cards, err := getCards(client, list, cardSortPosition, false)
+	if err != nil {
+		return nil, err
+	}
+
+	b.cards[list.Id] = *cards
+
+	return *cards, nil
+}
+
+// view returns the card's view, fetching it on first use
+func (b *browser) view(board *trello.Board, card *trello.Card) (*cardView, error) {
+	if view, ok := b.views[card.Id]; ok {
+		return view, nil
+	}
+
+	client, err := b.clients.forBoard(board.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	view, err := newCardView(client, *board, card, b.show)
+	if err != nil {
+		return nil, err
+	}
+
+	b.views[card.Id] = view
+
+	return view, nil
+}
+
+func (b *browser) isSelected(cardId string) bool {
+	for _, view := range b.selected {
+		if view.Card.Id == cardId {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (b *browser) selectCard(board *trello.Board, card *trello.Card, selected bool) error {
+	if !selected {
+		for i, view := range b.selected {
+			if view.Card.Id == card.Id {
+				b.selected = append(b.selected[:i], b.selected[i+1:]...)
+				break
+			}
+		}
+
+		return nil
+	}
+
+	if b.isSelected(card.Id) {
+		return nil
+	}
+
+	view, err := b.view(board, card)
+	if err != nil {
+		return err
+	}
+
+	b.selected = append(b.selected, view)
+
+	return nil
+}
+
+// export asks for the file to write the selected cards to as a single document grouped by board, a selection
+// exported without a file is written to stdout when the browser quits
+func (b *browser) export() error {
+	if len(b.selected) == 0 {
+		b.status = "nothing is selected"
+		return nil
+	}
+
+	rows, _ := b.term.size()
+	path, err := b.term.readLine(fmt.Sprintf("\x1b[%d;1H%sexport %d cards to the file, or to stdout on quitting when it's left empty: ", rows, ansiClearToLineEnd, len(b.selected)))
+	if err != nil {
+		return err
+	}
+
+	// cards are selected across boards in any order, they're grouped so each board is headed once
+	var boardIds []string
+	byBoard := map[string][]*cardView{}
+	for _, view := range b.selected {
+		if _, ok := byBoard[view.Board.Id]; !ok {
+			boardIds = append(boardIds, view.Board.Id)
+		}
+		byBoard[view.Board.Id] = append(byBoard[view.Board.Id], view)
+	}
+
+	var document bytes.Buffer
+	printDate(&document, time.Now())
+	for _, boardId := range boardIds {
+		printBoard(&document, byBoard[boardId][0].Board)
+		for _, view := range byBoard[boardId] {
+			err = b.tmpl.Execute(&document, view)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if path == "" {
+		b.exported = document.Bytes()
+		b.status = fmt.Sprintf("%d cards will be written to stdout on quitting", len(b.selected))
+		return nil
+	}
+
+	err = writeFileIfChanged(path, document.Bytes())
+	if err != nil {
+		return err
+	}
+
+	b.status = fmt.Sprintf("exported %d cards to %s", len(b.selected), path)
+
+	return nil
+}
+
+// draw redraws the screen with the pane's menu beside the preview, or the error fetching it, then reads keys until
+// one which isn't navigation is pressed. it returns errBrowseQuit on q
+func (b *browser) draw(pane *browserPane, preview string, previewErr error) (string, error) {
+	if previewErr != nil {
+		preview = "unable to load the preview: " + previewErr.Error()
+	}
+
+	for {
+		rows, columns := b.term.size()
+		menuWidth := columns / 3
+		if menuWidth > browseMenuWidth {
+			menuWidth = browseMenuWidth
+		}
+		previewWidth := columns - menuWidth - 3
+		body := rows - 2
+
+		lines := previewLines(preview, previewWidth)
+		pane.fit(body, len(lines))
+
+		var screen strings.Builder
+		screen.WriteString(ansiClearScreen)
+		screen.WriteString(truncateANSI(fmt.Sprintf("%s%s%s  %s(%d selected)%s", ansiBold, pane.title, ansiReset, ansiDim, len(b.selected), ansiReset), columns))
+		screen.WriteString("\r\n")
+		for row := 0; row < body; row++ {
+			item := ""
+			if i := pane.offset + row; i < len(pane.items) {
+				marker := "  "
+				if i < len(pane.marked) && pane.marked[i] {
+					marker = "* "
+				}
+				item = fmt.Sprintf("%-*s", menuWidth, truncateANSI(marker+pane.items[i], menuWidth))
+				if i == pane.cursor {
+					item = ansiReverse + item + ansiReset
+				}
+			} else {
+				item = strings.Repeat(" ", menuWidth)
+			}
+
+			line := ""
+			if i := pane.scroll + row; i < len(lines) {
+				line = lines[i]
+			}
+
+			screen.WriteString(item + ansiDim + " │ " + ansiReset + line + ansiReset + "\r\n")
+		}
+
+		footer := pane.help
+		if b.status != "" {
+			footer = b.status
+		}
+		screen.WriteString(ansiDim + truncateANSI(footer, columns) + ansiReset)
+
+		_, err := b.term.tty.WriteString(screen.String())
+		if err != nil {
+			return "", err
+		}
+
+		key, err := b.term.readKey()
+		if err != nil {
+			return "", err
+		}
+		b.status = ""
+
+		switch key {
+		case "q":
+			return "", errBrowseQuit
+		case keyUp, "k":
+			pane.move(-1)
+			return "", nil
+		case keyDown, "j":
+			pane.move(1)
+			return "", nil
+		case keyPageUp:
+			pane.scroll -= body
+		case keyPageDown:
+			pane.scroll += body
+		case "":
+		default:
+			return key, nil
+		}
+	}
+}
+
+// move moves the cursor by the number of items, the preview of the newly highlighted item starts at its top
+func (p *browserPane) move(by int) {
+	cursor := p.cursor + by
+	if cursor < 0 || cursor >= len(p.items) {
+		return
+	}
+
+	p.cursor = cursor
+	p.scroll = 0
+}
+
+// fit scrolls the menu so the cursor is visible and keeps the preview scrolled within its lines
+func (p *browserPane) fit(rows int, previewLines int) {
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if rows > 0 && p.cursor >= p.offset+rows {
+		p.offset = p.cursor - rows + 1
+	}
+
+	if p.scroll > previewLines-rows {
+		p.scroll = previewLines - rows
+	}
+	if p.scroll < 0 {
+		p.scroll = 0
+	}
+}
+
+// previewLines renders the markdown styled for the terminal, wrapped and cut to width
+func previewLines(markdown string, width int) []string {
+	var styled bytes.Buffer
+	err := previewMarkdown(&styled, []byte(reflow(strings.TrimSpace(markdown), width)))
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	lines := strings.Split(strings.TrimRight(styled.String(), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = truncateANSI(strings.Replace(line, "\t", "    ", -1), width)
+	}
+
+	return lines
+}
+
+// truncateANSI cuts the text to width characters, ansi escape sequences take up no width and are kept
+func truncateANSI(text string, width int) string {
+	var out strings.Builder
+	visible := 0
+	for i := 0; i < len(text); {
+		if text[i] == 0x1b {
+			end := strings.IndexFunc(text[i+1:], func(r rune) bool { return r >= '@' && r <= '~' && r != '[' })
+			if end < 0 {
+				break
+			}
+			out.WriteString(text[i : i+end+2])
+			i += end + 2
+			continue
+		}
+
+		if visible == width {
+			out.WriteString(ansiReset)
+			break
+		}
+
+		_, size := utf8.DecodeRuneInString(text[i:])
+		out.WriteString(text[i : i+size])
+		visible++
+		i += size
+	}
+
+	return out.String()
+}
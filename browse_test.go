@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestTruncateANSI(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  string
+	}{
+		{text: "short", width: 10, want: "short"},
+		{text: "exactly", width: 7, want: "exactly"},
+		{text: "too long", width: 3, want: "too" + ansiReset},
+		{text: ansiBold + "bold" + ansiReset + " text", width: 6, want: ansiBold + "bold" + ansiReset + " t" + ansiReset},
+		{text: "Café ☐ done", width: 6, want: "Café ☐" + ansiReset},
+		{text: "", width: 5, want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := truncateANSI(tt.text, tt.width); got != tt.want {
+			t.Errorf("truncateANSI(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+)
+
+var (
+	// responseCache is shared by every client of the process when set, run enables it so profiles exporting the
+	// same boards only fetch them once
+	responseCache *apiCache
+)
+
+// apiCache holds successful trello api responses keyed by request, anything other than a GET clears it as the
+// cached responses may no longer be current
+type apiCache struct {
+	mu        sync.Mutex
+	responses map[string][]byte
+}
+
+func newAPICache() *apiCache {
+	return &apiCache{responses: map[string][]byte{}}
+}
+
+// cacheTransport answers GET requests from the response cache when it's enabled
+type cacheTransport struct {
+	delegate http.RoundTripper
+	// namespace keeps apart the responses of clients whose requests are only signed after the cache
+	namespace string
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	cache := responseCache
+	if cache == nil {
+		return t.delegate.RoundTrip(req)
+	}
+
+	if req.Method != http.MethodGet {
+		cache.clear()
+		return t.delegate.RoundTrip(req)
+	}
+
+	// credentials are part of the key, boards with their own credentials may see different content
+	key := req.URL.String() + " " + req.Header.Get("Authorization") + " " + t.namespace
+	if resp, ok := cache.get(key, req); ok {
+		return resp, nil
+	}
+
+	resp, err := t.delegate.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return nil, err
+	}
+	cache.put(key, dump)
+
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(dump)), req)
+}
+
+func (c *apiCache) get(key string, req *http.Request) (*http.Response, bool) {
+	c.mu.Lock()
+	dump, ok := c.responses[key]
+	c.mu.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(dump)), req)
+	if err != nil {
+		return nil, false
+	}
+
+	return resp, true
+}
+
+func (c *apiCache) put(key string, dump []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.responses[key] = dump
+}
+
+func (c *apiCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.responses = map[string][]byte{}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// calendarShades darken as a day gets busier relative to the busiest day of the calendar
+var calendarShades = []string{"░", "▒", "▓", "█"}
+
+// printDueCalendar writes a month calendar for every month the cards of the lists are due in, each day showing how
+// many cards are due on it so crunch periods stand out. nothing is written when no card has a due date
+func printDueCalendar(w io.Writer, lists []*listExport) {
+	due := map[string]int{}
+	var months []time.Time
+	busiest := 0
+	for _, list := range lists {
+		for _, view := range list.cards {
+			if view.Card.Due == "" {
+				continue
+			}
+
+			date, err := time.Parse(time.RFC3339, view.Card.Due)
+			if err != nil {
+				continue
+			}
+
+			date = date.Local()
+			day := date.Format(dateFormat)
+			due[day]++
+			if due[day] > busiest {
+				busiest = due[day]
+			}
+
+			month := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.Local)
+			if !containsMonth(months, month) {
+				months = append(months, month)
+			}
+		}
+	}
+
+	sort.Slice(months, func(i, j int) bool {
+		return months[i].Before(months[j])
+	})
+
+	for _, month := range months {
+		fmt.Fprintf(w, "**%s**\n\n", month.Format("January 2006"))
+
+		var weekdays []string
+		for i := 0; i < 7; i++ {
+			weekdays = append(weekdays, time.Weekday((i + 1) % 7).String()[:3])
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(weekdays, " | "))
+		fmt.Fprintln(w, "| --- | --- | --- | --- | --- | --- | --- |")
+
+		// weeks start on monday
+		var week []string
+		for i := 0; i < (int(month.Weekday())+6)%7; i++ {
+			week = append(week, " ")
+		}
+
+		for day := month; day.Month() == month.Month(); day = day.AddDate(0, 0, 1) {
+			cell := strconv.Itoa(day.Day())
+			if count := due[day.Format(dateFormat)]; count > 0 {
+				cell = fmt.Sprintf("%s %s %d", cell, calendarShades[(count*len(calendarShades)-1)/busiest], count)
+			}
+			week = append(week, cell)
+
+			if len(week) == 7 {
+				fmt.Fprintf(w, "| %s |\n", strings.Join(week, " | "))
+				week = nil
+			}
+		}
+
+		if len(week) > 0 {
+			for len(week) < 7 {
+				week = append(week, " ")
+			}
+			fmt.Fprintf(w, "| %s |\n", strings.Join(week, " | "))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func containsMonth(months []time.Time, month time.Time) bool {
+	for _, m := range months {
+		if m.Equal(month) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jakekeeys/go-trello"
+)
+
+var (
+	// uploaderNames caches the full names of the members who uploaded attachments by member id, the same few members
+	// upload most of a board's attachments
+	uploaderNames sync.Map
+)
+
+// setUploaders fills in the full name of the member who uploaded each attachment, from the card's members when they
+// include the uploader or else looked up by id
+func setUploaders(client *trello.Client, attachments []Attachment, members []Member) {
+	for i := range attachments {
+		attachment := &attachments[i]
+		if attachment.IdMember == "" {
+			continue
+		}
+
+		for _, member := range members {
+			if member.Id == attachment.IdMember {
+				attachment.Uploader = member.FullName
+				break
+			}
+		}
+
+		if attachment.Uploader == "" && client != nil {
+			attachment.Uploader = getUploaderName(client, attachment.IdMember)
+		}
+	}
+}
+
+// getUploaderName looks up the member's full name, captions leave the uploader out when the member can't be found
+func getUploaderName(client *trello.Client, memberId string) string {
+	if name, ok := uploaderNames.Load(memberId); ok {
+		return name.(string)
+	}
+
+	var member Member
+	err := getJSON(client, "/members/"+memberId+"?fields=fullName", &member)
+	if err != nil {
+		warnings.add(WarningLookup, memberId, fmt.Sprintf("unable to look up the uploader %s for image captions: %v", memberId, err))
+	}
+
+	uploaderNames.Store(memberId, member.FullName)
+
+	return member.FullName
+}
+
+// Caption describes an attachment for the line rendered beneath its image, the attachment's name, the card and who
+// uploaded it when. it's empty unless image captions are shown
+func (v cardView) Caption(attachment Attachment) string {
+	if !v.Show.ImageCaptions {
+		return ""
+	}
+
+	parts := []string{attachment.Name, v.Card.Name}
+	date, _ := formatDate(attachment.Date)
+	switch {
+	case attachment.Uploader != "" && date != "":
+		parts = append(parts, tr("uploadedBy", attachment.Uploader, date))
+	case attachment.Uploader != "":
+		parts = append(parts, tr("uploadedByOnly", attachment.Uploader))
+	case date != "":
+		parts = append(parts, tr("uploaded", date))
+	}
+
+	var kept []string
+	for _, part := range parts {
+		if part = strings.Join(strings.Fields(part), " "); part != "" {
+			kept = append(kept, markdownEscaper.Replace(part))
+		}
+	}
+
+	return strings.Join(kept, " - ")
+}
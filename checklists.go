@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	checklistsInline   = "inline"
+	checklistsAppendix = "appendix"
+)
+
+func validateChecklists(placement string, split string) error {
+	switch placement {
+	case checklistsInline:
+		return nil
+	case checklistsAppendix:
+		if split != splitNone && split != splitBoard {
+			return errors.Errorf("--checklists appendix can't be combined with --split-by %s, the appendix follows each board", split)
+		}
+
+		return nil
+	default:
+		return errors.Errorf("unsupported checklist placement %q, expected one of inline or appendix", placement)
+	}
+}
+
+// checklistAppendix collects the checklists of a board's cards so they're listed after the board's lists rather
+// than under each card, keeping the cards short
+type checklistAppendix struct {
+	cards []appendixCard
+}
+
+type appendixCard struct {
+	card       Card
+	checklists []Checklist
+}
+
+// take moves the card's checklists into the appendix, returning the card to render without them
+func (a *checklistAppendix) take(view *cardView) *cardView {
+	if len(view.Checklists) == 0 {
+		return view
+	}
+
+	a.cards = append(a.cards, appendixCard{card: view.Card, checklists: view.Checklists})
+
+	without := *view
+	without.Checklists = nil
+
+	return &without
+}
+
+// print lists the checklists taken from the board's cards grouped by card and empties the appendix for the next
+// board
+func (a *checklistAppendix) print(w io.Writer) {
+	if len(a.cards) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "### %s\n", tr("checklists"))
+	for _, card := range a.cards {
+		if card.card.Url != "" {
+			fmt.Fprintf(w, "#### [%s](%s)\n", card.card.Name, card.card.Url)
+		} else {
+			fmt.Fprintf(w, "#### %s\n", card.card.Name)
+		}
+
+		for _, checklist := range card.checklists {
+			fmt.Fprintln(w, checklist.Name)
+			for _, item := range checklist.CheckItems {
+				checked := " "
+				if item.State == checkItemComplete {
+					checked = "x"
+				}
+				fmt.Fprintf(w, "- [%s] %s\n", checked, item.Name)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+
+	a.cards = nil
+}
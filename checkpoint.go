@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// checkpointInterval is how often the state file is rewritten while cards are being fetched, writing it after
+	// every page would rewrite every card fetched so far each time
+	checkpointInterval = 30 * time.Second
+)
+
+// exportCheckpoint records every card fetched during an export in the state file, so an export interrupted by a
+// network failure or rate limiting can be resumed without fetching those cards again
+type exportCheckpoint struct {
+	path string
+	mu   sync.Mutex
+	// saved is when the state file was last written
+	saved time.Time
+
+	// Args are the command line arguments of the export, a checkpoint is only resumed by the same export
+	Args []string `json:"args"`
+	// Cards holds the fetched cards keyed by id, before transforms and filters are applied
+	Cards map[string]*cardView `json:"cards"`
+}
+
+// loadCheckpoint starts a checkpoint in the state file, picking up the cards of a previous run when resuming
+func loadCheckpoint(path string, args []string, resume bool) (*exportCheckpoint, error) {
+	checkpoint := &exportCheckpoint{
+		path:  path,
+		Args:  args,
+		Cards: map[string]*cardView{},
+	}
+
+	if !resume {
+		return checkpoint, nil
+	}
+
+	data, err := readOutput(path)
+	if os.IsNotExist(err) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the state file")
+	}
+
+	var previous exportCheckpoint
+	err = json.Unmarshal(data, &previous)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the state file")
+	}
+
+	if !equalStrings(previous.Args, args) {
+		return nil, errors.Errorf("%s was written by an export with different arguments, run without --resume to start over", path)
+	}
+
+	if previous.Cards != nil {
+		checkpoint.Cards = previous.Cards
+	}
+	log.Printf("resuming export with %d cards already fetched", len(checkpoint.Cards))
+
+	return checkpoint, nil
+}
+
+// card returns the view of a card fetched before, the checkpoint may be nil when checkpointing is disabled
+func (e *exportCheckpoint) card(id string) (*cardView, bool) {
+	if e == nil {
+		return nil, false
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	view, ok := e.Cards[id]
+	if !ok {
+		return nil, false
+	}
+
+	// views are modified by transforms, the checkpoint keeps its own copy
+	copied := *view
+	return &copied, true
+}
+
+func (e *exportCheckpoint) add(view *cardView) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	copied := *view
+	e.Cards[view.Card.Id] = &copied
+}
+
+// saveDue saves the checkpoint when checkpointInterval has passed since it was last saved, a failed export saves
+// it regardless so only the cards fetched since are lost when the process is killed
+func (e *exportCheckpoint) saveDue() error {
+	if e == nil {
+		return nil
+	}
+
+	e.mu.Lock()
+	due := time.Since(e.saved) >= checkpointInterval
+	e.mu.Unlock()
+
+	if !due {
+		return nil
+	}
+
+	return e.save()
+}
+
+// save writes the checkpoint to the state file, encrypted when the export is
+func (e *exportCheckpoint) save() error {
+	if e == nil {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	e.saved = time.Now()
+
+	return writeStateFile(e.path, data)
+}
+
+// finish removes the state file once the export completed
+func (e *exportCheckpoint) finish() error {
+	if e == nil {
+		return nil
+	}
+
+	return removeOutput(e.path)
+}
+
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	trello_search "github.com/adlio/trello"
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	defaultAPIBaseURL = "https://api.trello.com/1"
+)
+
+func newClient(c *cli.Context) (*trello.Client, error) {
+	return newClientWithCredentials(c, globalCredentials(c))
+}
+
+func newClientWithCredentials(c *cli.Context, creds credentials) (*trello.Client, error) {
+	baseURL, err := getAPIBaseURL(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if creds.Secret != "" {
+		if creds.TokenSecret == "" {
+			return nil, errors.New("oauth needs the token secret along with the application secret, run authorize to get one")
+		}
+
+		httpTransport, err := newHTTPTransport(c, &creds)
+		if err != nil {
+			return nil, err
+		}
+
+		return trello.NewCustomClient(newHTTPClient(c, &baseURLTransport{
+			delegate: httpTransport,
+			baseURL:  baseURL,
+		}))
+	}
+
+	httpTransport, err := newHTTPTransport(c, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	token := creds.Token
+	transport := trello.NewBearerTokenTransport(creds.Key, &token)
+	transport.Delegate = &baseURLTransport{
+		delegate: httpTransport,
+		baseURL:  baseURL,
+	}
+
+	return trello.NewCustomClient(newHTTPClient(c, transport))
+}
+
+// globalCredentials are the key and token, and for oauth the secrets, given as flags
+func globalCredentials(c *cli.Context) credentials {
+	return credentials{
+		Key:         c.GlobalString("key"),
+		Token:       c.GlobalString("token"),
+		Secret:      c.GlobalString("secret"),
+		TokenSecret: c.GlobalString("token-secret"),
+	}
+}
+
+func newSearchClient(c *cli.Context) (*trello_search.Client, error) {
+	baseURL, err := getAPIBaseURL(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var oauth *credentials
+	if creds := globalCredentials(c); creds.Secret != "" {
+		oauth = &creds
+	}
+
+	httpTransport, err := newHTTPTransport(c, oauth)
+	if err != nil {
+		return nil, err
+	}
+
+	client := trello_search.NewClient(c.GlobalString("key"), c.GlobalString("token"))
+	client.Client = newHTTPClient(c, httpTransport)
+	client.BaseURL = strings.TrimSuffix(baseURL.String(), "/")
+
+	return client, nil
+}
+
+// boardClients hands out a trello client per board, boards configured with their own credentials get a dedicated
+// client while every other board shares the client built from the global key and token
+type boardClients struct {
+	c       *cli.Context
+	config  *config
+	mu      sync.Mutex
+	clients map[string]*trello.Client
+	// tokens are the checked tokens by credentials name
+	tokens map[string]*tokenInfo
+}
+
+func newBoardClients(c *cli.Context, cfg *config) *boardClients {
+	return &boardClients{
+		c:       c,
+		config:  cfg,
+		clients: map[string]*trello.Client{},
+		tokens:  map[string]*tokenInfo{},
+	}
+}
+
+func (b *boardClients) forBoard(boardId string) (*trello.Client, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	name := b.config.board(boardId).Credentials
+	if client, ok := b.clients[name]; ok {
+		return client, nil
+	}
+
+	var client *trello.Client
+	var err error
+	if name == "" {
+		client, err = newClient(b.c)
+	} else {
+		client, err = newClientWithCredentials(b.c, b.config.Credentials[name])
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	b.clients[name] = client
+
+	return client, nil
+}
+
+// credentials returns the key and token used for the board
+func (b *boardClients) credentials(boardId string) credentials {
+	name := b.config.board(boardId).Credentials
+	if name == "" {
+		return globalCredentials(b.c)
+	}
+
+	return b.config.Credentials[name]
+}
+
+func getAPIBaseURL(c *cli.Context) (*url.URL, error) {
+	rawURL := c.GlobalString("api-base-url")
+	if rawURL == "" {
+		rawURL = defaultAPIBaseURL
+	}
+
+	baseURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid api base url")
+	}
+
+	if baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, errors.Errorf("invalid api base url %q, expected an absolute url", rawURL)
+	}
+
+	return baseURL, nil
+}
+
+// newHTTPTransport builds the transport shared by both trello clients, proxies are picked up from the
+// HTTPS_PROXY/HTTP_PROXY/NO_PROXY environment variables, requests are signed with oauth 1.0a when oauth credentials
+// are given so each attempt carries a fresh signature
+func newHTTPTransport(c *cli.Context, oauth *credentials) (http.RoundTripper, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.GlobalBool("insecure-skip-verify"),
+	}
+
+	if caCert := c.GlobalString("ca-cert"); caCert != "" {
+		pem, err := ioutil.ReadFile(caCert)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read ca cert")
+		}
+
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil || rootCAs == nil {
+			rootCAs = x509.NewCertPool()
+		}
+
+		if !rootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificates found in %s", caCert)
+		}
+
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: c.GlobalDuration("keep-alive"),
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	transport.DialContext = dialer.DialContext
+	transport.TLSClientConfig = tlsConfig
+	transport.MaxIdleConns = c.GlobalInt("max-idle-conns")
+	transport.MaxIdleConnsPerHost = c.GlobalInt("max-idle-conns")
+	transport.IdleConnTimeout = c.GlobalDuration("idle-conn-timeout")
+	transport.DisableKeepAlives = c.GlobalBool("disable-keep-alives")
+
+	var signed http.RoundTripper = transport
+	namespace := ""
+	if oauth != nil {
+		signed = &oauthTransport{delegate: transport, creds: *oauth}
+		namespace = oauth.Token
+	}
+
+	return &cacheTransport{namespace: namespace, delegate: &throttleTransport{delegate: &breakerTransport{delegate: &metricsTransport{delegate: signed}}}}, nil
+}
+
+// newHTTPClient wraps the transport with the overall request timeout, a zero timeout waits forever
+func newHTTPClient(c *cli.Context, transport http.RoundTripper) *http.Client {
+	return &http.Client{
+		Transport: transport,
+		Timeout:   c.GlobalDuration("http-timeout"),
+	}
+}
+
+// baseURLTransport redirects requests made against the default trello api endpoint to the configured base url,
+// the go-trello client has the endpoint baked in so it has to be rewritten on the way out
+type baseURLTransport struct {
+	delegate http.RoundTripper
+	baseURL  *url.URL
+}
+
+func (t *baseURLTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	defaultURL, _ := url.Parse(defaultAPIBaseURL)
+	if req.URL.Host != defaultURL.Host {
+		return t.delegate.RoundTrip(req)
+	}
+
+	rewritten := req.Clone(req.Context())
+	rewritten.URL.Scheme = t.baseURL.Scheme
+	rewritten.URL.Host = t.baseURL.Host
+	rewritten.URL.Path = strings.TrimSuffix(t.baseURL.Path, "/") + strings.TrimPrefix(req.URL.Path, defaultURL.Path)
+	rewritten.URL.RawPath = ""
+	rewritten.Host = ""
+
+	return t.delegate.RoundTrip(rewritten)
+}
+
+// unexpectedStatus matches the status in the errors go-trello returns for responses other than 200
+var unexpectedStatus = regexp.MustCompile(`Received unexpected status (\d+)`)
+
+// apiStatus returns the http status of a failed go-trello request, 0 when it failed without a response
+func apiStatus(err error) int {
+	match := unexpectedStatus.FindStringSubmatch(errors.Cause(err).Error())
+	if match == nil {
+		return 0
+	}
+
+	status, _ := strconv.Atoi(match[1])
+	return status
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/collate"
+	languagetag "golang.org/x/text/language"
+)
+
+var (
+	collation = &nameCollation{}
+)
+
+// nameCollation orders names the way readers of a locale expect, e.g. ä with a in german and after z in swedish. a
+// collator can't be shared between goroutines so comparisons take turns
+type nameCollation struct {
+	mu       sync.Mutex
+	collator *collate.Collator
+}
+
+// setCollation selects the locale names are sorted for, selected once at startup with --collation. names are
+// compared byte by byte when no locale is given
+func setCollation(locale string) error {
+	if locale == "" {
+		collation.collator = nil
+		return nil
+	}
+
+	tag, err := languagetag.Parse(locale)
+	if err != nil {
+		return errors.Wrapf(err, "unsupported collation %q, expected a locale such as de or sv", locale)
+	}
+
+	collation.collator = collate.New(tag, collate.IgnoreCase)
+	return nil
+}
+
+// less reports whether name a sorts before name b
+func (n *nameCollation) less(a string, b string) bool {
+	if n.collator == nil {
+		return a < b
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.collator.CompareString(a, b) < 0
+}
+
+// sort orders the names
+func (n *nameCollation) sort(names []string) {
+	sort.SliceStable(names, func(i, j int) bool {
+		return n.less(names[i], names[j])
+	})
+}
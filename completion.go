@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	bashCompletionTemplate = `# bash completion for {{.Name}}
+_{{.Func}}() {
+    local cur prev cmd opts
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+
+    if [[ "$prev" == "--profile" ]]; then
+        # profiles are listed from the config given earlier on the command line, bash splits --config=path at the =
+        local config=() i
+        for ((i = 1; i < COMP_CWORD - 1; i++)); do
+            if [[ "${COMP_WORDS[i]}" == "--config" ]]; then
+                if [[ "${COMP_WORDS[i+1]}" == "=" ]]; then
+                    ((i++))
+                fi
+                config=(--config "${COMP_WORDS[i+1]/#\~/$HOME}")
+            fi
+        done
+        COMPREPLY=( $(compgen -W "$({{.Name}} "${config[@]}" completion --list-profiles 2>/dev/null)" -- "$cur") )
+        return 0
+    fi
+
+    cmd=""
+    for word in "${COMP_WORDS[@]:1:COMP_CWORD-1}"; do
+        case "$word" in
+            {{.CommandNames "|"}}) cmd="$word" ;;
+        esac
+    done
+
+    case "$cmd" in
+{{- range .Commands}}
+        {{.Name}}) opts="{{join .Flags " "}}" ;;
+{{- end}}
+        *) opts="{{.CommandNames " "}} {{join .GlobalFlags " "}}" ;;
+    esac
+
+    COMPREPLY=( $(compgen -W "$opts" -- "$cur") )
+    return 0
+}
+complete -F _{{.Func}} {{.Name}}
+`
+
+	zshCompletionTemplate = `#compdef {{.Name}}
+autoload -U +X bashcompinit && bashcompinit
+` + bashCompletionTemplate
+
+	fishCompletionTemplate = `# fish completion for {{.Name}}
+complete -c {{.Name}} -f
+# profiles are listed from the config given earlier on the command line
+function __{{.Func}}_config
+    set -l words (commandline -opc)
+    set -l config
+    for i in (seq 2 (count $words))
+        switch $words[$i]
+            case --config
+                if test $i -lt (count $words)
+                    set config --config $words[(math $i + 1)]
+                end
+            case '--config=*'
+                set config $words[$i]
+        end
+    end
+    if set -q config[1]
+        string replace -r '^~' $HOME -- $config | string replace -r '^--config=~' --config=$HOME
+    end
+end
+complete -c {{.Name}} -l profile -x -a '({{.Name}} (__{{.Func}}_config) completion --list-profiles 2>/dev/null)'
+{{- range .GlobalFlagDetails}}
+complete -c {{$.Name}} -n '__fish_use_subcommand' -l {{.Name}} -d '{{.Usage}}'
+{{- end}}
+{{- range .Commands}}
+complete -c {{$.Name}} -n '__fish_use_subcommand' -a {{.Name}} -d '{{.Usage}}'
+{{- $command := .Name}}
+{{- range .Subcommands}}
+complete -c {{$.Name}} -n '__fish_seen_subcommand_from {{$command}}' -a {{.Name}} -d '{{.Usage}}'
+{{- end}}
+{{- range .FlagDetails}}
+complete -c {{$.Name}} -n '__fish_seen_subcommand_from {{$command}}' -l {{.Name}} -d '{{.Usage}}'
+{{- end}}
+{{- end}}
+`
+)
+
+var (
+	completionTemplates = map[string]string{
+		"bash": bashCompletionTemplate,
+		"zsh":  zshCompletionTemplate,
+		"fish": fishCompletionTemplate,
+	}
+)
+
+type completionFlag struct {
+	Name  string
+	Usage string
+}
+
+type completionCommand struct {
+	Name        string
+	Usage       string
+	Flags       []string
+	FlagDetails []completionFlag
+	// Subcommands are completed after the command by fish, bash and zsh complete them along with the flags
+	Subcommands []completionFlag
+}
+
+type completionData struct {
+	Name              string
+	Func              string
+	Commands          []completionCommand
+	GlobalFlags       []string
+	GlobalFlagDetails []completionFlag
+}
+
+func (d completionData) CommandNames(sep string) string {
+	var names []string
+	for _, command := range d.Commands {
+		names = append(names, command.Name)
+	}
+
+	return strings.Join(names, sep)
+}
+
+func completion(c *cli.Context) error {
+	if c.Bool("list-profiles") {
+		cfg, err := loadConfig(c)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range cfg.profileNames() {
+			fmt.Println(name)
+		}
+
+		return nil
+	}
+
+	shell := c.Args().First()
+	text, ok := completionTemplates[shell]
+	if !ok {
+		return errors.Errorf("unsupported shell %q, expected one of bash, zsh or fish", shell)
+	}
+
+	tmpl, err := template.New(shell).Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+	if err != nil {
+		return err
+	}
+
+	return tmpl.Execute(os.Stdout, newCompletionData(c.App))
+}
+
+func newCompletionData(app *cli.App) completionData {
+	data := completionData{
+		Name: app.Name,
+		Func: strings.Replace(app.Name, "-", "_", -1),
+	}
+
+	data.GlobalFlags, data.GlobalFlagDetails = completionFlags(app.Flags)
+	for _, command := range app.Commands {
+		if command.Hidden {
+			continue
+		}
+
+		flags, details := completionFlags(command.Flags)
+
+		// subcommands are offered along with their flags, e.g. stats cfd --since
+		var subcommands []completionFlag
+		for _, subcommand := range command.Subcommands {
+			flags = append(flags, subcommand.Name)
+			subcommands = append(subcommands, completionFlag{
+				Name:  subcommand.Name,
+				Usage: escapeFishDescription(subcommand.Usage),
+			})
+			subFlags, subDetails := completionFlags(subcommand.Flags)
+			for i, flag := range subFlags {
+				if !containsString(flags, flag) {
+					flags = append(flags, flag)
+					details = append(details, subDetails[i])
+				}
+			}
+		}
+
+		data.Commands = append(data.Commands, completionCommand{
+			Name:        command.Name,
+			Usage:       escapeFishDescription(command.Usage),
+			Flags:       flags,
+			FlagDetails: details,
+			Subcommands: subcommands,
+		})
+	}
+
+	return data
+}
+
+func completionFlags(flags []cli.Flag) ([]string, []completionFlag) {
+	var names []string
+	var details []completionFlag
+	for _, flag := range flags {
+		if isHiddenFlag(flag) {
+			continue
+		}
+
+		name := strings.TrimSpace(strings.Split(flag.GetName(), ",")[0])
+		usage := ""
+		if docFlag, ok := flag.(cli.DocGenerationFlag); ok {
+			usage = docFlag.GetUsage()
+		}
+
+		names = append(names, "--"+name)
+		details = append(details, completionFlag{
+			Name:  name,
+			Usage: escapeFishDescription(usage),
+		})
+	}
+
+	return names, details
+}
+
+// isHiddenFlag reports whether the flag has its Hidden field set, cli v1 doesn't expose it through the Flag interface
+func isHiddenFlag(flag cli.Flag) bool {
+	v := reflect.Indirect(reflect.ValueOf(flag))
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+
+	hidden := v.FieldByName("Hidden")
+
+	return hidden.IsValid() && hidden.Kind() == reflect.Bool && hidden.Bool()
+}
+
+func escapeFishDescription(s string) string {
+	return strings.Replace(s, "'", `\'`, -1)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	defaultConfigPath = "trello2md.yaml"
+)
+
+type config struct {
+	// Credentials are named key/token pairs which can be referenced from boards
+	Credentials map[string]credentials `yaml:"credentials"`
+	// Aliases map readable names to board ids, an alias is accepted anywhere a board id is
+	Aliases map[string]string `yaml:"aliases"`
+	// Boards holds per board settings keyed by board id or alias
+	Boards map[string]boardConfig `yaml:"boards"`
+	// Profiles are named sets of export flag values selected with --profile
+	Profiles map[string]profile `yaml:"profiles"`
+	// Access protects the serve command, requests are open to anyone when there are no rules
+	Access []accessRule `yaml:"access"`
+	// Sections route cards into report sections by label when exporting with --sections
+	Sections reportSections `yaml:"sections"`
+}
+
+// profile maps export flag names to the values they take when the flag isn't set on the command line, the command
+// key names the command run executes for the profile
+type profile map[string]interface{}
+
+type credentials struct {
+	Key   string `yaml:"key"`
+	Token string `yaml:"token"`
+	// Secret is the application secret, requests are signed with oauth 1.0a along with the token secret when set
+	Secret      string `yaml:"secret"`
+	TokenSecret string `yaml:"token_secret"`
+}
+
+type boardConfig struct {
+	// Credentials names the credentials entry used to access the board, the global key and token are used when unset
+	Credentials string `yaml:"credentials"`
+	// Order places the board explicitly in the document, boards with a lower order come first and boards without
+	// an order follow those which have one
+	Order int `yaml:"order"`
+	// WIPLimits are the most cards each list should hold keyed by list name, lists holding more are flagged by the
+	// stats summary and snapshot diffs
+	WIPLimits map[string]int `yaml:"wip_limits"`
+	// Team groups the board's cards under the team in release trains, boards without a team are grouped on their own
+	Team string `yaml:"team"`
+}
+
+// loadConfig reads the config file, a missing file is only an error when the path was given explicitly
+func loadConfig(c *cli.Context) (*config, error) {
+	path := c.GlobalString("config")
+	if path == "" {
+		path = defaultConfigPath
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !c.GlobalIsSet("config") {
+		return &config{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read config")
+	}
+
+	var cfg config
+	err = yaml.UnmarshalStrict(data, &cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse config %s", path)
+	}
+
+	for name, creds := range cfg.Credentials {
+		cfg.Credentials[name] = credentials{
+			Key:         os.ExpandEnv(creds.Key),
+			Token:       os.ExpandEnv(creds.Token),
+			Secret:      os.ExpandEnv(creds.Secret),
+			TokenSecret: os.ExpandEnv(creds.TokenSecret),
+		}
+	}
+
+	for i, rule := range cfg.Access {
+		cfg.Access[i].Password = os.ExpandEnv(rule.Password)
+		cfg.Access[i].Token = os.ExpandEnv(rule.Token)
+	}
+
+	err = validateAccessRules(cfg.Access)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.Sections.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	for alias, boardId := range cfg.Aliases {
+		if boardId == "" {
+			return nil, errors.Errorf("alias %s has no board id", alias)
+		}
+	}
+
+	for boardId, board := range cfg.Boards {
+		for list, limit := range board.WIPLimits {
+			if limit < 1 {
+				return nil, errors.Errorf("board %s has a WIP limit of %d for %s, limits must be at least 1", boardId, limit, list)
+			}
+		}
+
+		if board.Credentials == "" {
+			continue
+		}
+
+		if _, ok := cfg.Credentials[board.Credentials]; !ok {
+			return nil, errors.Errorf("board %s references unknown credentials %q", boardId, board.Credentials)
+		}
+	}
+
+	return &cfg, nil
+}
+
+// boardID resolves an alias to the board id it stands for, anything else is assumed to already be a board id
+func (c *config) boardID(idOrAlias string) string {
+	if boardId, ok := c.Aliases[idOrAlias]; ok {
+		return boardId
+	}
+
+	return idOrAlias
+}
+
+// board returns the settings of the board, whether they're keyed by its id or one of its aliases
+func (c *config) board(boardId string) boardConfig {
+	boardId = c.boardID(boardId)
+	if board, ok := c.Boards[boardId]; ok {
+		return board
+	}
+
+	for key, board := range c.Boards {
+		if c.boardID(key) == boardId {
+			return board
+		}
+	}
+
+	return boardConfig{}
+}
+
+// wipViolation describes how a list holding the number of cards exceeds its WIP limit, it's empty when the list
+// has no limit or is within it
+func wipViolation(cards int, limit int) string {
+	if limit == 0 || cards <= limit {
+		return ""
+	}
+
+	return tr("overWIPLimit", limit)
+}
+
+// configuredBoards returns the ids of every board the config has an alias or settings for, sorted
+func (c *config) configuredBoards() []string {
+	var boardIds []string
+	for _, boardId := range c.Aliases {
+		if !containsString(boardIds, boardId) {
+			boardIds = append(boardIds, boardId)
+		}
+	}
+	for key := range c.Boards {
+		if boardId := c.boardID(key); !containsString(boardIds, boardId) {
+			boardIds = append(boardIds, boardId)
+		}
+	}
+
+	sort.Strings(boardIds)
+
+	return boardIds
+}
+
+func (c *config) profileNames() []string {
+	var names []string
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// applyProfile sets every flag held in the selected profile which wasn't already set by an argument or env var
+func applyProfile(c *cli.Context, cfg *config) error {
+	name := c.String("profile")
+	if name == "" {
+		return nil
+	}
+
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		return errors.Errorf("unknown profile %q", name)
+	}
+
+	for flagName, value := range p {
+		if flagName == profileCommandKey || c.IsSet(flagName) {
+			continue
+		}
+
+		values, ok := value.([]interface{})
+		if !ok {
+			values = []interface{}{value}
+		}
+
+		for _, v := range values {
+			err := c.Set(flagName, fmt.Sprint(v))
+			if err != nil {
+				return errors.Wrapf(err, "invalid value for %s in profile %s", flagName, name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var (
+	copyBoardArgs = []cli.Flag{
+		cli.StringFlag{
+			Name:  "from-board",
+			Usage: "the id or config alias of the board to copy cards from",
+		},
+		cli.StringFlag{
+			Name:  "from-list",
+			Usage: "the name of the list to copy cards from, every open list is copied when empty",
+		},
+		cli.StringFlag{
+			Name:  "to-board",
+			Usage: "the id or config alias of the board to copy cards to",
+		},
+		cli.StringFlag{
+			Name:  "to-list",
+			Usage: "the name of the list to copy cards into, defaults to the list of the same name as the card's list",
+		},
+		cli.BoolFlag{
+			Name:  "with-comments",
+			Usage: "copy comments too, they are posted by the token's member and credit the original author",
+		},
+	}
+)
+
+// boardLabel is a label defined on a board
+type boardLabel struct {
+	Id    string `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+// copyBoard clones cards along with their descriptions, labels, checklists and optionally comments from one board
+// to another, labels missing from the target board are created
+func copyBoard(c *cli.Context) error {
+	if c.String("from-board") == "" || c.String("to-board") == "" {
+		return errors.New("--from-board and --to-board are required")
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	clients := newBoardClients(c, cfg)
+	boards, err := getBoards(clients, []string{c.String("from-board"), c.String("to-board")})
+	if err != nil {
+		return err
+	}
+	from, to := (*boards)[0], (*boards)[1]
+
+	fromClient, err := clients.forBoard(from.Id)
+	if err != nil {
+		return err
+	}
+
+	toClient, err := clients.forBoard(to.Id)
+	if err != nil {
+		return err
+	}
+
+	var sourceLists []trello.List
+	if c.String("from-list") != "" {
+		list, err := getList(&from, c.String("from-list"))
+		if err != nil {
+			return err
+		}
+
+		sourceLists = append(sourceLists, *list)
+	} else {
+		sourceLists, err = getLists(&from)
+		if err != nil {
+			return err
+		}
+	}
+
+	labels, err := getBoardLabels(toClient, to.Id)
+	if err != nil {
+		return err
+	}
+
+	for i := range sourceLists {
+		targetName := c.String("to-list")
+		if targetName == "" {
+			targetName = sourceLists[i].Name
+		}
+
+		target, err := getList(&to, targetName)
+		if err != nil {
+			return errors.Wrapf(err, "unable to find list %s on board %s", targetName, to.Name)
+		}
+
+		cards, err := getCards(fromClient, &sourceLists[i], cardSortPosition, true)
+		if err != nil {
+			return err
+		}
+
+		for j := range *cards {
+			err = copyCard(fromClient, toClient, &(*cards)[j], target, labels, c.Bool("with-comments"))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func copyCard(fromClient *trello.Client, client *trello.Client, card *trello.Card, target *trello.List, labels map[string]string, withComments bool) error {
+	copied, err := target.AddCard(trello.Card{
+		Name: card.Name,
+		Desc: card.Desc,
+		Pos:  card.Pos,
+		Due:  card.Due,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "unable to copy card %s", card.Name)
+	}
+
+	for _, label := range card.Labels {
+		key := label.Color + "/" + label.Name
+		labelId, ok := labels[key]
+		if !ok {
+			labelId, err = createBoardLabel(client, target.IdBoard, label.Name, label.Color)
+			if err != nil {
+				return err
+			}
+			labels[key] = labelId
+		}
+
+		_, err = client.Post("/cards/"+copied.Id+"/idLabels", url.Values{"value": {labelId}})
+		if err != nil {
+			return errors.Wrapf(err, "unable to label card %s", card.Name)
+		}
+	}
+
+	checklists, err := getCardCheckLists(fromClient, card)
+	if err != nil {
+		return err
+	}
+
+	for _, checklist := range *checklists {
+		copiedChecklist, err := copied.AddChecklist(checklist.Name)
+		if err != nil {
+			return errors.Wrapf(err, "unable to copy checklist %s of card %s", checklist.Name, card.Name)
+		}
+
+		for _, item := range checklist.CheckItems {
+			checked := item.State == checkItemComplete
+			_, err = copiedChecklist.AddItem(item.Name, nil, &checked)
+			if err != nil {
+				return errors.Wrapf(err, "unable to copy checklist %s of card %s", checklist.Name, card.Name)
+			}
+		}
+	}
+
+	if withComments {
+		comments, err := getCardComments(fromClient, card)
+		if err != nil {
+			return err
+		}
+
+		for _, comment := range *comments {
+			date, err := formatDate(comment.Date)
+			if err != nil {
+				return err
+			}
+
+			_, err = copied.AddComment(fmt.Sprintf("**%s** on %s:\n\n%s", comment.MemberCreator.FullName, date, comment.Data.Text))
+			if err != nil {
+				return errors.Wrapf(err, "unable to copy comments of card %s", card.Name)
+			}
+		}
+	}
+
+	log.Printf("copied %s to %s", card.Name, copied.Url)
+
+	return nil
+}
+
+// getBoardLabels returns the ids of the board's labels keyed by color and name
+func getBoardLabels(client *trello.Client, boardId string) (map[string]string, error) {
+	body, err := client.Get("/boards/" + boardId + "/labels")
+	if err != nil {
+		return nil, err
+	}
+
+	var labels []boardLabel
+	err = json.Unmarshal(body, &labels)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := map[string]string{}
+	for _, label := range labels {
+		ids[label.Color+"/"+label.Name] = label.Id
+	}
+
+	return ids, nil
+}
+
+func createBoardLabel(client *trello.Client, boardId string, name string, color string) (string, error) {
+	body, err := client.Post("/labels", url.Values{"idBoard": {boardId}, "name": {name}, "color": {color}})
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to create label %s", name)
+	}
+
+	var label boardLabel
+	err = json.Unmarshal(body, &label)
+	if err != nil {
+		return "", err
+	}
+
+	return label.Id, nil
+}
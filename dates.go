@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// malformedDates remembers the malformed dates already warned about, sorts compare each date many times
+	malformedDates sync.Map
+)
+
+// parseTrelloDate reads an RFC3339 trello date, a date trello sent malformed or left empty is warned about once and
+// read as the zero time rather than failing the export over one bad card. subject names what the date belongs to
+func parseTrelloDate(date string, subject string) time.Time {
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		if _, warned := malformedDates.LoadOrStore(subject+"\x00"+date, true); !warned {
+			warnings.add(WarningMalformedDate, subject, fmt.Sprintf("%s has a malformed date %q, treating it as unknown", subject, date))
+		}
+
+		return time.Time{}
+	}
+
+	return t
+}
+
+// dateLess orders dates oldest first with unknown dates last
+func dateLess(a time.Time, b time.Time) bool {
+	if a.IsZero() || b.IsZero() {
+		return !a.IsZero() && b.IsZero()
+	}
+
+	return a.Before(b)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+const (
+	degradedCustomFields = "custom fields"
+	degradedReactions    = "reactions"
+	degradedPluginData   = "plugin data"
+	degradedStickers     = "stickers"
+)
+
+var (
+	degradations = &degradationLog{}
+)
+
+// Degradation is an optional section trello refused to serve, which was left out of the export rather than failing it
+type Degradation struct {
+	// Feature names the section, e.g. custom fields
+	Feature string `json:"feature"`
+	// Status is the status trello refused the section's endpoint with
+	Status int `json:"status"`
+	// Omitted counts the boards, cards or comments the section was left out of
+	Omitted int `json:"omitted"`
+}
+
+// degradationLog collects the optional sections left out during the lifetime of the process, each run report
+// summarises those left out while it ran
+type degradationLog struct {
+	mu     sync.Mutex
+	events []Degradation
+}
+
+// optional lets the export go on without an optional section when trello refuses its endpoint as forbidden or not
+// found, which it does when the board's plan doesn't include the feature or its power-up is disabled. the section is
+// logged as degraded and nil returned so the caller omits it, any other error is returned as is
+func optional(feature string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	status := apiStatus(err)
+	if status != http.StatusForbidden && status != http.StatusNotFound {
+		return err
+	}
+
+	degradations.mu.Lock()
+	defer degradations.mu.Unlock()
+
+	degradations.events = append(degradations.events, Degradation{Feature: feature, Status: status, Omitted: 1})
+
+	return nil
+}
+
+func (l *degradationLog) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.events)
+}
+
+// since totals the sections left out after the first n, one degradation per feature and status in the order they
+// were first left out
+func (l *degradationLog) since(n int) []Degradation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var summary []Degradation
+	index := map[Degradation]int{}
+	for _, event := range l.events[n:] {
+		key := Degradation{Feature: event.Feature, Status: event.Status}
+		i, ok := index[key]
+		if !ok {
+			i = len(summary)
+			index[key] = i
+			summary = append(summary, key)
+		}
+
+		summary[i].Omitted += event.Omitted
+	}
+
+	return summary
+}
+
+func (d Degradation) String() string {
+	return fmt.Sprintf("trello refused the %s with status %d, the board's plan may not include them or their power-up is disabled, they were left out %d times", d.Feature, d.Status, d.Omitted)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jakekeeys/go-trello"
+)
+
+// cardDependency is a card linked from a checklist item of another card along with where it is now
+type cardDependency struct {
+	Name string
+	Url  string
+	// Status is the list the card is in, or why it can't be followed
+	Status string
+}
+
+// dependencyCard is a linked card along with the list it's in
+type dependencyCard struct {
+	Name   string `json:"name"`
+	Url    string `json:"url"`
+	Closed bool   `json:"closed"`
+	List   struct {
+		Name string `json:"name"`
+	} `json:"list"`
+}
+
+// getCardDependencies looks up every card linked from the checklist items, which is how teams note the cards a card
+// depends on. a linked card which can't be read, e.g. on a board the token has no access to, is listed as
+// unavailable rather than failing the export
+func getCardDependencies(client *trello.Client, checklists []Checklist) []cardDependency {
+	var dependencies []cardDependency
+	seen := map[string]bool{}
+	for _, checklist := range checklists {
+		for _, item := range checklist.CheckItems {
+			for _, match := range trelloCardUrl.FindAllStringSubmatch(item.Name, -1) {
+				shortLink := match[1]
+				if seen[shortLink] {
+					continue
+				}
+				seen[shortLink] = true
+
+				card, err := getDependencyCard(client, shortLink)
+				if err != nil {
+					warnings.add(WarningUnresolvedLink, shortLink, fmt.Sprintf("unable to look up linked card %s: %v", shortLink, err))
+					dependencies = append(dependencies, cardDependency{Name: shortLink, Url: match[0], Status: tr("unavailable")})
+					continue
+				}
+
+				dependency := cardDependency{Name: card.Name, Url: card.Url, Status: card.List.Name}
+				if card.Closed {
+					dependency.Status = tr("archived")
+				}
+
+				dependencies = append(dependencies, dependency)
+			}
+		}
+	}
+
+	return dependencies
+}
+
+func getDependencyCard(client *trello.Client, shortLink string) (*dependencyCard, error) {
+	body, err := client.Get("/cards/" + shortLink + "?fields=name,url,closed&list=true&list_fields=name")
+	if err != nil {
+		return nil, err
+	}
+
+	var card dependencyCard
+	err = json.Unmarshal(body, &card)
+	if err != nil {
+		return nil, err
+	}
+
+	return &card, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jakekeeys/go-trello"
+)
+
+// listSummary is a list's card count and label distribution, used to draw the board diagram
+type listSummary struct {
+	list   List
+	cards  int
+	labels map[string]int
+}
+
+// fetchBoardStructure summarises every open list of the board left to right
+func fetchBoardStructure(board *trello.Board) ([]listSummary, error) {
+	lists, err := getLists(board)
+	if err != nil {
+		return nil, err
+	}
+
+	cards, err := board.Cards()
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]listSummary, len(lists))
+	byId := map[string]*listSummary{}
+	for i, list := range lists {
+		summaries[i] = listSummary{list: newList(list), labels: map[string]int{}}
+		byId[list.Id] = &summaries[i]
+	}
+
+	for _, card := range cards {
+		summary, ok := byId[card.IdList]
+		if !ok {
+			continue
+		}
+
+		summary.cards++
+		for _, label := range card.Labels {
+			name := label.Name
+			if name == "" {
+				name = label.Color
+			}
+			summary.labels[name]++
+		}
+	}
+
+	return summaries, nil
+}
+
+// printBoardDiagram writes a mermaid flowchart with a node per list showing its card count and labels
+func printBoardDiagram(w io.Writer, summaries []listSummary) {
+	fmt.Fprintln(w, "```mermaid")
+	fmt.Fprintln(w, "flowchart LR")
+
+	for _, summary := range summaries {
+		text := fmt.Sprintf("%s<br/>%d cards", mermaidText(summary.list.Name), summary.cards)
+
+		var names []string
+		for name := range summary.labels {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if summary.labels[names[i]] != summary.labels[names[j]] {
+				return summary.labels[names[i]] > summary.labels[names[j]]
+			}
+			return collation.less(names[i], names[j])
+		})
+
+		var labels []string
+		for _, name := range names {
+			labels = append(labels, fmt.Sprintf("%s %d", mermaidText(name), summary.labels[name]))
+		}
+		if len(labels) > 0 {
+			text += "<br/>" + strings.Join(labels, ", ")
+		}
+
+		fmt.Fprintf(w, "    %s[\"%s\"]\n", mermaidId(summary.list), text)
+	}
+
+	for i := 1; i < len(summaries); i++ {
+		fmt.Fprintf(w, "    %s --> %s\n", mermaidId(summaries[i-1].list), mermaidId(summaries[i].list))
+	}
+
+	fmt.Fprintln(w, "```")
+	fmt.Fprintln(w)
+}
+
+func mermaidId(list List) string {
+	return "list_" + list.Id
+}
+
+// mermaidText escapes the characters which would end or break a quoted mermaid node label
+func mermaidText(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	// defaultDigestTemplate renders a card as a newsletter story, its cover, the first paragraph of its description
+	// and a link to the rest
+	defaultDigestTemplate = `#### {{.Card.Name}}
+{{with .Cover -}}
+![{{altText .Name $.Card.Name}}]({{.Url}})
+
+{{end -}}
+{{with .Summary -}}
+{{.}}
+
+{{end -}}
+{{with .Card.Url -}}
+[{{tr "readMore"}}]({{.}})
+
+{{end -}}
+`
+
+	digestPickVotes = "votes"
+	digestPickLabel = "label"
+)
+
+var (
+	digestArgs = []cli.Flag{
+		cli.IntFlag{
+			Name:   "top",
+			Usage:  "the most cards the digest features",
+			EnvVar: "TRELLO2MD_DIGEST_TOP",
+			Value:  5,
+		},
+		cli.StringFlag{
+			Name:   "pick-by",
+			Usage:  "how the featured cards are picked, votes for the most voted cards or label for the cards with --digest-label",
+			EnvVar: "TRELLO2MD_DIGEST_PICK_BY",
+			Value:  digestPickVotes,
+		},
+		cli.StringFlag{
+			Name:   "digest-label",
+			Usage:  "the label marking the cards to feature when picking by label",
+			EnvVar: "TRELLO2MD_DIGEST_LABEL",
+			Value:  "digest",
+		},
+	}
+)
+
+// digest renders the top cards of the boards as a newsletter, each with its cover image, the first paragraph of its
+// description and a link, leaving out everything else. the --template flag replaces the digest template
+func digest(c *cli.Context) error {
+	pickBy := c.String("pick-by")
+	if pickBy != digestPickVotes && pickBy != digestPickLabel {
+		return errors.Errorf("unsupported pick %q, expected votes or label", pickBy)
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	err = applyProfile(c, cfg)
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := loadCardTemplate(c.String("template"), defaultDigestTemplate)
+	if err != nil {
+		return err
+	}
+
+	exports, err := fetchExports(c, cfg, fetchOptions{
+		ListFilters:      listFilters(c),
+		AllLists:         c.Bool("all-lists"),
+		Sort:             c.String("sort"),
+		IncludeTemplates: c.Bool("include-templates"),
+	})
+	if err != nil {
+		return err
+	}
+
+	picked := pickDigestCards(exportedCards(exports), pickBy, c.String("digest-label"), c.Int("top"))
+
+	clients := newBoardClients(c, cfg)
+
+	var document bytes.Buffer
+	printDate(&document, time.Now())
+	for _, view := range picked {
+		if view.Card.IdAttachmentCover != "" {
+			client, err := clients.forBoard(view.Board.Id)
+			if err != nil {
+				return err
+			}
+
+			view.Cover, err = getCardCover(client, &view.Card)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = tmpl.Execute(&document, view)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.String("output") != "" {
+		return writeFileIfChanged(c.String("output"), document.Bytes())
+	}
+
+	_, err = os.Stdout.Write(document.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "unable to write digest")
+	}
+
+	return nil
+}
+
+// pickDigestCards returns the top cards, the most voted first when picking by votes or the cards carrying the label
+// in document order
+func pickDigestCards(views []*cardView, pickBy string, label string, top int) []*cardView {
+	var picked []*cardView
+	switch pickBy {
+	case digestPickVotes:
+		picked = append(picked, views...)
+		sort.SliceStable(picked, func(i, j int) bool {
+			return picked[i].Card.Badges.Votes > picked[j].Card.Badges.Votes
+		})
+	case digestPickLabel:
+		for _, view := range views {
+			if containsString(view.LabelNames(), label) {
+				picked = append(picked, view)
+			}
+		}
+	}
+
+	if top > 0 && len(picked) > top {
+		picked = picked[:top]
+	}
+
+	return picked
+}
+
+// getCardCover fetches the attachment shown as the card's cover
+func getCardCover(client *trello.Client, card *Card) (*Attachment, error) {
+	body, err := client.Get("/cards/" + card.Id + "/attachments/" + card.IdAttachmentCover)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to fetch the cover of %s", card.Name)
+	}
+
+	var cover trello.Attachment
+	err = json.Unmarshal(body, &cover)
+	if err != nil {
+		return nil, err
+	}
+
+	return &newAttachments([]trello.Attachment{cover})[0], nil
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+const (
+	// docxMaxImageWidth keeps images within the margins of an A4 or letter page, in EMUs
+	docxMaxImageWidth = 6 * 914400
+	// docxEMUPerPixel converts image pixels at 96 dpi to EMUs
+	docxEMUPerPixel = 9525
+
+	docxRelImage     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/image"
+	docxRelHyperlink = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink"
+	docxRelStyles    = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles"
+
+	docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Default Extension="png" ContentType="image/png"/>
+<Default Extension="jpeg" ContentType="image/jpeg"/>
+<Default Extension="gif" ContentType="image/gif"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+</Types>`
+
+	docxPackageRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+</Relationships>`
+
+	docxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:eastAsia="Calibri" w:cs="Calibri"/><w:sz w:val="22"/></w:rPr></w:rPrDefault><w:pPrDefault><w:pPr><w:spacing w:after="120"/></w:pPr></w:pPrDefault></w:docDefaults>
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/></w:style>
+<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="360"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:sz w:val="36"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="320"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/><w:sz w:val="32"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading3"><w:name w:val="heading 3"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="280"/><w:outlineLvl w:val="2"/></w:pPr><w:rPr><w:b/><w:sz w:val="28"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading4"><w:name w:val="heading 4"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="240"/><w:outlineLvl w:val="3"/></w:pPr><w:rPr><w:b/><w:sz w:val="24"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading5"><w:name w:val="heading 5"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:outlineLvl w:val="4"/></w:pPr><w:rPr><w:b/><w:sz w:val="22"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading6"><w:name w:val="heading 6"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:outlineLvl w:val="5"/></w:pPr><w:rPr><w:b/><w:i/><w:sz w:val="22"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Quote"><w:name w:val="Quote"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="0"/><w:ind w:left="567"/><w:pBdr><w:left w:val="single" w:sz="12" w:space="8" w:color="BFBFBF"/></w:pBdr></w:pPr><w:rPr><w:i/><w:color w:val="595959"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="CodeBlock"><w:name w:val="Code Block"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="0"/><w:shd w:val="clear" w:color="auto" w:fill="F2F2F2"/></w:pPr><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/><w:sz w:val="18"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="ListParagraph"><w:name w:val="List Paragraph"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="40"/></w:pPr></w:style>
+<w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/><w:rPr><w:color w:val="0563C1"/><w:u w:val="single"/></w:rPr></w:style>
+<w:style w:type="character" w:styleId="Code"><w:name w:val="Code"/><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/><w:color w:val="C7254E"/></w:rPr></w:style>
+<w:style w:type="table" w:styleId="TableGrid"><w:name w:val="Table Grid"/><w:tblPr><w:tblBorders><w:top w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:left w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:bottom w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:right w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:insideH w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:insideV w:val="single" w:sz="4" w:space="0" w:color="auto"/></w:tblBorders></w:tblPr></w:style>
+</w:styles>`
+
+	docxDocumentStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture" xmlns:w14="http://schemas.microsoft.com/office/word/2010/wordml" xmlns:mc="http://schemas.openxmlformats.org/markup-compatibility/2006" mc:Ignorable="w14">
+<w:body>
+`
+	docxDocumentEnd = `<w:sectPr><w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440" w:header="720" w:footer="720" w:gutter="0"/></w:sectPr>
+</w:body>
+</w:document>`
+)
+
+// docxRelationship is a link from the document to an image or a web page
+type docxRelationship struct {
+	Id       string
+	Type     string
+	Target   string
+	External bool
+}
+
+// docxImage is an image embedded in the document
+type docxImage struct {
+	name string
+	data []byte
+}
+
+// docxWriter converts the markdown trello2md generates into a word document
+type docxWriter struct {
+	body          bytes.Buffer
+	relationships []docxRelationship
+	images        []docxImage
+	// embedded maps image urls to their relationship so an image used twice is only stored once, sizes holds the
+	// extent of each embedded image
+	embedded map[string]string
+	sizes    map[string][2]int
+	drawings int
+	// fetch downloads an image, images which can't be fetched are linked instead
+	fetch imageFetcher
+}
+
+// exportDocx renders the export as markdown and converts it into a word document written to --output, images are
+// downloaded and embedded
+func exportDocx(c *cli.Context) error {
+	markdown, path, err := renderDocument(c)
+	if err != nil {
+		return err
+	}
+
+	fetch, err := newImageFetcher(c)
+	if err != nil {
+		return err
+	}
+
+	docx, err := markdownToDocx(markdown, fetch)
+	if err != nil {
+		return err
+	}
+
+	return writeFileIfChanged(path, docx)
+}
+
+// markdownToDocx converts the markdown into a word document
+func markdownToDocx(markdown []byte, fetch imageFetcher) ([]byte, error) {
+	d := &docxWriter{
+		relationships: []docxRelationship{{Id: "rId1", Type: docxRelStyles, Target: "styles.xml"}},
+		embedded:      map[string]string{},
+		sizes:         map[string][2]int{},
+		fetch:         fetch,
+	}
+
+	err := walkMarkdown(markdown, d)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.pack()
+}
+
+func (d *docxWriter) heading(level int, text string) {
+	d.paragraph(fmt.Sprintf(`<w:pStyle w:val="Heading%d"/>`, level), d.inline(text, ""))
+}
+
+func (d *docxWriter) taskItem(indent string, checked bool, text string) {
+	d.paragraph(docxIndent(indent), docxCheckbox(checked)+d.text(" ", "")+d.inline(text, ""))
+}
+
+func (d *docxWriter) bulletItem(indent string, text string) {
+	d.paragraph(docxIndent(indent), d.text("•\t", "")+d.inline(text, ""))
+}
+
+func (d *docxWriter) quoteLine(text string) {
+	if strings.TrimSpace(text) != "" {
+		d.paragraph(`<w:pStyle w:val="Quote"/>`, d.inline(text, ""))
+	}
+}
+
+func (d *docxWriter) codeLine(line string) {
+	d.paragraph(`<w:pStyle w:val="CodeBlock"/>`, d.text(line, ""))
+}
+
+// tableRows writes the rows as a table with a bold header row
+func (d *docxWriter) tableRows(rows [][]string) {
+	d.body.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="TableGrid"/><w:tblW w:w="0" w:type="auto"/></w:tblPr>`)
+	for i, row := range rows {
+		d.body.WriteString("<w:tr>")
+		for _, cell := range row {
+			style := ""
+			if i == 0 {
+				style = "<w:b/>"
+			}
+			d.body.WriteString(`<w:tc><w:p>` + d.inline(strings.TrimSpace(cell), style) + `</w:p></w:tc>`)
+		}
+		d.body.WriteString("</w:tr>")
+	}
+	d.body.WriteString("</w:tbl>\n")
+	d.paragraph("", "")
+}
+
+func (d *docxWriter) summaryLine(text string) {
+	d.paragraph("", d.inline(text, "<w:b/>"))
+}
+
+func (d *docxWriter) textLine(text string) {
+	d.paragraph("", d.inline(text, ""))
+}
+
+func (d *docxWriter) rule() {
+	d.paragraph(`<w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="auto"/></w:pBdr>`, "")
+}
+
+// blank lines only separate blocks, every line is already a paragraph of its own
+func (d *docxWriter) blank() {}
+
+func (d *docxWriter) paragraph(properties string, runs string) {
+	d.body.WriteString("<w:p>")
+	if properties != "" {
+		d.body.WriteString("<w:pPr>" + properties + "</w:pPr>")
+	}
+	d.body.WriteString(runs)
+	d.body.WriteString("</w:p>\n")
+}
+
+// inline converts inline markdown to runs, style holds the run properties of the surrounding text
+func (d *docxWriter) inline(text string, style string) string {
+	var runs strings.Builder
+	last := 0
+	for _, m := range markdownInline.FindAllStringSubmatchIndex(text, -1) {
+		runs.WriteString(d.text(text[last:m[0]], style))
+		last = m[1]
+
+		switch {
+		case m[2] >= 0:
+			runs.WriteString(d.image(text[m[2]:m[3]], text[m[4]:m[5]], style))
+		case m[6] >= 0:
+			runs.WriteString(d.link(text[m[6]:m[7]], text[m[8]:m[9]], style))
+		case m[10] >= 0:
+			runs.WriteString(d.inline(text[m[10]:m[11]], style+"<w:b/>"))
+		case m[12] >= 0:
+			runs.WriteString(d.text(text[m[12]:m[13]], style+`<w:rStyle w:val="Code"/>`))
+		case m[14] >= 0:
+			runs.WriteString(d.inline(text[m[14]:m[15]], style+"<w:i/>"))
+		}
+	}
+	runs.WriteString(d.text(text[last:], style))
+
+	return runs.String()
+}
+
+func (d *docxWriter) text(text string, style string) string {
+	if text == "" {
+		return ""
+	}
+
+	var run strings.Builder
+	run.WriteString("<w:r>")
+	if style != "" {
+		run.WriteString("<w:rPr>" + docxRunProperties(style) + "</w:rPr>")
+	}
+
+	for i, part := range strings.Split(text, "\t") {
+		if i > 0 {
+			run.WriteString("<w:tab/>")
+		}
+		if part != "" {
+			run.WriteString(`<w:t xml:space="preserve">` + docxEscape(part) + "</w:t>")
+		}
+	}
+	run.WriteString("</w:r>")
+
+	return run.String()
+}
+
+// link writes a hyperlink, links within the document such as footnotes are left as text
+func (d *docxWriter) link(text string, target string, style string) string {
+	if strings.HasPrefix(target, "#") {
+		return d.inline(text, style)
+	}
+
+	id := d.relate(docxRelHyperlink, target, true)
+
+	return `<w:hyperlink r:id="` + id + `">` + d.inline(text, style+`<w:rStyle w:val="Hyperlink"/>`) + "</w:hyperlink>"
+}
+
+// image embeds the image inline, an image which can't be fetched or read is linked instead
+func (d *docxWriter) image(alt string, url string, style string) string {
+	id, ok := d.embedded[url]
+	if !ok {
+		var err error
+		id, err = d.embed(url)
+		if err != nil {
+			warnings.add(WarningImage, url, fmt.Sprintf("unable to embed image %s, linking it instead: %v", url, err))
+			id = ""
+		}
+		d.embedded[url] = id
+	}
+
+	if id == "" {
+		if alt == "" {
+			alt = url
+		}
+
+		return d.link(alt, url, style)
+	}
+
+	size := d.sizes[id]
+	d.drawings++
+
+	return fmt.Sprintf(`<w:r><w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0"><wp:extent cx="%d" cy="%d"/><wp:docPr id="%d" name="Picture %d" descr="%s"/><a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture"><pic:pic><pic:nvPicPr><pic:cNvPr id="%d" name="Picture %d"/><pic:cNvPicPr/></pic:nvPicPr><pic:blipFill><a:blip r:embed="%s"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill><pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr></pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r>`,
+		size[0], size[1], d.drawings, d.drawings, docxEscape(alt), d.drawings, d.drawings, id, size[0], size[1])
+}
+
+// embed downloads the image and adds it to the document, returning its relationship id
+func (d *docxWriter) embed(url string) (string, error) {
+	data, err := d.fetch(url)
+	if err != nil {
+		return "", err
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	width, height := config.Width*docxEMUPerPixel, config.Height*docxEMUPerPixel
+	if width > docxMaxImageWidth {
+		height = height * docxMaxImageWidth / width
+		width = docxMaxImageWidth
+	}
+
+	name := fmt.Sprintf("media/image%d.%s", len(d.images)+1, format)
+	d.images = append(d.images, docxImage{name: name, data: data})
+
+	id := d.relate(docxRelImage, name, false)
+	d.sizes[id] = [2]int{width, height}
+
+	return id, nil
+}
+
+func (d *docxWriter) relate(relType string, target string, external bool) string {
+	id := fmt.Sprintf("rId%d", len(d.relationships)+1)
+	d.relationships = append(d.relationships, docxRelationship{Id: id, Type: relType, Target: target, External: external})
+
+	return id
+}
+
+// pack zips the parts of the document together
+func (d *docxWriter) pack() ([]byte, error) {
+	var rels bytes.Buffer
+	rels.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	rels.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` + "\n")
+	for _, rel := range d.relationships {
+		mode := ""
+		if rel.External {
+			mode = ` TargetMode="External"`
+		}
+		fmt.Fprintf(&rels, `<Relationship Id="%s" Type="%s" Target="%s"%s/>`+"\n", rel.Id, rel.Type, docxEscape(rel.Target), mode)
+	}
+	rels.WriteString(`</Relationships>`)
+
+	parts := []docxImage{
+		{name: "[Content_Types].xml", data: []byte(docxContentTypes)},
+		{name: "_rels/.rels", data: []byte(docxPackageRels)},
+		{name: "word/document.xml", data: []byte(docxDocumentStart + d.body.String() + docxDocumentEnd)},
+		{name: "word/styles.xml", data: []byte(docxStyles)},
+		{name: "word/_rels/document.xml.rels", data: rels.Bytes()},
+	}
+	for _, media := range d.images {
+		parts = append(parts, docxImage{name: "word/" + media.name, data: media.data})
+	}
+
+	var out bytes.Buffer
+	archive := zip.NewWriter(&out)
+	for _, part := range parts {
+		w, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = w.Write(part.data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := archive.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+// docxCheckbox is a checkbox content control, it can be ticked in word like a task list item
+func docxCheckbox(checked bool) string {
+	value, symbol := "0", "☐"
+	if checked {
+		value, symbol = "1", "☒"
+	}
+
+	return `<w:sdt><w:sdtPr><w14:checkbox><w14:checked w14:val="` + value + `"/><w14:checkedState w14:val="2612" w14:font="MS Gothic"/><w14:uncheckedState w14:val="2610" w14:font="MS Gothic"/></w14:checkbox></w:sdtPr><w:sdtContent><w:r><w:rPr><w:rFonts w:ascii="MS Gothic" w:eastAsia="MS Gothic" w:hAnsi="MS Gothic"/></w:rPr><w:t>` + symbol + `</w:t></w:r></w:sdtContent></w:sdt>`
+}
+
+// docxIndent indents list items by their nesting, two spaces of markdown indentation to a level
+func docxIndent(indentation string) string {
+	level := len(indentation)/2 + 1
+	return fmt.Sprintf(`<w:pStyle w:val="ListParagraph"/><w:ind w:left="%d" w:hanging="360"/>`, level*360)
+}
+
+// docxRunProperties orders run properties as the schema requires, the style first
+func docxRunProperties(style string) string {
+	var order []string
+	for _, property := range []string{`<w:rStyle w:val="Hyperlink"/>`, `<w:rStyle w:val="Code"/>`, "<w:b/>", "<w:i/>"} {
+		if strings.Contains(style, property) {
+			order = append(order, property)
+		}
+	}
+
+	// a run takes a single style, a link in code keeps looking like a link
+	if len(order) > 1 && strings.HasPrefix(order[0], "<w:rStyle") && strings.HasPrefix(order[1], "<w:rStyle") {
+		order = append(order[:1], order[2:]...)
+	}
+
+	return strings.Join(order, "")
+}
+
+func docxEscape(s string) string {
+	var escaped bytes.Buffer
+	xml.EscapeText(&escaped, []byte(s))
+	return escaped.String()
+}
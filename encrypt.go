@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ageTool = encryptionTool{name: "age", extension: ".age"}
+	gpgTool = encryptionTool{name: "gpg", extension: ".gpg"}
+
+	// encryption is set by --encrypt-to, files are written in the clear when it's nil
+	encryption *encryptor
+
+	// ageIdentity is the identity file age decrypts earlier exports and snapshots with
+	ageIdentity string
+)
+
+// encryptionTool is a command line tool files are encrypted with, encrypted files have its extension appended
+type encryptionTool struct {
+	name      string
+	extension string
+}
+
+// run pipes stdin through the tool into stdout
+func (t encryptionTool) run(args []string, stdin io.Reader, stdout io.Writer) error {
+	binary, err := exec.LookPath(t.name)
+	if err != nil {
+		return errors.Wrapf(err, "encrypting and decrypting with %s needs it installed", t.name)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(binary, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "%s failed: %s", t.name, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+func (t encryptionTool) decrypt(data []byte) ([]byte, error) {
+	args := []string{"--batch", "--quiet", "--decrypt"}
+	if t == ageTool {
+		if ageIdentity == "" {
+			return nil, errors.New("decrypting with age needs --age-identity")
+		}
+		args = []string{"--decrypt", "--identity", ageIdentity}
+	}
+
+	var decrypted bytes.Buffer
+	err := t.run(args, bytes.NewReader(data), &decrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	return decrypted.Bytes(), nil
+}
+
+// encryptor encrypts the files an export writes to the recipients of --encrypt-to
+type encryptor struct {
+	tool       encryptionTool
+	recipients []string
+}
+
+// newEncryptor returns nil when there are no recipients, age recipients start with age1 or ssh- and any other
+// recipient is a gpg key id, fingerprint or email
+func newEncryptor(recipients []string) (*encryptor, error) {
+	if len(recipients) == 0 {
+		return nil, nil
+	}
+
+	e := &encryptor{tool: gpgTool, recipients: recipients}
+	if isAgeRecipient(recipients[0]) {
+		e.tool = ageTool
+	}
+
+	for _, recipient := range recipients {
+		if isAgeRecipient(recipient) != (e.tool == ageTool) {
+			return nil, errors.New("--encrypt-to can't mix age and gpg recipients")
+		}
+	}
+
+	_, err := exec.LookPath(e.tool.name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "--encrypt-to %s needs %s installed", recipients[0], e.tool.name)
+	}
+
+	return e, nil
+}
+
+func isAgeRecipient(recipient string) bool {
+	return strings.HasPrefix(recipient, "age1") || strings.HasPrefix(recipient, "ssh-")
+}
+
+func (e *encryptor) encrypt(r io.Reader, w io.Writer) error {
+	var args []string
+	switch e.tool {
+	case ageTool:
+		for _, recipient := range e.recipients {
+			args = append(args, "--recipient", recipient)
+		}
+	default:
+		// the recipients are named explicitly so their keys are used without having been signed as trusted
+		args = []string{"--batch", "--yes", "--trust-model", "always", "--output", "-", "--encrypt"}
+		for _, recipient := range e.recipients {
+			args = append(args, "--recipient", recipient)
+		}
+	}
+
+	return e.tool.run(args, r, w)
+}
+
+// writeFile encrypts data into path with the tool's extension appended, encrypting the same content twice never
+// gives the same bytes so the file is always rewritten
+func (e *encryptor) writeFile(path string, data []byte) error {
+	var encrypted bytes.Buffer
+	err := e.encrypt(bytes.NewReader(data), &encrypted)
+	if err != nil {
+		return errors.Wrapf(err, "unable to encrypt %s", path)
+	}
+
+	return writeFileAtomic(path+e.tool.extension, encrypted.Bytes())
+}
+
+// writeStateFile writes a file holding what was fetched from the boards alongside the export, such as the checkpoint
+// or run report, encrypted like the export when --encrypt-to is set. it's read back with readOutput
+func writeStateFile(path string, data []byte) error {
+	if encryption != nil {
+		return encryption.writeFile(path, data)
+	}
+
+	return writeFileAtomic(path, data)
+}
+
+// removeOutput removes the file an earlier export wrote to path whether or not it was encrypted
+func removeOutput(path string) error {
+	for _, candidate := range []string{path + ageTool.extension, path + gpgTool.extension, path} {
+		err := os.Remove(candidate)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findOutput returns the file an earlier export wrote to path, which is encrypted when it has an age or gpg
+// extension, or an empty path when there isn't one
+func findOutput(path string) (string, error) {
+	for _, candidate := range []string{path + ageTool.extension, path + gpgTool.extension, path} {
+		_, err := os.Stat(candidate)
+		if err == nil {
+			return candidate, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+
+	return "", nil
+}
+
+// readOutput reads a file written by an earlier export, decrypting it when it was encrypted, a missing file is
+// reported as os.IsNotExist
+func readOutput(path string) ([]byte, error) {
+	found, err := findOutput(path)
+	if err != nil {
+		return nil, err
+	}
+	if found == "" {
+		return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+	}
+
+	data, err := ioutil.ReadFile(found)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tool := range []encryptionTool{ageTool, gpgTool} {
+		if strings.HasSuffix(found, tool.extension) {
+			return tool.decrypt(data)
+		}
+	}
+
+	return data, nil
+}
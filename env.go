@@ -0,0 +1,88 @@
+package main
+
+import (
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+const (
+	envPrefix = "TRELLO2MD_"
+)
+
+var (
+	// deprecatedEnvVars maps the env vars read before they were namespaced to their TRELLO2MD_ replacement
+	deprecatedEnvVars = map[string]string{}
+)
+
+// prefixEnvVars namespaces the env vars of every flag of the app, its commands and their subcommands, flags
+// without an env var get one named after the flag
+func prefixEnvVars(app *cli.App) {
+	app.Flags = prefixFlagEnvVars(app.Flags)
+	for i := range app.Commands {
+		prefixCommandEnvVars(&app.Commands[i])
+	}
+}
+
+func prefixCommandEnvVars(command *cli.Command) {
+	command.Flags = prefixFlagEnvVars(command.Flags)
+	for i := range command.Subcommands {
+		prefixCommandEnvVars(&command.Subcommands[i])
+	}
+}
+
+// prefixFlagEnvVars returns copies of the flags reading their env var with the TRELLO2MD_ prefix, falling back to the
+// env var they read previously. the variable is named after the previous env var rather than the flag so flags of
+// different commands sharing a name, such as the --format of export-boards and of search-boards, keep variables of
+// their own. flags without an env var get one named after the flag
+func prefixFlagEnvVars(flags []cli.Flag) []cli.Flag {
+	prefixed := make([]cli.Flag, 0, len(flags))
+	for _, flag := range flags {
+		v := reflect.ValueOf(flag)
+		envVar := v.FieldByName("EnvVar")
+		if v.Kind() != reflect.Struct || !envVar.IsValid() || envVar.Kind() != reflect.String {
+			prefixed = append(prefixed, flag)
+			continue
+		}
+
+		name := strings.TrimSpace(strings.Split(flag.GetName(), ",")[0])
+		previous := envVar.String()
+		envName := envPrefix + strings.ToUpper(strings.Replace(name, "-", "_", -1))
+		if previous != "" {
+			envName = envPrefix + strings.TrimPrefix(previous, envPrefix)
+		}
+
+		names := []string{envName}
+		if previous != "" && previous != envName {
+			names = append(names, previous)
+			deprecatedEnvVars[previous] = envName
+		}
+
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		copied.FieldByName("EnvVar").SetString(strings.Join(names, ","))
+		prefixed = append(prefixed, copied.Interface().(cli.Flag))
+	}
+
+	return prefixed
+}
+
+// warnDeprecatedEnvVars logs every deprecated env var which is set, along with the name it should be set as
+func warnDeprecatedEnvVars() {
+	var names []string
+	for previous := range deprecatedEnvVars {
+		names = append(names, previous)
+	}
+
+	sort.Strings(names)
+
+	for _, previous := range names {
+		if _, ok := os.LookupEnv(previous); ok {
+			log.Printf("warning: %s is deprecated, use %s instead", previous, deprecatedEnvVars[previous])
+		}
+	}
+}
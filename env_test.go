@@ -0,0 +1,79 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+// TestPrefixEnvVars checks the flags reading the same TRELLO2MD_ variable are meant to, either they read the same env
+// var before it was prefixed or they're the same flag shared between commands
+func TestPrefixEnvVars(t *testing.T) {
+	type envFlag struct {
+		command  string
+		name     string
+		previous string
+		usage    string
+	}
+
+	byVar := map[string][]envFlag{}
+	var walk func(command string, flags []cli.Flag, subcommands []cli.Command)
+	walk = func(command string, flags []cli.Flag, subcommands []cli.Command) {
+		for _, flag := range flags {
+			v := reflect.ValueOf(flag)
+			if v.Kind() != reflect.Struct || !v.FieldByName("EnvVar").IsValid() {
+				continue
+			}
+
+			names := strings.Split(v.FieldByName("EnvVar").String(), ",")
+			if !strings.HasPrefix(names[0], envPrefix) {
+				t.Errorf("--%s of %s reads %s, which isn't prefixed", flag.GetName(), command, names[0])
+				continue
+			}
+
+			f := envFlag{command: command, name: flag.GetName(), usage: v.FieldByName("Usage").String()}
+			if len(names) > 1 {
+				f.previous = names[1]
+			}
+			byVar[names[0]] = append(byVar[names[0]], f)
+		}
+
+		for _, subcommand := range subcommands {
+			walk(strings.TrimPrefix(command+" "+subcommand.Name, "the app "), subcommand.Flags, subcommand.Subcommands)
+		}
+	}
+
+	app := newApp()
+	walk("the app", app.Flags, app.Commands)
+
+	for envName, flags := range byVar {
+		first := flags[0]
+		for _, f := range flags[1:] {
+			if f.previous != first.previous || (f.previous == "" && (f.name != first.name || f.usage != first.usage)) {
+				t.Errorf("%s is read by --%s of %s and by --%s of %s, which are different flags", envName, first.name,
+					first.command, f.name, f.command)
+			}
+		}
+	}
+}
+
+func TestPrefixFlagEnvVars(t *testing.T) {
+	tests := []struct {
+		flag cli.Flag
+		want string
+	}{
+		{flag: cli.StringFlag{Name: "format", EnvVar: "FORMAT"}, want: "TRELLO2MD_FORMAT,FORMAT"},
+		{flag: cli.StringFlag{Name: "format", EnvVar: "SEARCH_FORMAT"}, want: "TRELLO2MD_SEARCH_FORMAT,SEARCH_FORMAT"},
+		{flag: cli.StringFlag{Name: "show-age"}, want: "TRELLO2MD_SHOW_AGE"},
+		{flag: cli.StringFlag{Name: "trace", EnvVar: "TRELLO2MD_TRACE"}, want: "TRELLO2MD_TRACE"},
+	}
+
+	for _, tt := range tests {
+		got := reflect.ValueOf(prefixFlagEnvVars([]cli.Flag{tt.flag})[0]).FieldByName("EnvVar").String()
+		if got != tt.want {
+			t.Errorf("--%s reads %s, want %s", tt.flag.GetName(), got, tt.want)
+		}
+	}
+}
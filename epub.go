@@ -0,0 +1,477 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"image"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+const (
+	chapterBoard = "board"
+	chapterList  = "list"
+
+	// chapterMarker starts the comment marking a chapter in the markdown rendered for an e-book
+	chapterMarker = "<!-- trello2md:chapter"
+
+	epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles>
+<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+</rootfiles>
+</container>`
+
+	epubStylesheet = `body { font-family: serif; line-height: 1.4; }
+h1, h2, h3, h4, h5, h6 { font-family: sans-serif; }
+blockquote { margin-left: 1em; padding-left: 0.8em; border-left: 3px solid #bbb; color: #555; font-style: italic; }
+pre { background: #f2f2f2; padding: 0.5em; white-space: pre-wrap; }
+code { font-family: monospace; }
+ul { list-style: none; padding-left: 0; }
+ul li { margin-left: 1em; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #999; padding: 0.2em 0.5em; }
+img { max-width: 100%; }
+`
+)
+
+// epubChapter is a board or one of its lists, boards hold the lists that follow them in the table of contents
+type epubChapter struct {
+	kind     string
+	title    string
+	markdown bytes.Buffer
+	file     string
+}
+
+// epubImage is an image downloaded into the book
+type epubImage struct {
+	file      string
+	mediaType string
+	data      []byte
+}
+
+// xhtmlWriter converts the markdown of a chapter into xhtml, images are shared by every chapter of the book
+type xhtmlWriter struct {
+	body bytes.Buffer
+	// block is the element left open for the lines that follow, such as a list or a quote
+	block  string
+	images *epubImages
+}
+
+// epubImages are the images of the book by url, an empty file marks an image which couldn't be downloaded
+type epubImages struct {
+	fetch  imageFetcher
+	byURL  map[string]string
+	images []epubImage
+}
+
+// printChapterMarker marks the start of a board or list, names are escaped so they can't end the comment
+func printChapterMarker(w io.Writer, kind string, name string) {
+	fmt.Fprintf(w, "%s %s %s -->\n", chapterMarker, kind, url.PathEscape(name))
+}
+
+// exportEpub renders the export as markdown and packages it as an e-book written to --output, with a chapter per
+// board and per list
+func exportEpub(c *cli.Context) error {
+	markdown, path, err := renderDocument(c)
+	if err != nil {
+		return err
+	}
+
+	fetch, err := newImageFetcher(c)
+	if err != nil {
+		return err
+	}
+
+	epub, err := markdownToEpub(markdown, fetch, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return writeFileIfChanged(path, epub)
+}
+
+// markdownToEpub splits the markdown into chapters at the chapter markers and packages them as an epub 3 book
+func markdownToEpub(markdown []byte, fetch imageFetcher, date time.Time) ([]byte, error) {
+	preface, chapters, err := splitChapters(markdown)
+	if err != nil {
+		return nil, err
+	}
+
+	var boards []string
+	for _, chapter := range chapters {
+		if chapter.kind == chapterBoard {
+			boards = append(boards, chapter.title)
+		}
+	}
+	title := strings.Join(boards, ", ")
+
+	images := &epubImages{fetch: fetch, byURL: map[string]string{}}
+
+	var parts []docxImage
+	titlePage, err := images.xhtml(title, "<h1>"+docxEscape(title)+"</h1>\n", preface)
+	if err != nil {
+		return nil, err
+	}
+	parts = append(parts, docxImage{name: "OEBPS/title.xhtml", data: titlePage})
+
+	for i, chapter := range chapters {
+		chapter.file = fmt.Sprintf("chapter-%d.xhtml", i+1)
+
+		page, err := images.xhtml(chapter.title, "", chapter.markdown.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, docxImage{name: "OEBPS/" + chapter.file, data: page})
+	}
+
+	for _, media := range images.images {
+		parts = append(parts, docxImage{name: "OEBPS/" + media.file, data: media.data})
+	}
+
+	parts = append(parts,
+		docxImage{name: "OEBPS/nav.xhtml", data: epubNav(title, chapters)},
+		docxImage{name: "OEBPS/content.opf", data: epubPackage(title, markdown, date, chapters, images.images)},
+		docxImage{name: "OEBPS/style.css", data: []byte(epubStylesheet)},
+	)
+
+	var out bytes.Buffer
+	archive := zip.NewWriter(&out)
+
+	// the mimetype has to come first and be stored uncompressed so readers can identify the file
+	w, err := archive.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return nil, err
+	}
+	io.WriteString(w, "application/epub+zip")
+
+	parts = append([]docxImage{{name: "META-INF/container.xml", data: []byte(epubContainer)}}, parts...)
+	for _, part := range parts {
+		w, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = w.Write(part.data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = archive.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+// splitChapters returns the markdown before the first board, such as the date, and the chapters that follow
+func splitChapters(markdown []byte) ([]byte, []*epubChapter, error) {
+	var preface bytes.Buffer
+	var chapters []*epubChapter
+
+	scanner := bufio.NewScanner(bytes.NewReader(markdown))
+	scanner.Buffer(nil, len(markdown)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, chapterMarker) {
+			fields := strings.Fields(strings.TrimPrefix(line, chapterMarker))
+			if len(fields) == 3 {
+				name, err := url.PathUnescape(fields[1])
+				if err != nil {
+					return nil, nil, err
+				}
+
+				chapters = append(chapters, &epubChapter{kind: fields[0], title: name})
+				continue
+			}
+		}
+
+		if len(chapters) == 0 {
+			preface.WriteString(line + "\n")
+			continue
+		}
+
+		chapters[len(chapters)-1].markdown.WriteString(line + "\n")
+	}
+
+	return preface.Bytes(), chapters, scanner.Err()
+}
+
+// xhtml renders a chapter as an xhtml page
+func (i *epubImages) xhtml(title string, heading string, markdown []byte) ([]byte, error) {
+	x := &xhtmlWriter{images: i}
+	x.body.WriteString(heading)
+
+	err := walkMarkdown(markdown, x)
+	if err != nil {
+		return nil, err
+	}
+	x.close()
+
+	var page bytes.Buffer
+	page.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	page.WriteString(`<!DOCTYPE html>` + "\n")
+	fmt.Fprintf(&page, `<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="%s" lang="%s">`+"\n", language, language)
+	fmt.Fprintf(&page, "<head>\n<title>%s</title>\n<link rel=\"stylesheet\" type=\"text/css\" href=\"style.css\"/>\n</head>\n<body>\n", docxEscape(title))
+	page.Write(x.body.Bytes())
+	page.WriteString("</body>\n</html>\n")
+
+	return page.Bytes(), nil
+}
+
+// image returns the file of the image within the book, downloading it the first time it's used
+func (i *epubImages) image(url string) string {
+	if file, ok := i.byURL[url]; ok {
+		return file
+	}
+
+	file := ""
+	data, err := i.fetch(url)
+	if err == nil {
+		var format string
+		_, format, err = image.DecodeConfig(bytes.NewReader(data))
+		if err == nil {
+			file = fmt.Sprintf("images/image%d.%s", len(i.images)+1, format)
+			i.images = append(i.images, epubImage{file: file, mediaType: "image/" + format, data: data})
+		}
+	}
+
+	if err != nil {
+		warnings.add(WarningImage, url, fmt.Sprintf("unable to embed image %s, linking it instead: %v", url, err))
+	}
+	i.byURL[url] = file
+
+	return file
+}
+
+// open starts a block element unless the lines before were already in one, closing any other
+func (x *xhtmlWriter) open(block string) {
+	if x.block == block {
+		return
+	}
+
+	x.close()
+	x.block = block
+	x.body.WriteString("<" + block + ">")
+	if block == "pre" {
+		x.body.WriteString("<code>")
+	}
+}
+
+func (x *xhtmlWriter) close() {
+	if x.block == "" {
+		return
+	}
+
+	if x.block == "pre" {
+		x.body.WriteString("</code>")
+	}
+	x.body.WriteString("</" + x.block + ">\n")
+	x.block = ""
+}
+
+func (x *xhtmlWriter) heading(level int, text string) {
+	x.close()
+	fmt.Fprintf(&x.body, "<h%d>%s</h%d>\n", level, x.inline(text), level)
+}
+
+func (x *xhtmlWriter) taskItem(indent string, checked bool, text string) {
+	box := "☐"
+	if checked {
+		box = "☑"
+	}
+
+	x.open("ul")
+	fmt.Fprintf(&x.body, "<li style=\"margin-left: %dem\">%s %s</li>\n", len(indent)/2+1, box, x.inline(text))
+}
+
+func (x *xhtmlWriter) bulletItem(indent string, text string) {
+	x.open("ul")
+	fmt.Fprintf(&x.body, "<li style=\"margin-left: %dem\">• %s</li>\n", len(indent)/2+1, x.inline(text))
+}
+
+func (x *xhtmlWriter) quoteLine(text string) {
+	x.open("blockquote")
+	if strings.TrimSpace(text) != "" {
+		x.body.WriteString("<p>" + x.inline(text) + "</p>\n")
+	}
+}
+
+func (x *xhtmlWriter) codeLine(line string) {
+	x.open("pre")
+	x.body.WriteString(docxEscape(line) + "\n")
+}
+
+// tableRows writes the rows as a table with a header row
+func (x *xhtmlWriter) tableRows(rows [][]string) {
+	x.close()
+	x.body.WriteString("<table>\n")
+	for i, row := range rows {
+		cell := "td"
+		if i == 0 {
+			cell = "th"
+		}
+
+		x.body.WriteString("<tr>")
+		for _, text := range row {
+			fmt.Fprintf(&x.body, "<%s>%s</%s>", cell, x.inline(strings.TrimSpace(text)), cell)
+		}
+		x.body.WriteString("</tr>\n")
+	}
+	x.body.WriteString("</table>\n")
+}
+
+func (x *xhtmlWriter) summaryLine(text string) {
+	x.close()
+	x.body.WriteString("<p><strong>" + x.inline(text) + "</strong></p>\n")
+}
+
+// textLine adds the line to the open paragraph, lines keep their breaks as they do on the back of a card
+func (x *xhtmlWriter) textLine(text string) {
+	if x.block == "p" {
+		x.body.WriteString("<br/>")
+	}
+
+	x.open("p")
+	x.body.WriteString(x.inline(text))
+}
+
+func (x *xhtmlWriter) rule() {
+	x.close()
+	x.body.WriteString("<hr/>\n")
+}
+
+func (x *xhtmlWriter) blank() {
+	x.close()
+}
+
+// inline converts inline markdown to xhtml
+func (x *xhtmlWriter) inline(text string) string {
+	var out strings.Builder
+	last := 0
+	for _, m := range markdownInline.FindAllStringSubmatchIndex(text, -1) {
+		out.WriteString(docxEscape(text[last:m[0]]))
+		last = m[1]
+
+		switch {
+		case m[2] >= 0:
+			alt, src := text[m[2]:m[3]], text[m[4]:m[5]]
+			if file := x.images.image(src); file != "" {
+				fmt.Fprintf(&out, `<img src="%s" alt="%s"/>`, file, docxEscape(alt))
+			} else {
+				if alt == "" {
+					alt = src
+				}
+				out.WriteString(x.link(alt, src))
+			}
+		case m[6] >= 0:
+			out.WriteString(x.link(text[m[6]:m[7]], text[m[8]:m[9]]))
+		case m[10] >= 0:
+			out.WriteString("<strong>" + x.inline(text[m[10]:m[11]]) + "</strong>")
+		case m[12] >= 0:
+			out.WriteString("<code>" + docxEscape(text[m[12]:m[13]]) + "</code>")
+		case m[14] >= 0:
+			out.WriteString("<em>" + x.inline(text[m[14]:m[15]]) + "</em>")
+		}
+	}
+	out.WriteString(docxEscape(text[last:]))
+
+	return out.String()
+}
+
+// link writes a link, links within the document such as footnotes are left as text as chapters are separate files
+func (x *xhtmlWriter) link(text string, target string) string {
+	if strings.HasPrefix(target, "#") {
+		return x.inline(text)
+	}
+
+	return `<a href="` + docxEscape(target) + `">` + x.inline(text) + "</a>"
+}
+
+// epubNav is the table of contents, lists are nested under their board
+func epubNav(title string, chapters []*epubChapter) []byte {
+	var nav bytes.Buffer
+	nav.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	nav.WriteString(`<!DOCTYPE html>` + "\n")
+	fmt.Fprintf(&nav, `<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="%s" lang="%s">`+"\n", language, language)
+	fmt.Fprintf(&nav, "<head>\n<title>%s</title>\n</head>\n<body>\n<nav epub:type=\"toc\">\n<ol>\n", docxEscape(title))
+
+	inBoard, inLists := false, false
+	for _, chapter := range chapters {
+		entry := fmt.Sprintf(`<li><a href="%s">%s</a>`, chapter.file, docxEscape(chapter.title))
+
+		if chapter.kind == chapterList && inBoard {
+			if !inLists {
+				nav.WriteString("\n<ol>\n")
+				inLists = true
+			}
+			nav.WriteString(entry + "</li>\n")
+			continue
+		}
+
+		if inLists {
+			nav.WriteString("</ol>\n")
+			inLists = false
+		}
+		if inBoard {
+			nav.WriteString("</li>\n")
+		}
+
+		nav.WriteString(entry)
+		inBoard = chapter.kind == chapterBoard
+		if !inBoard {
+			nav.WriteString("</li>\n")
+		}
+	}
+
+	if inLists {
+		nav.WriteString("</ol>\n")
+	}
+	if inBoard {
+		nav.WriteString("</li>\n")
+	}
+	nav.WriteString("</ol>\n</nav>\n</body>\n</html>\n")
+
+	return nav.Bytes()
+}
+
+// epubPackage describes the book, its identifier is derived from the content so unchanged exports produce the
+// same book
+func epubPackage(title string, markdown []byte, date time.Time, chapters []*epubChapter, images []epubImage) []byte {
+	var opf bytes.Buffer
+	opf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	opf.WriteString(`<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id">` + "\n")
+	opf.WriteString(`<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">` + "\n")
+	fmt.Fprintf(&opf, "<dc:identifier id=\"book-id\">urn:sha1:%x</dc:identifier>\n", sha1.Sum(markdown))
+	fmt.Fprintf(&opf, "<dc:title>%s</dc:title>\n", docxEscape(title))
+	fmt.Fprintf(&opf, "<dc:language>%s</dc:language>\n", language)
+	fmt.Fprintf(&opf, "<dc:creator>%s</dc:creator>\n", appName)
+	fmt.Fprintf(&opf, "<meta property=\"dcterms:modified\">%s</meta>\n", date.UTC().Format("2006-01-02")+"T00:00:00Z")
+	opf.WriteString("</metadata>\n<manifest>\n")
+	opf.WriteString(`<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>` + "\n")
+	opf.WriteString(`<item id="style" href="style.css" media-type="text/css"/>` + "\n")
+	opf.WriteString(`<item id="title" href="title.xhtml" media-type="application/xhtml+xml"/>` + "\n")
+	for i, chapter := range chapters {
+		fmt.Fprintf(&opf, "<item id=\"chapter-%d\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", i+1, chapter.file)
+	}
+	for i, media := range images {
+		fmt.Fprintf(&opf, "<item id=\"image-%d\" href=\"%s\" media-type=\"%s\"/>\n", i+1, media.file, media.mediaType)
+	}
+	opf.WriteString("</manifest>\n<spine>\n<itemref idref=\"title\"/>\n")
+	for i := range chapters {
+		fmt.Fprintf(&opf, "<itemref idref=\"chapter-%d\"/>\n", i+1)
+	}
+	opf.WriteString("</spine>\n</package>\n")
+
+	return opf.Bytes()
+}
@@ -0,0 +1,551 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+)
+
+const (
+	boardOrderGiven    = "given"
+	boardOrderName     = "name"
+	boardOrderActivity = "activity"
+)
+
+// boardExport holds everything fetched for a single board, ready to be rendered
+type boardExport struct {
+	board Board
+	lists []*listExport
+	// headings renders a heading per list, set whenever more than a single list is exported
+	headings bool
+	// structure summarises every list of the board for the board diagram, nil unless the diagram is enabled
+	structure []listSummary
+	// customFields are the custom fields defined on the board, nil unless their definitions are shown
+	customFields []customField
+	// audit is who can see the board, nil unless audit fields are shown
+	audit *boardAudit
+	// reopened are the cards moved back out of a done list, nil unless reopened cards are shown
+	reopened []reopenedCard
+}
+
+// listExport holds the cards to render for a single list
+type listExport struct {
+	list  List
+	cards []*cardView
+	// streamed counts the cards rendered as they were fetched, which aren't kept in cards
+	streamed int
+}
+
+// fetchBoards runs fetch for every board with at most concurrency boards in flight, the results keep the order of
+// boards so the document is always assembled in the requested order. boards are started in order, so with a
+// concurrency of one each is fetched after the one before it
+func fetchBoards(boards []trello.Board, concurrency int, fetch func(trello.Board) (*boardExport, error)) ([]*boardExport, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	exports := make([]*boardExport, len(boards))
+	errs := make([]error, len(boards))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i, board := range boards {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, board trello.Board) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			exports[i], errs[i] = fetch(board)
+		}(i, board)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return exports, nil
+}
+
+// fetchOptions controls what is fetched for each board
+type fetchOptions struct {
+	// ListFilters name the lists to export in the order they're rendered
+	ListFilters []string
+	// ListIndex selects the list by its position instead of ListFilters when set, negative counts from the right
+	ListIndex *int
+	// AllLists exports every open list of the board left to right instead of the lists matching ListFilters
+	AllLists bool
+	Sort     string
+	// BoardDiagram fetches the structure of the whole board to draw a diagram of it
+	BoardDiagram bool
+	// FieldDefinitions fetches the custom fields defined on the board to list them under its heading
+	FieldDefinitions bool
+	Show             showOptions
+	// StoryPoints reads each card's estimate when set
+	StoryPoints *storyPoints
+	// Checkpoint records fetched cards so an interrupted export can be resumed, nil disables checkpointing
+	Checkpoint *exportCheckpoint
+	// PluginExtractors names the extractors run against every card
+	PluginExtractors []string
+	// IncludeTemplates exports card templates along with the cards made from them
+	IncludeTemplates bool
+	// StaleAfter only keeps cards which have been in their list for at least this long when set
+	StaleAfter time.Duration
+	// Reopened finds the cards moved back out of a done list when set
+	Reopened *reopenedCards
+	// ConvertHTML converts html in card descriptions to markdown
+	ConvertHTML bool
+	// AllowedBoards limits the export to these board ids, every board is exported when nil
+	AllowedBoards []string
+	// Filter only keeps the cards matching a --filter expression when set
+	Filter      *cardFilter
+	Transformer *cardTransformer
+	// MemberMap renames members by their username when set
+	MemberMap memberMap
+	// LabelMap renames, merges and hides labels when set
+	LabelMap *labelMap
+	// Manifest picks the boards, lists and cards exported when set
+	Manifest *manifest
+	// AttachmentFilter sorts, filters and caps the attachments of each card when set
+	AttachmentFilter *attachmentFilter
+	// BotComments leaves out the comments posted by automation when set
+	BotComments *botMembers
+	// HumanActivity replaces each card's last activity with the last action a member took rather than automation
+	// when set
+	HumanActivity *botMembers
+	// ASCII replaces typographic punctuation in card text with ascii
+	ASCII bool
+	// Progress is notified as each board is fetched, nil notifies nothing
+	Progress *progressNotifier
+	// Stream renders the cards as they're fetched instead of keeping them on their lists when set, boards are then
+	// fetched one at a time
+	Stream *exportStream
+}
+
+// fetchBoard fetches the lists of a board to export along with the cards to render
+func fetchBoard(client *trello.Client, board trello.Board, opts fetchOptions) (*boardExport, error) {
+	open, err := getLists(&board)
+	if err != nil {
+		return nil, err
+	}
+
+	lists, err := selectLists(board.Name, open, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &boardExport{
+		board:    newBoard(board),
+		headings: opts.AllLists || len(lists) > 1,
+	}
+
+	if opts.Stream != nil {
+		err = opts.Stream.startBoard(export)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.StoryPoints != nil {
+		points, err := opts.StoryPoints.forBoard(client, &board)
+		if err != nil {
+			return nil, err
+		}
+
+		opts.StoryPoints = points
+	}
+
+	for i := range lists {
+		listExport, err := fetchList(client, board, &lists[i], opts)
+		if err != nil {
+			return nil, err
+		}
+
+		export.lists = append(export.lists, listExport)
+	}
+
+	if opts.BoardDiagram {
+		structure, err := fetchBoardStructure(&board)
+		if err != nil {
+			return nil, err
+		}
+
+		export.structure = structure
+	}
+
+	if opts.FieldDefinitions {
+		fields, err := getBoardCustomFields(client, board.Id)
+		err = optional(degradedCustomFields, err)
+		if err != nil {
+			return nil, err
+		}
+
+		export.customFields = fields
+	}
+
+	if opts.Show.Audit {
+		audit, err := getBoardAudit(client, board.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		export.audit = audit
+	}
+
+	if opts.Reopened != nil {
+		reopened, err := opts.Reopened.find(client, &board)
+		if err != nil {
+			return nil, err
+		}
+
+		export.reopened = reopened
+	}
+
+	return export, nil
+}
+
+// selectLists picks the lists to export from the open lists of a board, ordered left to right
+func selectLists(boardName string, lists []trello.List, opts fetchOptions) ([]trello.List, error) {
+	if opts.AllLists {
+		return opts.Manifest.lists(lists), nil
+	}
+
+	if opts.ListIndex != nil {
+		list, err := listAt(boardName, lists, *opts.ListIndex)
+		if err != nil {
+			return nil, err
+		}
+
+		return opts.Manifest.lists([]trello.List{*list}), nil
+	}
+
+	named, err := listsNamed(boardName, lists, opts.ListFilters)
+	if err != nil {
+		return nil, err
+	}
+
+	return opts.Manifest.lists(named), nil
+}
+
+// fetchList fetches the cards of the list a page at a time so lists longer than a single response aren't truncated.
+// only a page of raw cards is held at once, but the view of every card is kept until the list is sorted and rendered
+// so memory still grows with the list unless the cards are streamed
+func fetchList(client *trello.Client, board trello.Board, list *trello.List, opts fetchOptions) (*listExport, error) {
+	err := validateCardSort(opts.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &listExport{
+		list: newList(*list),
+	}
+
+	if opts.Stream != nil {
+		return export, streamList(client, board, list, export, opts)
+	}
+
+	err = eachCardPage(client, list, opts.IncludeTemplates, func(cards []trello.Card) error {
+		for i := range cards {
+			view, err := fetchListCard(client, board, &cards[i], opts)
+			if err != nil {
+				return err
+			}
+
+			if view != nil {
+				export.cards = append(export.cards, view)
+			}
+		}
+
+		return errors.Wrap(opts.Checkpoint.saveDue(), "unable to checkpoint the export")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(export.cards, func(i, j int) bool {
+		return cardLess(&export.cards[i].Card, &export.cards[j].Card, opts.Sort)
+	})
+
+	return export, nil
+}
+
+// streamList renders the cards of the list as each is fetched. trello pages cards by id rather than by position so
+// the raw cards of the whole list are put in order first, but only the card being rendered has its view held
+func streamList(client *trello.Client, board trello.Board, list *trello.List, export *listExport, opts fetchOptions) error {
+	var cards []trello.Card
+	err := eachCardPage(client, list, opts.IncludeTemplates, func(page []trello.Card) error {
+		cards = append(cards, page...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(cards, func(i, j int) bool {
+		return cards[i].Pos < cards[j].Pos
+	})
+
+	err = opts.Stream.startList(export)
+	if err != nil {
+		return err
+	}
+
+	for i := range cards {
+		view, err := fetchListCard(client, board, &cards[i], opts)
+		if err != nil {
+			return err
+		}
+
+		if view != nil {
+			err = opts.Stream.card(export, view)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = opts.Checkpoint.saveDue()
+		if err != nil {
+			return errors.Wrap(err, "unable to checkpoint the export")
+		}
+	}
+
+	return nil
+}
+
+// fetchListCard returns the view of a card ready to render, nil when the card is filtered out
+func fetchListCard(client *trello.Client, board trello.Board, card *trello.Card, opts fetchOptions) (*cardView, error) {
+	if !opts.Manifest.keepsCard(card) {
+		return nil, nil
+	}
+
+	view, ok := opts.Checkpoint.card(card.Id)
+	if !ok {
+		var err error
+		view, err = fetchCardView(client, board, card, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		opts.Checkpoint.add(view)
+	}
+
+	return finishCardView(view, opts)
+}
+
+// finishCardView cleans the text of a fetched card, drops its bot comments and renames its members and labels, runs
+// the plugin extractors over it and applies the filters and transform, returning nil when the card is left out
+func finishCardView(view *cardView, opts fetchOptions) (*cardView, error) {
+	cleanCardText(view, opts.ASCII)
+
+	// a malformed last activity is warned about here, it sorts last and renders empty
+	parseTrelloDate(view.Card.DateLastActivity, "card "+view.Card.Name)
+
+	if opts.BotComments != nil {
+		view.Comments = opts.BotComments.skipComments(view.Comments)
+	}
+
+	if opts.MemberMap != nil {
+		opts.MemberMap.apply(view)
+	}
+
+	if opts.LabelMap != nil {
+		opts.LabelMap.apply(&view.Card)
+	}
+
+	if opts.AttachmentFilter != nil {
+		view.Attachments = opts.AttachmentFilter.apply(view.Attachments)
+	}
+
+	for _, name := range opts.PluginExtractors {
+		for key, value := range pluginExtractors[name](view) {
+			if view.Extracted == nil {
+				view.Extracted = map[string]string{}
+			}
+			view.Extracted[key] = value
+		}
+	}
+
+	if opts.StaleAfter > 0 && time.Since(view.ListEntered) < opts.StaleAfter {
+		return nil, nil
+	}
+
+	if opts.Filter != nil {
+		matches, err := opts.Filter.matches(view)
+		if err != nil {
+			return nil, err
+		}
+
+		if !matches {
+			return nil, nil
+		}
+	}
+
+	if opts.Transformer != nil {
+		keep, err := opts.Transformer.transform(view)
+		if err != nil {
+			return nil, err
+		}
+
+		if !keep {
+			return nil, nil
+		}
+	}
+
+	return view, nil
+}
+
+// fetchCardView fetches everything rendered for a card
+func fetchCardView(client *trello.Client, board trello.Board, card *trello.Card, opts fetchOptions) (*cardView, error) {
+	view, err := newCardView(client, board, card, opts.Show)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.ConvertHTML {
+		view.Card.Desc = htmlToMarkdown(view.Card.Desc)
+	}
+
+	if opts.Filter != nil && opts.Filter.members && view.Members == nil {
+		members, err := getCardMembers(client, card)
+		if err != nil {
+			return nil, err
+		}
+
+		view.Members = newMembers(members)
+	}
+
+	if opts.StoryPoints != nil {
+		view.Points, err = opts.StoryPoints.points(client, card)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.StaleAfter > 0 && view.ListEntered.IsZero() {
+		view.ListEntered, err = getCardListEntered(client, card)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.HumanActivity != nil {
+		view.Card.DateLastActivity, err = getCardLastHumanActivity(client, card, opts.HumanActivity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return view, nil
+}
+
+// sortBoardExports orders the boards for the document, boards with an explicit order in the config file always
+// lead in that order followed by the remaining boards in the requested order
+func sortBoardExports(exports []*boardExport, order string, cfg *config) error {
+	var less func(i, j *boardExport) bool
+	switch order {
+	case boardOrderGiven, "":
+		less = func(i, j *boardExport) bool { return false }
+	case boardOrderName:
+		less = func(i, j *boardExport) bool {
+			return collation.less(strings.ToLower(i.board.Name), strings.ToLower(j.board.Name))
+		}
+	case boardOrderActivity:
+		less = func(i, j *boardExport) bool {
+			return i.lastActivity() > j.lastActivity()
+		}
+	default:
+		return errors.Errorf("unsupported board order %q, expected one of given, name or activity", order)
+	}
+
+	sort.SliceStable(exports, func(i, j int) bool {
+		iOrder := cfg.board(exports[i].board.Id).Order
+		jOrder := cfg.board(exports[j].board.Id).Order
+		if iOrder != 0 || jOrder != 0 {
+			if iOrder == 0 {
+				return false
+			}
+			if jOrder == 0 {
+				return true
+			}
+			return iOrder < jOrder
+		}
+
+		return less(exports[i], exports[j])
+	})
+
+	return nil
+}
+
+// lastActivity returns the most recent activity date of the exported cards, RFC3339 dates sort lexically
+func (b *boardExport) lastActivity() string {
+	latest := ""
+	for _, list := range b.lists {
+		for _, view := range list.cards {
+			if view.Card.DateLastActivity > latest {
+				latest = view.Card.DateLastActivity
+			}
+		}
+	}
+
+	return latest
+}
+
+// mergeBoardExports combines the cards of every board into a single chronologically sorted list per list name, with
+// dedupe cards sharing a short link are only rendered once and annotated with every board they belong to
+func mergeBoardExports(exports []*boardExport, dedupe bool) []*boardExport {
+	if len(exports) == 0 {
+		return exports
+	}
+
+	var names []string
+	merged := &boardExport{}
+	listsByName := map[string]*listExport{}
+	seen := map[string]*cardView{}
+	for _, export := range exports {
+		names = append(names, export.board.Name)
+		merged.headings = merged.headings || export.headings
+
+		for _, list := range export.lists {
+			mergedList, ok := listsByName[list.list.Name]
+			if !ok {
+				mergedList = &listExport{list: list.list}
+				listsByName[list.list.Name] = mergedList
+				merged.lists = append(merged.lists, mergedList)
+			}
+
+			for _, view := range list.cards {
+				if first, ok := seen[view.Card.ShortLink]; ok && dedupe && view.Card.ShortLink != "" {
+					first.Boards = append(first.Boards, export.board.Name)
+					continue
+				}
+
+				view.Merged = true
+				view.Boards = []string{export.board.Name}
+				seen[view.Card.ShortLink] = view
+				mergedList.cards = append(mergedList.cards, view)
+			}
+		}
+	}
+
+	merged.board = Board{
+		Id:   exports[0].board.Id,
+		Name: strings.Join(names, ", "),
+	}
+
+	for _, list := range merged.lists {
+		cards := list.cards
+		sort.SliceStable(cards, func(i, j int) bool {
+			return dateLess(parseTrelloDate(cards[i].Card.DateLastActivity, "card "+cards[i].Card.Name),
+				parseTrelloDate(cards[j].Card.DateLastActivity, "card "+cards[j].Card.Name))
+		})
+	}
+
+	return []*boardExport{merged}
+}
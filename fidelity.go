@@ -0,0 +1,337 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+)
+
+const (
+	fidelityStandard = "standard"
+	fidelityFull     = "full"
+
+	// fullFidelityCardTemplate renders everything on the back of a card in a fixed layout, for archiving boards
+	fullFidelityCardTemplate = `#### {{if .Number}}{{.Number}}. {{end}}{{if .Card.Url}}[{{.Title}}]({{.Card.Url}}){{else}}{{.Title}}{{end}}
+{{if .TitleTruncated -}}
+**{{.Card.Name}}**
+
+{{end -}}
+- **{{tr "lastActivity"}}:** {{date .Card.DateLastActivity}}
+{{with .Card.Due}}- **{{tr "dueDate"}}:** {{date .}}
+{{end -}}
+{{with .Completion}}- {{if .By}}{{tr "completedBy" (date .Date) .By}}{{else}}{{tr "completed" (date .Date)}}{{end}}
+{{end -}}
+{{with .Creator}}- {{if .By}}{{tr "createdBy" (date .Date) .By}}{{else}}{{tr "created" (date .Date)}}{{end}}
+{{end -}}
+{{if not .ListEntered.IsZero}}- {{tr "daysInList" .DaysInList}}
+{{end -}}
+{{with .MemberNames}}- **{{tr "members"}}:** {{join . ", "}}
+{{end -}}
+{{with .LabelNames}}- **{{tr "labels"}}:** {{join . ", "}}
+{{end -}}
+{{range .CustomFields}}- **{{.Name}}:** {{.Value}}
+{{end -}}
+{{with .Location}}- 📍 {{if .Name}}**{{.Name}}**{{end}}{{if and .Name .Address}} - {{end}}{{.Address}}{{if .MapUrl}} ([{{tr "map"}}]({{.MapUrl}})){{end}}
+{{end -}}
+{{with .Stickers}}- {{range $i, $s := .}}{{if $i}} {{end}}![{{$s.Image}}]({{$s.ImageUrl}} "{{$s.Image}}"){{end}}
+{{end}}
+{{with .Card.Desc -}}
+{{.}}
+
+{{end -}}
+{{with .Checklists -}}
+##### {{tr "checklists"}}
+{{range . -}}
+{{.Name}}
+{{range .CheckItems -}}
+- [{{if eq .State "complete"}}x{{else}} {{end}}] {{.Name}}
+{{end}}
+{{end -}}
+{{end -}}
+{{with .Dependencies -}}
+##### {{tr "dependsOn"}}
+{{range . -}}
+- [{{.Name}}]({{.Url}}) - _{{.Status}}_
+{{end}}
+{{end -}}
+{{with .Attachments -}}
+##### {{tr "attachments"}}
+{{range . -}}
+- [{{.Name}}]({{.Url}}){{if .RequiresLogin}} _({{tr "trelloLogin"}})_{{end}}
+{{end}}
+{{end -}}
+{{with .PluginData -}}
+##### {{tr "powerUps"}}
+{{range . -}}
+` + "`{{.IdPlugin}}`" + `
+` + "```json" + `
+{{.Value}}
+` + "```" + `
+
+{{end -}}
+{{end -}}
+{{with .Comments -}}
+##### {{tr "commentHistory"}}
+{{range . -}}
+> **{{date .Date}}** - **{{.MemberCreator.FullName}}:**
+> {{quote .Data.Text}}
+{{if .Reactions -}}
+>
+> {{range $i, $r := .Reactions}}{{if $i}}  {{end}}{{$r.Emoji.Native}} {{$r.Count}}{{end}}
+{{end}}
+{{end -}}
+{{end -}}
+{{with .Activity -}}
+##### {{tr "activity"}}
+{{range . -}}
+- **{{date .Date}}** - {{.Member}}: {{.Text}}
+{{end}}
+{{end -}}
+`
+)
+
+var (
+	// boardCustomFields caches the custom fields defined on each board so they're fetched once per board rather than
+	// once per card
+	boardCustomFields   = map[string][]customField{}
+	boardCustomFieldsMu sync.Mutex
+)
+
+// customFieldValue is a custom field set on a card
+type customFieldValue struct {
+	Name  string
+	Value string
+}
+
+// activityView is something done to a card, described for the activity section
+type activityView struct {
+	Date   string
+	Member string
+	Text   string
+	// username is the trello username of Member
+	username string
+}
+
+func validateFidelity(fidelity string, layout string, customTemplate string) error {
+	switch fidelity {
+	case fidelityStandard:
+		return nil
+	case fidelityFull:
+		if customTemplate != "" || layout != layoutCards {
+			return errors.New("--fidelity full uses its own layout and can't be combined with --template or --layout")
+		}
+
+		return nil
+	default:
+		return errors.Errorf("unsupported fidelity %q, expected standard or full", fidelity)
+	}
+}
+
+// fullFidelity shows everything trello holds about a card, keeping the options which only change how it's shown
+func fullFidelity(show showOptions) showOptions {
+	return showOptions{
+		LabelsAndMembers:    true,
+		Description:         true,
+		Checklists:          true,
+		Comments:            true,
+		Reactions:           true,
+		Completion:          true,
+		Age:                 true,
+		PluginData:          true,
+		Location:            true,
+		Stickers:            true,
+		Attachments:         true,
+		CustomFields:        true,
+		Activity:            true,
+		DataviewFields:      show.DataviewFields,
+		Dependencies:        show.Dependencies,
+		Audit:               true,
+		CollapseDescription: show.CollapseDescription,
+		CollapseComments:    show.CollapseComments,
+		TitleMaxLength:      show.TitleMaxLength,
+	}
+}
+
+// printCustomFieldDefinitions writes a table of the custom fields along with their type and the options of list
+// fields
+func printCustomFieldDefinitions(w io.Writer, fields []customField) {
+	fmt.Fprintf(w, "##### %s\n", tr("customFields"))
+	fmt.Fprintf(w, "| %s | %s | %s |\n", tr("field"), tr("fieldType"), tr("fieldOptions"))
+	fmt.Fprintln(w, "| --- | --- | --- |")
+	for _, field := range fields {
+		var options []string
+		for _, option := range field.Options {
+			options = append(options, option.Value.Text)
+		}
+
+		fmt.Fprintf(w, "| %s | %s | %s |\n", tableCell(field.Name), field.Type, tableCell(strings.Join(options, ", ")))
+	}
+	fmt.Fprintln(w)
+}
+
+// getBoardCustomFields returns the custom fields defined on the board
+func getBoardCustomFields(client *trello.Client, boardId string) ([]customField, error) {
+	boardCustomFieldsMu.Lock()
+	defer boardCustomFieldsMu.Unlock()
+
+	if fields, ok := boardCustomFields[boardId]; ok {
+		return fields, nil
+	}
+
+	body, err := client.Get("/boards/" + boardId + "/customFields")
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []customField
+	err = json.Unmarshal(body, &fields)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the custom fields of board %s", boardId)
+	}
+
+	boardCustomFields[boardId] = fields
+
+	return fields, nil
+}
+
+// getCardCustomFields returns the custom fields set on the card in the order they're defined on the board
+func getCardCustomFields(client *trello.Client, card *trello.Card) ([]customFieldValue, error) {
+	fields, err := getBoardCustomFields(client, card.IdBoard)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	body, err := client.Get("/cards/" + card.Id + "/customFieldItems")
+	if err != nil {
+		return nil, err
+	}
+
+	var items []customFieldItem
+	err = json.Unmarshal(body, &items)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the custom fields of card %s", card.Id)
+	}
+
+	var values []customFieldValue
+	for _, field := range fields {
+		for _, item := range items {
+			if item.IdCustomField != field.Id {
+				continue
+			}
+
+			value, err := customFieldText(field, item)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, customFieldValue{Name: field.Name, Value: value})
+		}
+	}
+
+	return values, nil
+}
+
+// customFieldText formats a custom field value whatever its type, list fields name the selected option
+func customFieldText(field customField, item customFieldItem) (string, error) {
+	switch {
+	case item.IdValue != "":
+		for _, option := range field.Options {
+			if option.Id == item.IdValue {
+				return option.Value.Text, nil
+			}
+		}
+
+		return item.IdValue, nil
+	case item.Value.Date != "":
+		return formatDate(item.Value.Date)
+	case item.Value.Checked != "":
+		checked, err := strconv.ParseBool(item.Value.Checked)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid checkbox value %q", item.Value.Checked)
+		}
+
+		if checked {
+			return "✓", nil
+		}
+
+		return "✗", nil
+	case item.Value.Number != "":
+		return item.Value.Number, nil
+	default:
+		return item.Value.Text, nil
+	}
+}
+
+// getCardActivity returns everything done to the card other than comments, which are shown on their own, oldest
+// first
+func getCardActivity(client *trello.Client, card *trello.Card) ([]activityView, error) {
+	body, err := client.Get("/cards/" + card.Id + "/actions?filter=all&limit=" + strconv.Itoa(cardActionsLimit))
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []trello.Action
+	err = json.Unmarshal(body, &actions)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(actions) >= cardActionsLimit {
+		err = truncated("card %s has more than %d actions, only the latest are exported", card.Name, cardActionsLimit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var activity []activityView
+	for i := len(actions) - 1; i >= 0; i-- {
+		action := actions[i]
+		if action.Type == commentCardAction {
+			continue
+		}
+
+		activity = append(activity, activityView{
+			Date:     action.Date,
+			Member:   action.MemberCreator.FullName,
+			Text:     describeAction(action),
+			username: action.MemberCreator.Username,
+		})
+	}
+
+	return activity, nil
+}
+
+// describeAction names what an action did, e.g. moved to Done or update check item state on card: deploy
+func describeAction(action trello.Action) string {
+	if action.Data.ListAfter.Name != "" {
+		return tr("movedTo", action.Data.ListAfter.Name)
+	}
+
+	var words []string
+	start := 0
+	for i, r := range action.Type {
+		if unicode.IsUpper(r) {
+			words = append(words, strings.ToLower(action.Type[start:i]))
+			start = i
+		}
+	}
+	words = append(words, strings.ToLower(action.Type[start:]))
+	text := strings.Join(words, " ")
+
+	if action.Data.CheckItem.Name != "" {
+		text += ": " + action.Data.CheckItem.Name
+	}
+
+	return text
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+)
+
+const (
+	trackedDue     = "due"
+	trackedLabels  = "labels"
+	trackedMembers = "members"
+	trackedName    = "name"
+	trackedList    = "list"
+)
+
+var (
+	// trackedFieldFilters are the card actions changing each field which can be tracked
+	trackedFieldFilters = map[string][]string{
+		trackedDue:     {"updateCard:due"},
+		trackedLabels:  {"addLabelToCard", "removeLabelFromCard"},
+		trackedMembers: {"addMemberToCard", "removeMemberFromCard"},
+		trackedName:    {"updateCard:name"},
+		trackedList:    {"updateCard:idList"},
+	}
+)
+
+// fieldChange is a change to one of the tracked fields of a card, described for the card's timeline
+type fieldChange struct {
+	Date   string
+	Member string
+	Text   string
+}
+
+// fieldAction is an action changing a field of a card, old holds the values the update replaced
+type fieldAction struct {
+	Type string `json:"type"`
+	Date string `json:"date"`
+	Data struct {
+		Card struct {
+			Name string  `json:"name"`
+			Due  *string `json:"due"`
+		} `json:"card"`
+		Old   map[string]json.RawMessage `json:"old"`
+		Label struct {
+			Name  string `json:"name"`
+			Color string `json:"color"`
+		} `json:"label"`
+		Member struct {
+			Name string `json:"name"`
+		} `json:"member"`
+		ListBefore *struct {
+			Name string `json:"name"`
+		} `json:"listBefore"`
+		ListAfter *struct {
+			Name string `json:"name"`
+		} `json:"listAfter"`
+	} `json:"data"`
+	MemberCreator struct {
+		FullName string `json:"fullName"`
+	} `json:"memberCreator"`
+}
+
+// parseTrackedFields reads the comma separated fields given to --track-fields
+func parseTrackedFields(s string) ([]string, error) {
+	var fields []string
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		if _, ok := trackedFieldFilters[field]; !ok {
+			return nil, errors.Errorf("unsupported tracked field %q, expected due, labels, members, name or list", field)
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// getCardFieldChanges returns the changes to the tracked fields of the card, oldest first
+func getCardFieldChanges(client *trello.Client, card *trello.Card, fields []string) ([]fieldChange, error) {
+	var filters []string
+	for _, field := range fields {
+		filters = append(filters, trackedFieldFilters[field]...)
+	}
+	sort.Strings(filters)
+
+	body, err := client.Get("/cards/" + card.Id + "/actions?filter=" + strings.Join(filters, ",") + "&limit=" + strconv.Itoa(cardActionsLimit))
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []fieldAction
+	err = json.Unmarshal(body, &actions)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(actions) >= cardActionsLimit {
+		err = truncated("card %s has more than %d field changes, only the latest are exported", card.Name, cardActionsLimit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var changes []fieldChange
+	for i := len(actions) - 1; i >= 0; i-- {
+		text := describeFieldChange(actions[i], fields)
+		if text == "" {
+			continue
+		}
+
+		changes = append(changes, fieldChange{
+			Date:   actions[i].Date,
+			Member: actions[i].MemberCreator.FullName,
+			Text:   text,
+		})
+	}
+
+	return changes, nil
+}
+
+// describeFieldChange names what the action changed, e.g. due moved from 2024-04-10 to 2024-04-12, it's empty when
+// the action doesn't change a tracked field
+func describeFieldChange(action fieldAction, fields []string) string {
+	data := action.Data
+	switch action.Type {
+	case "addLabelToCard", "removeLabelFromCard":
+		if !containsString(fields, trackedLabels) {
+			return ""
+		}
+
+		label := data.Label.Name
+		if label == "" {
+			label = data.Label.Color
+		}
+
+		if action.Type == "addLabelToCard" {
+			return tr("labelAdded", label)
+		}
+		return tr("labelRemoved", label)
+	case "addMemberToCard", "removeMemberFromCard":
+		if !containsString(fields, trackedMembers) {
+			return ""
+		}
+
+		if action.Type == "addMemberToCard" {
+			return tr("memberAdded", data.Member.Name)
+		}
+		return tr("memberRemoved", data.Member.Name)
+	}
+
+	if _, ok := data.Old["due"]; ok && containsString(fields, trackedDue) {
+		var before string
+		_ = json.Unmarshal(data.Old["due"], &before)
+		before, _ = formatDate(before)
+
+		after := ""
+		if data.Card.Due != nil {
+			after, _ = formatDate(*data.Card.Due)
+		}
+
+		switch {
+		case after == "":
+			return tr("dueRemoved")
+		case before == "":
+			return tr("dueSet", after)
+		default:
+			return tr("dueMoved", before, after)
+		}
+	}
+
+	if _, ok := data.Old["name"]; ok && containsString(fields, trackedName) {
+		var before string
+		_ = json.Unmarshal(data.Old["name"], &before)
+
+		return tr("renamed", before, data.Card.Name)
+	}
+
+	if data.ListBefore != nil && data.ListAfter != nil && containsString(fields, trackedList) {
+		return tr("movedFromTo", data.ListBefore.Name, data.ListAfter.Name)
+	}
+
+	return ""
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// filterFields are the card fields filter expressions can refer to, label and member may hold several values
+	filterFields = map[string]bool{
+		"name":     true,
+		"desc":     true,
+		"label":    true,
+		"member":   true,
+		"due":      true,
+		"activity": true,
+		"board":    true,
+		"points":   true,
+	}
+)
+
+// cardFilter is a compiled --filter expression, e.g. label == "bug" && due < "2024-06-01" && member in ["jane"]
+type cardFilter struct {
+	match func(fields map[string][]string) bool
+	// members is set when the expression refers to members, they are fetched for every card
+	members bool
+}
+
+// filterToken is a lexed piece of a filter expression, strings hold their unquoted value
+type filterToken struct {
+	kind  string
+	value string
+	pos   int
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+	filter *cardFilter
+}
+
+// newCardFilter compiles a filter expression
+func newCardFilter(expression string) (*cardFilter, error) {
+	tokens, err := lexFilter(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filterParser{tokens: tokens, filter: &cardFilter{}}
+	match, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek().kind != "end" {
+		return nil, p.errorf("unexpected %q", p.peek().value)
+	}
+
+	p.filter.match = match
+
+	return p.filter, nil
+}
+
+// matches evaluates the filter against a card
+func (f *cardFilter) matches(view *cardView) (bool, error) {
+	fields := map[string][]string{
+		"name":  {view.Card.Name},
+		"desc":  {view.Card.Desc},
+		"board": {view.Board.Name},
+		"label": view.LabelNames(),
+	}
+
+	for _, member := range view.Members {
+		fields["member"] = append(fields["member"], member.Username, member.FullName)
+	}
+
+	for field, date := range map[string]string{"due": view.Card.Due, "activity": view.Card.DateLastActivity} {
+		if date == "" {
+			continue
+		}
+
+		formatted, err := formatDate(date)
+		if err != nil {
+			return false, err
+		}
+		fields[field] = []string{formatted}
+	}
+
+	if view.Points != nil {
+		fields["points"] = []string{view.PointsText()}
+	}
+
+	return f.match(fields), nil
+}
+
+func lexFilter(expression string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			end := i + 1
+			var value strings.Builder
+			for ; end < len(runes) && runes[end] != r; end++ {
+				if runes[end] == '\\' && end+1 < len(runes) {
+					end++
+				}
+				value.WriteRune(runes[end])
+			}
+			if end >= len(runes) {
+				return nil, errors.Errorf("invalid filter at %d: unterminated string", i+1)
+			}
+			tokens = append(tokens, filterToken{kind: "string", value: value.String(), pos: i})
+			i = end + 1
+		case unicode.IsDigit(r) || r == '-' || r == '.':
+			end := i + 1
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, filterToken{kind: "string", value: string(runes[i:end]), pos: i})
+			i = end
+		case unicode.IsLetter(r) || r == '_':
+			end := i + 1
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_') {
+				end++
+			}
+			word := string(runes[i:end])
+			kind := "field"
+			if word == "in" {
+				kind = "in"
+			}
+			tokens = append(tokens, filterToken{kind: kind, value: word, pos: i})
+			i = end
+		default:
+			op := ""
+			for _, candidate := range []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")", "[", "]", ","} {
+				if strings.HasPrefix(string(runes[i:]), candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, errors.Errorf("invalid filter at %d: unexpected %q", i+1, string(r))
+			}
+			tokens = append(tokens, filterToken{kind: op, value: op, pos: i})
+			i += len([]rune(op))
+		}
+	}
+
+	return append(tokens, filterToken{kind: "end", value: "end of filter", pos: len(runes)}), nil
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	token := p.tokens[p.pos]
+	if token.kind != "end" {
+		p.pos++
+	}
+
+	return token
+}
+
+func (p *filterParser) errorf(format string, args ...interface{}) error {
+	return errorAt(p.peek(), format, args...)
+}
+
+// errorAt reports a problem with the expression at the token
+func errorAt(token filterToken, format string, args ...interface{}) error {
+	return errors.Errorf("invalid filter at %d: "+format, append([]interface{}{token.pos + 1}, args...)...)
+}
+
+func (p *filterParser) or() (func(map[string][]string) bool, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == "||" {
+		p.next()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+
+		l := left
+		left = func(fields map[string][]string) bool { return l(fields) || right(fields) }
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) and() (func(map[string][]string) bool, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == "&&" {
+		p.next()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		l := left
+		left = func(fields map[string][]string) bool { return l(fields) && right(fields) }
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) unary() (func(map[string][]string) bool, error) {
+	switch p.peek().kind {
+	case "!":
+		p.next()
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		return func(fields map[string][]string) bool { return !operand(fields) }, nil
+	case "(":
+		p.next()
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.peek().kind != ")" {
+			return nil, p.errorf("expected )")
+		}
+		p.next()
+
+		return inner, nil
+	default:
+		return p.comparison()
+	}
+}
+
+// comparison parses field op value, field in [values] or a bare field which matches when it has any value
+func (p *filterParser) comparison() (func(map[string][]string) bool, error) {
+	token := p.next()
+	if token.kind != "field" {
+		return nil, errorAt(token, "expected a field, got %q", token.value)
+	}
+
+	field := token.value
+	if !filterFields[field] {
+		return nil, errorAt(token, "unknown field %q", field)
+	}
+
+	if field == "member" {
+		p.filter.members = true
+	}
+
+	op := p.peek().kind
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		p.next()
+		value := p.next()
+		if value.kind != "string" {
+			return nil, errorAt(value, "expected a value after %s", op)
+		}
+
+		return func(fields map[string][]string) bool {
+			for _, v := range fields[field] {
+				if compareFilterValues(v, op, value.value) {
+					return op != "!="
+				}
+			}
+
+			return op == "!="
+		}, nil
+	case "in":
+		p.next()
+		values, err := p.list()
+		if err != nil {
+			return nil, err
+		}
+
+		return func(fields map[string][]string) bool {
+			for _, v := range fields[field] {
+				if containsString(values, v) {
+					return true
+				}
+			}
+
+			return false
+		}, nil
+	default:
+		return func(fields map[string][]string) bool {
+			for _, v := range fields[field] {
+				if v != "" {
+					return true
+				}
+			}
+
+			return false
+		}, nil
+	}
+}
+
+func (p *filterParser) list() ([]string, error) {
+	if p.peek().kind != "[" {
+		return nil, p.errorf("expected [ after in")
+	}
+	p.next()
+
+	var values []string
+	for p.peek().kind != "]" {
+		if len(values) > 0 {
+			if p.peek().kind != "," {
+				return nil, p.errorf("expected , or ]")
+			}
+			p.next()
+		}
+
+		value := p.next()
+		if value.kind != "string" {
+			return nil, errorAt(value, "expected a value in the list")
+		}
+		values = append(values, value.value)
+	}
+	p.next()
+
+	return values, nil
+}
+
+// compareFilterValues compares numerically when both sides are numbers and as strings otherwise, dates are
+// formatted so they sort as strings, != is answered as == and negated by the caller
+func compareFilterValues(a string, op string, b string) bool {
+	cmp := strings.Compare(a, b)
+	if af, err := strconv.ParseFloat(a, 64); err == nil {
+		if bf, err := strconv.ParseFloat(b, 64); err == nil {
+			switch {
+			case af < bf:
+				cmp = -1
+			case af > bf:
+				cmp = 1
+			default:
+				cmp = 0
+			}
+		}
+	}
+
+	switch op {
+	case "==", "!=":
+		return cmp == 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
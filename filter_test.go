@@ -0,0 +1,120 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCardFilter(t *testing.T) {
+	view := &cardView{
+		Board: Board{Name: "Platform"},
+		Card: Card{
+			Name:             "Fix login",
+			Desc:             "users can't sign in",
+			Due:              "2024-05-20T12:00:00.000Z",
+			DateLastActivity: "2024-05-01T09:30:00.000Z",
+			Labels:           []Label{{Name: "bug", Color: "red"}, {Color: "green"}},
+		},
+		Members: []Member{{Username: "jane", FullName: "Jane Doe"}},
+	}
+
+	tests := []struct {
+		expression string
+		want       bool
+	}{
+		{expression: `label == "bug"`, want: true},
+		{expression: `label == "green"`, want: true},
+		{expression: `label != "bug"`, want: false},
+		{expression: `label != "feature"`, want: true},
+		{expression: `label == 'bug' && due < "2024-06-01"`, want: true},
+		{expression: `due >= "2024-05-21"`, want: false},
+		{expression: `activity <= "2024-05-01"`, want: true},
+		{expression: `member in ["bob", "jane"]`, want: true},
+		{expression: `member in ["Jane Doe"]`, want: true},
+		{expression: `member in []`, want: false},
+		{expression: `!(board == "Platform")`, want: false},
+		{expression: `board == "Mobile" || name == "Fix login"`, want: true},
+		{expression: `board == "Mobile" || name == "Fix login" && label == "feature"`, want: false},
+		{expression: `(board == "Mobile" || name == "Fix login") && label == "bug"`, want: true},
+		{expression: `desc`, want: true},
+		{expression: `points`, want: false},
+		{expression: `name == "Fix \"login\""`, want: false},
+		{expression: `name > "Apple"`, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			filter, err := newCardFilter(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := filter.matches(view)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("%s = %v, want %v", tt.expression, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCardFilterMembers(t *testing.T) {
+	for expression, want := range map[string]bool{
+		`member == "jane"`:       true,
+		`label == "bug"`:         false,
+		`name == "x" || member`:  true,
+		`member in ["a"] && due`: true,
+	} {
+		filter, err := newCardFilter(expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if filter.members != want {
+			t.Errorf("%s refers to members = %v, want %v", expression, filter.members, want)
+		}
+	}
+}
+
+func TestCompareFilterValues(t *testing.T) {
+	tests := []struct {
+		a, op, b string
+		want     bool
+	}{
+		{a: "9", op: "<", b: "10", want: true},
+		{a: "9", op: "<", b: "10a", want: false},
+		{a: "2.5", op: ">=", b: "2.50", want: true},
+		{a: "2024-05-01", op: "<", b: "2024-06-01", want: true},
+		{a: "b", op: "==", b: "b", want: true},
+		{a: "b", op: ">", b: "a", want: true},
+	}
+
+	for _, tt := range tests {
+		if got := compareFilterValues(tt.a, tt.op, tt.b); got != tt.want {
+			t.Errorf("compareFilterValues(%q, %q, %q) = %v, want %v", tt.a, tt.op, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCardFilterErrors(t *testing.T) {
+	for _, expression := range []string{
+		``,
+		`label ==`,
+		`label == "bug`,
+		`colour == "red"`,
+		`label == "bug" &&`,
+		`(label == "bug"`,
+		`label == "bug")`,
+		`member in "jane"`,
+		`member in ["jane"`,
+		`member in ["a" "b"]`,
+		`label @ "bug"`,
+		`"bug" == label`,
+	} {
+		_, err := newCardFilter(expression)
+		if err == nil {
+			t.Errorf("%q compiled, want an error", expression)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	statsFormatSankey = "sankey"
+
+	// flowFilter selects the board actions moving a card from one list to another
+	flowFilter = "updateCard:idList"
+)
+
+var (
+	statsFlowArgs = []cli.Flag{
+		cli.StringSliceFlag{
+			Name:   "board-id",
+			Usage:  "the ids or config aliases of the boards to report on",
+			EnvVar: "BOARD_ID",
+		},
+		cli.StringFlag{
+			Name:   "since",
+			Usage:  "the first day to count moves on as YYYY-MM-DD, defaults to 30 days ago",
+			EnvVar: "TRELLO2MD_FLOW_SINCE",
+		},
+		cli.StringFlag{
+			Name:   "until",
+			Usage:  "the last day to count moves on as YYYY-MM-DD, defaults to today",
+			EnvVar: "TRELLO2MD_FLOW_UNTIL",
+		},
+		cli.StringFlag{
+			Name:   "format",
+			Usage:  "the format to write the report in, a markdown table, csv or a mermaid sankey diagram",
+			EnvVar: "STATS_FORMAT",
+			Value:  statsFormatMarkdown,
+		},
+	}
+)
+
+// listFlow counts the cards moved between each pair of a board's lists
+type listFlow struct {
+	// lists are the open lists of the board in board order followed by the archived lists cards were moved between
+	lists []List
+	// open is how many of the lists are open, the position of archived lists isn't known
+	open  int
+	moves map[string]map[string]int
+	total int
+}
+
+// statsFlow reports how many cards were moved from each list of a board to each other list, moves back to an earlier
+// list show where work bounces
+func statsFlow(c *cli.Context) error {
+	since, until, err := statsRange(c)
+	if err != nil {
+		return err
+	}
+
+	format := c.String("format")
+	if format != statsFormatMarkdown && format != statsFormatCSV && format != statsFormatSankey {
+		return errors.Errorf("unsupported format %q, expected markdown, csv or sankey", format)
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	clients := newBoardClients(c, cfg)
+	boards, err := getBoards(clients, c.StringSlice("board-id"))
+	if err != nil {
+		return err
+	}
+
+	var rows *csv.Writer
+	if format == statsFormatCSV {
+		rows = csv.NewWriter(os.Stdout)
+		err = rows.Write([]string{"board", "from", "to", "moves"})
+		if err != nil {
+			return err
+		}
+	} else {
+		printDate(os.Stdout, time.Now())
+	}
+
+	for _, board := range *boards {
+		client, err := clients.forBoard(board.Id)
+		if err != nil {
+			return err
+		}
+
+		flow, err := loadListFlow(client, board, since, until)
+		if err != nil {
+			return err
+		}
+
+		if format == statsFormatCSV {
+			for _, from := range flow.lists {
+				for _, to := range flow.lists {
+					if flow.moves[from.Id][to.Id] == 0 {
+						continue
+					}
+
+					err = rows.Write([]string{board.Name, from.Name, to.Name, strconv.Itoa(flow.moves[from.Id][to.Id])})
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			continue
+		}
+
+		printBoard(os.Stdout, newBoard(board))
+		if format == statsFormatSankey {
+			err = printFlowSankey(os.Stdout, flow)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		printFlowTable(os.Stdout, flow)
+	}
+
+	if rows != nil {
+		rows.Flush()
+		return rows.Error()
+	}
+
+	return nil
+}
+
+// loadListFlow counts the moves between the board's lists made on the days in the range
+func loadListFlow(client *trello.Client, board trello.Board, since, until time.Time) (*listFlow, error) {
+	lists, err := getLists(&board)
+	if err != nil {
+		return nil, err
+	}
+
+	actions, err := getBoardActions(client, &board, flowFilter, since)
+	if err != nil {
+		return nil, err
+	}
+
+	flow := &listFlow{
+		lists: newLists(lists),
+		open:  len(lists),
+		moves: map[string]map[string]int{},
+	}
+
+	known := map[string]bool{}
+	for _, list := range lists {
+		known[list.Id] = true
+	}
+
+	endOfRange := until.AddDate(0, 0, 1).Format(time.RFC3339)
+	// oldest first so archived lists are added in the order cards first moved through them
+	for i := len(actions) - 1; i >= 0; i-- {
+		action := actions[i]
+		if action.Date >= endOfRange || action.Data.ListBefore == nil || action.Data.ListAfter == nil {
+			continue
+		}
+
+		for _, list := range []List{{Id: action.Data.ListBefore.Id, Name: action.Data.ListBefore.Name}, {Id: action.Data.ListAfter.Id, Name: action.Data.ListAfter.Name}} {
+			if !known[list.Id] {
+				known[list.Id] = true
+				flow.lists = append(flow.lists, list)
+			}
+		}
+
+		from, to := action.Data.ListBefore.Id, action.Data.ListAfter.Id
+		if flow.moves[from] == nil {
+			flow.moves[from] = map[string]int{}
+		}
+		flow.moves[from][to]++
+		flow.total++
+	}
+
+	return flow, nil
+}
+
+// backward counts the moves from an open list to an open list before it on the board
+func (f *listFlow) backward() int {
+	count := 0
+	for i := 0; i < f.open; i++ {
+		for j := 0; j < i; j++ {
+			count += f.moves[f.lists[i].Id][f.lists[j].Id]
+		}
+	}
+
+	return count
+}
+
+// printFlowTable writes a markdown table with a row for the list cards were moved from and a column for the list
+// they were moved to, followed by how many moves went back to an earlier list
+func printFlowTable(w io.Writer, flow *listFlow) {
+	if flow.total == 0 {
+		fmt.Fprintf(w, "_%s_\n\n", tr("flowNone"))
+		return
+	}
+
+	header := []string{tr("flowFromTo")}
+	for _, list := range flow.lists {
+		header = append(header, tableCell(list.Name))
+	}
+
+	fmt.Fprintf(w, "| %s |\n", strings.Join(header, " | "))
+	fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(header)))
+
+	for _, from := range flow.lists {
+		row := []string{tableCell(from.Name)}
+		for _, to := range flow.lists {
+			cell := ""
+			if moves := flow.moves[from.Id][to.Id]; moves > 0 {
+				cell = strconv.Itoa(moves)
+			}
+			row = append(row, cell)
+		}
+
+		fmt.Fprintf(w, "| %s |\n", strings.Join(row, " | "))
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%s\n\n", tr("flowBackward", flow.backward(), flow.total))
+}
+
+// printFlowSankey writes the moves as a mermaid sankey diagram. sankey diagrams can't have cycles, so lists appear
+// once on the left as where cards were moved from and once on the right as where they were moved to
+func printFlowSankey(w io.Writer, flow *listFlow) error {
+	if flow.total == 0 {
+		fmt.Fprintf(w, "_%s_\n\n", tr("flowNone"))
+		return nil
+	}
+
+	fmt.Fprintln(w, "```mermaid")
+	fmt.Fprintln(w, "sankey-beta")
+
+	links := csv.NewWriter(w)
+	for _, from := range flow.lists {
+		for _, to := range flow.lists {
+			moves := flow.moves[from.Id][to.Id]
+			if moves == 0 {
+				continue
+			}
+
+			err := links.Write([]string{from.Name, "→ " + to.Name, strconv.Itoa(moves)})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	links.Flush()
+	err := links.Error()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "```")
+	fmt.Fprintln(w)
+
+	return nil
+}
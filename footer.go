@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	// defaultFooterTemplate notes how an export was produced so a report found later can be traced back to it
+	defaultFooterTemplate = `---
+_{{tr "generated" .Tool (.Generated.Format "2006-01-02 15:04 MST") (join .Boards ", ")}}{{with .Filters}}, {{tr "filteredBy" (join . ", ")}}{{end}}_
+`
+)
+
+var (
+	// footerFilterFlags are the flags which leave cards out of an export, listed in the footer when applied
+	footerFilterFlags = []string{"list-filter", "filter", "stale-after", "transform"}
+)
+
+// footerData is the data handed to the footer template
+type footerData struct {
+	// Tool is the name and version of trello2md
+	Tool      string
+	Version   versionInfo
+	Generated time.Time
+	Boards    []string
+	// Filters are the filter flags the export was run with, e.g. `--list-filter Done`
+	Filters []string
+}
+
+// renderFooter renders the footer configured by --footer or --footer-template, nil when no footer is wanted
+func renderFooter(c *cli.Context, generated time.Time, exports []*boardExport) ([]byte, error) {
+	if !c.Bool("footer") && c.String("footer-template") == "" {
+		return nil, nil
+	}
+
+	tmpl, err := loadCardTemplate(c.String("footer-template"), defaultFooterTemplate)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load footer template")
+	}
+
+	version := buildVersion()
+	data := footerData{
+		Tool:      appName + " " + version.short(),
+		Version:   version,
+		Generated: generated,
+	}
+
+	for _, boardExport := range exports {
+		data.Boards = append(data.Boards, boardExport.board.Name)
+	}
+
+	if c.Bool("all-lists") {
+		data.Filters = append(data.Filters, "`--all-lists`")
+	}
+
+	if c.IsSet("list-index") {
+		data.Filters = append(data.Filters, fmt.Sprintf("`--list-index %d`", c.Int("list-index")))
+	}
+
+	for _, name := range footerFilterFlags {
+		if name == "list-filter" {
+			// the list filter isn't used when lists are selected with --all-lists or --list-index
+			if !c.Bool("all-lists") && !c.IsSet("list-index") {
+				for _, filter := range listFilters(c) {
+					data.Filters = append(data.Filters, fmt.Sprintf("`--%s %s`", name, filter))
+				}
+			}
+
+			continue
+		}
+
+		if c.String(name) != "" {
+			data.Filters = append(data.Filters, fmt.Sprintf("`--%s %s`", name, c.String(name)))
+		}
+	}
+
+	var footer bytes.Buffer
+	footer.WriteString("\n")
+	err = tmpl.Execute(&footer, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to render footer")
+	}
+
+	return footer.Bytes(), nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	tagStyleKebab = "kebab"
+	tagStyleSnake = "snake"
+	tagStyleKeep  = "keep"
+)
+
+// frontMatter is the yaml written at the top of per card files for knowledge bases such as obsidian
+type frontMatter struct {
+	Title string   `yaml:"title"`
+	Url   string   `yaml:"url,omitempty"`
+	Board string   `yaml:"board"`
+	List  string   `yaml:"list"`
+	Due   string   `yaml:"due,omitempty"`
+	Tags  []string `yaml:"tags,flow"`
+}
+
+// tagOptions controls how card labels are turned into tags
+type tagOptions struct {
+	Prefix string
+	Style  string
+}
+
+func validateFrontMatter(enabled bool, split string, tags tagOptions) error {
+	switch tags.Style {
+	case tagStyleKebab, tagStyleSnake, tagStyleKeep:
+	default:
+		return errors.Errorf("unsupported tag style %q, expected one of kebab, snake or keep", tags.Style)
+	}
+
+	if enabled && split != splitCard {
+		return errors.New("--front-matter requires --split-by card")
+	}
+
+	return nil
+}
+
+func printFrontMatter(w io.Writer, view *cardView, list List, tags tagOptions) error {
+	due := ""
+	if view.Card.Due != "" {
+		var err error
+		due, err = formatDate(view.Card.Due)
+		if err != nil {
+			return err
+		}
+	}
+
+	data, err := yaml.Marshal(frontMatter{
+		Title: view.Card.Name,
+		Url:   view.Card.Url,
+		Board: view.Board.Name,
+		List:  list.Name,
+		Due:   due,
+		Tags:  labelTags(&view.Card, tags),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "---\n%s---\n", data)
+
+	return err
+}
+
+// labelTags maps the card's labels to prefixed and normalised tags, unnamed labels are tagged with their color
+func labelTags(card *Card, opts tagOptions) []string {
+	tags := []string{}
+	for _, label := range card.Labels {
+		name := label.Name
+		if name == "" {
+			name = label.Color
+		}
+
+		tag := normaliseTag(name, opts.Style)
+		if tag == "" {
+			continue
+		}
+
+		tag = opts.Prefix + tag
+		if !containsString(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// normaliseTag makes a label name usable as a tag, tags can't contain spaces or most punctuation
+func normaliseTag(name string, style string) string {
+	switch style {
+	case tagStyleSnake:
+		return strings.Replace(slugify(name), "-", "_", -1)
+	case tagStyleKeep:
+		return strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '/'
+		}), "-")
+	default:
+		return slugify(name)
+	}
+}
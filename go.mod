@@ -7,4 +7,7 @@ require (
 	github.com/jakekeeys/go-trello v0.0.0-20191204102514-6ce4ead33f25
 	github.com/pkg/errors v0.8.1
 	github.com/urfave/cli v1.22.2
+	go.starlark.net v0.0.0-20191113183327-aaf7be003892
+	golang.org/x/text v0.3.2
+	gopkg.in/yaml.v2 v2.2.2
 )
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	historyFilename = "history.jsonl"
+)
+
+var (
+	// historyMu serializes writes to the history log, the server runs exports alongside each other
+	historyMu sync.Mutex
+
+	// historySensitiveFlags are the parts of flag names whose values are credentials or urls embedding them, they're
+	// left out of the history so it can be shared for audits
+	historySensitiveFlags = []string{"token", "secret", "key", "password", "webhook", "url"}
+)
+
+// historyEntry records a run in the history log, when it ran, how it was filtered and what it wrote
+type historyEntry struct {
+	Time       string            `json:"time"`
+	Command    string            `json:"command"`
+	Parameters map[string]string `json:"parameters"`
+	Status     string            `json:"status"`
+	Error      string            `json:"error,omitempty"`
+	Duration   float64           `json:"duration_seconds"`
+	// OutputHash is the sha256 of everything the run wrote, empty when it failed before writing
+	OutputHash string `json:"output_sha256,omitempty"`
+	Boards     int    `json:"boards"`
+	Cards      int    `json:"cards"`
+	Warnings   int    `json:"warnings"`
+}
+
+// recordHistory appends the run to the history log in --history-dir, if one is set. the log is rotated to
+// history.jsonl.1, .2 and so on once it would grow past --history-max-bytes, keeping --history-keep rotated logs
+func recordHistory(c *cli.Context, report *runReport, outputHash string, runErr error) error {
+	dir := c.String("history-dir")
+	if dir == "" {
+		return nil
+	}
+
+	entry := historyEntry{
+		Time:       report.start.UTC().Format(time.RFC3339),
+		Command:    c.Command.Name,
+		Parameters: historyParameters(c),
+		Status:     jobSucceeded,
+		Duration:   time.Since(report.start).Seconds(),
+		OutputHash: outputHash,
+		Boards:     report.Boards,
+		Cards:      report.Cards,
+		Warnings:   len(report.Warnings),
+	}
+	if runErr != nil {
+		entry.Status = jobFailed
+		entry.Error = runErr.Error()
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return errors.Wrap(err, "unable to create the history directory")
+	}
+
+	path := filepath.Join(dir, historyFilename)
+	if info, err := os.Stat(path); err == nil && info.Size()+int64(len(line)) > c.Int64("history-max-bytes") {
+		err = rotateHistory(path, c.Int("history-keep"))
+		if err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrap(err, "unable to open the history log")
+	}
+
+	_, err = f.Write(line)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrap(err, "unable to write the history log")
+	}
+
+	return nil
+}
+
+// rotateHistory shifts each rotated log up a number, dropping those past keep, and moves the log to .1
+func rotateHistory(path string, keep int) error {
+	if keep < 1 {
+		return errors.Wrap(os.Remove(path), "unable to rotate the history log")
+	}
+
+	err := os.Remove(fmt.Sprintf("%s.%d", path, keep))
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "unable to rotate the history log")
+	}
+
+	for n := keep - 1; n >= 1; n-- {
+		err = os.Rename(fmt.Sprintf("%s.%d", path, n), fmt.Sprintf("%s.%d", path, n+1))
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "unable to rotate the history log")
+		}
+	}
+
+	return errors.Wrap(os.Rename(path, path+".1"), "unable to rotate the history log")
+}
+
+// historyParameters are the flags of the command which were set, with credentials redacted
+func historyParameters(c *cli.Context) map[string]string {
+	parameters := map[string]string{}
+	for _, flag := range c.Command.Flags {
+		name := strings.TrimSpace(strings.Split(flag.GetName(), ",")[0])
+		if !c.IsSet(name) {
+			continue
+		}
+
+		if isSensitiveFlag(name) {
+			parameters[name] = "redacted"
+			continue
+		}
+
+		if _, ok := flag.(cli.StringSliceFlag); ok {
+			parameters[name] = strings.Join(c.StringSlice(name), ",")
+			continue
+		}
+
+		parameters[name] = fmt.Sprint(c.Generic(name))
+	}
+
+	return parameters
+}
+
+func isSensitiveFlag(name string) bool {
+	for _, part := range historySensitiveFlags {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+
+	return false
+}
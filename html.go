@@ -0,0 +1,314 @@
+package main
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// htmlTag matches the tags commonly found in descriptions pasted from email or other tools, text which merely
+	// mentions something in angle brackets doesn't match
+	htmlTag = regexp.MustCompile(`(?i)</?(p|br|div|span|b|strong|i|em|u|s|strike|del|a|img|ul|ol|li|table|thead|tbody|tr|td|th|h[1-6]|code|pre|hr|blockquote)\b[^>]*>`)
+	// htmlToken matches any tag or comment
+	htmlToken     = regexp.MustCompile(`(?s)<!--.*?-->|<(/?)([a-zA-Z][a-zA-Z0-9]*)([^>]*)>`)
+	htmlAttribute = regexp.MustCompile(`(?i)([a-z-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	blankLines    = regexp.MustCompile(`\n{3,}`)
+	// markdownCode matches fenced code blocks and code spans, html shown in them is left as it is
+	markdownCode = regexp.MustCompile("(?ms)^[ \t]*```.*?^[ \t]*```[^\n]*$|`[^`\n]+`")
+)
+
+// htmlList tracks the numbering of an open html list
+type htmlList struct {
+	ordered bool
+	items   int
+}
+
+// htmlConverter turns the common html found in card descriptions into markdown, anything it doesn't understand is
+// dropped leaving just its text
+type htmlConverter struct {
+	out   strings.Builder
+	lists []*htmlList
+	links []string
+	pre   bool
+
+	// table holds the rows of the open table, cell is the text of the open cell. tables nested in a cell can't be
+	// rendered in markdown, nested counts those open so their text is kept in the cell
+	table  [][]string
+	cell   *strings.Builder
+	nested int
+}
+
+// htmlToMarkdown converts the html in s to markdown, text without any html outside of code is returned unchanged
+func htmlToMarkdown(s string) string {
+	if !htmlTag.MatchString(markdownCode.ReplaceAllString(s, "")) {
+		return s
+	}
+
+	c := &htmlConverter{}
+	last := 0
+	for _, code := range markdownCode.FindAllStringIndex(s, -1) {
+		c.convert(s[last:code[0]])
+		c.write(s[code[0]:code[1]])
+		last = code[1]
+	}
+	c.convert(s[last:])
+
+	return strings.TrimSpace(blankLines.ReplaceAllString(c.out.String(), "\n\n"))
+}
+
+// convert converts the tags in s, which holds no markdown code
+func (c *htmlConverter) convert(s string) {
+	last := 0
+	for _, m := range htmlToken.FindAllStringSubmatchIndex(s, -1) {
+		c.text(s[last:m[0]])
+		last = m[1]
+
+		if m[4] < 0 {
+			// a comment
+			continue
+		}
+
+		c.tag(strings.ToLower(s[m[4]:m[5]]), s[m[2]:m[3]] == "/", s[m[6]:m[7]])
+	}
+	c.text(s[last:])
+}
+
+func (c *htmlConverter) write(s string) {
+	if c.cell != nil {
+		c.cell.WriteString(s)
+		return
+	}
+
+	c.out.WriteString(s)
+}
+
+func (c *htmlConverter) text(s string) {
+	s = html.UnescapeString(s)
+	if c.cell != nil {
+		s = strings.Join(strings.Fields(s), " ")
+		s = strings.Replace(s, "|", `\|`, -1)
+	}
+
+	c.write(s)
+}
+
+// block starts a new block on its own line separated from what came before it
+func (c *htmlConverter) block() {
+	if c.cell != nil {
+		return
+	}
+
+	text := c.out.String()
+	if text != "" && !strings.HasSuffix(text, "\n\n") {
+		if strings.HasSuffix(text, "\n") {
+			c.out.WriteString("\n")
+		} else {
+			c.out.WriteString("\n\n")
+		}
+	}
+}
+
+// line ends the current line unless it's already ended
+func (c *htmlConverter) line() {
+	if c.cell != nil {
+		return
+	}
+
+	text := c.out.String()
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		c.out.WriteString("\n")
+	}
+}
+
+func (c *htmlConverter) tag(name string, closing bool, attributes string) {
+	switch name {
+	case "b", "strong":
+		c.write("**")
+	case "i", "em":
+		c.write("_")
+	case "s", "strike", "del":
+		c.write("~~")
+	case "code":
+		if !c.pre {
+			c.write("`")
+		}
+	case "br":
+		if c.cell != nil {
+			c.write(" ")
+		} else {
+			c.write("\n")
+		}
+	case "hr":
+		c.block()
+		c.write("---\n\n")
+	case "p", "div", "blockquote":
+		c.block()
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		c.block()
+		if !closing {
+			level, _ := strconv.Atoi(name[1:])
+			c.write(strings.Repeat("#", level) + " ")
+		}
+	case "pre":
+		c.pre = !closing
+		if closing {
+			c.line()
+			c.write("```\n\n")
+			return
+		}
+
+		c.block()
+		c.write("```\n")
+	case "a":
+		if closing {
+			if len(c.links) == 0 {
+				return
+			}
+			href := c.links[len(c.links)-1]
+			c.links = c.links[:len(c.links)-1]
+			if href != "" {
+				c.write("](" + href + ")")
+			}
+			return
+		}
+
+		href := htmlAttributeValue(attributes, "href")
+		c.links = append(c.links, href)
+		if href != "" {
+			c.write("[")
+		}
+	case "img":
+		c.write("![" + htmlAttributeValue(attributes, "alt") + "](" + htmlAttributeValue(attributes, "src") + ")")
+	case "ul", "ol":
+		if closing {
+			if len(c.lists) > 0 {
+				c.lists = c.lists[:len(c.lists)-1]
+			}
+			if len(c.lists) == 0 {
+				c.block()
+			}
+			return
+		}
+
+		if len(c.lists) == 0 {
+			c.block()
+		}
+		c.lists = append(c.lists, &htmlList{ordered: name == "ol"})
+	case "li":
+		c.line()
+		if closing {
+			return
+		}
+
+		depth := len(c.lists)
+		if depth == 0 {
+			c.write("- ")
+			return
+		}
+
+		list := c.lists[depth-1]
+		list.items++
+		c.write(strings.Repeat("  ", depth-1))
+		if list.ordered {
+			c.write(strconv.Itoa(list.items) + ". ")
+		} else {
+			c.write("- ")
+		}
+	case "table":
+		if c.nested > 0 || (c.cell != nil && !closing) {
+			if closing {
+				c.nested--
+			} else {
+				c.nested++
+			}
+			c.write(" ")
+			return
+		}
+
+		if closing {
+			c.endCell()
+			c.writeTable()
+			c.table = nil
+			return
+		}
+
+		c.block()
+		c.table = [][]string{}
+	case "tr":
+		if c.nested > 0 {
+			c.write(" ")
+			return
+		}
+
+		c.endCell()
+		if !closing && c.table != nil {
+			c.table = append(c.table, []string{})
+		}
+	case "td", "th":
+		if c.nested > 0 {
+			c.write(" ")
+			return
+		}
+
+		c.endCell()
+		if !closing && len(c.table) > 0 {
+			c.cell = &strings.Builder{}
+		}
+	}
+}
+
+// endCell adds the open cell to the current table row
+func (c *htmlConverter) endCell() {
+	if c.cell == nil {
+		return
+	}
+
+	cell := strings.Join(strings.Fields(c.cell.String()), " ")
+	c.cell = nil
+	c.nested = 0
+
+	row := len(c.table) - 1
+	if row < 0 {
+		return
+	}
+	c.table[row] = append(c.table[row], cell)
+}
+
+// writeTable renders the table with its first row as the header
+func (c *htmlConverter) writeTable() {
+	columns := 0
+	for _, row := range c.table {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+
+	if columns == 0 {
+		return
+	}
+
+	for i, row := range c.table {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+
+		c.out.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			c.out.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+		}
+	}
+
+	c.out.WriteString("\n")
+}
+
+func htmlAttributeValue(attributes string, name string) string {
+	for _, m := range htmlAttribute.FindAllStringSubmatch(attributes, -1) {
+		if strings.EqualFold(m[1], name) {
+			return html.UnescapeString(m[2] + m[3] + m[4])
+		}
+	}
+
+	return ""
+}
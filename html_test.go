@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestHTMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "plain text",
+			in:   "no html <here>",
+			want: "no html <here>",
+		},
+		{
+			name: "paragraphs and emphasis",
+			in:   "<p>one <b>bold</b> and <em>em</em></p><p>two</p>",
+			want: "one **bold** and _em_\n\ntwo",
+		},
+		{
+			name: "links and images",
+			in:   `<p><a href="https://example.com">site</a> <img src="a.png" alt="a"></p>`,
+			want: "[site](https://example.com) ![a](a.png)",
+		},
+		{
+			name: "nested lists",
+			in:   "<ul><li>a<ol><li>b</li><li>c</li></ol></li><li>d</li></ul>",
+			want: "- a\n  1. b\n  2. c\n- d",
+		},
+		{
+			name: "table",
+			in:   "<table><tr><th>a</th><th>b</th></tr><tr><td>1</td><td>x | y</td></tr></table>",
+			want: "| a | b |\n| --- | --- |\n| 1 | x \\| y |",
+		},
+		{
+			name: "nested table",
+			in:   "<table><tr><td><table><tr><td>x</td></tr></table></td></tr></table>",
+			want: "| x |\n| --- |",
+		},
+		{
+			name: "nested table followed by cells",
+			in:   "<table><tr><td>a<table><tr><td>x</td><td>y</td></tr></table></td><td>b</td></tr><tr><td>c</td></tr></table>",
+			want: "| a x y | b |\n| --- | --- |\n| c |  |",
+		},
+		{
+			name: "rows outside a table",
+			in:   "<div><tr><td>x</td></tr></div>",
+			want: "x",
+		},
+		{
+			name: "pre",
+			in:   "<pre><code>a &lt; b</code></pre>",
+			want: "```\na < b\n```",
+		},
+		{
+			name: "code span",
+			in:   "wrap it in a `<div>` first",
+			want: "wrap it in a `<div>` first",
+		},
+		{
+			name: "fenced code",
+			in:   "before\n\n```\n<p>kept</p>\n```\n\nafter",
+			want: "before\n\n```\n<p>kept</p>\n```\n\nafter",
+		},
+		{
+			name: "html beside code",
+			in:   "<p>use `<br>` here</p>",
+			want: "use `<br>` here",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := htmlToMarkdown(tt.in)
+			if got != tt.want {
+				t.Errorf("htmlToMarkdown(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,533 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultLanguage = "en"
+)
+
+var (
+	// language is the bundle generated text is rendered in, selected once at startup with --lang
+	language = defaultLanguage
+
+	// messages holds the boilerplate text trello2md generates around card content, keyed by language and then
+	// message, messages are fmt format strings
+	messages = map[string]map[string]string{
+		"en": {
+			"completed":        "completed %s",
+			"completedBy":      "completed %s by %s",
+			"daysInList":       "%d days in list",
+			"points":           "%s pts",
+			"map":              "map",
+			"total":            "%s total: %s points",
+			"dueInNextDays":    "Due in the next %d days",
+			"due":              "Due %s",
+			"noDueDate":        "No due date",
+			"other":            "Other",
+			"vote":             "%d vote",
+			"votes":            "%d votes",
+			"unassigned":       "unassigned",
+			"movedTo":          "moved to %s",
+			"comment":          "1 comment",
+			"comments":         "%d comments",
+			"date":             "Date",
+			"remaining":        "Remaining",
+			"ideal":            "Ideal",
+			"burndown":         "%s burndown",
+			"remainingUnit":    "Remaining %s",
+			"cards":            "cards",
+			"checklist-items":  "checklist items",
+			"list":             "List",
+			"label":            "Label",
+			"cardAge":          "card age in days: %s",
+			"cardCount":        "Cards",
+			"lastActivity":     "Last activity",
+			"dueDate":          "Due",
+			"members":          "Members",
+			"labels":           "Labels",
+			"checklists":       "Checklists",
+			"attachments":      "Attachments",
+			"commentHistory":   "Comments",
+			"activity":         "Activity",
+			"powerUps":         "Power-Ups",
+			"description":      "Description",
+			"generated":        "Generated by %s on %s from %s",
+			"filteredBy":       "filtered with %s",
+			"truncated":        "truncated, the full export is %d bytes, over the %d byte limit",
+			"hygiene":          "Hygiene",
+			"missing":          "missing %s",
+			"needAttention":    "%d of %d cards need attention",
+			"fieldDue":         "due date",
+			"fieldMembers":     "members",
+			"fieldLabels":      "labels",
+			"fieldDesc":        "description",
+			"changesSince":     "Changes since %s",
+			"changesBetween":   "Changes between %s and %s",
+			"added":            "Added",
+			"removed":          "Removed",
+			"moved":            "Moved",
+			"changed":          "Changed",
+			"renamedFrom":      "renamed from %s",
+			"noChanges":        "No changes",
+			"name":             "Name",
+			"username":         "Username",
+			"role":             "Role",
+			"roleAdmin":        "admin",
+			"roleNormal":       "member",
+			"roleObserver":     "observer",
+			"roleDeactivated":  "%s, deactivated",
+			"openCards":        "Open",
+			"doneCards":        "Done",
+			"overWIPLimit":     "over the WIP limit of %d",
+			"overWIPLimits":    "Over WIP limits",
+			"dependsOn":        "Depends on",
+			"archived":         "archived",
+			"unavailable":      "unavailable",
+			"noAutomation":     "No power-up automation",
+			"customFields":     "Custom fields",
+			"field":            "Field",
+			"fieldType":        "Type",
+			"fieldOptions":     "Options",
+			"readMore":         "Read more",
+			"created":          "created %s",
+			"createdBy":        "created %s by %s",
+			"visibility":       "visible to: %s",
+			"enterprise":       "in enterprise %s",
+			"enterpriseOwned":  "owned by enterprise %s",
+			"trelloLogin":      "needs a trello login",
+			"author":           "Author",
+			"commentText":      "Comment",
+			"uploadedBy":       "uploaded by %s on %s",
+			"uploadedByOnly":   "uploaded by %s",
+			"uploaded":         "uploaded %s",
+			"deltaDone":        "+%d done",
+			"deltaNew":         "%d new",
+			"deltaNewComment":  "%d new comment",
+			"deltaNewComments": "%d new comments",
+			"deltaReopened":    "%d reopened",
+			"deltaRemoved":     "%d removed",
+			"deltasSince":      "%s since %s",
+			"deltasNone":       "no changes since %s",
+			"reopened":         "Reopened",
+			"reopenedMove":     "moved from %s to %s by %s on %s",
+			"fieldChanges":     "Changes",
+			"dueSet":           "due set to %s",
+			"dueMoved":         "due moved from %s to %s",
+			"dueRemoved":       "due date removed",
+			"labelAdded":       "label %s added",
+			"labelRemoved":     "label %s removed",
+			"memberAdded":      "%s added",
+			"memberRemoved":    "%s removed",
+			"renamed":          "renamed from %s to %s",
+			"movedFromTo":      "moved from %s to %s",
+			"flowFromTo":       "From \\ To",
+			"flowBackward":     "%d of %d moves went back to an earlier list",
+			"flowNone":         "No cards were moved between lists",
+			"indexTitle":       "Boards",
+			"indexProfiles":    "Profiles",
+			"indexAll":         "All boards",
+			"indexRefreshed":   "last refreshed %s",
+			"indexNotRendered": "not rendered yet",
+			"indexNewCards":    "%d new cards",
+			"indexActivity":    "last activity %s",
+			"indexCardCount":   "%d cards",
+			"releaseTrain":     "Release %s",
+			"releaseNoCards":   "No cards are marked %s",
+		},
+		"de": {
+			"completed":        "erledigt am %s",
+			"completedBy":      "erledigt am %s von %s",
+			"daysInList":       "%d Tage in der Liste",
+			"points":           "%s Pkt.",
+			"map":              "Karte",
+			"total":            "%s gesamt: %s Punkte",
+			"dueInNextDays":    "Fällig in den nächsten %d Tagen",
+			"due":              "Fällig am %s",
+			"noDueDate":        "Kein Fälligkeitsdatum",
+			"other":            "Sonstiges",
+			"vote":             "%d Stimme",
+			"votes":            "%d Stimmen",
+			"unassigned":       "nicht zugewiesen",
+			"movedTo":          "verschoben nach %s",
+			"comment":          "1 Kommentar",
+			"comments":         "%d Kommentare",
+			"date":             "Datum",
+			"remaining":        "Verbleibend",
+			"ideal":            "Ideal",
+			"burndown":         "%s Burndown",
+			"remainingUnit":    "Verbleibende %s",
+			"cards":            "Karten",
+			"checklist-items":  "Checklistenpunkte",
+			"list":             "Liste",
+			"label":            "Label",
+			"cardAge":          "Kartenalter in Tagen: %s",
+			"cardCount":        "Karten",
+			"lastActivity":     "Letzte Aktivität",
+			"dueDate":          "Fällig",
+			"members":          "Mitglieder",
+			"labels":           "Labels",
+			"checklists":       "Checklisten",
+			"attachments":      "Anhänge",
+			"commentHistory":   "Kommentare",
+			"activity":         "Aktivität",
+			"powerUps":         "Power-Ups",
+			"description":      "Beschreibung",
+			"generated":        "Erstellt von %s am %s aus %s",
+			"filteredBy":       "gefiltert mit %s",
+			"truncated":        "gekürzt, der vollständige Export hat %d Bytes und überschreitet die Grenze von %d Bytes",
+			"hygiene":          "Pflege",
+			"missing":          "fehlt: %s",
+			"needAttention":    "%d von %d Karten brauchen Aufmerksamkeit",
+			"fieldDue":         "Fälligkeitsdatum",
+			"fieldMembers":     "Mitglieder",
+			"fieldLabels":      "Labels",
+			"fieldDesc":        "Beschreibung",
+			"changesSince":     "Änderungen seit %s",
+			"changesBetween":   "Änderungen zwischen %s und %s",
+			"added":            "Neu",
+			"removed":          "Entfernt",
+			"moved":            "Verschoben",
+			"changed":          "Geändert",
+			"renamedFrom":      "umbenannt von %s",
+			"noChanges":        "Keine Änderungen",
+			"name":             "Name",
+			"username":         "Benutzername",
+			"role":             "Rolle",
+			"roleAdmin":        "Administrator",
+			"roleNormal":       "Mitglied",
+			"roleObserver":     "Beobachter",
+			"roleDeactivated":  "%s, deaktiviert",
+			"openCards":        "Offen",
+			"doneCards":        "Erledigt",
+			"overWIPLimit":     "über dem WIP-Limit von %d",
+			"overWIPLimits":    "Über WIP-Limits",
+			"dependsOn":        "Hängt ab von",
+			"archived":         "archiviert",
+			"unavailable":      "nicht verfügbar",
+			"noAutomation":     "Keine Power-Up-Automatisierung",
+			"customFields":     "Benutzerdefinierte Felder",
+			"field":            "Feld",
+			"fieldType":        "Typ",
+			"fieldOptions":     "Optionen",
+			"readMore":         "Weiterlesen",
+			"created":          "erstellt am %s",
+			"createdBy":        "erstellt am %s von %s",
+			"visibility":       "sichtbar für: %s",
+			"enterprise":       "im Enterprise %s",
+			"enterpriseOwned":  "im Besitz von Enterprise %s",
+			"trelloLogin":      "erfordert eine Trello-Anmeldung",
+			"author":           "Autor",
+			"commentText":      "Kommentar",
+			"uploadedBy":       "hochgeladen von %s am %s",
+			"uploadedByOnly":   "hochgeladen von %s",
+			"uploaded":         "hochgeladen am %s",
+			"deltaDone":        "+%d erledigt",
+			"deltaNew":         "%d neu",
+			"deltaNewComment":  "%d neuer Kommentar",
+			"deltaNewComments": "%d neue Kommentare",
+			"deltaReopened":    "%d wieder geöffnet",
+			"deltaRemoved":     "%d entfernt",
+			"deltasSince":      "%s seit %s",
+			"deltasNone":       "keine Änderungen seit %s",
+			"reopened":         "Wieder geöffnet",
+			"reopenedMove":     "von %s nach %s verschoben von %s am %s",
+			"fieldChanges":     "Änderungen",
+			"dueSet":           "Fälligkeit auf %s gesetzt",
+			"dueMoved":         "Fälligkeit von %s auf %s verschoben",
+			"dueRemoved":       "Fälligkeit entfernt",
+			"labelAdded":       "Label %s hinzugefügt",
+			"labelRemoved":     "Label %s entfernt",
+			"memberAdded":      "%s hinzugefügt",
+			"memberRemoved":    "%s entfernt",
+			"renamed":          "von %s in %s umbenannt",
+			"movedFromTo":      "von %s nach %s verschoben",
+			"flowFromTo":       "Von \\ Nach",
+			"flowBackward":     "%d von %d Verschiebungen gingen zurück in eine frühere Liste",
+			"flowNone":         "Es wurden keine Karten zwischen Listen verschoben",
+			"indexTitle":       "Boards",
+			"indexProfiles":    "Profile",
+			"indexAll":         "Alle Boards",
+			"indexRefreshed":   "zuletzt aktualisiert %s",
+			"indexNotRendered": "noch nicht erstellt",
+			"indexNewCards":    "%d neue Karten",
+			"indexActivity":    "letzte Aktivität %s",
+			"indexCardCount":   "%d Karten",
+			"releaseTrain":     "Release %s",
+			"releaseNoCards":   "Keine Karten sind mit %s markiert",
+		},
+		"fr": {
+			"completed":        "terminé le %s",
+			"completedBy":      "terminé le %s par %s",
+			"daysInList":       "%d jours dans la liste",
+			"points":           "%s pts",
+			"map":              "carte",
+			"total":            "Total %s : %s points",
+			"dueInNextDays":    "Échéances des %d prochains jours",
+			"due":              "Échéance le %s",
+			"noDueDate":        "Sans échéance",
+			"other":            "Autres",
+			"vote":             "%d vote",
+			"votes":            "%d votes",
+			"unassigned":       "non assigné",
+			"movedTo":          "déplacé vers %s",
+			"comment":          "1 commentaire",
+			"comments":         "%d commentaires",
+			"date":             "Date",
+			"remaining":        "Restant",
+			"ideal":            "Idéal",
+			"burndown":         "Burndown %s",
+			"remainingUnit":    "Reste (%s)",
+			"cards":            "cartes",
+			"checklist-items":  "éléments de checklist",
+			"list":             "Liste",
+			"label":            "Étiquette",
+			"cardAge":          "âge des cartes en jours : %s",
+			"cardCount":        "Cartes",
+			"lastActivity":     "Dernière activité",
+			"dueDate":          "Échéance",
+			"members":          "Membres",
+			"labels":           "Étiquettes",
+			"checklists":       "Checklists",
+			"attachments":      "Pièces jointes",
+			"commentHistory":   "Commentaires",
+			"activity":         "Activité",
+			"powerUps":         "Power-Ups",
+			"description":      "Description",
+			"generated":        "Généré par %s le %s à partir de %s",
+			"filteredBy":       "filtré avec %s",
+			"truncated":        "tronqué, l'export complet fait %d octets, au-delà de la limite de %d octets",
+			"hygiene":          "Hygiène",
+			"missing":          "il manque %s",
+			"needAttention":    "%d cartes sur %d demandent de l'attention",
+			"fieldDue":         "échéance",
+			"fieldMembers":     "membres",
+			"fieldLabels":      "étiquettes",
+			"fieldDesc":        "description",
+			"changesSince":     "Modifications depuis %s",
+			"changesBetween":   "Modifications entre %s et %s",
+			"added":            "Ajoutées",
+			"removed":          "Supprimées",
+			"moved":            "Déplacées",
+			"changed":          "Modifiées",
+			"renamedFrom":      "anciennement %s",
+			"noChanges":        "Aucune modification",
+			"name":             "Nom",
+			"username":         "Nom d'utilisateur",
+			"role":             "Rôle",
+			"roleAdmin":        "administrateur",
+			"roleNormal":       "membre",
+			"roleObserver":     "observateur",
+			"roleDeactivated":  "%s, désactivé",
+			"openCards":        "Ouvertes",
+			"doneCards":        "Terminées",
+			"overWIPLimit":     "au-delà de la limite WIP de %d",
+			"overWIPLimits":    "Au-delà des limites WIP",
+			"dependsOn":        "Dépend de",
+			"archived":         "archivée",
+			"unavailable":      "indisponible",
+			"noAutomation":     "Aucune automatisation de Power-Up",
+			"customFields":     "Champs personnalisés",
+			"field":            "Champ",
+			"fieldType":        "Type",
+			"fieldOptions":     "Options",
+			"readMore":         "Lire la suite",
+			"created":          "créé le %s",
+			"createdBy":        "créé le %s par %s",
+			"visibility":       "visible par : %s",
+			"enterprise":       "dans l'entreprise %s",
+			"enterpriseOwned":  "détenu par l'entreprise %s",
+			"trelloLogin":      "connexion à trello requise",
+			"author":           "Auteur",
+			"commentText":      "Commentaire",
+			"uploadedBy":       "ajouté par %s le %s",
+			"uploadedByOnly":   "ajouté par %s",
+			"uploaded":         "ajouté le %s",
+			"deltaDone":        "+%d terminées",
+			"deltaNew":         "%d nouvelles",
+			"deltaNewComment":  "%d nouveau commentaire",
+			"deltaNewComments": "%d nouveaux commentaires",
+			"deltaReopened":    "%d rouvertes",
+			"deltaRemoved":     "%d retirées",
+			"deltasSince":      "%s depuis le %s",
+			"deltasNone":       "aucune modification depuis le %s",
+			"reopened":         "Rouvertes",
+			"reopenedMove":     "déplacée de %s vers %s par %s le %s",
+			"fieldChanges":     "Modifications",
+			"dueSet":           "échéance fixée au %s",
+			"dueMoved":         "échéance déplacée du %s au %s",
+			"dueRemoved":       "échéance supprimée",
+			"labelAdded":       "étiquette %s ajoutée",
+			"labelRemoved":     "étiquette %s retirée",
+			"memberAdded":      "%s ajouté",
+			"memberRemoved":    "%s retiré",
+			"renamed":          "renommée de %s en %s",
+			"movedFromTo":      "déplacée de %s vers %s",
+			"flowFromTo":       "De \\ Vers",
+			"flowBackward":     "%d déplacements sur %d sont revenus vers une liste précédente",
+			"flowNone":         "Aucune carte n'a été déplacée entre les listes",
+			"indexTitle":       "Tableaux",
+			"indexProfiles":    "Profils",
+			"indexAll":         "Tous les tableaux",
+			"indexRefreshed":   "actualisé le %s",
+			"indexNotRendered": "pas encore généré",
+			"indexNewCards":    "%d nouvelles cartes",
+			"indexActivity":    "dernière activité %s",
+			"indexCardCount":   "%d cartes",
+			"releaseTrain":     "Version %s",
+			"releaseNoCards":   "Aucune carte n'est marquée %s",
+		},
+		"es": {
+			"completed":        "completada el %s",
+			"completedBy":      "completada el %s por %s",
+			"daysInList":       "%d días en la lista",
+			"points":           "%s ptos",
+			"map":              "mapa",
+			"total":            "Total de %s: %s puntos",
+			"dueInNextDays":    "Vencen en los próximos %d días",
+			"due":              "Vence el %s",
+			"noDueDate":        "Sin fecha de vencimiento",
+			"other":            "Otros",
+			"vote":             "%d voto",
+			"votes":            "%d votos",
+			"unassigned":       "sin asignar",
+			"movedTo":          "movida a %s",
+			"comment":          "1 comentario",
+			"comments":         "%d comentarios",
+			"date":             "Fecha",
+			"remaining":        "Pendiente",
+			"ideal":            "Ideal",
+			"burndown":         "Burndown de %s",
+			"remainingUnit":    "%s pendientes",
+			"cards":            "tarjetas",
+			"checklist-items":  "elementos de checklist",
+			"list":             "Lista",
+			"label":            "Etiqueta",
+			"cardAge":          "antigüedad de las tarjetas en días: %s",
+			"cardCount":        "Tarjetas",
+			"lastActivity":     "Última actividad",
+			"dueDate":          "Vencimiento",
+			"members":          "Miembros",
+			"labels":           "Etiquetas",
+			"checklists":       "Checklists",
+			"attachments":      "Adjuntos",
+			"commentHistory":   "Comentarios",
+			"activity":         "Actividad",
+			"powerUps":         "Power-Ups",
+			"description":      "Descripción",
+			"generated":        "Generado por %s el %s a partir de %s",
+			"filteredBy":       "filtrado con %s",
+			"truncated":        "truncado, la exportación completa ocupa %d bytes, por encima del límite de %d bytes",
+			"hygiene":          "Higiene",
+			"missing":          "falta %s",
+			"needAttention":    "%d de %d tarjetas necesitan atención",
+			"fieldDue":         "fecha de vencimiento",
+			"fieldMembers":     "miembros",
+			"fieldLabels":      "etiquetas",
+			"fieldDesc":        "descripción",
+			"changesSince":     "Cambios desde %s",
+			"changesBetween":   "Cambios entre %s y %s",
+			"added":            "Añadidas",
+			"removed":          "Eliminadas",
+			"moved":            "Movidas",
+			"changed":          "Modificadas",
+			"renamedFrom":      "antes %s",
+			"noChanges":        "Sin cambios",
+			"name":             "Nombre",
+			"username":         "Usuario",
+			"role":             "Rol",
+			"roleAdmin":        "administrador",
+			"roleNormal":       "miembro",
+			"roleObserver":     "observador",
+			"roleDeactivated":  "%s, desactivado",
+			"openCards":        "Abiertas",
+			"doneCards":        "Terminadas",
+			"overWIPLimit":     "por encima del límite WIP de %d",
+			"overWIPLimits":    "Por encima de los límites WIP",
+			"dependsOn":        "Depende de",
+			"archived":         "archivada",
+			"unavailable":      "no disponible",
+			"noAutomation":     "Sin automatización de Power-Ups",
+			"customFields":     "Campos personalizados",
+			"field":            "Campo",
+			"fieldType":        "Tipo",
+			"fieldOptions":     "Opciones",
+			"readMore":         "Leer más",
+			"created":          "creada el %s",
+			"createdBy":        "creada el %s por %s",
+			"visibility":       "visible para: %s",
+			"enterprise":       "en la empresa %s",
+			"enterpriseOwned":  "propiedad de la empresa %s",
+			"trelloLogin":      "requiere iniciar sesión en trello",
+			"author":           "Autor",
+			"commentText":      "Comentario",
+			"uploadedBy":       "subido por %s el %s",
+			"uploadedByOnly":   "subido por %s",
+			"uploaded":         "subido el %s",
+			"deltaDone":        "+%d terminadas",
+			"deltaNew":         "%d nuevas",
+			"deltaNewComment":  "%d comentario nuevo",
+			"deltaNewComments": "%d comentarios nuevos",
+			"deltaReopened":    "%d reabiertas",
+			"deltaRemoved":     "%d retiradas",
+			"deltasSince":      "%s desde el %s",
+			"deltasNone":       "sin cambios desde el %s",
+			"reopened":         "Reabiertas",
+			"reopenedMove":     "movida de %s a %s por %s el %s",
+			"fieldChanges":     "Cambios",
+			"dueSet":           "vencimiento fijado el %s",
+			"dueMoved":         "vencimiento movido del %s al %s",
+			"dueRemoved":       "vencimiento eliminado",
+			"labelAdded":       "etiqueta %s añadida",
+			"labelRemoved":     "etiqueta %s quitada",
+			"memberAdded":      "%s añadido",
+			"memberRemoved":    "%s quitado",
+			"renamed":          "renombrada de %s a %s",
+			"movedFromTo":      "movida de %s a %s",
+			"flowFromTo":       "De \\ A",
+			"flowBackward":     "%d de %d movimientos volvieron a una lista anterior",
+			"flowNone":         "No se movió ninguna tarjeta entre listas",
+			"indexTitle":       "Tableros",
+			"indexProfiles":    "Perfiles",
+			"indexAll":         "Todos los tableros",
+			"indexRefreshed":   "actualizado por última vez %s",
+			"indexNotRendered": "aún no generado",
+			"indexNewCards":    "%d tarjetas nuevas",
+			"indexActivity":    "última actividad %s",
+			"indexCardCount":   "%d tarjetas",
+			"releaseTrain":     "Versión %s",
+			"releaseNoCards":   "Ninguna tarjeta está marcada con %s",
+		},
+	}
+)
+
+// setLanguage selects the bundle generated text is rendered in
+func setLanguage(lang string) error {
+	if _, ok := messages[lang]; !ok {
+		var languages []string
+		for lang := range messages {
+			languages = append(languages, lang)
+		}
+		sort.Strings(languages)
+
+		return errors.Errorf("unsupported language %q, expected one of %s", lang, strings.Join(languages, ", "))
+	}
+
+	language = lang
+	return nil
+}
+
+// tr renders the message in the selected language, falling back to english for messages a bundle is missing
+func tr(key string, args ...interface{}) string {
+	message, ok := messages[language][key]
+	if !ok {
+		message = messages[defaultLanguage][key]
+	}
+
+	return fmt.Sprintf(message, args...)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	defaultTemplatePath = "card.tmpl"
+
+	starterConfig = `# trello2md config, flags given on the command line always take precedence over this file
+
+# named trello credentials, values may reference environment variables e.g. ${CLIENT_A_TOKEN}
+# boards without credentials use the global --key and --token
+credentials:
+#  client-a:
+#    key: ${CLIENT_A_KEY}
+#    token: ${CLIENT_A_TOKEN}
+
+# readable names for board ids, accepted anywhere a board id is e.g. --board-id platform
+aliases:
+#  platform: 5f2ab0c1d2e3f4a5b6c7d8e9
+
+# per board settings keyed by board id or alias, use the search-boards command to look ids up
+boards:
+#  5f2ab0c1d2e3f4a5b6c7d8e9:
+#    credentials: client-a
+#    # boards with an order always lead the document, lowest first
+#    order: 1
+
+# profiles are named sets of flag values, select one with --profile or run one with trello2md run <profile>
+# command names the command a profile runs, export-boards when it's left out
+profiles:
+#  weekly:
+#    board-id:
+#      - platform
+#    list-filter: Done
+#    show-labels-and-members: true
+#    show-comments: true
+#    template: ` + defaultTemplatePath + `
+#    output: weekly.md
+#  release-notes:
+#    command: release-notes
+#    board-id:
+#      - platform
+#    output: RELEASE_NOTES.md
+
+# access rules protect the serve command with basic auth or bearer tokens, each limited to the boards listed
+# or every board with *, the server is open to anyone when there are none
+access:
+#  - user: alice
+#    password: ${ALICE_PASSWORD}
+#    boards:
+#      - platform
+#  - token: ${DASHBOARD_TOKEN}
+#    boards:
+#      - "*"
+
+# sections route cards into headings by label with --sections, whichever list they're in, a card goes into the
+# first section it has a label of and cards without any are listed under Other
+sections:
+#  - name: New features
+#    labels:
+#      - feature
+#  - name: Fixes
+#    labels:
+#      - bug
+`
+)
+
+func initScaffolding(c *cli.Context) error {
+	dir := c.String("dir")
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{name: defaultConfigPath, content: starterConfig},
+		{name: defaultTemplatePath, content: defaultCardTemplate},
+	}
+
+	for _, file := range files {
+		path := filepath.Join(dir, file.name)
+
+		_, err := os.Stat(path)
+		if err == nil && !c.Bool("force") {
+			return errors.Errorf("%s already exists, use --force to overwrite it", path)
+		}
+
+		err = ioutil.WriteFile(path, []byte(file.content), 0644)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("wrote %s\n", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	jobSucceeded = "succeeded"
+	jobFailed    = "failed"
+
+	// exit statuses of a job, a failed run may succeed when retried while a misconfigured job won't
+	jobExitFailed        = 1
+	jobExitMisconfigured = 2
+)
+
+var (
+	jobArgs = []cli.Flag{
+		cli.StringFlag{
+			Name:   "job-command",
+			Usage:  "the command the job runs e.g. export-boards, run or publish github-release, its flags are read from their TRELLO2MD_ environment variables",
+			EnvVar: "TRELLO2MD_JOB_COMMAND",
+			Value:  defaultProfileCommand,
+		},
+	}
+
+	// jobRuns collects the report of every export while a job runs, nil otherwise
+	jobRuns *runReports
+)
+
+// runReports collects the reports of the exports of a job, profiles run by the job are exported one after another
+// but the reports are guarded in case an export runs alongside another
+type runReports struct {
+	mu      sync.Mutex
+	reports []*runReport
+}
+
+func (r *runReports) add(report *runReport) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reports = append(r.reports, report)
+}
+
+// jobReport is the json a job prints to stdout once it has run
+type jobReport struct {
+	Command  string       `json:"command"`
+	Status   string       `json:"status"`
+	Error    string       `json:"error,omitempty"`
+	ExitCode int          `json:"exit_code"`
+	Duration float64      `json:"duration_seconds"`
+	Runs     []*runReport `json:"runs"`
+}
+
+// runJob runs a command configured entirely by environment variables once, for kubernetes cron jobs and other
+// schedulers. everything the command prints goes to stderr along with the logs so stdout only holds the json report,
+// and the exit status tells a failed run from a misconfigured job
+func runJob(c *cli.Context) error {
+	start := time.Now()
+	report := jobReport{Command: c.String("job-command"), Runs: []*runReport{}}
+
+	err := checkJobCommand(c)
+	if err != nil {
+		return finishJob(report, start, err, jobExitMisconfigured)
+	}
+
+	jobRuns = &runReports{}
+	defer func() { jobRuns = nil }()
+
+	stdout, writer := os.Stdout, c.App.Writer
+	os.Stdout, c.App.Writer = os.Stderr, os.Stderr
+	err = c.App.Run(append([]string{c.App.Name}, strings.Fields(report.Command)...))
+	os.Stdout, c.App.Writer = stdout, writer
+
+	report.Runs = append(report.Runs, jobRuns.reports...)
+
+	return finishJob(report, start, err, jobExitFailed)
+}
+
+func checkJobCommand(c *cli.Context) error {
+	if c.NArg() > 0 {
+		return errors.New("job takes no arguments, configure the command with TRELLO2MD_ environment variables")
+	}
+
+	command := strings.Fields(c.String("job-command"))
+	if len(command) > 0 && command[0] == c.Command.Name {
+		return errors.New("a job can't run another job")
+	}
+
+	_, err := findCommand(c.App, command)
+
+	return err
+}
+
+// finishJob prints the report of the job and exits with the given status when it failed
+func finishJob(report jobReport, start time.Time, err error, exitCode int) error {
+	report.Status = jobSucceeded
+	if err != nil {
+		log.Printf("job failed: %v", err)
+		report.Status = jobFailed
+		report.Error = err.Error()
+		report.ExitCode = exitCode
+	}
+	report.Duration = time.Since(start).Seconds()
+
+	data, marshalErr := json.Marshal(report)
+	if marshalErr != nil {
+		return marshalErr
+	}
+	fmt.Println(string(data))
+
+	if err != nil {
+		return cli.NewExitError("", exitCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// labelMap renames and hides labels so reports can use their own taxonomy whatever the boards' labels are called,
+// labels renamed to the same name are merged into one
+type labelMap struct {
+	// Rename maps board label names to the names they're shown as, e.g. bug and defect to Bug fixes
+	Rename map[string]string `yaml:"rename"`
+	// Hide names the labels left out of the export entirely
+	Hide []string `yaml:"hide"`
+}
+
+func loadLabelMap(path string) (*labelMap, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read label map")
+	}
+
+	var labels labelMap
+	err = yaml.UnmarshalStrict(data, &labels)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse label map %s", path)
+	}
+
+	for from, to := range labels.Rename {
+		if to == "" {
+			return nil, errors.Errorf("label %s is renamed to nothing, hide it instead", from)
+		}
+	}
+
+	return &labels, nil
+}
+
+// apply renames the labels of the card and drops the hidden ones, a label merged with one before it keeps the
+// earlier label's color
+func (m *labelMap) apply(card *Card) {
+	var labels []Label
+	seen := map[string]bool{}
+	for _, label := range card.Labels {
+		if containsString(m.Hide, label.Name) {
+			continue
+		}
+
+		if name, ok := m.Rename[label.Name]; ok {
+			label.Name = name
+		}
+
+		// unnamed labels only have their color and are never merged
+		if label.Name != "" && seen[label.Name] {
+			continue
+		}
+		seen[label.Name] = true
+
+		labels = append(labels, label)
+	}
+
+	card.Labels = labels
+}
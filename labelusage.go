@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var (
+	statsLabelsArgs = []cli.Flag{
+		cli.StringSliceFlag{
+			Name:   "board-id",
+			Usage:  "the ids or config aliases of the boards to report on",
+			EnvVar: "BOARD_ID",
+		},
+		cli.StringSliceFlag{
+			Name:   "done-list",
+			Usage:  "the names of the lists holding finished cards, cards anywhere else are open, defaults to Done",
+			EnvVar: "DONE_LISTS",
+		},
+		cli.StringFlag{
+			Name:   "format",
+			Usage:  "the format to write the report in, markdown or csv",
+			EnvVar: "STATS_FORMAT",
+			Value:  statsFormatMarkdown,
+		},
+	}
+)
+
+// labelUsage counts the open and done cards carrying a label
+type labelUsage struct {
+	name string
+	open int
+	done int
+}
+
+// statsLabels reports how many open and done cards carry each of a board's labels, labels no card carries are
+// listed too so they can be cleaned up
+func statsLabels(c *cli.Context) error {
+	format := c.String("format")
+	if format != statsFormatMarkdown && format != statsFormatCSV {
+		return errors.Errorf("unsupported format %q, expected markdown or csv", format)
+	}
+
+	doneLists := c.StringSlice("done-list")
+	if len(doneLists) == 0 {
+		doneLists = []string{"Done"}
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	clients := newBoardClients(c, cfg)
+	boards, err := getBoards(clients, c.StringSlice("board-id"))
+	if err != nil {
+		return err
+	}
+
+	var rows *csv.Writer
+	if format == statsFormatCSV {
+		rows = csv.NewWriter(os.Stdout)
+		err = rows.Write([]string{"board", "label", "open", "done"})
+		if err != nil {
+			return err
+		}
+	} else {
+		printDate(os.Stdout, time.Now())
+	}
+
+	for _, board := range *boards {
+		client, err := clients.forBoard(board.Id)
+		if err != nil {
+			return err
+		}
+
+		usage, err := labelUsages(client, board, doneLists)
+		if err != nil {
+			return err
+		}
+
+		if format == statsFormatCSV {
+			for _, label := range usage {
+				err = rows.Write([]string{board.Name, label.name, strconv.Itoa(label.open), strconv.Itoa(label.done)})
+				if err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		printBoard(os.Stdout, newBoard(board))
+		printLabelUsage(os.Stdout, usage)
+	}
+
+	if rows != nil {
+		rows.Flush()
+		return rows.Error()
+	}
+
+	return nil
+}
+
+// labelUsages counts the open cards of the board carrying each of its labels, cards in the done lists are counted
+// as done. labels are sorted most used first
+func labelUsages(client *trello.Client, board trello.Board, doneLists []string) ([]labelUsage, error) {
+	var labels []boardLabel
+	err := getJSON(client, "/boards/"+board.Id+"/labels", &labels)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to list the labels of %s", board.Name)
+	}
+
+	lists, err := getLists(&board)
+	if err != nil {
+		return nil, err
+	}
+
+	done := map[string]bool{}
+	for _, list := range lists {
+		if containsString(doneLists, list.Name) {
+			done[list.Id] = true
+		}
+	}
+
+	cards, err := board.Cards()
+	if err != nil {
+		return nil, err
+	}
+
+	var usage []labelUsage
+	index := map[string]int{}
+	for _, label := range labels {
+		name := label.Name
+		if name == "" {
+			name = label.Color
+		}
+
+		index[label.Color+"/"+label.Name] = len(usage)
+		usage = append(usage, labelUsage{name: name})
+	}
+
+	for _, card := range cards {
+		for _, label := range card.Labels {
+			i, ok := index[label.Color+"/"+label.Name]
+			if !ok {
+				continue
+			}
+
+			if done[card.IdList] {
+				usage[i].done++
+			} else {
+				usage[i].open++
+			}
+		}
+	}
+
+	sort.SliceStable(usage, func(i, j int) bool {
+		return usage[i].open+usage[i].done > usage[j].open+usage[j].done
+	})
+
+	return usage, nil
+}
+
+func printLabelUsage(w io.Writer, usage []labelUsage) {
+	fmt.Fprintf(w, "| %s | %s | %s |\n", tr("label"), tr("openCards"), tr("doneCards"))
+	fmt.Fprintln(w, "| --- | --- | --- |")
+	for _, label := range usage {
+		fmt.Fprintf(w, "| %s | %d | %d |\n", tableCell(label.name), label.open, label.done)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	linkStyleMarkdown = "markdown"
+	linkStyleWikilink = "wikilink"
+	linkStyleFootnote = "footnote"
+
+	urlStyleFull  = "full"
+	urlStyleShort = "short"
+	urlStyleNone  = "none"
+)
+
+var (
+	// trelloCardLink matches markdown links to trello cards, including the smart card links trello writes
+	trelloCardLink = regexp.MustCompile(`\[[^\]]*\]\(https://trello\.com/c/(\w+)[^)]*\)`)
+	// trelloCardUrl matches bare trello card urls
+	trelloCardUrl = regexp.MustCompile(`https://trello\.com/c/(\w+)(?:/[^\s)\]]*)?`)
+	// inlineLink matches inline markdown links and images along with an optional title
+	inlineLink = regexp.MustCompile(`(!?\[[^\]]*\])\((\S+?)(\s+"[^"]*")?\)`)
+)
+
+func validateLinkStyle(style string, split string) error {
+	switch style {
+	case linkStyleMarkdown:
+		return nil
+	case linkStyleWikilink:
+		if split != splitCard {
+			return errors.New("--link-style wikilink requires --split-by card")
+		}
+
+		return nil
+	case linkStyleFootnote:
+		if split == splitMonth || split == splitQuarter {
+			return errors.New("--link-style footnote can't be combined with --split-by month or quarter")
+		}
+
+		return nil
+	default:
+		return errors.Errorf("unsupported link style %q, expected one of markdown, wikilink or footnote", style)
+	}
+}
+
+func validateUrlStyle(style string) error {
+	switch style {
+	case urlStyleFull, urlStyleShort, urlStyleNone:
+		return nil
+	default:
+		return errors.Errorf("unsupported url style %q, expected one of full, short or none", style)
+	}
+}
+
+// applyUrlStyle points the card's links at its short url or removes them for readers who can't open the board
+func applyUrlStyle(card *Card, style string) {
+	switch style {
+	case urlStyleShort:
+		if card.ShortUrl != "" {
+			card.Url = card.ShortUrl
+		}
+	case urlStyleNone:
+		card.Url = ""
+		card.ShortUrl = ""
+	}
+}
+
+// wikiLinker rewrites links to exported cards as [[Card Title]] wiki links, so the per card files of a vault link to
+// each other rather than back to trello
+type wikiLinker struct {
+	// names maps the short links of the exported cards to their names
+	names map[string]string
+}
+
+func newWikiLinker(exports []*boardExport) *wikiLinker {
+	linker := &wikiLinker{names: map[string]string{}}
+	for _, boardExport := range exports {
+		for _, listExport := range boardExport.lists {
+			for _, view := range listExport.cards {
+				linker.names[view.Card.ShortLink] = view.Card.Name
+			}
+		}
+	}
+
+	return linker
+}
+
+// apply rewrites the card links in the view's description, checklists and comments, and renders members as links
+func (l *wikiLinker) apply(view *cardView) {
+	view.WikiLinks = true
+	view.Card.Desc = l.link(view.Card.Desc)
+
+	for i := range view.Checklists {
+		for j := range view.Checklists[i].CheckItems {
+			view.Checklists[i].CheckItems[j].Name = l.link(view.Checklists[i].CheckItems[j].Name)
+		}
+	}
+
+	for i := range view.Comments {
+		view.Comments[i].Data.Text = l.link(view.Comments[i].Data.Text)
+	}
+}
+
+// link rewrites links to exported cards, links to cards outside the export are left pointing at trello
+func (l *wikiLinker) link(text string) string {
+	replace := func(pattern *regexp.Regexp) func(string) string {
+		return func(match string) string {
+			name, ok := l.names[pattern.FindStringSubmatch(match)[1]]
+			if !ok {
+				return match
+			}
+
+			return "[[" + name + "]]"
+		}
+	}
+
+	text = trelloCardLink.ReplaceAllStringFunc(text, replace(trelloCardLink))
+
+	return trelloCardUrl.ReplaceAllStringFunc(text, replace(trelloCardUrl))
+}
+
+// footnoter rewrites inline links as numbered reference links, a url keeps its number throughout a document so
+// sections sharing a url don't define it differently
+type footnoter struct {
+	numbers map[string]int
+}
+
+func newFootnoter() *footnoter {
+	return &footnoter{numbers: map[string]int{}}
+}
+
+// section rewrites the links of a section and lists the urls it refers to at its end, fenced code is left as is
+func (f *footnoter) section(markdown []byte) []byte {
+	var references []string
+	used := map[int]bool{}
+	reference := func(match string) string {
+		groups := inlineLink.FindStringSubmatch(match)
+		target := groups[2] + groups[3]
+		n, ok := f.numbers[target]
+		if !ok {
+			n = len(f.numbers) + 1
+			f.numbers[target] = n
+		}
+
+		if !used[n] {
+			used[n] = true
+			references = append(references, fmt.Sprintf("[%d]: %s", n, target))
+		}
+
+		return fmt.Sprintf("%s[%d]", groups[1], n)
+	}
+
+	lines := strings.Split(string(markdown), "\n")
+	fenced := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+			continue
+		}
+
+		if !fenced {
+			lines[i] = inlineLink.ReplaceAllStringFunc(line, reference)
+		}
+	}
+
+	if len(references) == 0 {
+		return markdown
+	}
+
+	text := strings.TrimRight(strings.Join(lines, "\n"), "\n")
+
+	return []byte(text + "\n\n" + strings.Join(references, "\n") + "\n\n")
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	lintRuleDue         = "due"
+	lintRuleMembers     = "members"
+	lintRuleLabels      = "labels"
+	lintRuleDescription = "description"
+)
+
+var (
+	// lintRules are checked in this order, each names the message describing what a card is missing
+	lintRules = []struct {
+		name    string
+		message string
+		missing func(card *Card) bool
+	}{
+		{lintRuleDue, "fieldDue", func(card *Card) bool { return card.Due == "" }},
+		{lintRuleMembers, "fieldMembers", func(card *Card) bool { return len(card.IdMembers) == 0 }},
+		{lintRuleLabels, "fieldLabels", func(card *Card) bool { return len(card.Labels) == 0 }},
+		{lintRuleDescription, "fieldDesc", func(card *Card) bool { return strings.TrimSpace(card.Desc) == "" }},
+	}
+
+	lintArgs = []cli.Flag{
+		cli.StringSliceFlag{
+			Name:   "rule",
+			Usage:  "a rule cards are checked against, one of due, members, labels or description, repeatable, every rule is checked when none are given",
+			EnvVar: "TRELLO2MD_LINT_RULE",
+		},
+	}
+)
+
+// lint reports the exported cards missing a due date, members, labels or a description as a hygiene section
+func lint(c *cli.Context) error {
+	rules := c.StringSlice("rule")
+	for _, rule := range rules {
+		if !isLintRule(rule) {
+			return errors.Errorf("unsupported lint rule %q, expected one of due, members, labels or description", rule)
+		}
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	err = applyProfile(c, cfg)
+	if err != nil {
+		return err
+	}
+
+	exports, err := fetchExports(c, cfg, fetchOptions{
+		ListFilters:      listFilters(c),
+		AllLists:         c.Bool("all-lists"),
+		Sort:             c.String("sort"),
+		IncludeTemplates: c.Bool("include-templates"),
+	})
+	if err != nil {
+		return err
+	}
+
+	var report bytes.Buffer
+	printDate(&report, time.Now())
+	fmt.Fprintf(&report, "### %s\n", tr("hygiene"))
+	for _, export := range exports {
+		printLintBoard(&report, export, rules)
+	}
+
+	if c.String("output") != "" {
+		return writeFileIfChanged(c.String("output"), report.Bytes())
+	}
+
+	_, err = os.Stdout.Write(report.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "unable to write lint report")
+	}
+
+	return nil
+}
+
+func isLintRule(name string) bool {
+	for _, rule := range lintRules {
+		if rule.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// printLintBoard lists the cards of the board breaking any of the rules along with what each is missing, every
+// rule is checked when rules is empty
+func printLintBoard(w io.Writer, export *boardExport, rules []string) {
+	fmt.Fprintf(w, "#### %s\n", export.board.Name)
+
+	total, failing := 0, 0
+	for _, list := range export.lists {
+		for _, view := range list.cards {
+			total++
+
+			var missing []string
+			for _, rule := range lintRules {
+				if (len(rules) == 0 || containsString(rules, rule.name)) && rule.missing(&view.Card) {
+					missing = append(missing, tr(rule.message))
+				}
+			}
+
+			if len(missing) == 0 {
+				continue
+			}
+			failing++
+
+			name := view.Card.Name
+			if view.Card.Url != "" {
+				name = fmt.Sprintf("[%s](%s)", view.Card.Name, view.Card.Url)
+			}
+			fmt.Fprintf(w, "- %s - %s\n", name, tr("missing", strings.Join(missing, ", ")))
+		}
+	}
+
+	fmt.Fprintf(w, "\n_%s_\n\n", tr("needAttention", failing, total))
+}
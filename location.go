@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+)
+
+// cardLocation is the location the map power-up stored on a card
+type cardLocation struct {
+	Name        string `json:"locationName"`
+	Address     string `json:"address"`
+	Coordinates *struct {
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+	} `json:"coordinates"`
+}
+
+// MapUrl links to the location on openstreetmap, empty when the card has no coordinates
+func (l cardLocation) MapUrl() string {
+	if l.Coordinates == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("https://www.openstreetmap.org/?mlat=%[1]f&mlon=%[2]f#map=16/%[1]f/%[2]f", l.Coordinates.Latitude, l.Coordinates.Longitude)
+}
+
+// getCardLocation returns the card's location, nil when the card doesn't have one
+func getCardLocation(client *trello.Client, card *trello.Card) (*cardLocation, error) {
+	body, err := client.Get("/cards/" + card.Id + "?fields=locationName,address,coordinates")
+	if err != nil {
+		return nil, err
+	}
+
+	var location cardLocation
+	err = json.Unmarshal(body, &location)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the location of card %s", card.Id)
+	}
+
+	if location.Name == "" && location.Address == "" && location.Coordinates == nil {
+		return nil, nil
+	}
+
+	return &location, nil
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	lockExtension = ".lock"
+	// splitLockName locks the directory split output is written to
+	splitLockName = ".trello2md" + lockExtension
+	// lockPollInterval is how often a waiting run checks whether the lock was released
+	lockPollInterval = 500 * time.Millisecond
+
+	// exitLocked is the exit status of a run which couldn't take a lock, EX_TEMPFAIL so schedulers know to retry later
+	// rather than alert
+	exitLocked = 75
+)
+
+// lockedError is returned when another run still held a lock once --lock-wait passed, main exits with exitLocked on
+// it. it's a plain error rather than a cli exit error so a profile locked out of a run of several profiles doesn't
+// exit the whole run
+type lockedError struct {
+	holder string
+	path   string
+}
+
+func (e *lockedError) Error() string {
+	return fmt.Sprintf("another run (%s) is writing the same files, it holds %s, use --lock-wait to wait for it", e.holder, e.path)
+}
+
+// outputLocks are the lock files a run holds on the files it writes, so overlapping runs don't interleave writes
+type outputLocks struct {
+	paths []string
+}
+
+// lockOutputs locks the output, split output directory, state file and json output of the export, waiting up to
+// --lock-wait for a run already holding one of them to finish. a lock left behind by a run which died is taken over
+func lockOutputs(c *cli.Context) (*outputLocks, error) {
+	var targets []string
+	for _, path := range []string{c.String("output"), c.String("state-file"), c.String("json-output")} {
+		if path != "" {
+			targets = append(targets, path+lockExtension)
+		}
+	}
+	if c.String("split-by") != "" {
+		targets = append(targets, filepath.Join(c.String("output-dir"), splitLockName))
+	}
+
+	locks := &outputLocks{}
+	deadline := time.Now().Add(c.Duration("lock-wait"))
+	for _, target := range targets {
+		err := locks.take(target, deadline)
+		if err != nil {
+			locks.release()
+			return nil, err
+		}
+	}
+
+	return locks, nil
+}
+
+// take creates the lock file, polling until the deadline while another live run holds it
+func (l *outputLocks) take(path string, deadline time.Time) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	hostname, _ := os.Hostname()
+	owner := fmt.Sprintf("%d %s %s\n", os.Getpid(), hostname, time.Now().UTC().Format(time.RFC3339))
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, err = f.WriteString(owner)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return errors.Wrapf(err, "unable to write lock %s", path)
+			}
+
+			l.paths = append(l.paths, path)
+			return nil
+		}
+		if !os.IsExist(err) {
+			return errors.Wrapf(err, "unable to create lock %s", path)
+		}
+
+		holder, content, stale := readLock(path, hostname)
+		if stale {
+			log.Printf("taking over lock %s left behind by %s, which is no longer running", path, holder)
+			err = takeOver(path, content)
+			if err != nil {
+				return errors.Wrapf(err, "unable to take over lock %s", path)
+			}
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return &lockedError{holder: holder, path: path}
+		}
+
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// takeOver moves a stale lock aside so it can be created again. the rename is atomic so of several runs waiting on
+// the lock only one moves the stale one, a run which moved a lock another run had just created instead puts it back
+func takeOver(path string, stale []byte) error {
+	aside := fmt.Sprintf("%s.%d.stale", path, os.Getpid())
+	err := os.Rename(path, aside)
+	if os.IsNotExist(err) {
+		// another run moved it first
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer os.Remove(aside)
+
+	data, err := ioutil.ReadFile(aside)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(data, stale) {
+		return os.Link(aside, path)
+	}
+
+	return nil
+}
+
+// release removes the lock files, it's safe to call on a nil set of locks
+func (l *outputLocks) release() {
+	if l == nil {
+		return
+	}
+
+	for _, path := range l.paths {
+		os.Remove(path)
+	}
+	l.paths = nil
+}
+
+// readLock describes the run holding the lock and returns the lock's content, it's stale when that run was on this
+// host and is no longer running. runs on other hosts sharing the files can't be checked so their locks are never
+// taken over
+func readLock(path string, hostname string) (string, []byte, bool) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		// the lock was released while it was being read
+		return path, nil, os.IsNotExist(err)
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return strings.TrimSpace(string(data)), data, false
+	}
+
+	holder := fmt.Sprintf("pid %s on %s since %s", fields[0], fields[1], fields[2])
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil || fields[1] != hostname {
+		return holder, data, false
+	}
+
+	return holder, data, !processRunning(pid)
+}
+
+// processRunning checks whether the process exists by sending it the null signal, windows can't send it but only
+// finds processes which exist
+func processRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	if runtime.GOOS == "windows" {
+		return true
+	}
+
+	err = process.Signal(syscall.Signal(0))
+
+	return err == nil || err == syscall.EPERM
+}
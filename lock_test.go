@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTakeOver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trello2md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "export.md.lock")
+	tests := []struct {
+		name  string
+		lock  string
+		stale string
+		want  string
+	}{
+		{name: "stale lock", lock: "1 host 2024-05-01T00:00:00Z\n", stale: "1 host 2024-05-01T00:00:00Z\n", want: ""},
+		// another run took the lock over and created its own since the stale one was read
+		{name: "replaced lock", lock: "2 host 2024-05-02T00:00:00Z\n", stale: "1 host 2024-05-01T00:00:00Z\n", want: "2 host 2024-05-02T00:00:00Z\n"},
+		// another run moved the stale lock first
+		{name: "missing lock", stale: "1 host 2024-05-01T00:00:00Z\n", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(path)
+			if tt.lock != "" {
+				err := ioutil.WriteFile(path, []byte(tt.lock), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := takeOver(path, []byte(tt.stale))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ioutil.ReadFile(path)
+			if err != nil && !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("the lock holds %q, want %q", got, tt.want)
+			}
+
+			leftovers, _ := filepath.Glob(path + ".*")
+			if len(leftovers) > 0 {
+				t.Errorf("takeOver left %v behind", leftovers)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	loginLinksWarn     = "warn"
+	loginLinksAnnotate = "annotate"
+	loginLinksIgnore   = "ignore"
+)
+
+// loginLinkCard is a card linking to files only trello members signed in to trello can open
+type loginLinkCard struct {
+	view *cardView
+	// links are the names of the attachments, or the urls of the description's links, needing a login
+	links []string
+}
+
+func validateLoginLinks(mode string) error {
+	if mode != loginLinksWarn && mode != loginLinksAnnotate && mode != loginLinksIgnore {
+		return errors.Errorf("unsupported login links mode %q, expected warn, annotate or ignore", mode)
+	}
+
+	return nil
+}
+
+// needsTrelloLogin is true for files uploaded to trello, which trello only serves to signed in members of the board
+// since it stopped serving uploads publicly
+func needsTrelloLogin(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	host := u.Hostname()
+	if host == "trello-attachments.s3.amazonaws.com" {
+		return true
+	}
+
+	return (host == "trello.com" || strings.HasSuffix(host, ".trello.com")) && strings.Contains(u.Path, "/attachments/")
+}
+
+// findLoginLinks returns the cards of the exports linking to uploads readers of the document will need a trello
+// login to open. images are left out when they're embedded, as they're downloaded into the document
+func findLoginLinks(exports []*boardExport, imagesEmbedded bool) []loginLinkCard {
+	var cards []loginLinkCard
+	for _, boardExport := range exports {
+		for _, listExport := range boardExport.lists {
+			for _, view := range listExport.cards {
+				if links := cardLoginLinks(view, imagesEmbedded); len(links) > 0 {
+					cards = append(cards, loginLinkCard{view: view, links: links})
+				}
+			}
+		}
+	}
+
+	return cards
+}
+
+// cardLoginLinks returns the names of the attachments and the urls of the description's links of the card needing
+// a trello login to open
+func cardLoginLinks(view *cardView, imagesEmbedded bool) []string {
+	var links []string
+	for _, attachment := range view.Attachments {
+		if imagesEmbedded && isImageAttachment(attachment) {
+			continue
+		}
+
+		if attachment.IsUpload || needsTrelloLogin(attachment.Url) {
+			links = append(links, attachment.Name)
+		}
+	}
+
+	for _, m := range markdownInline.FindAllStringSubmatch(view.Card.Desc, -1) {
+		if m[4] != "" && needsTrelloLogin(m[4]) {
+			links = append(links, m[4])
+		}
+		if m[2] != "" && !imagesEmbedded && needsTrelloLogin(m[2]) {
+			links = append(links, m[2])
+		}
+	}
+
+	return links
+}
+
+// annotateLoginLinks marks the attachments readers need a trello login to open, the templates render a note by them
+func annotateLoginLinks(exports []*boardExport, imagesEmbedded bool) {
+	for _, boardExport := range exports {
+		for _, listExport := range boardExport.lists {
+			for _, view := range listExport.cards {
+				annotateCardLoginLinks(view, imagesEmbedded)
+			}
+		}
+	}
+}
+
+func annotateCardLoginLinks(view *cardView, imagesEmbedded bool) {
+	for i := range view.Attachments {
+		attachment := &view.Attachments[i]
+		if imagesEmbedded && isImageAttachment(*attachment) {
+			continue
+		}
+
+		attachment.RequiresLogin = attachment.IsUpload || needsTrelloLogin(attachment.Url)
+	}
+}
+
+// warnLoginLinks logs a warning for each card with links that will appear broken to readers without a trello login
+func warnLoginLinks(cards []loginLinkCard) {
+	for _, card := range cards {
+		warnings.add(WarningLoginLink, card.view.Card.Url, fmt.Sprintf("%s (%s) links to files readers need a trello login to open: %s",
+			card.view.Card.Name, card.view.Card.Url, strings.Join(card.links, ", ")))
+	}
+}
@@ -1,10 +1,16 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -20,12 +26,58 @@ const (
 
 	dateFormat        = "2006-01-02"
 	commentCardAction = "commentCard"
+
+	// cardsPageSize is the most cards trello returns for a single request
+	cardsPageSize = 1000
+	// searchBoardsLimit is the most boards a trello search returns, search results can't be paged
+	searchBoardsLimit = 1000
+	// cardActionsLimit is the most actions trello returns for a card
+	cardActionsLimit = 1000
+
+	cardSortDate     = "date"
+	cardSortPosition = "position"
+	cardSortName     = "name"
 )
 
 var (
 	revision string
 
+	// failOnTruncation turns truncation warnings into errors
+	failOnTruncation bool
+
 	globalArguments = []cli.Flag{
+		cli.BoolFlag{
+			Name:   "fail-on-truncation",
+			Usage:  "fail instead of warning when trello returns incomplete results which can't be paged through",
+			EnvVar: "FAIL_ON_TRUNCATION",
+		},
+		cli.StringSliceFlag{
+			Name:   "encrypt-to",
+			Usage:  "encrypt the files written to this recipient, repeatable, age recipients start with age1 or ssh- and any other is a gpg key id or email, encrypted files have .age or .gpg appended",
+			EnvVar: "TRELLO2MD_ENCRYPT_TO",
+		},
+		cli.StringFlag{
+			Name:   "age-identity",
+			Usage:  "the age identity file encrypted snapshots and earlier exports are decrypted with",
+			EnvVar: "TRELLO2MD_AGE_IDENTITY",
+		},
+		cli.StringFlag{
+			Name:   "lang",
+			Usage:  "the language of generated text such as totals and headings, one of en, de, fr or es",
+			EnvVar: "LANG_BUNDLE",
+			Value:  defaultLanguage,
+		},
+		cli.StringFlag{
+			Name:   "collation",
+			Usage:  "the locale names of cards, boards, members and labels are sorted for e.g. de or sv, byte order when unset",
+			EnvVar: "TRELLO2MD_COLLATION",
+		},
+		cli.StringFlag{
+			Name:   "config",
+			Usage:  "path to the yaml config file",
+			EnvVar: "CONFIG",
+			Value:  defaultConfigPath,
+		},
 		cli.StringFlag{
 			Name:   "key",
 			Usage:  "trello application key",
@@ -36,19 +88,126 @@ var (
 			Usage:  "trello api token",
 			EnvVar: "TOKEN",
 		},
+		cli.StringFlag{
+			Name:   "secret",
+			Usage:  "trello application secret, requests are signed with oauth 1.0a rather than sending the token when set",
+			EnvVar: "TRELLO2MD_SECRET",
+		},
+		cli.StringFlag{
+			Name:   "token-secret",
+			Usage:  "the secret of an oauth token, as printed by authorize",
+			EnvVar: "TRELLO2MD_TOKEN_SECRET",
+		},
+		cli.StringFlag{
+			Name:   "api-base-url",
+			Usage:  "the trello api base url, useful for pointing at a mock server or proxy",
+			EnvVar: "TRELLO_API_URL",
+			Value:  defaultAPIBaseURL,
+		},
+		cli.StringFlag{
+			Name:   "ca-cert",
+			Usage:  "path to a pem encoded ca certificate to trust in addition to the system roots",
+			EnvVar: "CA_CERT",
+		},
+		cli.BoolFlag{
+			Name:   "insecure-skip-verify",
+			Usage:  "skip tls certificate verification, only use this behind a trusted intercepting proxy",
+			EnvVar: "INSECURE_SKIP_VERIFY",
+		},
+		cli.DurationFlag{
+			Name:   "http-timeout",
+			Usage:  "the overall timeout for a single trello api request, 0 disables the timeout",
+			EnvVar: "HTTP_TIMEOUT",
+			Value:  30 * time.Second,
+		},
+		cli.IntFlag{
+			Name:   "max-idle-conns",
+			Usage:  "the maximum number of idle connections kept open to the trello api",
+			EnvVar: "MAX_IDLE_CONNS",
+			Value:  10,
+		},
+		cli.DurationFlag{
+			Name:   "idle-conn-timeout",
+			Usage:  "how long an idle connection is kept open before being closed",
+			EnvVar: "IDLE_CONN_TIMEOUT",
+			Value:  90 * time.Second,
+		},
+		cli.DurationFlag{
+			Name:   "keep-alive",
+			Usage:  "the tcp keep-alive period for connections to the trello api",
+			EnvVar: "KEEP_ALIVE",
+			Value:  30 * time.Second,
+		},
+		cli.BoolFlag{
+			Name:   "disable-keep-alives",
+			Usage:  "open a new connection for every request",
+			EnvVar: "DISABLE_KEEP_ALIVES",
+		},
+		cli.BoolTFlag{
+			Name:   "preflight",
+			Usage:  "check each trello token is valid and can read boards before exporting, use --preflight=false to disable",
+			EnvVar: "TRELLO2MD_PREFLIGHT",
+		},
+		cli.StringFlag{
+			Name:   "throttle",
+			Usage:  "keep within trello's rate limits with a preset of concurrency, request pacing and retries, conservative, normal or aggressive",
+			EnvVar: "TRELLO2MD_THROTTLE",
+		},
+		cli.IntFlag{
+			Name:   "retry-budget",
+			Usage:  "the most retries made across every request of a run, -1 for no limit",
+			EnvVar: "TRELLO2MD_RETRY_BUDGET",
+			Value:  50,
+		},
+		cli.IntFlag{
+			Name:   "breaker-threshold",
+			Usage:  "fail fast once this many trello api requests in a row fail with a network error or server error, 0 disables",
+			EnvVar: "TRELLO2MD_BREAKER_THRESHOLD",
+			Value:  5,
+		},
+		cli.DurationFlag{
+			Name:   "breaker-cooldown",
+			Usage:  "how long requests fail fast once the breaker opens before one is sent to check trello has recovered",
+			EnvVar: "TRELLO2MD_BREAKER_COOLDOWN",
+			Value:  time.Minute,
+		},
+		cli.StringFlag{
+			Name:   "cpuprofile",
+			Usage:  "write a cpu profile of the command to this file, for go tool pprof",
+			EnvVar: "TRELLO2MD_CPUPROFILE",
+		},
+		cli.StringFlag{
+			Name:   "memprofile",
+			Usage:  "write a heap profile to this file as the command exits, for go tool pprof",
+			EnvVar: "TRELLO2MD_MEMPROFILE",
+		},
+		cli.StringFlag{
+			Name:   "trace",
+			Usage:  "write an execution trace of the command to this file, for go tool trace",
+			EnvVar: "TRELLO2MD_TRACE",
+		},
 	}
 
 	exportBoardsArguments = []cli.Flag{
+		cli.StringFlag{
+			Name:   "profile",
+			Usage:  "the config file profile to take flag values from",
+			EnvVar: "PROFILE",
+		},
 		cli.StringSliceFlag{
 			Name:   "board-id",
-			Usage:  "the trello board ids for boards to export",
+			Usage:  "the trello board ids, or config aliases, for boards to export",
 			EnvVar: "BOARD_ID",
 		},
-		cli.StringFlag{
+		cli.StringSliceFlag{
+			Name:   "from-trello-json",
+			Usage:  "render a board from the file written by trello's Export as JSON board menu item instead of the api, repeat for several boards, comments and history are limited to the actions trello includes",
+			EnvVar: "TRELLO2MD_FROM_TRELLO_JSON",
+		},
+		cli.StringSliceFlag{
 			Name:   "list-filter",
-			Usage:  "the filter to apply when looking for lists to export",
+			Usage:  "the name of the list to export, repeat to export several lists under their own headings in the given order, defaults to Done",
 			EnvVar: "LIST_FILTER",
-			Value:  "Done",
 		},
 		cli.BoolFlag{
 			Name:        "show-labels-and-members",
@@ -65,171 +224,1780 @@ var (
 			Usage:       "render ticket checklists",
 			EnvVar:      "SHOW_CHECKLISTS",
 		},
+		cli.StringFlag{
+			Name:   "checklists",
+			Usage:  "where checklists are rendered, inline under each card or appendix to list them after each board's lists grouped by card",
+			EnvVar: "TRELLO2MD_CHECKLISTS",
+			Value:  checklistsInline,
+		},
+		cli.BoolFlag{
+			Name:   "show-dependencies",
+			Usage:  "render the cards linked from ticket checklist items as the cards it depends on, along with the list each is in now",
+			EnvVar: "TRELLO2MD_SHOW_DEPENDENCIES",
+		},
 		cli.BoolFlag{
 			Name:        "show-comments",
 			Usage:       "render ticket comments",
 			EnvVar:      "SHOW_COMMENTS",
 		},
+		cli.BoolFlag{
+			Name:   "show-reactions",
+			Usage:  "render emoji reactions under each comment, requires --show-comments",
+			EnvVar: "SHOW_REACTIONS",
+		},
+		cli.BoolFlag{
+			Name:   "skip-bot-comments",
+			Usage:  "leave out the comments posted by butler, power-ups and the --bot-username accounts",
+			EnvVar: "TRELLO2MD_SKIP_BOT_COMMENTS",
+		},
+		cli.StringSliceFlag{
+			Name:   "bot-username",
+			Usage:  "the username of a bot account such as a ci integration which --skip-bot-comments and --human-activity ignore, repeatable",
+			EnvVar: "TRELLO2MD_BOT_USERNAME",
+		},
+		cli.BoolFlag{
+			Name:   "human-activity",
+			Usage:  "date and sort cards by the last thing a member did to them rather than butler, power-ups or the --bot-username accounts, fetches the actions of every card",
+			EnvVar: "TRELLO2MD_HUMAN_ACTIVITY",
+		},
+		cli.IntFlag{
+			Name:   "collapse-comments",
+			Usage:  "fold comment threads with more than this many comments into a <details> block, 0 never folds them",
+			EnvVar: "TRELLO2MD_COLLAPSE_COMMENTS",
+		},
+		cli.StringFlag{
+			Name:   "comment-style",
+			Usage:  "how comments are rendered, quote for blockquotes, bullet for a compact list with the date and author before each, table for a table or plain for paragraphs",
+			EnvVar: "TRELLO2MD_COMMENT_STYLE",
+			Value:  commentStyleQuote,
+		},
+		cli.IntFlag{
+			Name:   "collapse-description",
+			Usage:  "fold descriptions longer than this many lines into a <details> block, 0 never folds them",
+			EnvVar: "TRELLO2MD_COLLAPSE_DESCRIPTION",
+		},
+		cli.IntFlag{
+			Name:   "title-max-length",
+			Usage:  "shorten card names longer than this many characters in headings and render the full name under the heading, 0 never shortens them",
+			EnvVar: "TRELLO2MD_TITLE_MAX_LENGTH",
+		},
+		cli.BoolFlag{
+			Name:   "show-completion",
+			Usage:  "render when and by whom each card was completed, taken from the due date being marked complete or the card's last list move",
+			EnvVar: "SHOW_COMPLETION",
+		},
+		cli.BoolFlag{
+			Name:   "show-audit",
+			Usage:  "render who created each card and each board's visibility and trello enterprise, for compliance archives",
+			EnvVar: "TRELLO2MD_SHOW_AUDIT",
+		},
+		cli.BoolFlag{
+			Name:   "show-age",
+			Usage:  "render how long each card has been in its current list",
+			EnvVar: "SHOW_AGE",
+		},
+		cli.StringFlag{
+			Name:   "filter",
+			Usage:  `only export cards matching an expression over name, desc, label, member, due, activity, board and points, e.g. label == "bug" && due < "2024-06-01" && member in ["jane"]`,
+			EnvVar: "TRELLO2MD_FILTER",
+		},
+		cli.StringFlag{
+			Name:   "query",
+			Usage:  "print the result of a jq style expression over the boards, lists and cards instead of markdown, cards hold what the --show flags fetch, e.g. .boards[].lists[].cards[].Attachments[].url",
+			EnvVar: "TRELLO2MD_QUERY",
+		},
+		cli.StringFlag{
+			Name:   "stale-after",
+			Usage:  "only export cards which have been in their list for at least this long e.g. 14d or 36h, use with an in progress --list-filter for a stuck work report",
+			EnvVar: "STALE_AFTER",
+		},
 		cli.BoolFlag{
 			Name:        "show-attachments",
 			Usage:       "render ticket attachments",
 			EnvVar:      "SHOW_ATTACHMENTS",
 		},
+		cli.StringFlag{
+			Name:   "attachment-sort",
+			Usage:  "the order attachments are rendered in, trello keeps trello's order while newest and oldest sort them by upload date",
+			EnvVar: "TRELLO2MD_ATTACHMENT_SORT",
+			Value:  attachmentSortTrello,
+		},
+		cli.BoolFlag{
+			Name:   "images-only",
+			Usage:  "only render the attachments which are images",
+			EnvVar: "TRELLO2MD_IMAGES_ONLY",
+		},
+		cli.StringSliceFlag{
+			Name:   "attachment-extension",
+			Usage:  "only render attachments with this extension e.g. pdf, may be repeated",
+			EnvVar: "TRELLO2MD_ATTACHMENT_EXTENSIONS",
+		},
+		cli.IntFlag{
+			Name:   "max-attachments",
+			Usage:  "the most attachments rendered per card after they're filtered and sorted, 0 renders them all",
+			EnvVar: "TRELLO2MD_MAX_ATTACHMENTS",
+		},
+		cli.BoolFlag{
+			Name:   "image-captions",
+			Usage:  "render a caption beneath each attachment's image naming the attachment, the card and who uploaded it when, so screenshots make sense out of context",
+			EnvVar: "TRELLO2MD_IMAGE_CAPTIONS",
+		},
+		cli.StringFlag{
+			Name:   "login-links",
+			Usage:  "what to do about links to files uploaded to trello, which readers need a trello login to open, warn lists them per card at the end of the run, annotate notes it by each attachment and ignore does neither",
+			EnvVar: "TRELLO2MD_LOGIN_LINKS",
+			Value:  loginLinksWarn,
+		},
+		cli.BoolFlag{
+			Name:   "show-stickers",
+			Usage:  "render the stickers placed on each card",
+			EnvVar: "SHOW_STICKERS",
+		},
+		cli.BoolFlag{
+			Name:   "show-location",
+			Usage:  "render the location set with the map power-up along with an openstreetmap link",
+			EnvVar: "SHOW_LOCATION",
+		},
+		cli.BoolFlag{
+			Name:   "show-plugin-data",
+			Usage:  "render the raw data power-ups store on each card",
+			EnvVar: "SHOW_PLUGIN_DATA",
+		},
+		cli.StringSliceFlag{
+			Name:   "plugin-extractor",
+			Usage:  "extract values kept by a power-up and render them under each card, supported: scrum-for-trello",
+			EnvVar: "PLUGIN_EXTRACTORS",
+		},
+		cli.StringFlag{
+			Name:   "story-points",
+			Usage:  "render story points next to card titles with per list and per board totals, read from a leading (3) in the title or a custom field given as custom-field:<name>",
+			EnvVar: "STORY_POINTS",
+		},
+		cli.StringFlag{
+			Name:   "template",
+			Usage:  "path to a go text/template used to render each card, see the init command for the default",
+			EnvVar: "TEMPLATE",
+		},
+		cli.StringFlag{
+			Name:   "partials-dir",
+			Usage:  "a directory of partials overriding single blocks of the card template, each named after its block e.g. title.tmpl or comment.tmpl",
+			EnvVar: "TRELLO2MD_PARTIALS_DIR",
+		},
+		cli.StringFlag{
+			Name:   "layout",
+			Usage:  "how cards are laid out, cards or tasklist to render every card as a task with its checklist items as sub-tasks",
+			EnvVar: "TRELLO2MD_LAYOUT",
+			Value:  layoutCards,
+		},
+		cli.BoolFlag{
+			Name:   "verify",
+			Usage:  "check the cards of every exported list and the comments of every card against counts fetched from trello and fail on any mismatch",
+			EnvVar: "TRELLO2MD_VERIFY",
+		},
+		cli.StringFlag{
+			Name:   "fidelity",
+			Usage:  "standard, or full to render everything on the back of every card, including custom fields and activity, in a fixed layout for archiving",
+			EnvVar: "TRELLO2MD_FIDELITY",
+			Value:  fidelityStandard,
+		},
+		cli.StringSliceFlag{
+			Name:   "done-list",
+			Usage:  "the names of the lists holding finished cards, their tasks are checked in the tasklist layout, defaults to Done",
+			EnvVar: "DONE_LISTS",
+		},
+		cli.StringFlag{
+			Name:   "compare-snapshot",
+			Usage:  "note under each board heading what changed since this earlier --json-output or snapshot, e.g. +5 done, 2 new comments, 1 reopened, nothing is noted until it exists so it can be the previous run's --json-output",
+			EnvVar: "TRELLO2MD_COMPARE_SNAPSHOT",
+		},
+		cli.StringFlag{
+			Name:   "track-fields",
+			Usage:  "render a timeline of the changes to these comma separated fields under each card, any of due, labels, members, name and list, for auditing approvals",
+			EnvVar: "TRELLO2MD_TRACK_FIELDS",
+		},
+		cli.BoolFlag{
+			Name:   "show-reopened",
+			Usage:  "list the cards moved back out of a --done-list, and not returned since, in a reopened section under each board",
+			EnvVar: "TRELLO2MD_SHOW_REOPENED",
+		},
+		cli.StringFlag{
+			Name:   "reopened-within",
+			Usage:  "how far back --show-reopened looks for cards moved out of a done list e.g. 14d or 36h",
+			EnvVar: "TRELLO2MD_REOPENED_WITHIN",
+			Value:  "30d",
+		},
+		cli.StringFlag{
+			Name:   "transform",
+			Usage:  "path to a starlark script defining transform(card) which can modify or drop cards before rendering",
+			EnvVar: "TRANSFORM",
+		},
+		cli.StringFlag{
+			Name:   "member-map",
+			Usage:  "path to a yaml file mapping trello usernames to the names members are shown as, e.g. display names, email addresses or github handles",
+			EnvVar: "TRELLO2MD_MEMBER_MAP",
+		},
+		cli.StringFlag{
+			Name:   "label-map",
+			Usage:  "path to a yaml file renaming labels under rename: (labels renamed alike are merged) and listing labels to leave out under hide:",
+			EnvVar: "TRELLO2MD_LABEL_MAP",
+		},
+		cli.StringFlag{
+			Name:   "manifest",
+			Usage:  "path to a yaml file listing the boards, lists (by name or id) and cards (by id or short link) to include: and exclude:, for hand picking what external reports show, its boards are exported when no --board-id is given",
+			EnvVar: "TRELLO2MD_MANIFEST",
+		},
+		cli.StringFlag{
+			Name:   "output",
+			Usage:  "the file to write the document to instead of stdout, the file is left untouched when its content is unchanged",
+			EnvVar: "OUTPUT",
+		},
+		cli.IntFlag{
+			Name:   "max-output-bytes",
+			Usage:  "the largest file the export may write, e.g. a wiki's page size limit, 0 for no limit",
+			EnvVar: "TRELLO2MD_MAX_OUTPUT_BYTES",
+		},
+		cli.StringFlag{
+			Name:   "oversize",
+			Usage:  "what happens to output over --max-output-bytes, split into numbered -partN files cut between cards or truncate with a notice, stdout is always truncated",
+			EnvVar: "TRELLO2MD_OVERSIZE",
+			Value:  oversizeSplit,
+		},
+		cli.StringFlag{
+			Name:   "json-output",
+			Usage:  "also write the exported boards, lists and cards as json to this file, a machine readable artifact from the same pass over the api as the document",
+			EnvVar: "TRELLO2MD_JSON_OUTPUT",
+		},
+		cli.BoolFlag{
+			Name:  "preview",
+			Usage: "render the document with terminal styling for a quick look instead of printing the markdown",
+		},
+		cli.StringFlag{
+			Name:   "format",
+			Usage:  "the format of the document, markdown, docx for a word document or epub for an e-book with a chapter per board and list, both written to --output with images embedded",
+			EnvVar: "TRELLO2MD_FORMAT",
+			Value:  formatMarkdown,
+		},
+		cli.StringFlag{
+			Name:   "via-pandoc",
+			Usage:  "convert the markdown to another format with pandoc e.g. odt, rst or pdf, written to --output with the board names as the title and images downloaded for formats which embed them",
+			EnvVar: "TRELLO2MD_VIA_PANDOC",
+		},
+		cli.StringFlag{
+			Name:   "pandoc-path",
+			Usage:  "the pandoc binary used by --via-pandoc, looked up on the PATH",
+			EnvVar: "TRELLO2MD_PANDOC",
+			Value:  "pandoc",
+		},
+		cli.StringSliceFlag{
+			Name:  "pandoc-arg",
+			Usage: "an extra argument passed to pandoc after trello2md's own e.g. --pandoc-arg=--toc, may be repeated",
+		},
+		cli.StringFlag{
+			Name:   "via-plugin",
+			Usage:  "render the document with the output plugin of this name, the trello2md-NAME executable is sent the markdown and the json export on stdin and replies with the document",
+			EnvVar: "TRELLO2MD_VIA_PLUGIN",
+		},
+		cli.StringFlag{
+			Name:   "plugin-dir",
+			Usage:  "a directory searched for output plugins before the PATH",
+			EnvVar: "TRELLO2MD_PLUGIN_DIR",
+		},
+		cli.StringFlag{
+			Name:   "split-by",
+			Usage:  "write a file per board, list, card, month or quarter into the output directory instead of a single document to stdout, month and quarter files are appended to on later runs",
+			EnvVar: "SPLIT_BY",
+		},
+		cli.StringFlag{
+			Name:   "output-dir",
+			Usage:  "the directory split output is written to",
+			EnvVar: "OUTPUT_DIR",
+			Value:  ".",
+		},
+		cli.StringFlag{
+			Name:   "filename-template",
+			Usage:  "go text/template for split output filenames relative to the output directory, each path segment is slugified e.g. {{.Board.Name}}/{{.Date}}-{{.List.Name}}.md",
+			EnvVar: "FILENAME_TEMPLATE",
+		},
+		cli.DurationFlag{
+			Name:   "lock-wait",
+			Usage:  "how long to wait for another run writing the same --output, --output-dir, --state-file or --json-output to finish, a run still locked out exits with status 75",
+			EnvVar: "TRELLO2MD_LOCK_WAIT",
+		},
+		cli.StringFlag{
+			Name:   "state-file",
+			Usage:  "checkpoint every fetched card to this file so an interrupted export can be resumed, it's removed once the export completes",
+			EnvVar: "STATE_FILE",
+		},
+		cli.BoolFlag{
+			Name:  "resume",
+			Usage: "resume the interrupted export checkpointed in --state-file instead of starting over",
+		},
+		cli.StringFlag{
+			Name:   "notify-url",
+			Usage:  "post a json event to this url as the export starts, as each board is fetched and once it completes or fails, for workflow engines tracking export jobs",
+			EnvVar: "TRELLO2MD_NOTIFY_URL",
+		},
+		cli.StringFlag{
+			Name:   "run-report",
+			Usage:  "write a json summary of the boards, lists and cards exported, api requests made, warnings and duration to this file",
+			EnvVar: "RUN_REPORT",
+		},
+		cli.StringFlag{
+			Name:   "history-dir",
+			Usage:  "append each run's flags, duration and output hash to history.jsonl in this directory, for auditing when reports were generated and how they were filtered",
+			EnvVar: "TRELLO2MD_HISTORY_DIR",
+		},
+		cli.Int64Flag{
+			Name:   "history-max-bytes",
+			Usage:  "rotate the history log to history.jsonl.1 once it would grow past this size",
+			EnvVar: "TRELLO2MD_HISTORY_MAX_BYTES",
+			Value:  1 << 20,
+		},
+		cli.IntFlag{
+			Name:   "history-keep",
+			Usage:  "how many rotated history logs are kept, older ones are deleted",
+			EnvVar: "TRELLO2MD_HISTORY_KEEP",
+			Value:  5,
+		},
+		cli.IntFlag{
+			Name:   "wrap",
+			Usage:  "hard wrap prose at this many columns, code blocks, tables and headings are left as they are",
+			EnvVar: "TRELLO2MD_WRAP",
+		},
+		cli.BoolFlag{
+			Name:   "no-wrap",
+			Usage:  "join the lines of each paragraph so prose is never wrapped, for renderers which treat line breaks as significant",
+			EnvVar: "TRELLO2MD_NO_WRAP",
+		},
+		cli.BoolFlag{
+			Name:   "normalize-blank-lines",
+			Usage:  "collapse runs of blank lines into one and set headings apart with a blank line, for renderers which are picky about spacing",
+			EnvVar: "TRELLO2MD_NORMALIZE_BLANK_LINES",
+		},
+		cli.StringFlag{
+			Name:   "card-separator",
+			Usage:  "a line written between the cards of each list, e.g. --card-separator=--- for a horizontal rule, cards aren't separated when empty",
+			EnvVar: "TRELLO2MD_CARD_SEPARATOR",
+		},
+		cli.BoolFlag{
+			Name:   "number-cards",
+			Usage:  "number the cards of each list from 1 in the order they're rendered",
+			EnvVar: "TRELLO2MD_NUMBER_CARDS",
+		},
+		cli.BoolFlag{
+			Name:   "footer",
+			Usage:  "end the export with a footer noting the trello2md version, when it was generated, the boards included and the filters applied",
+			EnvVar: "TRELLO2MD_FOOTER",
+		},
+		cli.StringFlag{
+			Name:   "footer-template",
+			Usage:  "the path to a template for the footer, implies --footer",
+			EnvVar: "TRELLO2MD_FOOTER_TEMPLATE",
+		},
+		cli.StringFlag{
+			Name:   "url-style",
+			Usage:  "how cards link back to trello, full for the card's url, short for its trello.com/c/ url or none to leave the links out for readers without access to the board",
+			EnvVar: "TRELLO2MD_URL_STYLE",
+			Value:  urlStyleFull,
+		},
+		cli.StringFlag{
+			Name:   "link-style",
+			Usage:  "how links to other exported cards and members are written, markdown, wikilink for [[Card Title]] links between per card files or footnote to collect card, member and attachment urls as numbered references at the end of each board",
+			EnvVar: "TRELLO2MD_LINK_STYLE",
+			Value:  linkStyleMarkdown,
+		},
+		cli.BoolFlag{
+			Name:   "ascii",
+			Usage:  "replace smart quotes, dashes, ellipses and non-breaking spaces in card text with ascii, e.g. for text pasted from word",
+			EnvVar: "TRELLO2MD_ASCII",
+		},
+		cli.BoolFlag{
+			Name:   "convert-html",
+			Usage:  "convert html pasted into card descriptions, such as lists, links, bold text and tables, to markdown",
+			EnvVar: "TRELLO2MD_CONVERT_HTML",
+		},
+		cli.BoolFlag{
+			Name:   "show-dataview-fields",
+			Usage:  "write the card's due date, assignees, labels and url as obsidian dataview inline fields, e.g. due:: 2024-05-01",
+			EnvVar: "TRELLO2MD_SHOW_DATAVIEW_FIELDS",
+		},
+		cli.BoolFlag{
+			Name:   "front-matter",
+			Usage:  "start every per card file with yaml front matter holding the card's title, url, board, list, due date and label tags",
+			EnvVar: "TRELLO2MD_FRONT_MATTER",
+		},
+		cli.StringFlag{
+			Name:   "tag-prefix",
+			Usage:  "prefix the front matter tags made from labels with this, e.g. trello/",
+			EnvVar: "TRELLO2MD_TAG_PREFIX",
+		},
+		cli.StringFlag{
+			Name:   "tag-style",
+			Usage:  "how label names are normalised into tags, kebab and snake lower case them while keep only replaces characters tags can't hold",
+			EnvVar: "TRELLO2MD_TAG_STYLE",
+			Value:  tagStyleKebab,
+		},
+		cli.StringFlag{
+			Name:   "slug-style",
+			Usage:  "how split output filenames are slugified, unicode keeps letters of any script and kebab folds them to ascii kebab-case",
+			EnvVar: "SLUG_STYLE",
+			Value:  slugStyleUnicode,
+		},
+		cli.BoolFlag{
+			Name:   "sections",
+			Usage:  "render cards under the sections of the config file by label, e.g. bug cards under Fixes, rather than by list",
+			EnvVar: "TRELLO2MD_SECTIONS",
+		},
+		cli.IntFlag{
+			Name:   "list-index",
+			Usage:  "export the list at this position instead of the list filter, 0 is the leftmost open list and --list-index=-1 the rightmost",
+			EnvVar: "TRELLO2MD_LIST_INDEX",
+		},
+		cli.BoolFlag{
+			Name:   "all-lists",
+			Usage:  "export every open list of the board left to right under its own heading instead of the list filter",
+			EnvVar: "ALL_LISTS",
+		},
+		cli.BoolFlag{
+			Name:   "include-templates",
+			Usage:  "export card templates, which are skipped by default",
+			EnvVar: "TRELLO2MD_INCLUDE_TEMPLATES",
+		},
+		cli.BoolFlag{
+			Name:   "show-list-counts",
+			Usage:  "render the number of cards next to list headings",
+			EnvVar: "SHOW_LIST_COUNTS",
+		},
+		cli.BoolFlag{
+			Name:   "board-diagram",
+			Usage:  "render a mermaid diagram of every list with its card count and labels under each board heading, not drawn for merged boards",
+			EnvVar: "BOARD_DIAGRAM",
+		},
+		cli.BoolFlag{
+			Name:   "show-custom-field-definitions",
+			Usage:  "render a table of the custom fields defined on each board with their types and list options under its heading, so exports can be read once the board is gone",
+			EnvVar: "TRELLO2MD_SHOW_CUSTOM_FIELD_DEFINITIONS",
+		},
+		cli.BoolFlag{
+			Name:   "due-calendar",
+			Usage:  "render a month calendar under each board heading with the number of cards due on every day",
+			EnvVar: "TRELLO2MD_DUE_CALENDAR",
+		},
+		cli.StringFlag{
+			Name:   "sort",
+			Usage:  "the order cards appear in, date sorts by last activity, position keeps the manual order from trello and name sorts by --collation. with position each card is rendered as it's fetched rather than once every board has been, unless a flag needing the whole export is set",
+			EnvVar: "SORT",
+			Value:  cardSortDate,
+		},
+		cli.StringFlag{
+			Name:   "board-order",
+			Usage:  "the order boards appear in, one of given, name or activity, boards with an order in the config file always lead",
+			EnvVar: "BOARD_ORDER",
+			Value:  boardOrderGiven,
+		},
+		cli.BoolFlag{
+			Name:   "merge-boards",
+			Usage:  "render the cards of every board as a single chronologically sorted list tagged with their board",
+			EnvVar: "MERGE_BOARDS",
+		},
+		cli.BoolFlag{
+			Name:   "dedupe",
+			Usage:  "render cards appearing on several boards (same short link) once in a merged export, tagged with every board",
+			EnvVar: "DEDUPE",
+		},
+		cli.BoolFlag{
+			Name:   "skip-missing-boards",
+			Usage:  "warn about and skip boards that were deleted or can no longer be read rather than failing the export",
+			EnvVar: "TRELLO2MD_SKIP_MISSING_BOARDS",
+		},
+		cli.IntFlag{
+			Name:   "concurrency",
+			Usage:  "the number of boards fetched in parallel, output is always assembled in the given board order, the --throttle preset sets it when not given",
+			EnvVar: "CONCURRENCY",
+			Value:  4,
+		},
+		cli.BoolTFlag{
+			Name:   "index",
+			Usage:  "write an index.md linking every file produced by a split export, use --index=false to disable",
+			EnvVar: "INDEX",
+		},
 	}
 
 	searchBoardsArgs = []cli.Flag{
 		cli.StringFlag{
 			Name:   "board-filter",
-			Usage:  "the board name filter to apply",
+			Usage:  "the board name filter to apply, or a pasted board url to print the board's id, lists and member count",
 			EnvVar: "BOARD_FILTER",
 		},
+		cli.StringFlag{
+			Name:   "format",
+			Usage:  "the format to print boards in, text or json",
+			EnvVar: "TRELLO2MD_SEARCH_FORMAT",
+			Value:  searchFormatText,
+		},
+		cli.StringFlag{
+			Name:   "workspace",
+			Usage:  "only find boards in the workspace with this id or short name",
+			EnvVar: "TRELLO2MD_WORKSPACE",
+		},
+		cli.BoolFlag{
+			Name:   "starred",
+			Usage:  "only find boards you've starred",
+			EnvVar: "TRELLO2MD_STARRED",
+		},
+		cli.StringFlag{
+			Name:   "sort",
+			Usage:  "the order boards are printed in, relevance as trello ranks them or activity for the most recently active first",
+			EnvVar: "TRELLO2MD_SEARCH_SORT",
+			Value:  searchSortRelevance,
+		},
+	}
+
+	serveArgs = []cli.Flag{
+		cli.StringFlag{
+			Name:   "listen",
+			Usage:  "the address to serve on",
+			EnvVar: "LISTEN",
+			Value:  ":8080",
+		},
+		cli.DurationFlag{
+			Name:   "shutdown-timeout",
+			Usage:  "how long to wait for in-flight exports to finish after receiving SIGINT or SIGTERM",
+			EnvVar: "SHUTDOWN_TIMEOUT",
+			Value:  30 * time.Second,
+		},
+		cli.DurationFlag{
+			Name:   "cache-ttl",
+			Usage:  "how long a rendered export is served before trello is crawled again, 0 renders every request",
+			EnvVar: "TRELLO2MD_CACHE_TTL",
+			Value:  time.Minute,
+		},
+	}
+
+	initArgs = []cli.Flag{
+		cli.StringFlag{
+			Name:  "dir",
+			Usage: "the directory to write the starter config and template to",
+			Value: ".",
+		},
+		cli.BoolFlag{
+			Name:  "force",
+			Usage: "overwrite existing files",
+		},
+	}
+
+	completionArgs = []cli.Flag{
+		cli.BoolFlag{
+			Name:   "list-profiles",
+			Usage:  "print the profile names from the config file, used by the completion scripts",
+			Hidden: true,
+		},
 	}
 )
 
 func main() {
+	if err := newApp().Run(os.Args); err != nil {
+		// a run locked out by another exits with a status schedulers retry on
+		if _, ok := errors.Cause(err).(*lockedError); ok {
+			log.Print(err)
+			os.Exit(exitLocked)
+		}
+		log.Panic(err)
+	}
+}
+
+// newApp returns the command line app with every command and flag
+func newApp() *cli.App {
 	app := cli.NewApp()
 	app.Name = appName
 	app.Description = appDesc
-	app.Version = revision
+	version := buildVersion()
+	app.Version = version.Version
+	cli.VersionPrinter = func(c *cli.Context) {
+		version.print(c.App.Writer)
+	}
 	app.Flags = globalArguments
-	app.Commands = []cli.Command{
+	app.Before = func(c *cli.Context) error {
+		warnDeprecatedEnvVars()
+		failOnTruncation = c.GlobalBool("fail-on-truncation")
+
+		err := setThrottle(c.GlobalString("throttle"))
+		if err != nil {
+			return err
+		}
+		retryBudget.set(c.GlobalInt("retry-budget"))
+		breaker.configure(c.GlobalInt("breaker-threshold"), c.GlobalDuration("breaker-cooldown"))
+
+		ageIdentity = c.GlobalString("age-identity")
+		encryption, err = newEncryptor(c.GlobalStringSlice("encrypt-to"))
+		if err != nil {
+			return err
+		}
+
+		err = setLanguage(c.GlobalString("lang"))
+		if err != nil {
+			return err
+		}
+
+		err = setCollation(c.GlobalString("collation"))
+		if err != nil {
+			return err
+		}
+
+		return profiling.start(c)
+	}
+	app.After = func(c *cli.Context) error {
+		return profiling.stop()
+	}
+	app.Commands = []cli.Command{
 		{
 			Name:   "export-boards",
 			Flags:  exportBoardsArguments,
 			Action: exportBoards,
 		},
+		{
+			Name:   "export-my-cards",
+			Usage:  "export the cards assigned to you across all of your boards, grouped by board and due date",
+			Flags:  append(myCardsArgs, exportBoardsArguments...),
+			Action: exportMyCards,
+		},
+		{
+			Name:   "render-cards",
+			Usage:  "render the cards whose ids, short links or urls are read from stdin one per line, for rendering cards picked by other scripts",
+			Flags:  exportBoardsArguments,
+			Action: renderCards,
+		},
+		{
+			Name:   "benchmark",
+			Usage:  "export trello json exports given with --from-trello-json repeatedly and report the time and memory each export took, for measuring performance changes",
+			Flags:  append(benchmarkArgs, exportBoardsArguments...),
+			Action: benchmark,
+		},
+		{
+			Name:   "agenda",
+			Usage:  "export the open cards due in the coming days as a checklist grouped by day",
+			Flags:  append(agendaArgs, exportBoardsArguments...),
+			Action: agenda,
+		},
 		{
 			Name:   "search-boards",
 			Flags:  searchBoardsArgs,
 			Action: searchBoards,
 		},
+		{
+			Name:   "browse",
+			Usage:  "browse boards, lists and cards in the terminal with a preview of each card and export a selection of them",
+			Flags:  exportBoardsArguments,
+			Action: browse,
+		},
+		{
+			Name:   "serve",
+			Usage:  "serve the rendered export over http along with an index of its boards at /boards, prometheus metrics and health checks",
+			Flags:  append(serveArgs, exportBoardsArguments...),
+			Action: serve,
+		},
+		{
+			Name:   "release-notes",
+			Usage:  "render only card titles, optionally grouped by label, for pasting into a release or changelog",
+			Flags:  append(releaseNotesArgs, exportBoardsArguments...),
+			Action: releaseNotes,
+		},
+		{
+			Name:   "release-train",
+			Usage:  "render the cards marked with a release label or custom field on every board as a single document grouped by team and board",
+			Flags:  append(releaseTrainArgs, exportBoardsArguments...),
+			Action: releaseTrain,
+		},
+		{
+			Name:   "digest",
+			Usage:  "render the top cards by votes or label as a newsletter with each card's cover image, first paragraph and link",
+			Flags:  append(digestArgs, exportBoardsArguments...),
+			Action: digest,
+		},
+		{
+			Name:   "standup",
+			Usage:  "print the cards moved or commented on recently grouped by member, formatted for slack",
+			Flags:  append(standupArgs, exportBoardsArguments...),
+			Action: standup,
+		},
+		{
+			Name:   "lint",
+			Usage:  "report the cards missing a due date, members, labels or a description so boards can be kept tidy",
+			Flags:  append(lintArgs, exportBoardsArguments...),
+			Action: lint,
+		},
+		{
+			Name:   "copy-board",
+			Usage:  "copy cards with their descriptions, labels, checklists and optionally comments to another board",
+			Flags:  copyBoardArgs,
+			Action: copyBoard,
+		},
+		{
+			Name:   "retro",
+			Usage:  "render retrospective boards as a section per list ordered by votes with action items as a task list",
+			Flags:  append(retroArgs, exportBoardsArguments...),
+			Action: retro,
+		},
+		{
+			Name:  "publish",
+			Usage: "publish generated documents to other services",
+			Subcommands: []cli.Command{
+				{
+					Name:   "github-release",
+					Usage:  "create or update the github release for a tag with the generated release notes",
+					Flags:  append(append(publishGitHubReleaseArgs, releaseNotesArgs...), exportBoardsArguments...),
+					Action: publishGitHubRelease,
+				},
+				{
+					Name:   "pipeline",
+					Usage:  "render the boards once and deliver the document to several targets, writing files, committing to git and posting to slack, reporting the result of each",
+					Flags:  append(publishPipelineArgs, exportBoardsArguments...),
+					Action: publishPipeline,
+				},
+			},
+		},
+		{
+			Name:   "archive-board",
+			Usage:  "archive everything on a board along with its uploaded attachments to a dated directory, verify it and optionally close the board",
+			Flags:  append(archiveBoardArgs, exportBoardsArguments...),
+			Action: archiveBoard,
+		},
+		{
+			Name:   "watch",
+			Usage:  "check the boards every interval and post the cards which entered the exported lists to slack",
+			Flags:  append(watchArgs, exportBoardsArguments...),
+			Action: watch,
+		},
+		{
+			Name:   "record",
+			Usage:  "proxy the trello api, saving every request and response with credentials redacted as fixtures for bug reports and offline development",
+			Flags:  recordArgs,
+			Action: record,
+		},
+		{
+			Name:   "whoami",
+			Usage:  "print the member, expiry, scopes, workspaces and rate limit headroom of the token, to troubleshoot credentials",
+			Flags:  whoamiArgs,
+			Action: whoami,
+		},
+		{
+			Name:  "webhooks",
+			Usage: "manage the trello webhooks registered by the token",
+			Subcommands: []cli.Command{
+				{
+					Name:   "list",
+					Usage:  "print every webhook with its board, callback url and whether trello still calls it",
+					Action: listWebhooks,
+				},
+				{
+					Name:   "create",
+					Usage:  "register a webhook calling back on changes to a board",
+					Flags:  webhooksCreateArgs,
+					Action: createWebhook,
+				},
+				{
+					Name:      "delete",
+					Usage:     "delete webhooks by id",
+					ArgsUsage: "id...",
+					Flags:     webhooksDeleteArgs,
+					Action:    deleteWebhooks,
+				},
+			},
+		},
+		{
+			Name:   "stats",
+			Usage:  "summarise the cards of boards as they are now, or report on board history",
+			Flags:  statsSummaryArgs,
+			Action: statsSummary,
+			Subcommands: []cli.Command{
+				{
+					Name:   "cfd",
+					Usage:  "print the number of cards in each list at the end of every day, for cumulative flow diagrams",
+					Flags:  statsArgs,
+					Action: statsCFD,
+				},
+				{
+					Name:   "burndown",
+					Usage:  "print the work remaining at the end of every day of a sprint window along with a mermaid chart",
+					Flags:  burndownArgs,
+					Action: statsBurndown,
+				},
+				{
+					Name:   "labels",
+					Usage:  "print how many open and done cards carry each label of the boards, unused labels included",
+					Flags:  statsLabelsArgs,
+					Action: statsLabels,
+				},
+				{
+					Name:   "flow",
+					Usage:  "print how many cards were moved from each list to each other list, as a table or a mermaid sankey diagram",
+					Flags:  statsFlowArgs,
+					Action: statsFlow,
+				},
+			},
+		},
+		{
+			Name:      "run",
+			Usage:     "run the commands bound to config file profiles with each profile's flags, export-boards when one names none",
+			ArgsUsage: "profile...",
+			Flags:     runArgs,
+			Action:    runProfiles,
+		},
+		{
+			Name:   "export-members",
+			Usage:  "render the member roster of boards or a workspace with each member's role and number of assigned cards as a markdown table",
+			Flags:  exportMembersArgs,
+			Action: exportMembers,
+		},
+		{
+			Name:   "export-automation",
+			Usage:  "render the butler commands and other data power-ups keep on boards as markdown, for handing boards over",
+			Flags:  exportAutomationArgs,
+			Action: exportAutomation,
+		},
+		{
+			Name:   "schema",
+			Usage:  "print the json schema of the export --json-output and snapshot save write, for validating exports",
+			Flags:  schemaArgs,
+			Action: schema,
+		},
+		{
+			Name:   "job",
+			Usage:  "run --job-command once configured only by TRELLO2MD_ environment variables, print a json report to stdout and exit 1 when the run failed or 2 when the job is misconfigured, for kubernetes cron jobs",
+			Flags:  jobArgs,
+			Action: runJob,
+		},
+		{
+			Name:  "template",
+			Usage: "work on card templates",
+			Subcommands: []cli.Command{
+				{
+					Name:   "preview",
+					Usage:  "render a template against a bundled sample board or a saved snapshot without calling trello",
+					Flags:  templatePreviewArgs,
+					Action: templatePreview,
+				},
+			},
+		},
+		{
+			Name:  "snapshot",
+			Usage: "keep named json snapshots of boards to see what changed between them",
+			Subcommands: []cli.Command{
+				{
+					Name:      "save",
+					Usage:     "export the boards into the snapshot directory, named after today's date unless a name is given",
+					ArgsUsage: "[name]",
+					Flags:     append(snapshotSaveArgs, exportBoardsArguments...),
+					Action:    snapshotSave,
+				},
+				{
+					Name:   "list",
+					Usage:  "list the saved snapshots oldest first",
+					Flags:  snapshotArgs,
+					Action: snapshotList,
+				},
+				{
+					Name:      "diff",
+					Usage:     "show the cards added, removed, moved and changed between two snapshots, or since a snapshot when only one is named",
+					ArgsUsage: "from [to]",
+					Flags:     append(snapshotArgs, exportBoardsArguments...),
+					Action:    snapshotDiff,
+				},
+			},
+		},
+		{
+			Name:   "authorize",
+			Usage:  "get an oauth token for the application --key and --secret, for workspaces that restrict api tokens",
+			Flags:  authorizeArgs,
+			Action: authorize,
+		},
+		{
+			Name:   "init",
+			Usage:  "write a commented starter config file and the default card template",
+			Flags:  initArgs,
+			Action: initScaffolding,
+		},
+		{
+			Name:      "completion",
+			Usage:     "print a shell completion script",
+			ArgsUsage: "bash|zsh|fish",
+			Flags:     completionArgs,
+			Action:    completion,
+		},
 	}
 
-	if err := app.Run(os.Args); err != nil {
-		log.Panic(err)
+	prefixEnvVars(app)
+
+	return app
+}
+
+func searchBoards(c *cli.Context) error {
+	err := validateSearchFormat(c.String("format"))
+	if err != nil {
+		return err
+	}
+
+	if match := trelloBoardUrl.FindStringSubmatch(c.String("board-filter")); match != nil {
+		board, err := resolveBoardUrl(c, match[1])
+		if err != nil {
+			return err
+		}
+
+		return printBoardSearchResults([]boardSearchResult{*board}, c.String("format"))
+	}
+
+	err = validateSearchSort(c.String("sort"))
+	if err != nil {
+		return err
+	}
+
+	client, err := newSearchClient(c)
+	if err != nil {
+		return err
+	}
+
+	args := trello_search.Defaults()
+	args["query"] = c.String("board-filter")
+	args["modelTypes"] = "boards"
+	args["boards_limit"] = strconv.Itoa(searchBoardsLimit)
+	args["board_fields"] = searchBoardFields
+
+	workspace := ""
+	if c.String("workspace") != "" {
+		workspace, err = resolveWorkspace(c, c.String("workspace"))
+		if err != nil {
+			return err
+		}
+
+		args["idOrganizations"] = workspace
+	}
+
+	var found struct {
+		Boards []searchBoard `json:"boards"`
+	}
+	err = client.Get("search", args, &found)
+	if err != nil {
+		return err
+	}
+	boards := found.Boards
+
+	// trello doesn't page board search results, a full response may be missing boards
+	if len(boards) >= searchBoardsLimit {
+		err = truncated("search matched at least %d boards, narrow --board-filter to see them all", searchBoardsLimit)
+		if err != nil {
+			return err
+		}
+	}
+
+	results := []boardSearchResult{}
+	for _, board := range boards {
+		if workspace != "" && board.IdOrganization != workspace {
+			continue
+		}
+
+		if c.Bool("starred") && !board.Starred {
+			continue
+		}
+
+		results = append(results, boardSearchResult{Id: board.Id, Name: board.Name, LastActivity: board.DateLastActivity})
+	}
+
+	if c.String("sort") == searchSortActivity {
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].LastActivity > results[j].LastActivity
+		})
+	}
+
+	return printBoardSearchResults(results, c.String("format"))
+}
+
+func exportBoards(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	// the profile may pick the format, a plugin, pandoc or the preview so it's applied before choosing between them
+	err = applyProfile(c, cfg)
+	if err != nil {
+		return err
+	}
+
+	err = validateFormat(c)
+	if err != nil {
+		return err
+	}
+
+	switch c.String("format") {
+	case formatDocx:
+		return exportDocx(c)
+	case formatEpub:
+		return exportEpub(c)
+	}
+
+	if c.String("via-plugin") != "" {
+		return exportPlugin(c)
+	}
+
+	if c.String("via-pandoc") != "" {
+		return exportPandoc(c)
+	}
+
+	if !c.Bool("preview") {
+		return export(c, os.Stdout, exportOptions{})
+	}
+
+	var document bytes.Buffer
+	err = export(c, &document, exportOptions{})
+	if err != nil {
+		return err
+	}
+
+	return previewMarkdown(os.Stdout, document.Bytes())
+}
+
+// exportOptions are how an export is run beyond the command's flags
+type exportOptions struct {
+	// Allowed are the boards which may be exported, every board may be when it's nil
+	Allowed []string
+	// PlainJSONOutput writes --json-output unencrypted whatever --encrypt-to is, for exports read back and removed
+	// straight away
+	PlainJSONOutput bool
+	// BoardNames is filled with the names of the exported boards keyed by their id when it's set
+	BoardNames map[string]string
+}
+
+// export runs a full export with the command's flags, a single document is written to w unless an output file
+// or split is configured
+func export(c *cli.Context, w io.Writer, opts exportOptions) (err error) {
+	defer metrics.observeExport(time.Now(), &err)
+	report := startRunReport()
+
+	var outputHash string
+	defer func() {
+		historyErr := recordHistory(c, report, outputHash, err)
+		if err == nil {
+			err = historyErr
+		}
+	}()
+
+	notifier := newProgressNotifier(c.String("notify-url"))
+	notifier.started()
+	defer func() {
+		notifier.finish(report, err)
+	}()
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	err = applyProfile(c, cfg)
+	if err != nil {
+		return err
+	}
+
+	layoutTemplate, err := layoutCardTemplate(c.String("layout"), c.String("template"))
+	if err != nil {
+		return err
+	}
+
+	err = validateFidelity(c.String("fidelity"), c.String("layout"), c.String("template"))
+	if err != nil {
+		return err
+	}
+
+	if c.String("fidelity") == fidelityFull {
+		layoutTemplate = fullFidelityCardTemplate
+	}
+
+	tmpl, err := loadCardTemplate(c.String("template"), layoutTemplate)
+	if err != nil {
+		return err
+	}
+
+	err = loadTemplatePartials(tmpl, c.String("partials-dir"))
+	if err != nil {
+		return err
+	}
+
+	var transformer *cardTransformer
+	if c.String("transform") != "" {
+		transformer, err = loadTransformer(c.String("transform"))
+		if err != nil {
+			return err
+		}
+	}
+
+	var members memberMap
+	if c.String("member-map") != "" {
+		members, err = loadMemberMap(c.String("member-map"))
+		if err != nil {
+			return err
+		}
+	}
+
+	var labels *labelMap
+	if c.String("label-map") != "" {
+		labels, err = loadLabelMap(c.String("label-map"))
+		if err != nil {
+			return err
+		}
+	}
+
+	var picked *manifest
+	if c.String("manifest") != "" {
+		picked, err = loadManifest(c.String("manifest"), cfg)
+		if err != nil {
+			return err
+		}
+	}
+
+	attachments, err := newAttachmentFilter(c)
+	if err != nil {
+		return err
+	}
+
+	err = validateLoginLinks(c.String("login-links"))
+	if err != nil {
+		return err
+	}
+
+	err = validateCommentStyle(c.String("comment-style"))
+	if err != nil {
+		return err
+	}
+
+	trackFields, err := parseTrackedFields(c.String("track-fields"))
+	if err != nil {
+		return err
+	}
+
+	bots := &botMembers{usernames: c.StringSlice("bot-username")}
+	var botComments, humanActivity *botMembers
+	if c.Bool("skip-bot-comments") {
+		botComments = bots
+	}
+	if c.Bool("human-activity") {
+		humanActivity = bots
+	}
+
+	show := showOptions{
+		LabelsAndMembers:    c.Bool("show-labels-and-members"),
+		Description:         c.Bool("show-description"),
+		Checklists:          c.Bool("show-checklists") || c.String("checklists") == checklistsAppendix,
+		Comments:            c.Bool("show-comments"),
+		Reactions:           c.Bool("show-reactions"),
+		Completion:          c.Bool("show-completion"),
+		CompletionDates:     c.String("split-by") == splitMonth || c.String("split-by") == splitQuarter,
+		Age:                 c.Bool("show-age"),
+		Attachments:         c.Bool("show-attachments"),
+		PluginData:          c.Bool("show-plugin-data"),
+		Location:            c.Bool("show-location"),
+		Stickers:            c.Bool("show-stickers"),
+		DataviewFields:      c.Bool("show-dataview-fields"),
+		Dependencies:        c.Bool("show-dependencies"),
+		Audit:               c.Bool("show-audit"),
+		CollapseDescription: c.Int("collapse-description"),
+		CollapseComments:    c.Int("collapse-comments"),
+		TitleMaxLength:      c.Int("title-max-length"),
+		CommentStyle:        c.String("comment-style"),
+		TrackFields:         trackFields,
+		ImageCaptions:       c.Bool("image-captions"),
+	}
+
+	// every card's checklist items become its sub-tasks
+	if c.String("layout") == layoutTasklist {
+		show.Checklists = true
+	}
+
+	if c.String("fidelity") == fidelityFull {
+		show = fullFidelity(show)
+	}
+
+	doneLists := c.StringSlice("done-list")
+	if len(doneLists) == 0 {
+		doneLists = []string{"Done"}
+	}
+
+	var comparison *snapshotComparison
+	if c.String("compare-snapshot") != "" {
+		comparison, err = loadSnapshotComparison(c.String("compare-snapshot"))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = validatePluginExtractors(c.StringSlice("plugin-extractor"))
+	if err != nil {
+		return err
+	}
+
+	points, err := newStoryPoints(c.String("story-points"))
+	if err != nil {
+		return err
+	}
+
+	locks, err := lockOutputs(c)
+	if err != nil {
+		return err
+	}
+	defer locks.release()
+
+	var checkpoint *exportCheckpoint
+	if c.String("state-file") != "" {
+		checkpoint, err = loadCheckpoint(c.String("state-file"), checkpointArgs(os.Args[1:]), c.Bool("resume"))
+		if err != nil {
+			return err
+		}
+	} else if c.Bool("resume") {
+		return errors.New("--resume requires --state-file")
+	}
+
+	var filter *cardFilter
+	if c.String("filter") != "" {
+		filter, err = newCardFilter(c.String("filter"))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = validateVerify(c)
+	if err != nil {
+		return err
+	}
+
+	var query exportQuery
+	if c.String("query") != "" {
+		if c.Bool("preview") || c.String("output") != "" || c.String("split-by") != "" {
+			return errors.New("--query can't be combined with --preview, --output or --split-by")
+		}
+
+		query, err = newExportQuery(c.String("query"))
+		if err != nil {
+			return err
+		}
+	}
+
+	var reopened *reopenedCards
+	if c.Bool("show-reopened") {
+		since, err := parseAge(c.String("reopened-within"))
+		if err != nil {
+			return err
+		}
+
+		reopened = &reopenedCards{doneLists: doneLists, since: since}
+	}
+
+	var staleAfter time.Duration
+	if c.String("stale-after") != "" {
+		staleAfter, err = parseAge(c.String("stale-after"))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = validateLinkStyle(c.String("link-style"), c.String("split-by"))
+	if err != nil {
+		return err
+	}
+
+	err = validateUrlStyle(c.String("url-style"))
+	if err != nil {
+		return err
+	}
+
+	err = validateChecklists(c.String("checklists"), c.String("split-by"))
+	if err != nil {
+		return err
+	}
+
+	err = validateOversize(c.Int("max-output-bytes"), c.String("oversize"), c.String("split-by"))
+	if err != nil {
+		return err
+	}
+
+	err = validateCardSeparator(c.String("card-separator"), c.String("split-by"))
+	if err != nil {
+		return err
+	}
+
+	tags := tagOptions{
+		Prefix: c.String("tag-prefix"),
+		Style:  c.String("tag-style"),
+	}
+	err = validateFrontMatter(c.Bool("front-matter"), c.String("split-by"), tags)
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("preview") && (c.String("output") != "" || c.String("split-by") != "") {
+		return errors.New("--preview can't be combined with --output or --split-by")
+	}
+
+	if c.Int("wrap") < 0 {
+		return errors.New("--wrap must be a positive number of columns")
+	}
+
+	wrap := c.Int("wrap")
+	if c.Bool("no-wrap") {
+		if wrap != 0 {
+			return errors.New("--wrap and --no-wrap can't be combined")
+		}
+		wrap = -1
+	}
+
+	out, err := newExportWriter(exportWriterOptions{
+		Split:            c.String("split-by"),
+		Dir:              c.String("output-dir"),
+		FilenameTemplate: c.String("filename-template"),
+		Output:           c.String("output"),
+		Index:            c.BoolT("index"),
+		SlugStyle:        c.String("slug-style"),
+		Wrap:             wrap,
+		Writer:           w,
+		Footnotes:        c.String("link-style") == linkStyleFootnote,
+		Chapters:         c.String("format") == formatEpub || c.String("via-pandoc") != "",
+		MaxBytes:         c.Int("max-output-bytes"),
+		Oversize:         c.String("oversize"),
+		BlankLines:       c.Bool("normalize-blank-lines"),
+	})
+	if err != nil {
+		return err
+	}
+
+	// documents embedding images download them, so only the other links need a login
+	imagesEmbedded := c.String("format") != formatMarkdown || pandocEmbeddedFormats[c.String("via-pandoc")]
+
+	var linker *wikiLinker
+	var appendix *checklistAppendix
+	if c.String("checklists") == checklistsAppendix {
+		appendix = &checklistAppendix{}
+	}
+
+	// renderCard renders the card to w, returning what was rendered so a separator can follow it
+	renderCard := func(w io.Writer, list List, number int, view *cardView) ([]byte, error) {
+		if linker != nil {
+			linker.apply(view)
+		}
+		applyUrlStyle(&view.Card, c.String("url-style"))
+		view.LinkMembers = c.String("link-style") == linkStyleFootnote
+		view.Done = containsString(doneLists, list.Name)
+		if c.Bool("number-cards") {
+			view.Number = number
+		}
+
+		if c.Bool("front-matter") {
+			err := printFrontMatter(w, view, list, tags)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if appendix != nil {
+			view = appendix.take(view)
+		}
+
+		var card bytes.Buffer
+		err := tmpl.Execute(&card, view)
+		if err != nil {
+			return nil, err
+		}
+		_, err = w.Write(card.Bytes())
+
+		return card.Bytes(), err
+	}
+
+	var stream *exportStream
+	if streamable(c, cfg, show) {
+		stream = &exportStream{
+			out:            out,
+			render:         renderCard,
+			separator:      c.String("card-separator"),
+			loginLinks:     c.String("login-links"),
+			imagesEmbedded: imagesEmbedded,
+		}
+		out.start()
+	}
+
+	exports, err := fetchExports(c, cfg, fetchOptions{
+		ListFilters:      listFilters(c),
+		AllLists:         c.Bool("all-lists"),
+		Sort:             c.String("sort"),
+		BoardDiagram:     c.Bool("board-diagram"),
+		FieldDefinitions: c.Bool("show-custom-field-definitions"),
+		Show:             show,
+		StaleAfter:       staleAfter,
+		Reopened:         reopened,
+		IncludeTemplates: c.Bool("include-templates"),
+		StoryPoints:      points,
+		Checkpoint:       checkpoint,
+		PluginExtractors: c.StringSlice("plugin-extractor"),
+		ConvertHTML:      c.Bool("convert-html"),
+		AllowedBoards:    opts.Allowed,
+		Filter:           filter,
+		Transformer:      transformer,
+		MemberMap:        members,
+		LabelMap:         labels,
+		Manifest:         picked,
+		AttachmentFilter: attachments,
+		BotComments:      botComments,
+		HumanActivity:    humanActivity,
+		ASCII:            c.Bool("ascii"),
+		Progress:         notifier,
+		Stream:           stream,
+	})
+	if err != nil {
+		// keep whatever was fetched before the failure for --resume
+		if saveErr := checkpoint.save(); saveErr != nil {
+			log.Printf("unable to checkpoint the export: %v", saveErr)
+		}
+
+		return err
+	}
+
+	if opts.BoardNames != nil {
+		for _, boardExport := range exports {
+			if boardExport.board.Id != "" {
+				opts.BoardNames[boardExport.board.Id] = boardExport.board.Name
+			}
+		}
+	}
+
+	if c.Bool("verify") {
+		err = verifyExports(newBoardClients(c, cfg), exports, show)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.String("login-links") == loginLinksAnnotate {
+		annotateLoginLinks(exports, imagesEmbedded)
+	}
+
+	if c.String("json-output") != "" {
+		err = writeExportJSON(c.String("json-output"), exports, !opts.PlainJSONOutput)
+		if err != nil {
+			return err
+		}
+	}
+
+	if query != nil {
+		err = query.run(w, exports)
+		if err != nil {
+			return err
+		}
+
+		return checkpoint.finish()
+	}
+
+	if c.String("link-style") == linkStyleWikilink {
+		linker = newWikiLinker(exports)
+	}
+
+	// streamed boards were rendered as they were fetched
+	unrendered := exports
+	if stream != nil {
+		unrendered = nil
+	} else {
+		out.start()
+	}
+
+	for _, boardExport := range unrendered {
+		w, err := out.openBoard(boardExport.board)
+		if err != nil {
+			return err
+		}
+
+		if comparison != nil {
+			printBoardDeltas(w, comparison.deltas(boardExport, doneLists), comparison.saved)
+		}
+
+		if boardExport.audit != nil {
+			printBoardAudit(w, boardExport.audit)
+		}
+
+		if boardExport.structure != nil {
+			printBoardDiagram(w, boardExport.structure)
+		}
+
+		if len(boardExport.customFields) > 0 {
+			printCustomFieldDefinitions(w, boardExport.customFields)
+		}
+
+		if c.Bool("due-calendar") {
+			printDueCalendar(w, boardExport.lists)
+		}
+
+		for _, listExport := range boardExport.lists {
+			lw, err := out.openList(boardExport.board, listExport.list)
+			if err != nil {
+				return err
+			}
+
+			if boardExport.headings {
+				printList(lw, listExport, c.Bool("show-list-counts"))
+			}
+
+			for i, view := range listExport.cards {
+				w, err := out.openCard(boardExport.board, listExport.list, view)
+				if err != nil {
+					return err
+				}
+
+				card, err := renderCard(w, listExport.list, i+1, view)
+				if err != nil {
+					return err
+				}
+
+				if separator := c.String("card-separator"); separator != "" && i < len(listExport.cards)-1 {
+					printCardSeparator(w, card, separator)
+				}
+			}
+
+			if points != nil {
+				printPointsTotal(lw, listExport.list.Name, totalPoints(listExport.cards))
+			}
+		}
+
+		if points != nil {
+			boardTotal := 0.0
+			for _, listExport := range boardExport.lists {
+				boardTotal += totalPoints(listExport.cards)
+			}
+
+			printPointsTotal(w, boardExport.board.Name, boardTotal)
+		}
+
+		printReopened(w, boardExport.reopened)
+
+		if appendix != nil {
+			appendix.print(w)
+		}
+	}
+
+	footer, err := renderFooter(c, out.date, exports)
+	if err != nil {
+		return err
+	}
+	out.end(footer)
+
+	err = out.close()
+	if err != nil {
+		return err
+	}
+	outputHash = out.sum()
+
+	err = checkpoint.finish()
+	if err != nil {
+		return err
+	}
+
+	if c.String("login-links") == loginLinksWarn && stream == nil {
+		warnLoginLinks(findLoginLinks(exports, imagesEmbedded))
+	}
+
+	report.finish(exports)
+	jobRuns.add(report)
+	log.Print(report)
+	if c.String("run-report") != "" {
+		return report.write(c.String("run-report"))
+	}
+
+	return nil
+}
+
+// checkpointArgs are the arguments identifying an export, leaving out whether it's being resumed
+func checkpointArgs(args []string) []string {
+	var identifying []string
+	for _, arg := range args {
+		if strings.TrimLeft(strings.SplitN(arg, "=", 2)[0], "-") == "resume" {
+			continue
+		}
+
+		identifying = append(identifying, arg)
+	}
+
+	return identifying
+}
+
+// fetchExports fetches every board selected by the command's flags, ordered and merged as requested
+func fetchExports(c *cli.Context, cfg *config, opts fetchOptions) ([]*boardExport, error) {
+	if c.Bool("dedupe") && !c.Bool("merge-boards") {
+		return nil, errors.New("--dedupe requires --merge-boards")
+	}
+
+	if c.Bool("sections") && len(cfg.Sections) == 0 {
+		return nil, errors.New("--sections needs sections defined in the config file")
+	}
+
+	if c.IsSet("list-index") {
+		if opts.AllLists {
+			return nil, errors.New("--list-index selects a single list and can't be combined with --all-lists")
+		}
+
+		index := c.Int("list-index")
+		opts.ListIndex = &index
 	}
-}
 
-func searchBoards(c *cli.Context) error {
-	client := trello_search.NewClient(c.GlobalString("key"), c.GlobalString("token"))
+	var exports []*boardExport
+	var err error
+	if paths := c.StringSlice("from-trello-json"); len(paths) > 0 {
+		err = validateTrelloJSON(c, opts)
+		if err != nil {
+			return nil, err
+		}
 
-	boards, err := client.SearchBoards(c.String("board-filter"), trello_search.Defaults())
+		exports, err = loadTrelloJSONExports(paths, opts)
+	} else {
+		exports, err = fetchAPIExports(c, cfg, opts)
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	for _, board := range boards {
-		fmt.Printf("%s - %s\n", board.ID, board.Name)
+	err = sortBoardExports(exports, c.String("board-order"), cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	if c.Bool("merge-boards") {
+		exports = mergeBoardExports(exports, c.Bool("dedupe"))
+	}
+
+	if c.Bool("sections") {
+		cfg.Sections.regroup(exports)
+	}
+
+	return exports, nil
 }
 
-func exportBoards(c *cli.Context) error {
-	token := c.GlobalString("token")
-	client, err := trello.NewAuthClient(c.GlobalString("key"), &token)
-	if err != nil {
-		return err
+// fetchAPIExports fetches the boards to export from the trello api
+func fetchAPIExports(c *cli.Context, cfg *config, opts fetchOptions) ([]*boardExport, error) {
+	boardIds := opts.Manifest.boards(c.StringSlice("board-id"), cfg)
+	if opts.AllowedBoards != nil {
+		var allowed []string
+		for _, boardId := range boardIds {
+			if containsString(opts.AllowedBoards, cfg.boardID(boardId)) {
+				allowed = append(allowed, boardId)
+			}
+		}
+		boardIds = allowed
 	}
 
-	printDate()
+	clients := newBoardClients(c, cfg)
+	getBoardsFunc := getBoards
+	if c.Bool("skip-missing-boards") {
+		getBoardsFunc = getAvailableBoards
+	}
 
-	boards, err := getBoards(client, c.StringSlice("board-id"))
+	boards, err := getBoardsFunc(clients, boardIds)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	for _, board := range *boards {
-		printBoard(board)
+	// streamed boards are rendered as they're fetched so they can't be fetched side by side
+	concurrency := boardConcurrency(c)
+	if opts.Stream != nil {
+		concurrency = 1
+	}
 
-		list, err := getList(&board, c.String("list-filter"))
+	return fetchBoards(*boards, concurrency, func(board trello.Board) (*boardExport, error) {
+		client, err := clients.forBoard(board.Id)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		cards, err := getCards(list)
+		export, err := fetchBoard(client, board, opts)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		opts.Progress.board(board.Name, len(*boards))
 
-		for _, card := range *cards {
-			err := printCardTitle(&card)
-			if err != nil {
-				return err
-			}
-
-			if c.Bool("show-labels-and-members") {
-				err = printCardLabelsAndMembers(&card)
-				if err != nil {
-					return err
-				}
-			}
-
-			if c.Bool("show-description") {
-				printCardDescription(&card)
-			}
-
-			if c.Bool("show-attachments") {
-				attachments, err := getCardAttachments(&card)
-				if err != nil {
-					return err
-				}
-
-				for _, attachment := range *attachments {
-					printCardAttachment(&attachment)
-				}
-			}
+		return export, nil
+	})
+}
 
-			if c.Bool("show-checklists") {
-				checklists, err := getCardCheckLists(&card)
-				if err != nil {
-					return err
-				}
+func printDate(w io.Writer, date time.Time) {
+	fmt.Fprintf(w, "## %s\n", date.Format(dateFormat))
+}
 
-				for _, checklist := range *checklists {
-					printCardChecklist(&checklist)
-				}
-			}
+func getBoards(clients *boardClients, boardIds []string) (*[]trello.Board, error) {
+	var boards []trello.Board
+	for _, boardId := range boardIds {
+		boardId = clients.config.boardID(boardId)
+		client, err := clients.forBoard(boardId)
+		if err != nil {
+			return nil, err
+		}
 
-			if c.Bool("show-comments") {
-				commentActions, err := getCardComments(&card)
-				if err != nil {
-					return err
-				}
+		err = clients.preflight(boardId)
+		if err != nil {
+			return nil, err
+		}
 
-				for _, commentAction := range *commentActions {
-					err := printCardComment(&commentAction)
-					if err != nil {
-						return err
-					}
-				}
-			}
+		board, err := client.Board(boardId)
+		if err != nil {
+			return nil, clients.accessError(boardId, err)
 		}
-	}
 
-	return nil
-}
+		boards = append(boards, *board)
+	}
 
-func printDate() {
-	fmt.Printf("## %s\n", time.Now().Format(dateFormat))
+	return &boards, nil
 }
 
-func getBoards(client *trello.Client, boardIds []string) (*[]trello.Board, error) {
+// getAvailableBoards returns the boards that can still be read, warning about those that were deleted or the
+// token lost access to
+func getAvailableBoards(clients *boardClients, boardIds []string) (*[]trello.Board, error) {
 	var boards []trello.Board
 	for _, boardId := range boardIds {
-		board, err := client.Board(boardId)
+		found, err := getBoards(clients, []string{boardId})
 		if err != nil {
+			switch apiStatus(err) {
+			case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+				warnings.add(WarningSkipped, boardId, fmt.Sprintf("skipping board %s, it was deleted or can't be read: %s", boardId, err))
+				continue
+			}
+
 			return nil, err
 		}
 
-		boards = append(boards, *board)
+		boards = append(boards, *found...)
+	}
+
+	if len(boards) == 0 && len(boardIds) > 0 {
+		return nil, errors.New("none of the boards could be read")
 	}
 
 	return &boards, nil
 }
 
-func printBoard(board trello.Board) {
-	fmt.Printf("### %s\n", board.Name)
+func printBoard(w io.Writer, board Board) {
+	fmt.Fprintf(w, "### %s\n", board.Name)
+}
+
+func printList(w io.Writer, list *listExport, showCount bool) {
+	if showCount {
+		fmt.Fprintf(w, "### %s (%d)\n", list.list.Name, len(list.cards))
+		return
+	}
+
+	fmt.Fprintf(w, "### %s\n", list.list.Name)
+}
+
+// getLists returns the open lists of a board ordered left to right as they appear on the board
+func getLists(board *trello.Board) ([]trello.List, error) {
+	lists, err := board.Lists()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(lists, func(i, j int) bool {
+		return lists[i].Pos < lists[j].Pos
+	})
+
+	return lists, nil
 }
 
 func getList(board *trello.Board, listFilter string) (*trello.List, error) {
@@ -247,138 +2015,438 @@ func getList(board *trello.Board, listFilter string) (*trello.List, error) {
 	return nil, errors.New("no matching list found")
 }
 
-func getCards(list *trello.List) (*[]trello.Card, error) {
-	cards, err := list.Cards()
+// listFilters are the names of the lists to export, Done when none are given
+func listFilters(c *cli.Context) []string {
+	filters := c.StringSlice("list-filter")
+	if len(filters) == 0 {
+		return []string{"Done"}
+	}
+
+	return filters
+}
+
+// listsNamed returns the list matching each name in the order the names are given
+func listsNamed(boardName string, lists []trello.List, names []string) ([]trello.List, error) {
+	var matched []trello.List
+	for _, name := range names {
+		found := false
+		for _, list := range lists {
+			if list.Name == name {
+				matched = append(matched, list)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, errors.Errorf("no matching list found for %s on %s", name, boardName)
+		}
+	}
+
+	return matched, nil
+}
+
+// listAt returns the open list at the position counting from the left, or from the right when negative
+func listAt(boardName string, lists []trello.List, index int) (*trello.List, error) {
+	position := index
+	if position < 0 {
+		position += len(lists)
+	}
+
+	if position < 0 || position >= len(lists) {
+		return nil, errors.Errorf("%s has %d open lists, there is no list at index %d", boardName, len(lists), index)
+	}
+
+	return &lists[position], nil
+}
+
+// truncated reports results trello cut short, as a warning unless --fail-on-truncation is set
+func truncated(format string, args ...interface{}) error {
+	if failOnTruncation {
+		return errors.Errorf(format, args...)
+	}
+
+	warnings.add(WarningTruncated, "", fmt.Sprintf(format, args...))
+	return nil
+}
+
+// getCards fetches every card of the list, a page at a time, in the requested order
+func getCards(client *trello.Client, list *trello.List, sortBy string, includeTemplates bool) (*[]trello.Card, error) {
+	err := validateCardSort(sortBy)
+	if err != nil {
+		return nil, err
+	}
+
+	var cards []trello.Card
+	err = eachCardPage(client, list, includeTemplates, func(page []trello.Card) error {
+		cards = append(cards, page...)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	sort.Slice(cards, func(i, j int) bool {
-		iDate, err := time.Parse(time.RFC3339, cards[i].DateLastActivity)
+	sort.SliceStable(cards, func(i, j int) bool {
+		a, b := newCard(cards[i]), newCard(cards[j])
+		return cardLess(&a, &b, sortBy)
+	})
+
+	return &cards, nil
+}
+
+// eachCardPage fetches the open cards of the list in pages so lists larger than a single response aren't
+// truncated, fn is called with each page as it arrives, card templates are skipped unless includeTemplates is set
+func eachCardPage(client *trello.Client, list *trello.List, includeTemplates bool, fn func([]trello.Card) error) error {
+	seen := map[string]bool{}
+
+	before := ""
+	for {
+		query := url.Values{}
+		query.Set("limit", strconv.Itoa(cardsPageSize))
+		if before != "" {
+			query.Set("before", before)
+		}
+
+		body, err := client.Get("/lists/" + list.Id + "/cards?" + query.Encode())
 		if err != nil {
-			log.Panic(err)
+			return err
 		}
 
-		jDate, err := time.Parse(time.RFC3339, cards[j].DateLastActivity)
+		var page []trello.Card
+		err = json.Unmarshal(body, &page)
 		if err != nil {
-			log.Panic(err)
+			return errors.Wrapf(err, "unable to read the cards of list %s", list.Name)
 		}
 
-		return iDate.Before(jDate)
-	})
+		// the trello client's cards don't say whether they're templates
+		var templates []struct {
+			IsTemplate bool `json:"isTemplate"`
+		}
+		err = json.Unmarshal(body, &templates)
+		if err != nil {
+			return errors.Wrapf(err, "unable to read the cards of list %s", list.Name)
+		}
 
-	return &cards, nil
+		// pages overlap when cards are added while paging, only unseen cards are handed on
+		var unseen []trello.Card
+		oldest := ""
+		for i, card := range page {
+			if oldest == "" || card.Id < oldest {
+				oldest = card.Id
+			}
+
+			if templates[i].IsTemplate && !includeTemplates {
+				continue
+			}
+
+			if !seen[card.Id] {
+				seen[card.Id] = true
+				unseen = append(unseen, card)
+			}
+		}
+
+		err = fn(unseen)
+		if err != nil {
+			return err
+		}
+
+		if len(page) < cardsPageSize || oldest == before {
+			return nil
+		}
+		before = oldest
+	}
+}
+
+func validateCardSort(sortBy string) error {
+	switch sortBy {
+	case cardSortDate, cardSortPosition, cardSortName:
+		return nil
+	default:
+		return errors.Errorf("unsupported sort %q, expected one of date, position or name", sortBy)
+	}
+}
+
+// cardLess orders cards by last activity, by their position in the list or by name
+func cardLess(a *Card, b *Card, sortBy string) bool {
+	switch sortBy {
+	case cardSortPosition:
+		return a.Pos < b.Pos
+	case cardSortName:
+		return collation.less(a.Name, b.Name)
+	}
+
+	return dateLess(parseTrelloDate(a.DateLastActivity, "card "+a.Name), parseTrelloDate(b.DateLastActivity, "card "+b.Name))
 }
 
-func printCardTitle(card *trello.Card) error {
-	lastActivity, err := time.Parse(time.RFC3339, card.DateLastActivity)
+// getCardMembers fetches the members assigned to the card
+func getCardMembers(client *trello.Client, card *trello.Card) ([]trello.Member, error) {
+	body, err := client.Get("/cards/" + card.Id + "/members")
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	var members []trello.Member
+	err = json.Unmarshal(body, &members)
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("#### **%s** [%s](%s)\n", lastActivity.Format(dateFormat), card.Name, card.Url)
+	return members, nil
+}
 
-	return nil
+func getCardCheckLists(client *trello.Client, card *trello.Card) (*[]trello.Checklist, error) {
+	body, err := client.Get("/cards/" + card.Id + "/checklists")
+	if err != nil {
+		return nil, err
+	}
+
+	var checklists []trello.Checklist
+	err = json.Unmarshal(body, &checklists)
+	if err != nil {
+		return nil, err
+	}
+
+	return &checklists, nil
 }
 
-func printCardLabelsAndMembers(card *trello.Card) error {
-	fmt.Printf("##### ")
-	for _, label := range card.Labels {
-		fmt.Printf("`%s` ", label.Name)
+// cardComment is a comment action along with the power-up which posted it, butler and power-ups post comments
+// on behalf of the member who set them up
+type cardComment struct {
+	trello.Action
+	AppCreator *appCreator `json:"appCreator"`
+}
+
+func getCardComments(client *trello.Client, card *trello.Card) (*[]cardComment, error) {
+	body, err := client.Get("/cards/" + card.Id + "/actions?filter=" + commentCardAction + "&limit=" + strconv.Itoa(cardActionsLimit))
+	if err != nil {
+		return nil, err
 	}
 
-	members, err := card.Members()
+	var actions []cardComment
+	err = json.Unmarshal(body, &actions)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if len(actions) >= cardActionsLimit {
+		err = truncated("card %s has more than %d comments, only the latest are exported", card.Name, cardActionsLimit)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	var memberNames []string
-	for _, member := range members {
-		memberNames = append(memberNames, member.FullName)
+	var commentCardActions []cardComment
+	for _, action := range actions {
+		if action.Type == commentCardAction {
+			commentCardActions = append(commentCardActions, action)
+		}
 	}
 
-	fmt.Printf("- **[%s]**\n", strings.Join(memberNames, ", "))
+	sortCommentsByDate(commentCardActions)
 
-	return nil
+	return &commentCardActions, nil
+}
+
+// sortCommentsByDate orders comments oldest first, comments with malformed dates last
+func sortCommentsByDate(actions []cardComment) {
+	sort.Slice(actions, func(i, j int) bool {
+		return dateLess(parseTrelloDate(actions[i].Date, "comment "+actions[i].Id), parseTrelloDate(actions[j].Date, "comment "+actions[j].Id))
+	})
 }
 
-func printCardDescription(card *trello.Card) {
-	fmt.Printf("%s\n\n", card.Desc)
+// cardCompletion is when and by whom a card was completed
+type cardCompletion struct {
+	Date string
+	By   string
+	// username is the trello username of the member who completed the card
+	username string
 }
 
-func getCardCheckLists(card *trello.Card) (*[]trello.Checklist, error) {
-	checklists, err := card.Checklists()
+type cardUpdateAction struct {
+	Type string `json:"type"`
+	Date string `json:"date"`
+	Data struct {
+		Card struct {
+			Id          string `json:"id"`
+			DueComplete *bool  `json:"dueComplete"`
+		} `json:"card"`
+		ListAfter *struct {
+			Id   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"listAfter"`
+	} `json:"data"`
+	MemberCreator struct {
+		FullName string `json:"fullName"`
+		Username string `json:"username"`
+	} `json:"memberCreator"`
+}
+
+// getCardCompletion finds the action which completed the card, marking the due date complete wins over the card's
+// last list move, nil is returned when neither happened
+func getCardCompletion(client *trello.Client, card *trello.Card) (*cardCompletion, error) {
+	body, err := client.Get("/cards/" + card.Id + "/actions?filter=updateCard:idList,updateCard:dueComplete")
 	if err != nil {
 		return nil, err
 	}
 
-	return &checklists, nil
+	var actions []cardUpdateAction
+	err = json.Unmarshal(body, &actions)
+	if err != nil {
+		return nil, err
+	}
+
+	return cardCompletionOf(actions), nil
 }
 
-func printCardChecklist(checklist *trello.Checklist) {
-	fmt.Printf("%s\n", checklist.Name)
-	for _, checkItem := range checklist.CheckItems {
-		if checkItem.State == "complete" {
-			fmt.Printf("- [x] %s\n", checkItem.Name)
-		} else {
-			fmt.Printf("- [ ] %s\n", checkItem.Name)
+// cardCompletionOf finds the completing action among the card's updates, ordered newest first
+func cardCompletionOf(actions []cardUpdateAction) *cardCompletion {
+	var lastMove *cardUpdateAction
+	for i, action := range actions {
+		if action.Data.Card.DueComplete != nil {
+			if !*action.Data.Card.DueComplete {
+				break
+			}
+
+			return &cardCompletion{Date: action.Date, By: action.MemberCreator.FullName, username: action.MemberCreator.Username}
+		}
+
+		if action.Data.ListAfter != nil && lastMove == nil {
+			lastMove = &actions[i]
 		}
 	}
-	fmt.Printf("\n")
+
+	if lastMove == nil {
+		return nil
+	}
+
+	return &cardCompletion{Date: lastMove.Date, By: lastMove.MemberCreator.FullName, username: lastMove.MemberCreator.Username}
 }
 
-func getCardComments(card *trello.Card) (*[]trello.Action, error) {
-	actions, err := card.Actions()
+// getCardListEntered returns when the card entered its current list, falling back to when it was created
+func getCardListEntered(client *trello.Client, card *trello.Card) (time.Time, error) {
+	body, err := client.Get("/cards/" + card.Id + "/actions?filter=updateCard:idList,createCard,copyCard,moveCardToBoard")
 	if err != nil {
-		return nil, err
+		return time.Time{}, err
+	}
+
+	var actions []cardUpdateAction
+	err = json.Unmarshal(body, &actions)
+	if err != nil {
+		return time.Time{}, err
 	}
 
-	var commentCardActions []trello.Action
+	return cardListEntered(card, actions)
+}
+
+// cardListEntered finds when the card entered its list among its moves and creation, ordered newest first
+func cardListEntered(card *trello.Card, actions []cardUpdateAction) (time.Time, error) {
+	// actions are returned newest first, the card entered its list with the latest move into it or its creation
 	for _, action := range actions {
-		if action.Type == commentCardAction {
-			commentCardActions = append(commentCardActions, action)
+		moved := action.Type == "updateCard" && action.Data.ListAfter != nil && action.Data.ListAfter.Id == card.IdList
+		created := action.Type == "createCard" || action.Type == "copyCard" || action.Type == "moveCardToBoard"
+		if moved || created {
+			entered := parseTrelloDate(action.Date, action.Type+" action of card "+card.Name)
+			if entered.IsZero() {
+				continue
+			}
+
+			return entered, nil
 		}
 	}
 
-	sort.Slice(commentCardActions, func(i, j int) bool {
-		iDate, err := time.Parse(time.RFC3339, commentCardActions[i].Date)
-		if err != nil {
-			log.Panic(err)
-		}
+	return cardCreated(card)
+}
+
+// cardCreated reads when the card was created from its id
+func cardCreated(card *trello.Card) (time.Time, error) {
+	return idCreated(card.Id)
+}
+
+// idCreated reads when a card or other trello object was created from its id, the first eight hex characters of a
+// trello id are the unix time it was created at
+func idCreated(id string) (time.Time, error) {
+	if len(id) < 8 {
+		return time.Time{}, errors.Errorf("unable to determine when card %s was created", id)
+	}
+
+	created, err := strconv.ParseInt(id[:8], 16, 64)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "unable to determine when card %s was created", id)
+	}
+
+	return time.Unix(created, 0), nil
+}
 
-		jDate, err := time.Parse(time.RFC3339, commentCardActions[j].Date)
+// parseAge parses a duration which may also be given in whole days, e.g. 14d
+func parseAge(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
 		if err != nil {
-			log.Panic(err)
+			return 0, errors.Errorf("invalid age %q", s)
 		}
 
-		return iDate.Before(jDate)
-	})
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
 
-	return &commentCardActions, nil
+	return time.ParseDuration(s)
+}
+
+// sticker is a sticker placed on a card
+type sticker struct {
+	Id       string `json:"id"`
+	Image    string `json:"image"`
+	ImageUrl string `json:"imageUrl"`
 }
 
-func printCardComment(commentAction *trello.Action) error {
-	actionDate, err := time.Parse(time.RFC3339, commentAction.Date)
+func getCardStickers(client *trello.Client, card *trello.Card) ([]sticker, error) {
+	body, err := client.Get("/cards/" + card.Id + "/stickers")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	fmt.Printf("> **%s** - **%s:**\n", actionDate.Format(dateFormat), commentAction.MemberCreator.FullName)
-	fmt.Printf("> %s\n\n", strings.Replace(commentAction.Data.Text, "\n", "\n> ", -1))
+	var stickers []sticker
+	err = json.Unmarshal(body, &stickers)
+	if err != nil {
+		return nil, err
+	}
 
-	return nil
+	return stickers, nil
+}
+
+type reaction struct {
+	Count int `json:"count"`
+	Emoji struct {
+		Native    string `json:"native"`
+		ShortName string `json:"shortName"`
+	} `json:"emoji"`
 }
 
-func getCardAttachments(card *trello.Card) (*[]trello.Attachment, error) {
-	attachments, err := card.Attachments()
+func getCommentReactions(client *trello.Client, commentAction *trello.Action) ([]reaction, error) {
+	body, err := client.Get("/actions/" + commentAction.Id + "/reactionsSummary")
 	if err != nil {
 		return nil, err
 	}
 
-	return &attachments, nil
+	var reactions []reaction
+	err = json.Unmarshal(body, &reactions)
+	if err != nil {
+		return nil, err
+	}
+
+	return reactions, nil
 }
 
-func printCardAttachment(attatchment *trello.Attachment) {
-	fmt.Printf("[%s](%s)\n", attatchment.Name, attatchment.Url)
-	fmt.Printf("![](%s)\n\n", attatchment.Url)
-}
\ No newline at end of file
+func getCardAttachments(client *trello.Client, card *trello.Card) (*[]trello.Attachment, error) {
+	body, err := client.Get("/cards/" + card.Id + "/attachments")
+	if err != nil {
+		return nil, err
+	}
+
+	var attachments []trello.Attachment
+	err = json.Unmarshal(body, &attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	return &attachments, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		age  string
+		want time.Duration
+		err  bool
+	}{
+		{age: "14d", want: 14 * 24 * time.Hour},
+		{age: "0d", want: 0},
+		{age: "36h", want: 36 * time.Hour},
+		{age: "90m", want: 90 * time.Minute},
+		{age: "d", err: true},
+		{age: "1.5d", err: true},
+		{age: "two weeks", err: true},
+		{age: "", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.age, func(t *testing.T) {
+			got, err := parseAge(tt.age)
+			if tt.err {
+				if err == nil {
+					t.Errorf("parseAge(%q) = %s, want an error", tt.age, got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseAge(%q) returned %v", tt.age, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseAge(%q) = %s, want %s", tt.age, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestExportBoardsProfileFormat checks a format picked by the profile is used rather than the markdown default
+func TestExportBoardsProfileFormat(t *testing.T) {
+	server := replayFixtures(t)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "trello2md-profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	output := filepath.Join(dir, "report.docx")
+	configPath := filepath.Join(dir, "config.yaml")
+	err = ioutil.WriteFile(configPath, []byte("profiles:\n  report:\n    format: docx\n    output: "+output+"\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// attachments are left out so the document doesn't embed images fetched from trello
+	err = newApp().Run([]string{appName, "--api-base-url", server.URL + "/1", "--key", "k", "--token", "t",
+		"--config", configPath, "export-boards", "--profile", "report", "--board-id", "b1", "--board-id", "b2",
+		"--show-labels-and-members", "--show-description"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	document, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(document, []byte("PK\x03\x04")) {
+		t.Errorf("%s isn't a word document, it starts with %q", output, document[:20])
+	}
+}
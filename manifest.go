@@ -0,0 +1,115 @@
+package main
+
+import (
+	"io/ioutil"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// manifest lists exactly which boards, lists and cards an export includes and leaves out, for exports whose content
+// is edited by hand and kept in git alongside the report
+type manifest struct {
+	// Include limits the export to these boards, lists and cards, everything is included when a kind is left empty
+	Include manifestEntries `yaml:"include"`
+	// Exclude leaves these boards, lists and cards out even when they're included
+	Exclude manifestEntries `yaml:"exclude"`
+}
+
+type manifestEntries struct {
+	// Boards are board ids or aliases
+	Boards []string `yaml:"boards"`
+	// Lists are list names or ids
+	Lists []string `yaml:"lists"`
+	// Cards are card ids or short links
+	Cards []string `yaml:"cards"`
+}
+
+// loadManifest reads the manifest, board aliases are resolved to the board ids they stand for
+func loadManifest(path string, cfg *config) (*manifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read manifest")
+	}
+
+	var m manifest
+	err = yaml.UnmarshalStrict(data, &m)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse manifest %s", path)
+	}
+
+	for _, entries := range []*manifestEntries{&m.Include, &m.Exclude} {
+		for i, board := range entries.Boards {
+			entries.Boards[i] = cfg.boardID(board)
+		}
+	}
+
+	return &m, nil
+}
+
+// boards returns the board ids to export, the manifest's included boards when none were given
+func (m *manifest) boards(boardIds []string, cfg *config) []string {
+	if m == nil {
+		return boardIds
+	}
+
+	if len(boardIds) == 0 {
+		boardIds = m.Include.Boards
+	}
+
+	var kept []string
+	for _, boardId := range boardIds {
+		if m.keepsBoard(cfg.boardID(boardId)) {
+			kept = append(kept, boardId)
+		}
+	}
+
+	return kept
+}
+
+func (m *manifest) keepsBoard(boardId string) bool {
+	if m == nil {
+		return true
+	}
+
+	return m.keeps(m.Include.Boards, m.Exclude.Boards, boardId)
+}
+
+// lists drops the lists the manifest leaves out from those selected for export
+func (m *manifest) lists(lists []trello.List) []trello.List {
+	if m == nil {
+		return lists
+	}
+
+	var kept []trello.List
+	for _, list := range lists {
+		if m.keeps(m.Include.Lists, m.Exclude.Lists, list.Name, list.Id) {
+			kept = append(kept, list)
+		}
+	}
+
+	return kept
+}
+
+func (m *manifest) keepsCard(card *trello.Card) bool {
+	if m == nil {
+		return true
+	}
+
+	return m.keeps(m.Include.Cards, m.Exclude.Cards, card.Id, card.ShortLink)
+}
+
+// keeps is true when one of the keys is included, or nothing is, and none is excluded
+func (m *manifest) keeps(include []string, exclude []string, keys ...string) bool {
+	included := len(include) == 0
+	for _, key := range keys {
+		if containsString(exclude, key) {
+			return false
+		}
+
+		included = included || containsString(include, key)
+	}
+
+	return included
+}
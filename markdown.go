@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	formatMarkdown = "markdown"
+	formatDocx     = "docx"
+	formatEpub     = "epub"
+)
+
+var (
+	markdownDetails = regexp.MustCompile(`^\s*</?details>\s*$`)
+	markdownSummary = regexp.MustCompile(`^\s*<summary>(.*)</summary>\s*$`)
+	// markdownInline matches the inline markdown trello2md generates, images, links, bold, code and italics
+	markdownInline    = regexp.MustCompile("!\\[([^\\]]*)\\]\\(([^)\\s]+)(?:\\s+\"[^\"]*\")?\\)|\\[([^\\]]+)\\]\\(([^)\\s]+)\\)|\\*\\*(.+?)\\*\\*|`([^`]+)`|\\b_([^_]+)_\\b")
+	markdownTableRow  = regexp.MustCompile(`^\s*\|(.*)\|\s*$`)
+	markdownTableRule = regexp.MustCompile(`^[\s|:-]+$`)
+	// tableCellEscaper keeps text within a single markdown table cell, pipes would start a new cell and line breaks
+	// a new row
+	tableCellEscaper = strings.NewReplacer(
+		"|", `\|`,
+		"\r\n", "<br>",
+		"\n", "<br>",
+	)
+	// tableCellUnescaper turns a cell written by tableCell back into its text
+	tableCellUnescaper = strings.NewReplacer(
+		`\|`, "|",
+		"<br>", " ",
+	)
+)
+
+// tableCell escapes text for a markdown table cell so card content containing pipes or line breaks can't break
+// the table apart
+func tableCell(text string) string {
+	return tableCellEscaper.Replace(strings.TrimSpace(text))
+}
+
+// splitTableRow splits the cells of a markdown table row at its unescaped pipes
+func splitTableRow(row string) []string {
+	var cells []string
+	start := 0
+	for i := 0; i < len(row); i++ {
+		switch row[i] {
+		case '\\':
+			i++
+		case '|':
+			cells = append(cells, tableCellUnescaper.Replace(row[start:i]))
+			start = i + 1
+		}
+	}
+
+	return append(cells, tableCellUnescaper.Replace(row[start:]))
+}
+
+// imageFetcher downloads an image for embedding in a document
+type imageFetcher func(url string) ([]byte, error)
+
+func validateFormat(c *cli.Context) error {
+	format := c.String("format")
+	pandoc := c.String("via-pandoc")
+	if pandoc != "" && format != formatMarkdown {
+		return errors.Errorf("--via-pandoc converts the markdown and can't be combined with --format %s", format)
+	}
+
+	if c.String("via-plugin") != "" && (pandoc != "" || format != formatMarkdown) {
+		return errors.New("--via-plugin renders the document itself and can't be combined with --via-pandoc or --format")
+	}
+
+	switch format {
+	case formatMarkdown:
+		if pandoc == "" {
+			return nil
+		}
+
+		return validateDocument(c, "--via-pandoc "+pandoc)
+	case formatDocx, formatEpub:
+		return validateDocument(c, "--format "+format)
+	default:
+		return errors.Errorf("unsupported format %q, expected markdown, docx or epub", format)
+	}
+}
+
+// validateDocument checks the flags of an option that converts the export into a document written to --output
+func validateDocument(c *cli.Context, option string) error {
+	if c.String("output") == "" {
+		return errors.Errorf("%s writes a binary document and needs --output", option)
+	}
+
+	if c.Bool("preview") || c.String("split-by") != "" || c.String("query") != "" {
+		return errors.Errorf("%s can't be combined with --preview, --split-by or --query", option)
+	}
+
+	if c.Int("max-output-bytes") > 0 {
+		return errors.Errorf("%s writes a binary document and can't be combined with --max-output-bytes", option)
+	}
+
+	return nil
+}
+
+// renderDocument renders the export as a single markdown document for conversion into another format, returning
+// it along with the --output path the converted document is written to
+func renderDocument(c *cli.Context) ([]byte, string, error) {
+	path := c.String("output")
+	err := c.Set("output", "")
+	if err != nil {
+		return nil, "", err
+	}
+
+	var document bytes.Buffer
+	err = export(c, &document, exportOptions{})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return document.Bytes(), path, nil
+}
+
+// newImageFetcher downloads images with the global credentials, which are only sent to trello
+func newImageFetcher(c *cli.Context) (imageFetcher, error) {
+	transport, err := newHTTPTransport(c, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := newHTTPClient(c, transport)
+	creds := globalCredentials(c)
+
+	return func(url string) ([]byte, error) {
+		resp, err := getAttachment(httpClient, creds, url, 0)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		return ioutil.ReadAll(resp.Body)
+	}, nil
+}
+
+// markdownBlocks receives the blocks of a document from walkMarkdown, one line at a time other than tables
+type markdownBlocks interface {
+	heading(level int, text string)
+	taskItem(indent string, checked bool, text string)
+	bulletItem(indent string, text string)
+	quoteLine(text string)
+	codeLine(line string)
+	tableRows(rows [][]string)
+	summaryLine(text string)
+	textLine(text string)
+	rule()
+	// blank is a blank line, ending whatever block came before it
+	blank()
+}
+
+// walkMarkdown hands the blocks of the markdown trello2md generates to a document format, it understands the
+// markdown the templates produce rather than being a complete markdown parser
+func walkMarkdown(markdown []byte, blocks markdownBlocks) error {
+	scanner := bufio.NewScanner(bytes.NewReader(markdown))
+	scanner.Buffer(nil, len(markdown)+1)
+
+	inCode := false
+	var table [][]string
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if m := markdownTableRow.FindStringSubmatch(line); m != nil && !inCode {
+			if !markdownTableRule.MatchString(line) {
+				table = append(table, splitTableRow(m[1]))
+			}
+			continue
+		}
+
+		if table != nil {
+			blocks.tableRows(table)
+			table = nil
+		}
+
+		if strings.HasPrefix(line, "```") {
+			inCode = !inCode
+			if !inCode {
+				blocks.blank()
+			}
+			continue
+		}
+
+		if inCode {
+			blocks.codeLine(line)
+			continue
+		}
+
+		walkMarkdownLine(line, blocks)
+	}
+
+	if table != nil {
+		blocks.tableRows(table)
+	}
+
+	return scanner.Err()
+}
+
+func walkMarkdownLine(line string, blocks markdownBlocks) {
+	if strings.TrimSpace(line) == "" || markdownDetails.MatchString(line) {
+		blocks.blank()
+		return
+	}
+
+	if m := markdownSummary.FindStringSubmatch(line); m != nil {
+		blocks.summaryLine(m[1])
+		return
+	}
+
+	if m := previewHeading.FindStringSubmatch(line); m != nil {
+		blocks.heading(len(m[1]), m[2])
+		return
+	}
+
+	if m := previewTask.FindStringSubmatch(line); m != nil {
+		blocks.taskItem(m[1], m[2] != " ", m[3])
+		return
+	}
+
+	if m := previewBullet.FindStringSubmatch(line); m != nil {
+		blocks.bulletItem(m[1], m[2])
+		return
+	}
+
+	if m := previewQuote.FindStringSubmatch(line); m != nil {
+		blocks.quoteLine(m[1])
+		return
+	}
+
+	if strings.TrimSpace(line) == "---" {
+		blocks.rule()
+		return
+	}
+
+	blocks.textLine(line)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// memberMap maps trello usernames to the names they're shown as instead of their trello full names, e.g. display
+// names, email addresses or github handles, members who aren't mapped keep their full names
+type memberMap map[string]string
+
+func loadMemberMap(path string) (memberMap, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read member map")
+	}
+
+	var members memberMap
+	err = yaml.UnmarshalStrict(data, &members)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse member map %s", path)
+	}
+
+	return members, nil
+}
+
+// name returns the name the member is shown as, their trello full name when they aren't mapped
+func (m memberMap) name(username string, fullName string) string {
+	if name, ok := m[username]; ok && username != "" {
+		return name
+	}
+
+	return fullName
+}
+
+// apply renames the members of the card and everyone who commented on or changed it
+func (m memberMap) apply(view *cardView) {
+	for i, member := range view.Members {
+		view.Members[i].FullName = m.name(member.Username, member.FullName)
+	}
+
+	for i, comment := range view.Comments {
+		view.Comments[i].MemberCreator.FullName = m.name(comment.MemberCreator.Username, comment.MemberCreator.FullName)
+	}
+
+	if view.Completion != nil {
+		view.Completion.By = m.name(view.Completion.username, view.Completion.By)
+	}
+
+	for i, activity := range view.Activity {
+		view.Activity[i].Member = m.name(activity.username, activity.Member)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	metrics = &exportMetrics{}
+)
+
+// exportMetrics tracks export and api activity for the lifetime of the process, it's exposed in the prometheus
+// text format by the serve command
+type exportMetrics struct {
+	mu sync.Mutex
+
+	exportsSucceeded   int64
+	exportsFailed      int64
+	exportDurationSum  float64
+	lastExportDuration float64
+	lastSuccess        time.Time
+	apiRequests        int64
+	apiErrors          int64
+	apiRateLimited     int64
+	// rateLimit is the headroom trello reported with the latest response carrying its rate limit headers
+	rateLimit rateLimit
+}
+
+// rateLimit is how many more requests the token and the api key may make in the current interval, trello limits
+// both separately. the counts are -1 when trello didn't report them
+type rateLimit struct {
+	TokenRemaining int
+	TokenMax       int
+	TokenInterval  time.Duration
+	KeyRemaining   int
+	KeyMax         int
+	KeyInterval    time.Duration
+}
+
+func (m *exportMetrics) observeExport(start time.Time, err *error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	duration := time.Since(start).Seconds()
+	m.exportDurationSum += duration
+	m.lastExportDuration = duration
+
+	if *err != nil {
+		m.exportsFailed++
+		return
+	}
+
+	m.exportsSucceeded++
+	m.lastSuccess = time.Now()
+}
+
+func (m *exportMetrics) observeRequest(resp *http.Response, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.apiRequests++
+
+	if err != nil || resp.StatusCode >= 400 {
+		m.apiErrors++
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+		m.apiRateLimited++
+	}
+
+	if resp != nil && resp.Header.Get("X-Rate-Limit-Api-Token-Remaining") != "" {
+		m.rateLimit = rateLimit{
+			TokenRemaining: headerInt(resp.Header, "X-Rate-Limit-Api-Token-Remaining"),
+			TokenMax:       headerInt(resp.Header, "X-Rate-Limit-Api-Token-Max"),
+			TokenInterval:  time.Duration(headerInt(resp.Header, "X-Rate-Limit-Api-Token-Interval-Ms")) * time.Millisecond,
+			KeyRemaining:   headerInt(resp.Header, "X-Rate-Limit-Api-Key-Remaining"),
+			KeyMax:         headerInt(resp.Header, "X-Rate-Limit-Api-Key-Max"),
+			KeyInterval:    time.Duration(headerInt(resp.Header, "X-Rate-Limit-Api-Key-Interval-Ms")) * time.Millisecond,
+		}
+	}
+}
+
+// latestRateLimit returns the rate limit headroom of the latest response, false when no response reported it
+func (m *exportMetrics) latestRateLimit() (rateLimit, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.rateLimit, m.rateLimit != rateLimit{}
+}
+
+// headerInt reads a numeric response header, -1 when it's missing or not a number
+func headerInt(header http.Header, name string) int {
+	n, err := strconv.Atoi(header.Get(name))
+	if err != nil {
+		return -1
+	}
+
+	return n
+}
+
+// requests returns how many requests have been made to the trello api
+func (m *exportMetrics) requests() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.apiRequests
+}
+
+// write renders the metrics in the prometheus text exposition format
+func (m *exportMetrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lastSuccess := float64(0)
+	if !m.lastSuccess.IsZero() {
+		lastSuccess = float64(m.lastSuccess.Unix())
+	}
+
+	fmt.Fprintf(w, "# HELP trello2md_exports_total Exports run by result.\n")
+	fmt.Fprintf(w, "# TYPE trello2md_exports_total counter\n")
+	fmt.Fprintf(w, "trello2md_exports_total{result=\"success\"} %d\n", m.exportsSucceeded)
+	fmt.Fprintf(w, "trello2md_exports_total{result=\"failure\"} %d\n", m.exportsFailed)
+	fmt.Fprintf(w, "# HELP trello2md_export_duration_seconds Time spent running exports.\n")
+	fmt.Fprintf(w, "# TYPE trello2md_export_duration_seconds summary\n")
+	fmt.Fprintf(w, "trello2md_export_duration_seconds_sum %g\n", m.exportDurationSum)
+	fmt.Fprintf(w, "trello2md_export_duration_seconds_count %d\n", m.exportsSucceeded+m.exportsFailed)
+	fmt.Fprintf(w, "# HELP trello2md_last_export_duration_seconds Duration of the most recent export.\n")
+	fmt.Fprintf(w, "# TYPE trello2md_last_export_duration_seconds gauge\n")
+	fmt.Fprintf(w, "trello2md_last_export_duration_seconds %g\n", m.lastExportDuration)
+	fmt.Fprintf(w, "# HELP trello2md_last_success_timestamp_seconds Unix time of the last successful export.\n")
+	fmt.Fprintf(w, "# TYPE trello2md_last_success_timestamp_seconds gauge\n")
+	fmt.Fprintf(w, "trello2md_last_success_timestamp_seconds %g\n", lastSuccess)
+	fmt.Fprintf(w, "# HELP trello2md_api_requests_total Requests made to the trello api.\n")
+	fmt.Fprintf(w, "# TYPE trello2md_api_requests_total counter\n")
+	fmt.Fprintf(w, "trello2md_api_requests_total %d\n", m.apiRequests)
+	fmt.Fprintf(w, "# HELP trello2md_api_errors_total Trello api requests which failed or returned an error status.\n")
+	fmt.Fprintf(w, "# TYPE trello2md_api_errors_total counter\n")
+	fmt.Fprintf(w, "trello2md_api_errors_total %d\n", m.apiErrors)
+	fmt.Fprintf(w, "# HELP trello2md_api_rate_limited_total Trello api requests rejected by rate limiting.\n")
+	fmt.Fprintf(w, "# TYPE trello2md_api_rate_limited_total counter\n")
+	fmt.Fprintf(w, "trello2md_api_rate_limited_total %d\n", m.apiRateLimited)
+}
+
+// metricsTransport counts every request made to the trello api
+type metricsTransport struct {
+	delegate http.RoundTripper
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.delegate.RoundTrip(req)
+	metrics.observeRequest(resp, err)
+
+	return resp, err
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"github.com/jakekeeys/go-trello"
+)
+
+// Board is a board as the renderers see it. Board, List, Card and the types they hold are populated from the trello
+// client's types by the fetch code so output never depends on the client and can be rendered from snapshots or any
+// other source, fields and json names match trello's so templates and saved snapshots read the same either way
+type Board struct {
+	Id             string `json:"id"`
+	Name           string `json:"name"`
+	Desc           string `json:"desc"`
+	Closed         bool   `json:"closed"`
+	IdOrganization string `json:"idOrganization"`
+	Pinned         bool   `json:"pinned"`
+	Url            string `json:"url"`
+	ShortUrl       string `json:"shortUrl"`
+}
+
+// List is a list of a board
+type List struct {
+	Id      string  `json:"id"`
+	Name    string  `json:"name"`
+	Closed  bool    `json:"closed"`
+	IdBoard string  `json:"idBoard"`
+	Pos     float32 `json:"pos"`
+}
+
+// Card is a card along with its labels and badges
+type Card struct {
+	Id                    string   `json:"id"`
+	Name                  string   `json:"name"`
+	Email                 string   `json:"email"`
+	IdShort               int      `json:"idShort"`
+	IdAttachmentCover     string   `json:"idAttachmentCover"`
+	IdCheckLists          []string `json:"idCheckLists"`
+	IdBoard               string   `json:"idBoard"`
+	IdList                string   `json:"idList"`
+	IdMembers             []string `json:"idMembers"`
+	IdMembersVoted        []string `json:"idMembersVoted"`
+	ManualCoverAttachment bool     `json:"manualCoverAttachment"`
+	Closed                bool     `json:"closed"`
+	Pos                   float64  `json:"pos"`
+	ShortLink             string   `json:"shortLink"`
+	DateLastActivity      string   `json:"dateLastActivity"`
+	ShortUrl              string   `json:"shortUrl"`
+	Subscribed            bool     `json:"subscribed"`
+	Url                   string   `json:"url"`
+	Due                   string   `json:"due"`
+	Desc                  string   `json:"desc"`
+	CheckItemStates       []struct {
+		IdCheckItem string `json:"idCheckItem"`
+		State       string `json:"state"`
+	} `json:"checkItemStates"`
+	Badges struct {
+		Votes              int    `json:"votes"`
+		ViewingMemberVoted bool   `json:"viewingMemberVoted"`
+		Subscribed         bool   `json:"subscribed"`
+		Fogbugz            string `json:"fogbugz"`
+		CheckItems         int    `json:"checkItems"`
+		CheckItemsChecked  int    `json:"checkItemsChecked"`
+		Comments           int    `json:"comments"`
+		Attachments        int    `json:"attachments"`
+		Description        bool   `json:"description"`
+		Due                string `json:"due"`
+	} `json:"badges"`
+	Labels []Label `json:"labels"`
+}
+
+type Label struct {
+	Color string `json:"color"`
+	Name  string `json:"name"`
+}
+
+// Member is a member assigned to a card
+type Member struct {
+	Id         string `json:"id"`
+	AvatarHash string `json:"avatarHash"`
+	FullName   string `json:"fullName"`
+	Initials   string `json:"initials"`
+	Url        string `json:"url"`
+	Username   string `json:"username"`
+}
+
+// Attachment is a file uploaded to a card or a link attached to it
+type Attachment struct {
+	Id       string `json:"id"`
+	Bytes    int    `json:"bytes"`
+	Date     string `json:"date"`
+	IdMember string `json:"idMember"`
+	IsUpload bool   `json:"isUpload"`
+	MimeType string `json:"mimeType"`
+	Name     string `json:"name"`
+	Url      string `json:"url"`
+	// Uploader is the full name of the member who uploaded the attachment, set when image captions are shown
+	Uploader string `json:"uploader,omitempty"`
+	// RequiresLogin is set when links readers need a trello login to open are annotated
+	RequiresLogin bool `json:"requiresLogin,omitempty"`
+}
+
+// Checklist is a checklist of a card with its items in order
+type Checklist struct {
+	Id         string      `json:"id"`
+	Name       string      `json:"name"`
+	IdBoard    string      `json:"idBoard"`
+	IdCard     string      `json:"idCard"`
+	Pos        float32     `json:"pos"`
+	CheckItems []CheckItem `json:"checkItems"`
+}
+
+type CheckItem struct {
+	Id    string  `json:"id"`
+	Name  string  `json:"name"`
+	State string  `json:"state"`
+	Pos   float32 `json:"pos"`
+}
+
+// Comment is a comment left on a card along with the reactions to it
+type Comment struct {
+	Id              string `json:"id"`
+	IdMemberCreator string `json:"idMemberCreator"`
+	Type            string `json:"type"`
+	Date            string `json:"date"`
+	Data            struct {
+		DateLastEdited string `json:"dateLastEdited"`
+		Text           string `json:"text"`
+	} `json:"data"`
+	MemberCreator struct {
+		Id         string `json:"id"`
+		AvatarHash string `json:"avatarHash"`
+		FullName   string `json:"fullName"`
+		Initials   string `json:"initials"`
+		Username   string `json:"username"`
+	} `json:"memberCreator"`
+	// AppCreator is the power-up which posted the comment, nil for comments members wrote themselves
+	AppCreator *appCreator `json:"appCreator,omitempty"`
+	Reactions  []reaction
+}
+
+type appCreator struct {
+	Id string `json:"id"`
+}
+
+func newBoard(board trello.Board) Board {
+	return Board{
+		Id:             board.Id,
+		Name:           board.Name,
+		Desc:           board.Desc,
+		Closed:         board.Closed,
+		IdOrganization: board.IdOrganization,
+		Pinned:         board.Pinned,
+		Url:            board.Url,
+		ShortUrl:       board.ShortUrl,
+	}
+}
+
+func newList(list trello.List) List {
+	return List{
+		Id:      list.Id,
+		Name:    list.Name,
+		Closed:  list.Closed,
+		IdBoard: list.IdBoard,
+		Pos:     list.Pos,
+	}
+}
+
+func newLists(lists []trello.List) []List {
+	var converted []List
+	for _, list := range lists {
+		converted = append(converted, newList(list))
+	}
+
+	return converted
+}
+
+func newCard(card trello.Card) Card {
+	c := Card{
+		Id:                    card.Id,
+		Name:                  card.Name,
+		Email:                 card.Email,
+		IdShort:               card.IdShort,
+		IdAttachmentCover:     card.IdAttachmentCover,
+		IdCheckLists:          card.IdCheckLists,
+		IdBoard:               card.IdBoard,
+		IdList:                card.IdList,
+		IdMembers:             card.IdMembers,
+		IdMembersVoted:        card.IdMembersVoted,
+		ManualCoverAttachment: card.ManualCoverAttachment,
+		Closed:                card.Closed,
+		Pos:                   card.Pos,
+		ShortLink:             card.ShortLink,
+		DateLastActivity:      card.DateLastActivity,
+		ShortUrl:              card.ShortUrl,
+		Subscribed:            card.Subscribed,
+		Url:                   card.Url,
+		Due:                   card.Due,
+		Desc:                  card.Desc,
+		CheckItemStates:       card.CheckItemStates,
+		Badges:                card.Badges,
+	}
+
+	for _, label := range card.Labels {
+		c.Labels = append(c.Labels, Label{Color: label.Color, Name: label.Name})
+	}
+
+	return c
+}
+
+func newMembers(members []trello.Member) []Member {
+	var converted []Member
+	for _, member := range members {
+		converted = append(converted, Member{
+			Id:         member.Id,
+			AvatarHash: member.AvatarHash,
+			FullName:   member.FullName,
+			Initials:   member.Initials,
+			Url:        member.Url,
+			Username:   member.Username,
+		})
+	}
+
+	return converted
+}
+
+func newAttachments(attachments []trello.Attachment) []Attachment {
+	var converted []Attachment
+	for _, attachment := range attachments {
+		converted = append(converted, Attachment{
+			Id:       attachment.Id,
+			Bytes:    attachment.Bytes,
+			Date:     attachment.Date,
+			IdMember: attachment.IdMember,
+			IsUpload: attachment.IsUpload,
+			MimeType: attachment.MimeType,
+			Name:     attachment.Name,
+			Url:      attachment.Url,
+		})
+	}
+
+	return converted
+}
+
+func newChecklists(checklists []trello.Checklist) []Checklist {
+	var converted []Checklist
+	for _, checklist := range checklists {
+		c := Checklist{
+			Id:      checklist.Id,
+			Name:    checklist.Name,
+			IdBoard: checklist.IdBoard,
+			IdCard:  checklist.IdCard,
+			Pos:     checklist.Pos,
+		}
+
+		for _, item := range checklist.CheckItems {
+			c.CheckItems = append(c.CheckItems, CheckItem{Id: item.Id, Name: item.Name, State: item.State, Pos: item.Pos})
+		}
+
+		converted = append(converted, c)
+	}
+
+	return converted
+}
+
+func newComment(action cardComment) Comment {
+	comment := Comment{
+		Id:              action.Id,
+		IdMemberCreator: action.IdMemberCreator,
+		Type:            action.Type,
+		Date:            action.Date,
+	}
+	comment.Data.DateLastEdited = action.Data.DateLastEdited
+	comment.Data.Text = action.Data.Text
+	comment.MemberCreator = action.MemberCreator
+	comment.AppCreator = action.AppCreator
+
+	return comment
+}
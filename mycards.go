@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/urfave/cli"
+)
+
+var (
+	myCardsArgs = []cli.Flag{
+		cli.BoolFlag{
+			Name:   "include-watched",
+			Usage:  "also export cards the user is watching but isn't assigned to",
+			EnvVar: "INCLUDE_WATCHED",
+		},
+	}
+)
+
+// exportMyCards exports the cards assigned to the authenticated member across every open board they belong to,
+// grouped by board and then by due date
+func exportMyCards(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	err = applyProfile(c, cfg)
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := loadCardTemplate(c.String("template"), defaultCardTemplate)
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	me, err := client.Member("me")
+	if err != nil {
+		return err
+	}
+
+	boards, err := me.Boards()
+	if err != nil {
+		return err
+	}
+
+	show := showOptions{
+		LabelsAndMembers: c.Bool("show-labels-and-members"),
+		Description:      c.Bool("show-description"),
+		Checklists:       c.Bool("show-checklists"),
+		Comments:         c.Bool("show-comments"),
+		Attachments:      c.Bool("show-attachments"),
+	}
+
+	printDate(os.Stdout, time.Now())
+
+	for _, board := range boards {
+		if board.Closed {
+			continue
+		}
+
+		cards, err := board.Cards()
+		if err != nil {
+			return err
+		}
+
+		var mine []trello.Card
+		for _, card := range cards {
+			if containsString(card.IdMembers, me.Id) || (c.Bool("include-watched") && card.Subscribed) {
+				mine = append(mine, card)
+			}
+		}
+
+		if len(mine) == 0 {
+			continue
+		}
+
+		// cards due soonest come first, cards without a due date last
+		sort.SliceStable(mine, func(i, j int) bool {
+			if mine[i].Due == "" || mine[j].Due == "" {
+				return mine[j].Due == "" && mine[i].Due != ""
+			}
+
+			return mine[i].Due < mine[j].Due
+		})
+
+		printBoard(os.Stdout, newBoard(board))
+
+		due := "-"
+		for i := range mine {
+			cardDue := tr("noDueDate")
+			if mine[i].Due != "" {
+				date, err := formatDate(mine[i].Due)
+				if err != nil {
+					return err
+				}
+				cardDue = tr("due", date)
+			}
+
+			if cardDue != due {
+				fmt.Printf("**%s**\n", cardDue)
+				due = cardDue
+			}
+
+			view, err := newCardView(client, board, &mine[i], show)
+			if err != nil {
+				return err
+			}
+
+			err = tmpl.Execute(os.Stdout, view)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	progressStarted   = "started"
+	progressBoard     = "board"
+	progressCompleted = "completed"
+	progressFailed    = "failed"
+
+	notifyTimeout = 10 * time.Second
+)
+
+// progressEvent is the json posted to --notify-url as an export progresses
+type progressEvent struct {
+	Event       string     `json:"event"`
+	Time        string     `json:"time"`
+	Board       string     `json:"board,omitempty"`
+	BoardsDone  int        `json:"boards_done,omitempty"`
+	BoardsTotal int        `json:"boards_total,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	Report      *runReport `json:"report,omitempty"`
+}
+
+// progressNotifier posts the progress of an export to an endpoint so orchestration can follow it without parsing
+// logs, a nil notifier posts nothing. an endpoint which can't be reached is logged rather than failing the export
+type progressNotifier struct {
+	url    string
+	client *http.Client
+
+	mu   sync.Mutex
+	done int
+}
+
+func newProgressNotifier(url string) *progressNotifier {
+	if url == "" {
+		return nil
+	}
+
+	return &progressNotifier{url: url, client: &http.Client{Timeout: notifyTimeout}}
+}
+
+func (n *progressNotifier) started() {
+	if n == nil {
+		return
+	}
+
+	n.post(progressEvent{Event: progressStarted})
+}
+
+// board notes that one of total boards has been fetched, boards are fetched in parallel so they're reported in the
+// order they finish
+func (n *progressNotifier) board(name string, total int) {
+	if n == nil {
+		return
+	}
+
+	n.mu.Lock()
+	n.done++
+	event := progressEvent{Event: progressBoard, Board: name, BoardsDone: n.done, BoardsTotal: total}
+	n.mu.Unlock()
+
+	n.post(event)
+}
+
+// finish posts the report of a completed export or the error a failed one stopped with
+func (n *progressNotifier) finish(report *runReport, err error) {
+	if n == nil {
+		return
+	}
+
+	if err != nil {
+		n.post(progressEvent{Event: progressFailed, Error: err.Error()})
+		return
+	}
+
+	n.post(progressEvent{Event: progressCompleted, Report: report})
+}
+
+func (n *progressNotifier) post(event progressEvent) {
+	event.Time = time.Now().Format(time.RFC3339)
+
+	err := n.send(event)
+	if err != nil {
+		log.Printf("unable to notify %s of the %s event: %v", n.url, event.Event, err)
+	}
+}
+
+func (n *progressNotifier) send(event progressEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("the endpoint responded with %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	// oauthBaseURL serves trello's oauth 1.0a endpoints, they live on trello.com rather than the api host
+	oauthBaseURL = "https://trello.com/1"
+)
+
+var (
+	authorizeArgs = []cli.Flag{
+		cli.StringFlag{
+			Name:  "scope",
+			Usage: "the access the token is granted, read or read,write",
+			Value: "read",
+		},
+		cli.StringFlag{
+			Name:  "expiration",
+			Usage: "how long the token lasts, 1hour, 1day, 30days or never",
+			Value: "never",
+		},
+	}
+)
+
+// oauthTransport signs requests with oauth 1.0a using the application key and secret and the token and its
+// secret, for workspaces that only allow applications approved through oauth
+type oauthTransport struct {
+	delegate http.RoundTripper
+	creds    credentials
+}
+
+func (t *oauthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	signed := req.Clone(req.Context())
+
+	var form url.Values
+	if req.Body != nil && req.Header.Get("Content-Type") == "application/x-www-form-urlencoded" {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+
+		form, err = url.ParseQuery(string(body))
+		if err != nil {
+			return nil, err
+		}
+
+		signed.Body = ioutil.NopCloser(bytes.NewReader(body))
+		signed.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	oauth := map[string]string{"oauth_token": t.creds.Token}
+	signed.Header.Set("Authorization", oauthHeader(req.Method, req.URL, form, oauth, t.creds.Key, t.creds.Secret, t.creds.TokenSecret))
+
+	return t.delegate.RoundTrip(signed)
+}
+
+// oauthHeader builds the authorization header for a request signed with hmac-sha1, the query and form parameters
+// are part of the signature
+func oauthHeader(method string, u *url.URL, form url.Values, oauth map[string]string, key string, secret string, tokenSecret string) string {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+
+	params := map[string]string{
+		"oauth_consumer_key":     key,
+		"oauth_nonce":            hex.EncodeToString(nonce),
+		"oauth_signature_method": "HMAC-SHA1",
+		"oauth_timestamp":        strconv.FormatInt(time.Now().Unix(), 10),
+		"oauth_version":          "1.0",
+	}
+	for name, value := range oauth {
+		if value != "" {
+			params[name] = value
+		}
+	}
+
+	var pairs [][2]string
+	for name, value := range params {
+		pairs = append(pairs, [2]string{oauthEscape(name), oauthEscape(value)})
+	}
+	for _, values := range []url.Values{u.Query(), form} {
+		for name, vs := range values {
+			for _, value := range vs {
+				pairs = append(pairs, [2]string{oauthEscape(name), oauthEscape(value)})
+			}
+		}
+	}
+
+	// parameters are sorted by name and then value, both encoded
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+
+		return pairs[i][1] < pairs[j][1]
+	})
+
+	var normalized []string
+	for _, pair := range pairs {
+		normalized = append(normalized, pair[0]+"="+pair[1])
+	}
+
+	baseURL := strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host) + u.EscapedPath()
+	base := strings.ToUpper(method) + "&" + oauthEscape(baseURL) + "&" + oauthEscape(strings.Join(normalized, "&"))
+
+	mac := hmac.New(sha1.New, []byte(oauthEscape(secret)+"&"+oauthEscape(tokenSecret)))
+	mac.Write([]byte(base))
+	params["oauth_signature"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	var names []string
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var fields []string
+	for _, name := range names {
+		fields = append(fields, fmt.Sprintf(`%s="%s"`, name, oauthEscape(params[name])))
+	}
+
+	return "OAuth " + strings.Join(fields, ", ")
+}
+
+// oauthEscape percent encodes everything but the unreserved characters as oauth requires
+func oauthEscape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
+
+// authorize runs the oauth 1.0a flow for the application key and secret, printing the token and token secret to
+// use once the user has allowed access in their browser
+func authorize(c *cli.Context) error {
+	key, secret := c.GlobalString("key"), c.GlobalString("secret")
+	if key == "" || secret == "" {
+		return errors.New("authorize needs the application --key and --secret")
+	}
+
+	transport, err := newHTTPTransport(c, nil)
+	if err != nil {
+		return err
+	}
+	httpClient := newHTTPClient(c, transport)
+
+	requestToken, err := oauthTokenRequest(httpClient, "/OAuthGetRequestToken", map[string]string{"oauth_callback": "oob"}, key, secret, "")
+	if err != nil {
+		return errors.Wrap(err, "unable to get a request token")
+	}
+
+	authorizeURL := oauthBaseURL + "/OAuthAuthorizeToken?" + url.Values{
+		"oauth_token": {requestToken.Get("oauth_token")},
+		"name":        {appName},
+		"scope":       {c.String("scope")},
+		"expiration":  {c.String("expiration")},
+	}.Encode()
+	fmt.Fprintf(os.Stderr, "allow access at %s\nthen enter the verification code: ", authorizeURL)
+
+	verifier, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return errors.Wrap(err, "unable to read the verification code")
+	}
+
+	oauth := map[string]string{
+		"oauth_token":    requestToken.Get("oauth_token"),
+		"oauth_verifier": strings.TrimSpace(verifier),
+	}
+	accessToken, err := oauthTokenRequest(httpClient, "/OAuthGetAccessToken", oauth, key, secret, requestToken.Get("oauth_token_secret"))
+	if err != nil {
+		return errors.Wrap(err, "unable to get an access token")
+	}
+
+	fmt.Printf("token: %s\ntoken_secret: %s\n", accessToken.Get("oauth_token"), accessToken.Get("oauth_token_secret"))
+
+	return nil
+}
+
+// oauthTokenRequest makes a signed request to an oauth endpoint, returning the token and secret it answers with
+func oauthTokenRequest(httpClient *http.Client, path string, oauth map[string]string, key string, secret string, tokenSecret string) (url.Values, error) {
+	req, err := http.NewRequest(http.MethodPost, oauthBaseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", oauthHeader(req.Method, req.URL, nil, oauth, key, secret, tokenSecret))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return url.ParseQuery(string(body))
+}
@@ -0,0 +1,686 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	indexFilename = "index.md"
+
+	splitNone    = ""
+	splitBoard   = "board"
+	splitList    = "list"
+	splitCard    = "card"
+	splitMonth   = "month"
+	splitQuarter = "quarter"
+
+	// unknownPeriod files the cards of month and quarter splits whose completion date isn't known
+	unknownPeriod = "unknown"
+	// periodCardMarker starts the comment marking each card in a month or quarter file, later runs skip the cards
+	// already marked
+	periodCardMarker = "<!-- trello2md:card"
+
+	slugStyleUnicode = "unicode"
+	slugStyleKebab   = "kebab"
+)
+
+var (
+	// generationDate matches the date heading printDate starts documents with
+	generationDate = regexp.MustCompile(`(?m)^## \d{4}-\d{2}-\d{2}\n`)
+
+	// windowsReservedNames can't be used as file names on windows regardless of extension
+	windowsReservedNames = map[string]bool{
+		"CON": true, "PRN": true, "AUX": true, "NUL": true,
+		"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+		"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+	}
+
+	defaultFilenameTemplates = map[string]string{
+		splitBoard:   "{{.Board.Name}}.md",
+		splitList:    "{{.Board.Name}}/{{.List.Name}}.md",
+		splitCard:    "{{.Board.Name}}/{{.Card.Name}}.md",
+		splitMonth:   "{{.Period}}.md",
+		splitQuarter: "{{.Period}}.md",
+	}
+)
+
+// filenameData is the data handed to the filename template
+type filenameData struct {
+	Board  Board
+	List   List
+	Card   Card
+	Date   string
+	Period string
+}
+
+// indexEntry records a file written during a split export for the index page
+type indexEntry struct {
+	path   string
+	board  Board
+	list   List
+	card   Card
+	period string
+	lists  []string
+	cards  int
+}
+
+// outputFile is a file being produced by a split export, period files collect cards from every board and are
+// appended to when they already exist from a previous run
+type outputFile struct {
+	path     string
+	buffer   bytes.Buffer
+	existing []byte
+	boardId  string
+	entry    *indexEntry
+}
+
+// exportWriter hands out the writer each part of the export is rendered to, everything goes to stdout unless
+// the output is split into a file per board, list, card or completion period
+type exportWriter struct {
+	split    string
+	dir      string
+	output   string
+	filename *template.Template
+	date     time.Time
+	index    bool
+	// slugStyle is how rendered filenames are slugified, unicode or kebab
+	slugStyle string
+	// wrap reflows the rendered markdown at this width when positive and joins paragraph lines when negative
+	wrap int
+	// writer receives the single document once it's reflowed
+	writer io.Writer
+	// footnotes rewrites inline links as reference links listed at the end of each board or file, nil unless the
+	// footnote link style is used
+	footnotes *footnoter
+	// boardStarts are the offsets of each board in the single document
+	boardStarts []int
+	// chapters marks where each board and list starts in the single document so it can be split into chapters
+	chapters bool
+	// maxBytes is the size limit of each file written, zero for no limit
+	maxBytes int
+	// oversize is how a file over maxBytes is handled, split into numbered parts or truncated
+	oversize string
+	// blankLines collapses runs of blank lines and sets headings apart once the markdown is rendered
+	blankLines bool
+
+	// digest hashes everything written, on stdout or to files, for the history log
+	digest hash.Hash
+
+	current  io.Writer
+	document *bytes.Buffer
+	files    []*outputFile
+	byPath   map[string]*outputFile
+	entries  []*indexEntry
+}
+
+type exportWriterOptions struct {
+	// Split selects how the export is split into files, everything is written to a single document when empty
+	Split string
+	// Dir is the directory split files are written to
+	Dir string
+	// FilenameTemplate overrides the default filename template for the split
+	FilenameTemplate string
+	// Output is the file a single document is written to, stdout is used when empty
+	Output string
+	// Index writes an index page linking every split file
+	Index bool
+	// SlugStyle selects how filenames are slugified, unicode keeps letters of any script and kebab folds to ascii
+	SlugStyle string
+	// Wrap hard wraps prose at this many columns, a negative width joins the lines of paragraphs instead and zero
+	// leaves lines as rendered
+	Wrap int
+	// Writer receives the single document when no output file is set
+	Writer io.Writer
+	// Footnotes collects the links of each board, or of each file when split, as numbered references at its end
+	Footnotes bool
+	// Chapters marks the start of each board and list in the single document
+	Chapters bool
+	// MaxBytes limits the size of each file written or of the document on stdout, zero for no limit
+	MaxBytes int
+	// Oversize is what happens to output over MaxBytes, split into numbered parts or truncated with a notice
+	Oversize string
+	// BlankLines normalizes the blank lines between blocks of the rendered markdown
+	BlankLines bool
+}
+
+func newExportWriter(opts exportWriterOptions) (*exportWriter, error) {
+	e := &exportWriter{
+		split:      opts.Split,
+		dir:        opts.Dir,
+		output:     opts.Output,
+		index:      opts.Index,
+		slugStyle:  opts.SlugStyle,
+		wrap:       opts.Wrap,
+		chapters:   opts.Chapters,
+		maxBytes:   opts.MaxBytes,
+		oversize:   opts.Oversize,
+		blankLines: opts.BlankLines,
+		date:       time.Now(),
+		digest:     sha256.New(),
+		byPath:     map[string]*outputFile{},
+	}
+	e.writer = io.MultiWriter(opts.Writer, e.digest)
+	e.current = e.writer
+
+	if opts.Footnotes {
+		e.footnotes = newFootnoter()
+	}
+
+	if opts.Split == splitNone {
+		// the document is buffered when it's written to a file or has to be rewritten as a whole
+		if opts.Output != "" || opts.Wrap != 0 || opts.Footnotes || opts.MaxBytes > 0 || opts.BlankLines {
+			e.document = &bytes.Buffer{}
+			e.current = e.document
+		}
+
+		return e, nil
+	}
+
+	if opts.SlugStyle != slugStyleUnicode && opts.SlugStyle != slugStyleKebab {
+		return nil, errors.Errorf("unsupported slug style %q, expected unicode or kebab", opts.SlugStyle)
+	}
+
+	if _, ok := defaultFilenameTemplates[opts.Split]; !ok {
+		return nil, errors.Errorf("unsupported split %q, expected one of board, list, card, month or quarter", opts.Split)
+	}
+
+	filenameTemplate := opts.FilenameTemplate
+	if filenameTemplate == "" {
+		filenameTemplate = defaultFilenameTemplates[opts.Split]
+	}
+
+	tmpl, err := template.New("filename").Funcs(templateFuncs()).Parse(filenameTemplate)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse filename template")
+	}
+	e.filename = tmpl
+
+	return e, nil
+}
+
+// start writes the document header when everything goes to a single document
+func (e *exportWriter) start() {
+	if e.split == splitNone {
+		printDate(e.current, e.date)
+	}
+}
+
+// end appends the footer to the single document or to every file written by this run
+func (e *exportWriter) end(footer []byte) {
+	if e.split == splitNone {
+		e.current.Write(footer)
+		return
+	}
+
+	for _, file := range e.files {
+		if file.buffer.Len() > 0 {
+			file.buffer.Write(footer)
+		}
+	}
+}
+
+// openBoard starts a board and returns the writer for content heading the board, a new file is started when
+// splitting by board and that content is discarded when splitting any finer
+func (e *exportWriter) openBoard(board Board) (io.Writer, error) {
+	switch e.split {
+	case splitBoard:
+		file, err := e.create(filenameData{Board: board})
+		if err != nil {
+			return nil, err
+		}
+
+		e.current = &file.buffer
+		printDate(e.current, e.date)
+		printBoard(e.current, board)
+	case splitNone:
+		if e.document != nil {
+			e.boardStarts = append(e.boardStarts, e.document.Len())
+		}
+		if e.chapters {
+			printChapterMarker(e.current, chapterBoard, board.Name)
+		}
+		printBoard(e.current, board)
+	default:
+		return ioutil.Discard, nil
+	}
+
+	return e.current, nil
+}
+
+// openList returns the writer for a list, a new file is started when splitting by list
+func (e *exportWriter) openList(board Board, list List) (io.Writer, error) {
+	if e.split == splitList {
+		file, err := e.create(filenameData{Board: board, List: list})
+		if err != nil {
+			return nil, err
+		}
+
+		e.current = &file.buffer
+		printDate(e.current, e.date)
+		printBoard(e.current, board)
+	}
+
+	if e.chapters {
+		printChapterMarker(e.current, chapterList, list.Name)
+	}
+
+	if len(e.entries) > 0 {
+		entry := e.entries[len(e.entries)-1]
+		entry.lists = append(entry.lists, list.Name)
+	}
+
+	return e.current, nil
+}
+
+// openCard returns the writer for a card, a new file is started when splitting by card and the card is routed to
+// the file for its completion period when splitting by month or quarter
+func (e *exportWriter) openCard(board Board, list List, view *cardView) (io.Writer, error) {
+	switch e.split {
+	case splitCard:
+		file, err := e.create(filenameData{Board: board, List: list, Card: view.Card})
+		if err != nil {
+			return nil, err
+		}
+
+		e.current = &file.buffer
+	case splitMonth, splitQuarter:
+		return e.openPeriod(board, list, view)
+	}
+
+	if len(e.entries) > 0 {
+		e.entries[len(e.entries)-1].cards++
+	}
+
+	return e.current, nil
+}
+
+// openPeriod routes the card to the file for the month or quarter it was completed in. cards never moved or marked
+// complete were created where they are and are filed by when they were created, cards whose date can't be read are
+// filed under unknown rather than failing the export
+func (e *exportWriter) openPeriod(board Board, list List, view *cardView) (io.Writer, error) {
+	card := view.Card
+
+	var completed time.Time
+	if view.Completion != nil {
+		completed = parseTrelloDate(view.Completion.Date, "card "+card.Name)
+	} else if created, err := idCreated(card.Id); err == nil {
+		completed = created
+	}
+
+	period := unknownPeriod
+	if !completed.IsZero() {
+		period = completed.Format("2006-01")
+		if e.split == splitQuarter {
+			period = fmt.Sprintf("%d-Q%d", completed.Year(), (int(completed.Month())+2)/3)
+		}
+	}
+
+	data := filenameData{Board: board, List: list, Card: card, Period: period}
+	path, err := e.renderPath(data)
+	if err != nil {
+		return nil, err
+	}
+
+	file, ok := e.byPath[path]
+	if !ok {
+		file, err = e.add(path, data)
+		if err != nil {
+			return nil, err
+		}
+
+		file.existing, err = readOutput(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		if file.existing == nil {
+			fmt.Fprintf(&file.buffer, "## %s\n", period)
+		}
+	}
+
+	// cards already present from a previous run are skipped so rerunning an export doesn't duplicate them, files
+	// written before cards were marked are searched for the card's link
+	marker := fmt.Sprintf("%s %s -->\n", periodCardMarker, card.Id)
+	if bytes.Contains(file.existing, []byte(marker)) {
+		return ioutil.Discard, nil
+	}
+	if !bytes.Contains(file.existing, []byte(periodCardMarker)) && card.Url != "" && bytes.Contains(file.existing, []byte("("+card.Url+")")) {
+		return ioutil.Discard, nil
+	}
+
+	if file.boardId != board.Id {
+		printBoard(&file.buffer, board)
+		file.boardId = board.Id
+	}
+	file.buffer.WriteString(marker)
+
+	file.entry.cards++
+
+	return &file.buffer, nil
+}
+
+// close writes every file produced by the export and the index page once the export is complete
+func (e *exportWriter) close() error {
+	if e.document != nil {
+		document := e.reflow(e.footnoteBoards(e.document.Bytes()))
+		if e.output == "" {
+			// stdout can't be split, oversize output written there is always truncated
+			if e.maxBytes > 0 && len(document) > e.maxBytes {
+				var err error
+				document, err = truncateOutput(document, e.maxBytes)
+				if err != nil {
+					return err
+				}
+			}
+
+			_, err := e.writer.Write(document)
+			return err
+		}
+
+		e.digest.Write(document)
+		_, err := e.writeLimited(e.output, document)
+		return err
+	}
+
+	for _, file := range e.files {
+		if file.existing != nil && file.buffer.Len() == 0 {
+			continue
+		}
+
+		content := file.buffer.Bytes()
+		if e.footnotes != nil {
+			// every file is a document of its own so its references are numbered from one
+			e.footnotes = newFootnoter()
+			content = e.footnotes.section(content)
+		}
+
+		content = append(file.existing, e.reflow(content)...)
+		e.digest.Write(content)
+		paths, err := e.writeLimited(file.path, content)
+		if err != nil {
+			return err
+		}
+
+		// the index links to the first part of a file split for its size
+		file.entry.path = paths[0]
+	}
+
+	if e.split == splitNone || !e.index {
+		return nil
+	}
+
+	return e.writeIndex()
+}
+
+// sum is the sha256 of everything written once the writer is closed
+func (e *exportWriter) sum() string {
+	return hex.EncodeToString(e.digest.Sum(nil))
+}
+
+// reflow wraps or joins the lines of rendered markdown and normalizes its blank lines as configured
+func (e *exportWriter) reflow(markdown []byte) []byte {
+	if e.wrap != 0 {
+		markdown = []byte(reflow(string(markdown), e.wrap))
+	}
+
+	if e.blankLines {
+		markdown = []byte(normalizeBlankLines(string(markdown)))
+	}
+
+	return markdown
+}
+
+// footnoteBoards moves the links of each board of the single document to references at the end of the board
+func (e *exportWriter) footnoteBoards(document []byte) []byte {
+	if e.footnotes == nil || len(e.boardStarts) == 0 {
+		return document
+	}
+
+	rewritten := append([]byte{}, document[:e.boardStarts[0]]...)
+	for i, start := range e.boardStarts {
+		end := len(document)
+		if i+1 < len(e.boardStarts) {
+			end = e.boardStarts[i+1]
+		}
+
+		rewritten = append(rewritten, e.footnotes.section(document[start:end])...)
+	}
+
+	return rewritten
+}
+
+// create starts a new file, numbering the path when it was already produced during this run
+func (e *exportWriter) create(data filenameData) (*outputFile, error) {
+	path, err := e.renderPath(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.add(e.uniquePath(path), data)
+}
+
+func (e *exportWriter) add(path string, data filenameData) (*outputFile, error) {
+	entry := &indexEntry{
+		path:   path,
+		board:  data.Board,
+		list:   data.List,
+		card:   data.Card,
+		period: data.Period,
+	}
+
+	file := &outputFile{
+		path:  path,
+		entry: entry,
+	}
+
+	e.files = append(e.files, file)
+	e.byPath[path] = file
+	e.entries = append(e.entries, entry)
+
+	return file, nil
+}
+
+func (e *exportWriter) renderPath(data filenameData) (string, error) {
+	data.Date = e.date.Format(dateFormat)
+
+	var name bytes.Buffer
+	err := e.filename.Execute(&name, data)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to render filename")
+	}
+
+	return filepath.Join(e.dir, slugifyPath(name.String(), e.slugStyle)), nil
+}
+
+// writeIndex writes an index page linking every file produced by the export, grouped by board
+func (e *exportWriter) writeIndex() error {
+	var index bytes.Buffer
+	printDate(&index, e.date)
+
+	boardId := ""
+	for _, entry := range e.entries {
+		if entry.period == "" && entry.board.Id != boardId {
+			printBoard(&index, entry.board)
+			boardId = entry.board.Id
+		}
+
+		link, err := filepath.Rel(e.dir, entry.path)
+		if err != nil {
+			return err
+		}
+		link = filepath.ToSlash(link)
+
+		switch e.split {
+		case splitMonth, splitQuarter:
+			fmt.Fprintf(&index, "- [%s](%s) - %s\n", entry.period, link, tr("indexNewCards", entry.cards))
+		case splitCard:
+			lastActivity, err := formatDate(entry.card.DateLastActivity)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(&index, "- [%s](%s) - %s - %s\n", entry.card.Name, link, entry.list.Name, tr("indexActivity", lastActivity))
+		case splitList:
+			fmt.Fprintf(&index, "- [%s](%s) - %s\n", entry.list.Name, link, tr("indexCardCount", entry.cards))
+		default:
+			fmt.Fprintf(&index, "- [%s](%s) - %s - %s\n", entry.board.Name, link, strings.Join(entry.lists, ", "), tr("indexCardCount", entry.cards))
+		}
+	}
+
+	return writeFileIfChanged(filepath.Join(e.dir, indexFilename), index.Bytes())
+}
+
+// writeFileIfChanged only writes the file when the checksum of its content differs from what is already on disk,
+// leaving unchanged files untouched so scheduled runs don't produce noise downstream. the date heading documents
+// start with is left out of the checksum, otherwise every daily run would rewrite every file
+func writeFileIfChanged(path string, data []byte) error {
+	if encryption != nil {
+		return encryption.writeFile(path, data)
+	}
+
+	existing, err := ioutil.ReadFile(path)
+	if err == nil && sha256.Sum256(withoutGenerationDate(existing)) == sha256.Sum256(withoutGenerationDate(data)) {
+		log.Printf("%s unchanged, skipping write", path)
+		return nil
+	}
+
+	return writeFileAtomic(path, data)
+}
+
+// withoutGenerationDate removes the first date heading written by printDate
+func withoutGenerationDate(data []byte) []byte {
+	heading := generationDate.FindIndex(data)
+	if heading == nil {
+		return data
+	}
+
+	return append(append([]byte{}, data[:heading[0]]...), data[heading[1]:]...)
+}
+
+// writeFileAtomic writes to a temporary file alongside path and renames it into place, so an interrupted run never
+// leaves a half written file behind
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Chmod(0644)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// uniquePath numbers paths which were already written during this run, e.g. two cards sharing a name
+func (e *exportWriter) uniquePath(path string) string {
+	if _, ok := e.byPath[path]; !ok {
+		return path
+	}
+
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d%s", base, i, ext)
+		if _, ok := e.byPath[candidate]; !ok {
+			return candidate
+		}
+	}
+}
+
+// slugifyPath slugifies every segment of a rendered filename, keeping the directory structure and extension, the
+// kebab style also folds accented letters to ascii and segments windows reserves or that end up empty are renamed
+func slugifyPath(name string, style string) string {
+	segments := strings.Split(filepath.ToSlash(name), "/")
+	for i, segment := range segments {
+		ext := ""
+		if i == len(segments)-1 {
+			ext = filepath.Ext(segment)
+		}
+
+		base := strings.TrimSuffix(segment, ext)
+		if !isSafeExt(ext) {
+			ext = ""
+		}
+		if style == slugStyleKebab {
+			base = foldToASCII(base)
+		}
+
+		slug := slugify(base)
+		if slug == "" {
+			slug = "untitled"
+		}
+		if windowsReservedNames[strings.ToUpper(slug)] {
+			slug += "_"
+		}
+
+		segments[i] = slug + ext
+	}
+
+	return filepath.Join(segments...)
+}
+
+// isSafeExt reports whether an extension is plain enough to keep as is, anything else is dropped with the base
+// having been slugified
+func isSafeExt(ext string) bool {
+	if len(ext) < 2 {
+		return false
+	}
+
+	for _, r := range ext[1:] {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// foldToASCII strips accents and drops anything else outside ascii, e.g. Café Ü → Cafe U
+func foldToASCII(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if r < unicode.MaxASCII && !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
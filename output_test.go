@@ -0,0 +1,65 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileIfChanged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trello2md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "board.md")
+	writes := []struct {
+		data string
+		want string
+	}{
+		{data: "## 2024-05-01\n# board\n", want: "## 2024-05-01\n# board\n"},
+		// only the generation date changed, the file is left as it is
+		{data: "## 2024-05-02\n# board\n", want: "## 2024-05-01\n# board\n"},
+		{data: "## 2024-05-03\n# board\n- card\n", want: "## 2024-05-03\n# board\n- card\n"},
+		// dates within the document are content
+		{data: "## 2024-05-03\n# board\n- card\n## 2024-06-01\n", want: "## 2024-05-03\n# board\n- card\n## 2024-06-01\n"},
+	}
+
+	for _, write := range writes {
+		err = writeFileIfChanged(path, []byte(write.data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != write.want {
+			t.Errorf("after writing %q the file holds %q, want %q", write.data, got, write.want)
+		}
+	}
+}
+
+func TestSlugifyPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		style string
+		want  string
+	}{
+		{name: "Platform/Done.md", style: slugStyleUnicode, want: filepath.Join("platform", "done.md")},
+		{name: "Über Board/Café.md", style: slugStyleUnicode, want: filepath.Join("über-board", "café.md")},
+		{name: "Über Board/Café.md", style: slugStyleKebab, want: filepath.Join("uber-board", "cafe.md")},
+		{name: "../secrets/CON.md", style: slugStyleUnicode, want: filepath.Join("untitled", "secrets", "con_.md")},
+		{name: "report.$(rm).md", style: slugStyleUnicode, want: "report-rm.md"},
+		{name: "notes.tar.gz", style: slugStyleUnicode, want: "notes-tar.gz"},
+	}
+
+	for _, tt := range tests {
+		if got := slugifyPath(tt.name, tt.style); got != tt.want {
+			t.Errorf("slugifyPath(%q, %q) = %q, want %q", tt.name, tt.style, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	// outputPluginPrefix prefixes the executables of output plugins, the plugin foo is the executable trello2md-foo
+	outputPluginPrefix = "trello2md-"
+	// outputPluginProtocol is the version of the json plugins are sent and reply with, bumped when either changes
+	// in a way existing plugins can't read
+	outputPluginProtocol = 1
+
+	outputPluginRender  = "render"
+	outputPluginPublish = "publish"
+)
+
+// outputPlugin is an executable which renders or publishes exports, so documents can be written in formats or sent
+// to services trello2md doesn't support itself. it's sent an outputPluginRequest as json on stdin and replies with
+// an outputPluginResponse as json on stdout, exiting non-zero with the reason on stderr when it fails
+type outputPlugin struct {
+	name string
+	path string
+}
+
+// outputPluginRequest is what a plugin is asked to do along with the document and the export it was rendered from
+type outputPluginRequest struct {
+	Protocol int    `json:"protocol"`
+	Action   string `json:"action"`
+	// Target is what follows the plugin's name in a publish pipeline --target, e.g. the page of wiki:page
+	Target string `json:"target,omitempty"`
+	// Document is the markdown rendered with the export's flags
+	Document string `json:"document"`
+	// Export is the export in the structure --json-output writes, described by the schema command
+	Export queryDocument `json:"export"`
+	// Warnings are the issues which didn't stop the export but left something out of or wrong in the document
+	Warnings []Warning `json:"warnings"`
+}
+
+// outputPluginResponse is a plugin's reply, renderers reply with the document to write and publishers with where
+// the document was published to
+type outputPluginResponse struct {
+	Document string `json:"document,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// findOutputPlugin looks up the plugin's executable in dir and then on the PATH
+func findOutputPlugin(dir string, name string) (*outputPlugin, error) {
+	executable := outputPluginPrefix + name
+	if dir != "" {
+		path := filepath.Join(dir, executable)
+		if _, err := os.Stat(path); err == nil {
+			return &outputPlugin{name: name, path: path}, nil
+		}
+	}
+
+	path, err := exec.LookPath(executable)
+	if err != nil {
+		return nil, errors.Errorf("unable to find the %s plugin, install %s on the PATH or in --plugin-dir", name, executable)
+	}
+
+	return &outputPlugin{name: name, path: path}, nil
+}
+
+// run sends the plugin the request and returns its reply
+func (p *outputPlugin) run(request outputPluginRequest) (*outputPluginResponse, error) {
+	request.Protocol = outputPluginProtocol
+	request.Warnings = warnings.since(0)
+
+	input, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(p.path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "the %s plugin failed to %s: %s", p.name, request.Action, strings.TrimSpace(stderr.String()))
+	}
+
+	var response outputPluginResponse
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return &response, nil
+	}
+
+	err = json.Unmarshal(stdout.Bytes(), &response)
+	if err != nil {
+		return nil, errors.Wrapf(err, "the %s plugin replied with invalid json", p.name)
+	}
+
+	return &response, nil
+}
+
+// exportPlugin renders the export with --via-plugin's plugin, written to --output or stdout
+func exportPlugin(c *cli.Context) error {
+	if c.String("split-by") != "" {
+		return errors.New("--via-plugin renders a single document and can't be combined with --split-by")
+	}
+
+	plugin, err := findOutputPlugin(c.String("plugin-dir"), c.String("via-plugin"))
+	if err != nil {
+		return err
+	}
+
+	path := c.String("output")
+	err = c.Set("output", "")
+	if err != nil {
+		return err
+	}
+
+	markdown, exported, err := renderWithExport(c)
+	if err != nil {
+		return err
+	}
+
+	response, err := plugin.run(outputPluginRequest{
+		Action:   outputPluginRender,
+		Document: string(markdown),
+		Export:   exported,
+	})
+	if err != nil {
+		return err
+	}
+
+	if path != "" {
+		return writeFileIfChanged(path, []byte(response.Document))
+	}
+
+	_, err = os.Stdout.WriteString(response.Document)
+	if err != nil {
+		return errors.Wrap(err, "unable to write the document")
+	}
+
+	return nil
+}
+
+// renderWithExport renders the document and returns it along with the export it was rendered from, read back from
+// --json-output or a temporary file when it isn't set
+func renderWithExport(c *cli.Context) ([]byte, queryDocument, error) {
+	path := c.String("json-output")
+	opts := exportOptions{}
+	if path == "" {
+		dir, err := ioutil.TempDir("", "trello2md-plugin")
+		if err != nil {
+			return nil, queryDocument{}, err
+		}
+		defer os.RemoveAll(dir)
+
+		// the export is read back and removed straight away so it's never encrypted
+		opts.PlainJSONOutput = true
+		path = filepath.Join(dir, "export.json")
+		err = c.Set("json-output", path)
+		if err != nil {
+			return nil, queryDocument{}, err
+		}
+		defer c.Set("json-output", "")
+	}
+
+	var document bytes.Buffer
+	err := export(c, &document, opts)
+	if err != nil {
+		return nil, queryDocument{}, err
+	}
+
+	exported, err := loadExportJSON(path)
+	if err != nil {
+		return nil, queryDocument{}, err
+	}
+
+	return document.Bytes(), exported, nil
+}
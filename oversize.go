@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	oversizeSplit    = "split"
+	oversizeTruncate = "truncate"
+)
+
+var (
+	// outputPartStart matches the headings of cards and everything above them, where oversize output is split
+	outputPartStart = regexp.MustCompile(`(?m)^#{1,4} `)
+)
+
+func validateOversize(maxBytes int, oversize string, split string) error {
+	if maxBytes < 0 || (maxBytes > 0 && maxBytes < utf8.UTFMax) {
+		return errors.Errorf("--max-output-bytes must be 0 for no limit or at least %d", utf8.UTFMax)
+	}
+
+	switch oversize {
+	case oversizeSplit:
+		if maxBytes == 0 {
+			return nil
+		}
+
+		if split == splitMonth || split == splitQuarter {
+			return errors.Errorf("--oversize split can't be combined with --split-by %s, later runs append to the files", split)
+		}
+
+		return nil
+	case oversizeTruncate:
+		return nil
+	default:
+		return errors.Errorf("unsupported oversize handling %q, expected split or truncate", oversize)
+	}
+}
+
+// writeLimited writes the content of a file produced by the export, a file over the size limit is written as
+// numbered parts or truncated with a notice, the paths written are returned
+func (e *exportWriter) writeLimited(path string, content []byte) ([]string, error) {
+	if e.maxBytes == 0 || len(content) <= e.maxBytes {
+		return []string{path}, writeFileIfChanged(path, content)
+	}
+
+	if e.oversize == oversizeTruncate {
+		truncated, err := truncateOutput(content, e.maxBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{path}, writeFileIfChanged(path, truncated)
+	}
+
+	parts := splitOutput(content, e.maxBytes)
+	log.Printf("%s is %d bytes, over the %d byte limit, writing it as %d parts", path, len(content), e.maxBytes, len(parts))
+
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+
+	var paths []string
+	for i, part := range parts {
+		partPath := fmt.Sprintf("%s-part%d%s", base, i+1, ext)
+		err := writeFileIfChanged(partPath, part)
+		if err != nil {
+			return nil, err
+		}
+
+		paths = append(paths, partPath)
+	}
+
+	return paths, nil
+}
+
+// splitOutput cuts markdown into parts of at most max bytes, each part ending before a card, list or board heading
+// where possible so cards aren't split between parts
+func splitOutput(content []byte, max int) [][]byte {
+	var parts [][]byte
+	for len(content) > max {
+		end := lineEnd(content, max)
+		if starts := outputPartStart.FindAllIndex(content[:max], -1); len(starts) > 0 {
+			if start := starts[len(starts)-1][0]; start > 0 {
+				end = start
+			}
+		}
+
+		parts = append(parts, content[:end])
+		content = content[end:]
+	}
+
+	return append(parts, content)
+}
+
+// truncateOutput cuts markdown at the last line which fits within max bytes along with a notice saying so
+func truncateOutput(content []byte, max int) ([]byte, error) {
+	notice := fmt.Sprintf("\n_%s_\n", tr("truncated", len(content), max))
+	if len(notice) >= max {
+		return nil, errors.Errorf("--max-output-bytes %d leaves no room for the truncation notice", max)
+	}
+
+	end := lineEnd(content, max-len(notice))
+
+	return append(append([]byte{}, content[:end]...), notice...), nil
+}
+
+// lineEnd returns the end of the last whole line within the first max bytes, a line longer than max is cut after
+// its last whole character which fits
+func lineEnd(content []byte, max int) int {
+	if end := bytes.LastIndexByte(content[:max], '\n') + 1; end > 0 {
+		return end
+	}
+
+	end := max
+	for end > 0 && !utf8.RuneStart(content[end]) {
+		end--
+	}
+
+	return end
+}
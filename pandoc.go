@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var (
+	// pandocEmbeddedFormats are the pandoc formats which embed images in the document, their images are downloaded
+	// with the trello credentials first as pandoc can't fetch uploaded attachments itself
+	pandocEmbeddedFormats = map[string]bool{
+		"docx": true,
+		"odt":  true,
+		"epub": true, "epub2": true, "epub3": true,
+		"pptx": true,
+		"pdf":  true,
+	}
+)
+
+// exportPandoc renders the export as markdown and converts it to --via-pandoc's format with pandoc, written to
+// --output
+func exportPandoc(c *cli.Context) error {
+	binary, err := exec.LookPath(c.String("pandoc-path"))
+	if err != nil {
+		return errors.Wrap(err, "--via-pandoc needs pandoc, install it from https://pandoc.org/installing.html or set --pandoc-path")
+	}
+
+	markdown, path, err := renderDocument(c)
+	if err != nil {
+		return err
+	}
+
+	dir, err := ioutil.TempDir("", "trello2md-pandoc")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	format := c.String("via-pandoc")
+	if pandocEmbeddedFormats[format] {
+		fetch, err := newImageFetcher(c)
+		if err != nil {
+			return err
+		}
+
+		markdown, err = downloadImages(markdown, fetch, dir)
+		if err != nil {
+			return err
+		}
+	}
+
+	preface, chapters, err := splitChapters(markdown)
+	if err != nil {
+		return err
+	}
+
+	var document bytes.Buffer
+	var boards []string
+	document.Write(preface)
+	for _, chapter := range chapters {
+		if chapter.kind == chapterBoard {
+			boards = append(boards, chapter.title)
+		}
+		document.Write(chapter.markdown.Bytes())
+	}
+
+	output := filepath.Join(dir, "document"+filepath.Ext(path))
+	args := []string{
+		"--from", "gfm",
+		"--standalone",
+		"--resource-path", dir,
+		"--metadata", "title=" + strings.Join(boards, ", "),
+		"--metadata", "lang=" + language,
+		"--metadata", "date=" + time.Now().Format(dateFormat),
+		"--output", output,
+	}
+	// pandoc has no pdf writer, pdfs are produced by a pdf engine chosen from the output's extension
+	if format != "pdf" {
+		args = append(args, "--to", format)
+	}
+	args = append(args, c.StringSlice("pandoc-arg")...)
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(binary, args...)
+	cmd.Stdin = &document
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "pandoc was unable to convert the export to %s: %s", format, strings.TrimSpace(stderr.String()))
+	}
+
+	converted, err := ioutil.ReadFile(output)
+	if err != nil {
+		return err
+	}
+
+	return writeFileIfChanged(path, converted)
+}
+
+// downloadImages saves the images of the markdown into dir and points the markdown at the saved files, images
+// which can't be downloaded are left pointing at trello
+func downloadImages(markdown []byte, fetch imageFetcher, dir string) ([]byte, error) {
+	files := map[string]string{}
+	var failed error
+
+	converted := markdownInline.ReplaceAllStringFunc(string(markdown), func(match string) string {
+		if !strings.HasPrefix(match, "![") || failed != nil {
+			return match
+		}
+		m := markdownInline.FindStringSubmatch(match)
+
+		alt, src := m[1], m[2]
+		file, ok := files[src]
+		if !ok {
+			data, err := fetch(src)
+			if err == nil {
+				var format string
+				_, format, err = image.DecodeConfig(bytes.NewReader(data))
+				if err == nil {
+					file = fmt.Sprintf("image%d.%s", len(files)+1, format)
+					failed = ioutil.WriteFile(filepath.Join(dir, file), data, 0644)
+				}
+			}
+
+			if err != nil {
+				warnings.add(WarningImage, src, fmt.Sprintf("unable to download image %s, pandoc will link it instead: %v", src, err))
+			}
+			files[src] = file
+		}
+
+		if file == "" {
+			return match
+		}
+
+		return fmt.Sprintf("![%s](%s)", alt, file)
+	})
+
+	return []byte(converted), failed
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	// slackMessageLimit is roughly the longest message slack shows in full, longer documents are cut short
+	slackMessageLimit = 40000
+)
+
+var (
+	publishPipelineArgs = []cli.Flag{
+		cli.StringSliceFlag{
+			Name:   "target",
+			Usage:  "a destination for the document, file:PATH writes it, git:PATH writes it into a git work tree and commits it, slack:WEBHOOK_URL posts it and NAME:TARGET sends it to the output plugin NAME, may be repeated and targets run in order",
+			EnvVar: "TRELLO2MD_PUBLISH_TARGETS",
+		},
+		cli.StringFlag{
+			Name:   "commit-message",
+			Usage:  "the message of the commits made by git targets",
+			EnvVar: "TRELLO2MD_COMMIT_MESSAGE",
+			Value:  "Update trello export",
+		},
+		cli.BoolFlag{
+			Name:   "git-push",
+			Usage:  "push the commits made by git targets",
+			EnvVar: "TRELLO2MD_GIT_PUSH",
+		},
+		cli.StringFlag{
+			Name:   "period",
+			Usage:  "the period the document covers e.g. 2019-W42, a rerun for the same boards and period updates what it published rather than publishing again, defaults to today's date",
+			EnvVar: "TRELLO2MD_PUBLISH_PERIOD",
+		},
+		cli.StringFlag{
+			Name:   "publish-state",
+			Usage:  "the file recording what each target published for every set of boards and period",
+			EnvVar: "TRELLO2MD_PUBLISH_STATE",
+			Value:  "trello2md-published.json",
+		},
+	}
+)
+
+// publishLedger records the targets a document was delivered to keyed by a marker naming the boards and period it
+// covers, so a rerun of a failed scheduled publish never posts the same document twice
+type publishLedger struct {
+	path string
+
+	// Published maps markers to the targets published for them and the sha256 of the document each received
+	Published map[string]map[string]string `json:"published"`
+}
+
+func loadPublishLedger(path string) (*publishLedger, error) {
+	ledger := &publishLedger{path: path, Published: map[string]map[string]string{}}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ledger, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the publish state")
+	}
+
+	err = json.Unmarshal(data, ledger)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse the publish state %s", path)
+	}
+
+	if ledger.Published == nil {
+		ledger.Published = map[string]map[string]string{}
+	}
+
+	return ledger, nil
+}
+
+// published returns the hash of the document the target received for the marker, empty when it hasn't been
+// published to
+func (l *publishLedger) published(marker string, target string) string {
+	return l.Published[marker][target]
+}
+
+// record notes the target received the document and saves the ledger straight away, a later target failing or the
+// run being killed doesn't lose the record
+func (l *publishLedger) record(marker string, target string, hash string) error {
+	if l.Published[marker] == nil {
+		l.Published[marker] = map[string]string{}
+	}
+	l.Published[marker][target] = hash
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(l.path, append(data, '\n'))
+}
+
+// publishMarker keys a published document by the boards it covers and the period, whatever order the boards were
+// given in
+func publishMarker(cfg *config, boardIds []string, period string) string {
+	var ids []string
+	for _, id := range boardIds {
+		ids = append(ids, cfg.boardID(id))
+	}
+	sort.Strings(ids)
+
+	return "trello2md:" + strings.Join(ids, ",") + ":" + period
+}
+
+// publishTarget is a destination of a published document
+type publishTarget struct {
+	kind   string
+	target string
+	// publish delivers the document to the target, the export it was rendered from is only read by plugins
+	publish func(document []byte, exported queryDocument) error
+	// plugin is the output plugin publishing to the target, nil for the built in targets
+	plugin *outputPlugin
+	// updates is set for targets which replace what they published before, a target which can't is skipped once
+	// it has published for the marker
+	updates bool
+}
+
+func (t publishTarget) String() string {
+	return t.kind + ":" + t.target
+}
+
+// key names the target in the publish state, webhook urls are secrets so only their hash is kept
+func (t publishTarget) key() string {
+	if t.kind == "slack" {
+		return fmt.Sprintf("slack:%x", sha256.Sum256([]byte(t.target)))
+	}
+
+	return t.String()
+}
+
+// publishPipeline renders the boards into a single document once and delivers it to every target in turn. a failed
+// target doesn't stop the ones after it, the result of each is printed and the run fails when any of them did.
+// targets which already received the document for the boards and period are updated in place or skipped
+func publishPipeline(c *cli.Context) error {
+	if c.String("output") != "" || c.String("split-by") != "" {
+		return errors.New("publish pipeline renders a single document, set where it goes with --target instead of --output or --split-by")
+	}
+
+	targets, err := newPublishTargets(c)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	period := c.String("period")
+	if period == "" {
+		period = time.Now().Format(dateFormat)
+	}
+	marker := publishMarker(cfg, c.StringSlice("board-id"), period)
+
+	ledger, err := loadPublishLedger(c.String("publish-state"))
+	if err != nil {
+		return err
+	}
+
+	var document []byte
+	var exported queryDocument
+	if usesOutputPlugins(targets) {
+		document, exported, err = renderWithExport(c)
+	} else {
+		var rendered bytes.Buffer
+		err = export(c, &rendered, exportOptions{})
+		document = rendered.Bytes()
+	}
+	if err != nil {
+		return err
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256(document))
+
+	var failed []string
+	for _, target := range targets {
+		if previous := ledger.published(marker, target.key()); previous != "" && !target.updates {
+			if previous != hash {
+				log.Printf("%s already published %s and can't update it, skipping the changed document", target, marker)
+			}
+			fmt.Printf("%s - already published\n", target)
+			continue
+		}
+
+		err := target.publish(document, exported)
+		if err != nil {
+			log.Printf("unable to publish to %s: %v", target, err)
+			fmt.Printf("%s - failed: %v\n", target, err)
+			failed = append(failed, target.String())
+			continue
+		}
+
+		err = ledger.record(marker, target.key(), hash)
+		if err != nil {
+			return errors.Wrap(err, "unable to save the publish state")
+		}
+
+		fmt.Printf("%s - published\n", target)
+	}
+
+	if len(failed) > 0 {
+		return errors.Errorf("%d of %d targets failed: %s", len(failed), len(targets), strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+func newPublishTargets(c *cli.Context) ([]publishTarget, error) {
+	specs := c.StringSlice("target")
+	if len(specs) == 0 {
+		return nil, errors.New("publish pipeline needs at least one --target")
+	}
+
+	var targets []publishTarget
+	for _, spec := range specs {
+		parts := strings.SplitN(spec, ":", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, errors.Errorf("invalid target %q, expected file:PATH, git:PATH or slack:WEBHOOK_URL", spec)
+		}
+
+		target := publishTarget{kind: parts[0], target: parts[1]}
+		switch target.kind {
+		case "file":
+			target.updates = true
+			target.publish = func(document []byte, _ queryDocument) error {
+				return writeFileIfChanged(target.target, document)
+			}
+		case "git":
+			target.updates = true
+			target.publish = func(document []byte, _ queryDocument) error {
+				return publishToGit(target.target, document, c.String("commit-message"), c.Bool("git-push"))
+			}
+		case "slack":
+			// webhooks can only post new messages
+			target.publish = func(document []byte, _ queryDocument) error {
+				return postToSlack(target.target, slackDocument(document))
+			}
+		default:
+			// plugins are only published to once per boards and period as they may only be able to add documents
+			plugin, err := findOutputPlugin(c.String("plugin-dir"), target.kind)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unsupported target %q, expected file, git, slack or an output plugin", target.kind)
+			}
+
+			target.plugin = plugin
+			target.publish = func(document []byte, exported queryDocument) error {
+				response, err := plugin.run(outputPluginRequest{
+					Action:   outputPluginPublish,
+					Target:   target.target,
+					Document: string(document),
+					Export:   exported,
+				})
+				if err != nil {
+					return err
+				}
+
+				if response.Message != "" {
+					log.Printf("%s: %s", target, response.Message)
+				}
+
+				return nil
+			}
+		}
+
+		targets = append(targets, target)
+	}
+
+	return targets, nil
+}
+
+// usesOutputPlugins is true when any of the targets is published to by a plugin, which needs the export as json
+func usesOutputPlugins(targets []publishTarget) bool {
+	for _, target := range targets {
+		if target.plugin != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// publishToGit writes the document into the work tree path belongs to and commits it, nothing is committed when
+// the document is unchanged
+func publishToGit(path string, document []byte, message string, push bool) error {
+	err := writeFileIfChanged(path, document)
+	if err != nil {
+		return err
+	}
+
+	written, err := findOutput(path)
+	if err != nil {
+		return err
+	}
+
+	dir, file := filepath.Dir(written), filepath.Base(written)
+	_, err = git(dir, "add", "--", file)
+	if err != nil {
+		return err
+	}
+
+	status, err := git(dir, "status", "--porcelain", "--", file)
+	if err != nil {
+		return err
+	}
+	if status == "" {
+		log.Printf("%s is already committed", written)
+		return nil
+	}
+
+	_, err = git(dir, "commit", "-m", message, "--", file)
+	if err != nil {
+		return err
+	}
+
+	if push {
+		_, err = git(dir, "push")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// git runs git in dir and returns what it printed
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "git %s failed: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// slackDocument cuts documents too long for a slack message short
+func slackDocument(document []byte) string {
+	if len(document) <= slackMessageLimit {
+		return string(document)
+	}
+
+	return string(bytes.ToValidUTF8(document[:slackMessageLimit], nil)) + "\n\n_" + tr("truncated", len(document), slackMessageLimit) + "_\n"
+}
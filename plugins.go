@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+)
+
+var (
+	// pluginExtractors pull well known values out of the data power-ups keep on a card, keyed by the name used with
+	// --plugin-extractor
+	pluginExtractors = map[string]func(view *cardView) map[string]string{
+		"scrum-for-trello": extractScrumForTrello,
+	}
+
+	scrumForTrelloPoints = regexp.MustCompile(`^\s*\((\d+(?:\.\d+)?)\)`)
+	scrumForTrelloSpent  = regexp.MustCompile(`\[(\d+(?:\.\d+)?)\]\s*$`)
+)
+
+// pluginData is a value a power-up stored on a card
+type pluginData struct {
+	Id       string `json:"id"`
+	IdPlugin string `json:"idPlugin"`
+	Scope    string `json:"scope"`
+	Access   string `json:"access"`
+	Value    string `json:"value"`
+}
+
+// getCardPluginData returns the power-up data of the card with values pretty printed when they hold json
+func getCardPluginData(client *trello.Client, card *trello.Card) ([]pluginData, error) {
+	return getPluginData(client, "/cards/"+card.Id)
+}
+
+// getPluginData returns the power-up data of the card or board at path with values pretty printed when they hold json
+func getPluginData(client *trello.Client, path string) ([]pluginData, error) {
+	body, err := client.Get(path + "/pluginData")
+	if err != nil {
+		return nil, err
+	}
+
+	var data []pluginData
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read plugin data of %s", path)
+	}
+
+	for i := range data {
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, []byte(data[i].Value), "", "  ") == nil {
+			data[i].Value = pretty.String()
+		}
+	}
+
+	return data, nil
+}
+
+// validatePluginExtractors checks every extractor name is known
+func validatePluginExtractors(names []string) error {
+	for _, name := range names {
+		if _, ok := pluginExtractors[name]; !ok {
+			var known []string
+			for name := range pluginExtractors {
+				known = append(known, name)
+			}
+			sort.Strings(known)
+
+			return errors.Errorf("unknown plugin extractor %q, expected one of %s", name, strings.Join(known, ", "))
+		}
+	}
+
+	return nil
+}
+
+// extractScrumForTrello reads the estimate from a leading (3) and the time spent from a trailing [2] in the title
+func extractScrumForTrello(view *cardView) map[string]string {
+	values := map[string]string{}
+
+	if match := scrumForTrelloPoints.FindStringSubmatch(view.Card.Name); match != nil {
+		values["story points"] = match[1]
+	}
+
+	if match := scrumForTrelloSpent.FindStringSubmatch(view.Card.Name); match != nil {
+		values["points spent"] = match[1]
+	}
+
+	return values
+}
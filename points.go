@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+)
+
+const (
+	storyPointsTitle       = "title"
+	storyPointsCustomField = "custom-field:"
+)
+
+// customField is a custom field defined on a board, list fields have the options a card may select
+type customField struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Options []struct {
+		Id    string `json:"id"`
+		Value struct {
+			Text string `json:"text"`
+		} `json:"value"`
+	} `json:"options"`
+}
+
+// customFieldItem is the value a card holds for a custom field, IdValue is the option selected in a list field
+type customFieldItem struct {
+	IdCustomField string `json:"idCustomField"`
+	IdValue       string `json:"idValue"`
+	Value         struct {
+		Number  string `json:"number"`
+		Text    string `json:"text"`
+		Date    string `json:"date"`
+		Checked string `json:"checked"`
+	} `json:"value"`
+}
+
+// storyPoints reads the estimate of each card either from a leading (3) in its title or from a custom field
+type storyPoints struct {
+	fieldName string
+	fieldId   string
+}
+
+// newStoryPoints parses the --story-points source, nil is returned when story points are disabled
+func newStoryPoints(source string) (*storyPoints, error) {
+	switch {
+	case source == "":
+		return nil, nil
+	case source == storyPointsTitle:
+		return &storyPoints{}, nil
+	case strings.HasPrefix(source, storyPointsCustomField) && len(source) > len(storyPointsCustomField):
+		return &storyPoints{fieldName: strings.TrimPrefix(source, storyPointsCustomField)}, nil
+	default:
+		return nil, errors.Errorf("unsupported story points source %q, expected title or custom-field:<name>", source)
+	}
+}
+
+// forBoard resolves the custom field on the board, boards without the field have no points
+func (s *storyPoints) forBoard(client *trello.Client, board *trello.Board) (*storyPoints, error) {
+	if s.fieldName == "" {
+		return s, nil
+	}
+
+	body, err := client.Get("/boards/" + board.Id + "/customFields")
+	if err != nil {
+		// a board whose plan doesn't include custom fields has no points
+		return &storyPoints{fieldName: s.fieldName}, optional(degradedCustomFields, err)
+	}
+
+	var fields []customField
+	err = json.Unmarshal(body, &fields)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the custom fields of board %s", board.Id)
+	}
+
+	resolved := &storyPoints{fieldName: s.fieldName}
+	for _, field := range fields {
+		if field.Name == s.fieldName {
+			resolved.fieldId = field.Id
+		}
+	}
+
+	return resolved, nil
+}
+
+// points returns the card's estimate, nil when it doesn't have one
+func (s *storyPoints) points(client *trello.Client, card *trello.Card) (*float64, error) {
+	if s.fieldName == "" {
+		match := scrumForTrelloPoints.FindStringSubmatch(card.Name)
+		if match == nil {
+			return nil, nil
+		}
+
+		points, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			return nil, err
+		}
+
+		return &points, nil
+	}
+
+	if s.fieldId == "" {
+		return nil, nil
+	}
+
+	body, err := client.Get("/cards/" + card.Id + "/customFieldItems")
+	if err != nil {
+		return nil, err
+	}
+
+	var items []customFieldItem
+	err = json.Unmarshal(body, &items)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the custom fields of card %s", card.Id)
+	}
+
+	for _, item := range items {
+		if item.IdCustomField != s.fieldId {
+			continue
+		}
+
+		value := item.Value.Number
+		if value == "" {
+			value = item.Value.Text
+		}
+
+		points, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid story points %q on card %s", value, card.Name)
+		}
+
+		return &points, nil
+	}
+
+	return nil, nil
+}
+
+// totalPoints sums the estimates of the cards
+func totalPoints(views []*cardView) float64 {
+	total := 0.0
+	for _, view := range views {
+		if view.Points != nil {
+			total += *view.Points
+		}
+	}
+
+	return total
+}
+
+func printPointsTotal(w io.Writer, scope string, total float64) {
+	fmt.Fprintf(w, "_%s_\n\n", tr("total", scope, strconv.FormatFloat(total, 'f', -1, 64)))
+}
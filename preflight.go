@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+)
+
+const (
+	appKeyURL = "https://trello.com/app-key"
+)
+
+// tokenInfo is what trello knows about an api token, including the models it was granted access to
+type tokenInfo struct {
+	DateExpires string            `json:"dateExpires"`
+	Permissions []tokenPermission `json:"permissions"`
+}
+
+type tokenPermission struct {
+	IdModel   string `json:"idModel"`
+	ModelType string `json:"modelType"`
+	Read      bool   `json:"read"`
+	Write     bool   `json:"write"`
+}
+
+// readsBoard reports whether the token was granted read access to the board, either directly, to every board or
+// through a workspace
+func (t *tokenInfo) readsBoard(boardId string) bool {
+	for _, permission := range t.Permissions {
+		if !permission.Read {
+			continue
+		}
+
+		switch permission.ModelType {
+		case "Board":
+			if permission.IdModel == "*" || permission.IdModel == boardId {
+				return true
+			}
+		case "Organization":
+			return true
+		}
+	}
+
+	return false
+}
+
+// preflight checks the token used for a board is valid and can read boards before anything is fetched, each token
+// is only checked once
+func (b *boardClients) preflight(boardId string) error {
+	if !b.c.GlobalBool("preflight") {
+		return nil
+	}
+
+	client, err := b.forBoard(boardId)
+	if err != nil {
+		return err
+	}
+
+	name := b.config.board(boardId).Credentials
+	b.mu.Lock()
+	token, ok := b.tokens[name]
+	b.mu.Unlock()
+
+	if !ok {
+		token, err = getTokenInfo(client, b.credentials(boardId).Token)
+		if err != nil {
+			return tokenError(name, err)
+		}
+
+		b.mu.Lock()
+		b.tokens[name] = token
+		b.mu.Unlock()
+	}
+
+	if token.DateExpires != "" {
+		expires, err := time.Parse(time.RFC3339, token.DateExpires)
+		if err == nil && expires.Before(time.Now()) {
+			return errors.Errorf("the trello token%s expired on %s, create a new one at %s", credentialsLabel(name), expires.Format(dateFormat), appKeyURL)
+		}
+	}
+
+	if !token.readsBoard(boardId) {
+		return errors.Errorf("the trello token%s wasn't granted read access to board %s, authorize it again with the read scope at %s", credentialsLabel(name), boardId, appKeyURL)
+	}
+
+	return nil
+}
+
+// accessError explains why a board couldn't be fetched when trello refused the request or doesn't know the board
+func (b *boardClients) accessError(boardId string, err error) error {
+	switch apiStatus(err) {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		client, clientErr := b.forBoard(boardId)
+		if clientErr != nil {
+			return err
+		}
+
+		username, memberErr := getTokenUsername(client, b.credentials(boardId).Token)
+		if memberErr != nil {
+			return errors.Wrapf(err, "the trello token lacks read access to board %s", boardId)
+		}
+
+		return errors.Wrapf(err, "the trello token of @%s lacks read access to board %s, ask an admin of the board or its workspace to add @%s", username, boardId, username)
+	case http.StatusBadRequest, http.StatusNotFound:
+		return errors.Wrapf(err, "board %s doesn't exist or was deleted, check the board id", boardId)
+	default:
+		return err
+	}
+}
+
+func getTokenInfo(client *trello.Client, token string) (*tokenInfo, error) {
+	body, err := client.Get("/tokens/" + token + "?fields=dateExpires,permissions")
+	if err != nil {
+		return nil, err
+	}
+
+	var info tokenInfo
+	err = json.Unmarshal(body, &info)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the token")
+	}
+
+	return &info, nil
+}
+
+func getTokenUsername(client *trello.Client, token string) (string, error) {
+	body, err := client.Get("/tokens/" + token + "/member?fields=username")
+	if err != nil {
+		return "", err
+	}
+
+	var member trello.Member
+	err = json.Unmarshal(body, &member)
+	if err != nil {
+		return "", err
+	}
+
+	return member.Username, nil
+}
+
+func tokenError(name string, err error) error {
+	switch apiStatus(err) {
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound:
+		return errors.Errorf("the trello token%s is invalid or was revoked, create a new one at %s", credentialsLabel(name), appKeyURL)
+	default:
+		return errors.Wrap(err, "unable to check the trello token")
+	}
+}
+
+// credentialsLabel names the config credentials entry in messages, the global key and token have no name
+func credentialsLabel(name string) string {
+	if name == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(" of credentials %q", name)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiDim       = "\x1b[2m"
+	ansiItalic    = "\x1b[3m"
+	ansiUnderline = "\x1b[4m"
+	ansiRed       = "\x1b[31m"
+	ansiGreen     = "\x1b[32m"
+	ansiYellow    = "\x1b[33m"
+	ansiBlue      = "\x1b[34m"
+	ansiMagenta   = "\x1b[35m"
+	ansiCyan      = "\x1b[36m"
+)
+
+var (
+	previewHeading  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	previewTask     = regexp.MustCompile(`^(\s*)- \[([ xX])\] (.*)$`)
+	previewBullet   = regexp.MustCompile(`^(\s*)[-*] (.*)$`)
+	previewQuote    = regexp.MustCompile(`^>\s?(.*)$`)
+	previewImage    = regexp.MustCompile(`!\[[^\]]*\]\(([^)]+)\)`)
+	previewLink     = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
+	previewBoldText = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	previewCode     = regexp.MustCompile("`([^`]+)`")
+
+	// previewHeadingColours colour headings by level, boards and lists stand out from cards
+	previewHeadingColours = []string{ansiMagenta, ansiMagenta, ansiBlue, ansiCyan, ansiGreen, ansiYellow}
+)
+
+// previewMarkdown renders the markdown with ansi styling for a quick look in the terminal, it styles the markdown
+// trello2md generates rather than being a complete markdown renderer
+func previewMarkdown(w io.Writer, markdown []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(markdown))
+	scanner.Buffer(nil, len(markdown)+1)
+
+	inCode := false
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "```") {
+			inCode = !inCode
+			fmt.Fprintln(w, ansiDim+line+ansiReset)
+			continue
+		}
+
+		if inCode {
+			fmt.Fprintln(w, ansiYellow+line+ansiReset)
+			continue
+		}
+
+		fmt.Fprintln(w, previewLine(line))
+	}
+
+	return scanner.Err()
+}
+
+func previewLine(line string) string {
+	if m := previewHeading.FindStringSubmatch(line); m != nil {
+		colour := previewHeadingColours[len(m[1])-1]
+		return ansiBold + colour + previewInline(m[2], ansiBold+colour) + ansiReset
+	}
+
+	if m := previewTask.FindStringSubmatch(line); m != nil {
+		if m[2] == " " {
+			return m[1] + ansiYellow + "☐" + ansiReset + " " + previewInline(m[3], "")
+		}
+
+		return m[1] + ansiGreen + "☑" + ansiReset + " " + ansiDim + previewInline(m[3], ansiDim) + ansiReset
+	}
+
+	if m := previewBullet.FindStringSubmatch(line); m != nil {
+		return m[1] + ansiCyan + "•" + ansiReset + " " + previewInline(m[2], "")
+	}
+
+	if m := previewQuote.FindStringSubmatch(line); m != nil {
+		style := ansiDim + ansiItalic
+		return style + "│ " + previewInline(m[1], style) + ansiReset
+	}
+
+	if strings.TrimSpace(line) == "---" {
+		return ansiDim + strings.Repeat("─", 40) + ansiReset
+	}
+
+	return previewInline(line, "")
+}
+
+// previewInline styles inline markdown, restoring the style of the surrounding line after each span
+func previewInline(text string, style string) string {
+	restore := ansiReset + style
+
+	text = previewImage.ReplaceAllString(text, ansiDim+"🖼 $1"+restore)
+	text = previewLink.ReplaceAllString(text, ansiUnderline+ansiBlue+"$1"+restore)
+	text = previewBoldText.ReplaceAllString(text, ansiBold+"$1"+restore)
+	text = previewCode.ReplaceAllString(text, ansiRed+"$1"+restore)
+
+	return text
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var (
+	profiling = &profiler{}
+)
+
+// profiler records cpu profiles, execution traces and heap profiles of a run to measure the fetch and render pipeline
+type profiler struct {
+	cpu     *os.File
+	trace   *os.File
+	memPath string
+}
+
+// start begins the cpu profile and execution trace requested by the global flags
+func (p *profiler) start(c *cli.Context) error {
+	p.memPath = c.GlobalString("memprofile")
+
+	if path := c.GlobalString("cpuprofile"); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return errors.Wrap(err, "unable to create the cpu profile")
+		}
+
+		err = pprof.StartCPUProfile(f)
+		if err != nil {
+			f.Close()
+			return errors.Wrap(err, "unable to start the cpu profile")
+		}
+		p.cpu = f
+	}
+
+	if path := c.GlobalString("trace"); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return errors.Wrap(err, "unable to create the trace")
+		}
+
+		err = trace.Start(f)
+		if err != nil {
+			f.Close()
+			return errors.Wrap(err, "unable to start the trace")
+		}
+		p.trace = f
+	}
+
+	return nil
+}
+
+// stop finishes the cpu profile and trace and writes the heap profile, it's called as the command exits whether or
+// not it failed so slow failing runs can be profiled too
+func (p *profiler) stop() error {
+	if p.cpu != nil {
+		pprof.StopCPUProfile()
+		err := p.cpu.Close()
+		p.cpu = nil
+		if err != nil {
+			return errors.Wrap(err, "unable to write the cpu profile")
+		}
+	}
+
+	if p.trace != nil {
+		trace.Stop()
+		err := p.trace.Close()
+		p.trace = nil
+		if err != nil {
+			return errors.Wrap(err, "unable to write the trace")
+		}
+	}
+
+	if p.memPath != "" {
+		f, err := os.Create(p.memPath)
+		if err != nil {
+			return errors.Wrap(err, "unable to create the memory profile")
+		}
+		defer f.Close()
+
+		// collect garbage first so the profile shows the memory still in use rather than what's awaiting collection
+		runtime.GC()
+		err = pprof.WriteHeapProfile(f)
+		if err != nil {
+			return errors.Wrap(err, "unable to write the memory profile")
+		}
+	}
+
+	return nil
+}
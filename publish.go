@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	defaultGitHubAPIURL = "https://api.github.com"
+)
+
+var (
+	publishGitHubReleaseArgs = []cli.Flag{
+		cli.StringFlag{
+			Name:   "repo",
+			Usage:  "the github repository to publish the release to as owner/name",
+			EnvVar: "GITHUB_REPO",
+		},
+		cli.StringFlag{
+			Name:   "tag",
+			Usage:  "the tag of the release to create or update e.g. v1.2.3",
+			EnvVar: "RELEASE_TAG",
+		},
+		cli.StringFlag{
+			Name:   "github-token",
+			Usage:  "a github token allowed to write releases to the repository",
+			EnvVar: "GITHUB_TOKEN",
+		},
+		cli.StringFlag{
+			Name:   "github-api-url",
+			Usage:  "the github api base url, override for github enterprise",
+			EnvVar: "GITHUB_API_URL",
+			Value:  defaultGitHubAPIURL,
+		},
+	}
+)
+
+// gitHubRelease is the part of a github release the publish command reads and writes
+type gitHubRelease struct {
+	Id      int64  `json:"id,omitempty"`
+	TagName string `json:"tag_name,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Body    string `json:"body"`
+	HtmlUrl string `json:"html_url,omitempty"`
+}
+
+// gitHubClient makes the few github api calls needed to publish release notes
+type gitHubClient struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// publishGitHubRelease renders the release notes and sets them as the body of the release for the tag, the
+// release is created when the tag doesn't have one yet
+func publishGitHubRelease(c *cli.Context) error {
+	repo := c.String("repo")
+	if strings.Count(repo, "/") != 1 {
+		return errors.Errorf("invalid --repo %q, expected owner/name", repo)
+	}
+
+	tag := c.String("tag")
+	if tag == "" {
+		return errors.New("--tag is required")
+	}
+
+	if c.String("github-token") == "" {
+		return errors.New("--github-token is required")
+	}
+
+	notes, err := renderReleaseNotes(c)
+	if err != nil {
+		return err
+	}
+
+	github := &gitHubClient{
+		baseURL: strings.TrimSuffix(c.String("github-api-url"), "/"),
+		token:   c.String("github-token"),
+		client:  &http.Client{Timeout: c.GlobalDuration("http-timeout")},
+	}
+
+	var existing gitHubRelease
+	found, err := github.do(http.MethodGet, "/repos/"+repo+"/releases/tags/"+url.PathEscape(tag), nil, &existing)
+	if err != nil {
+		return err
+	}
+
+	var published gitHubRelease
+	if found {
+		_, err = github.do(http.MethodPatch, fmt.Sprintf("/repos/%s/releases/%d", repo, existing.Id), &gitHubRelease{Body: string(notes)}, &published)
+		if err != nil {
+			return errors.Wrapf(err, "unable to update the release for %s", tag)
+		}
+
+		log.Printf("updated release %s", published.HtmlUrl)
+		return nil
+	}
+
+	_, err = github.do(http.MethodPost, "/repos/"+repo+"/releases", &gitHubRelease{TagName: tag, Name: tag, Body: string(notes)}, &published)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create a release for %s", tag)
+	}
+
+	log.Printf("created release %s", published.HtmlUrl)
+	return nil
+}
+
+// do sends the request and decodes the response into out, false is returned when the resource doesn't exist
+func (g *gitHubClient) do(method string, path string, in interface{}, out interface{}) (bool, error) {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	req, err := http.NewRequest(method, g.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "token "+g.token)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound && method == http.MethodGet {
+		return false, nil
+	}
+
+	if resp.StatusCode >= 300 {
+		return false, errors.Errorf("github returned %s for %s %s: %s", resp.Status, method, path, strings.TrimSpace(string(data)))
+	}
+
+	return true, json.Unmarshal(data, out)
+}
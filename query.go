@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// queryDocument is the json structure of an export which --query expressions are evaluated against and
+// --json-output writes, cards hold the same fields as the card template data
+type queryDocument struct {
+	// Schema and Version name the version of the format the export was written in, the schema command prints it
+	Schema  string       `json:"$schema,omitempty"`
+	Version int          `json:"version,omitempty"`
+	Boards  []queryBoard `json:"boards"`
+}
+
+type queryBoard struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	Url  string `json:"url"`
+	// Audit is who can see the board, set when audit fields are shown
+	Audit *boardAudit `json:"audit,omitempty"`
+	Lists []queryList `json:"lists"`
+}
+
+type queryList struct {
+	Id    string      `json:"id"`
+	Name  string      `json:"name"`
+	Cards []*cardView `json:"cards"`
+}
+
+func newQueryDocument(exports []*boardExport) queryDocument {
+	document := queryDocument{Schema: exportSchemaId(), Version: exportSchemaVersion}
+	for _, boardExport := range exports {
+		board := queryBoard{
+			Id:    boardExport.board.Id,
+			Name:  boardExport.board.Name,
+			Url:   boardExport.board.Url,
+			Audit: boardExport.audit,
+		}
+
+		for _, listExport := range boardExport.lists {
+			board.Lists = append(board.Lists, queryList{
+				Id:    listExport.list.Id,
+				Name:  listExport.list.Name,
+				Cards: listExport.cards,
+			})
+		}
+
+		document.Boards = append(document.Boards, board)
+	}
+
+	return document
+}
+
+// writeExportJSON writes the export as json alongside the document, it's the structure --query reads. it's
+// encrypted with --encrypt-to unless encrypted is false
+func writeExportJSON(path string, exports []*boardExport, encrypted bool) error {
+	data, err := json.MarshalIndent(newQueryDocument(exports), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if !encrypted {
+		return writeFileAtomic(path, append(data, '\n'))
+	}
+
+	return writeFileIfChanged(path, append(data, '\n'))
+}
+
+// queryStage is one step of a query pipeline, it maps a value to any number of results
+type queryStage func(value interface{}) ([]interface{}, error)
+
+// exportQuery is a compiled --query expression, a jq style pipeline of paths such as
+// .boards[].lists[].cards[].Attachments[].url and the length and keys functions
+type exportQuery []queryStage
+
+// newExportQuery compiles a query expression
+func newExportQuery(expression string) (exportQuery, error) {
+	var stages exportQuery
+	for _, part := range strings.Split(expression, "|") {
+		part = strings.TrimSpace(part)
+		switch part {
+		case "length":
+			stages = append(stages, queryLength)
+		case "keys":
+			stages = append(stages, queryKeys)
+		default:
+			path, err := parseQueryPath(part)
+			if err != nil {
+				return nil, err
+			}
+			stages = append(stages, path...)
+		}
+	}
+
+	return stages, nil
+}
+
+// run evaluates the query against the export and prints every result on its own line, strings are printed as they
+// are and anything else as json
+func (q exportQuery) run(w io.Writer, exports []*boardExport) error {
+	document := newQueryDocument(exports)
+
+	// round trip through json so the query sees the same maps and slices it would in a json export
+	data, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	var root interface{}
+	err = json.Unmarshal(data, &root)
+	if err != nil {
+		return err
+	}
+
+	values, err := q.evaluate(root)
+	if err != nil {
+		return errors.Wrap(err, "unable to run query")
+	}
+
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			fmt.Fprintln(w, s)
+			continue
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(data))
+	}
+
+	return nil
+}
+
+// evaluate passes the value through each stage of the query in turn, returning the results of the last
+func (q exportQuery) evaluate(root interface{}) ([]interface{}, error) {
+	values := []interface{}{root}
+	for _, stage := range q {
+		var next []interface{}
+		for _, value := range values {
+			results, err := stage(value)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, results...)
+		}
+		values = next
+	}
+
+	return values, nil
+}
+
+func parseQueryPath(path string) ([]queryStage, error) {
+	if !strings.HasPrefix(path, ".") {
+		return nil, errors.Errorf("invalid query %q, paths start with .", path)
+	}
+
+	var stages []queryStage
+	rest := path
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, errors.Errorf("invalid query %q, missing ]", path)
+			}
+
+			index := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			if index == "" {
+				stages = append(stages, queryIterate)
+				continue
+			}
+
+			n, err := strconv.Atoi(index)
+			if err != nil {
+				return nil, errors.Errorf("invalid query %q, %q isn't an index", path, index)
+			}
+			stages = append(stages, queryIndex(n))
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[]")
+			if end < 0 {
+				end = len(rest)
+			}
+
+			if name := rest[:end]; name != "" {
+				stages = append(stages, queryField(name))
+			}
+			rest = rest[end:]
+		default:
+			return nil, errors.Errorf("invalid query %q at %q", path, rest)
+		}
+	}
+
+	return stages, nil
+}
+
+func queryField(name string) queryStage {
+	return func(value interface{}) ([]interface{}, error) {
+		switch v := value.(type) {
+		case nil:
+			return []interface{}{nil}, nil
+		case map[string]interface{}:
+			return []interface{}{v[name]}, nil
+		default:
+			return nil, errors.Errorf("can't read .%s of %s", name, queryType(value))
+		}
+	}
+}
+
+func queryIndex(n int) queryStage {
+	return func(value interface{}) ([]interface{}, error) {
+		switch v := value.(type) {
+		case nil:
+			return []interface{}{nil}, nil
+		case []interface{}:
+			// negative indexes count from the end of each array, n is shared by every array the stage sees
+			i := n
+			if i < 0 {
+				i += len(v)
+			}
+			if i < 0 || i >= len(v) {
+				return []interface{}{nil}, nil
+			}
+			return []interface{}{v[i]}, nil
+		default:
+			return nil, errors.Errorf("can't index %s", queryType(value))
+		}
+	}
+}
+
+// queryIterate yields every element of an array or value of an object, objects in key order
+func queryIterate(value interface{}) ([]interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		return v, nil
+	case map[string]interface{}:
+		var values []interface{}
+		for _, key := range sortedKeys(v) {
+			values = append(values, v[key])
+		}
+		return values, nil
+	default:
+		return nil, errors.Errorf("can't iterate over %s", queryType(value))
+	}
+}
+
+func queryLength(value interface{}) ([]interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return []interface{}{0}, nil
+	case string:
+		return []interface{}{len([]rune(v))}, nil
+	case []interface{}:
+		return []interface{}{len(v)}, nil
+	case map[string]interface{}:
+		return []interface{}{len(v)}, nil
+	default:
+		return nil, errors.Errorf("%s has no length", queryType(value))
+	}
+}
+
+func queryKeys(value interface{}) ([]interface{}, error) {
+	v, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("%s has no keys", queryType(value))
+	}
+
+	var keys []interface{}
+	for _, key := range sortedKeys(v) {
+		keys = append(keys, key)
+	}
+
+	return []interface{}{keys}, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// queryType names the json type of a value for errors
+func queryType(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "a string"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	default:
+		return "null"
+	}
+}
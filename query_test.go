@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// evaluate runs the query against the json document, returning the results as json
+func evaluate(t *testing.T, q exportQuery, document string) []string {
+	t.Helper()
+
+	var root interface{}
+	err := json.Unmarshal([]byte(document), &root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := q.evaluate(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var results []string
+	for _, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, string(data))
+	}
+
+	return results
+}
+
+func TestParseQueryPath(t *testing.T) {
+	tests := []struct {
+		path   string
+		stages int
+		err    bool
+	}{
+		{path: ".", stages: 0},
+		{path: ".boards", stages: 1},
+		{path: ".boards[]", stages: 2},
+		{path: ".boards[].lists[0].name", stages: 5},
+		{path: ".boards[ -1 ]", stages: 2},
+		{path: "boards", err: true},
+		{path: ".boards[", err: true},
+		{path: ".boards[x]", err: true},
+		{path: ".boards]", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			stages, err := parseQueryPath(tt.path)
+			if tt.err {
+				if err == nil {
+					t.Errorf("parseQueryPath(%q) returned no error", tt.path)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseQueryPath(%q) returned %v", tt.path, err)
+			}
+			if len(stages) != tt.stages {
+				t.Errorf("parseQueryPath(%q) returned %d stages, want %d", tt.path, len(stages), tt.stages)
+			}
+		})
+	}
+}
+
+func TestExportQuery(t *testing.T) {
+	document := `{"boards": [
+		{"name": "a", "lists": [{"cards": [1, 2, 3, 4, 5]}, {"cards": [6, 7]}]},
+		{"name": "b", "lists": [], "extra": null}
+	]}`
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: ".boards[].name", want: []string{`"a"`, `"b"`}},
+		{query: ".boards[0].lists[].cards[-1]", want: []string{"5", "7"}},
+		{query: ".boards[0].lists[].cards[1]", want: []string{"2", "7"}},
+		{query: ".boards[0].lists[].cards[9]", want: []string{"null", "null"}},
+		{query: ".boards[0].lists[].cards[-9]", want: []string{"null", "null"}},
+		{query: ".boards[].lists | length", want: []string{"2", "0"}},
+		{query: ".boards[1] | keys", want: []string{`["extra","lists","name"]`}},
+		{query: ".boards[1].extra.name", want: []string{"null"}},
+		{query: ".boards[1].extra[]", want: nil},
+		{query: ".boards[0].name | length", want: []string{"1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := newExportQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := evaluate(t, q, document)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportQueryErrors(t *testing.T) {
+	for _, query := range []string{".boards[0].name.first", ".boards[0].name[0]", ".boards[0].name[]", ".boards | keys", ".boards[0].lists[0].cards[0] | length"} {
+		t.Run(query, func(t *testing.T) {
+			q, err := newExportQuery(query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var root interface{}
+			err = json.Unmarshal([]byte(`{"boards": [{"name": "a", "lists": [{"cards": [1]}]}]}`), &root)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			values, err := q.evaluate(root)
+			if err == nil {
+				t.Errorf("%s returned %v, want an error", query, values)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	// redacted replaces the credentials in recorded fixtures
+	redacted = "REDACTED"
+	// fixtureSecretMinLength is the shortest credential redacted wherever it appears, query parameters holding
+	// credentials are always redacted
+	fixtureSecretMinLength = 8
+)
+
+var (
+	recordArgs = []cli.Flag{
+		cli.StringFlag{
+			Name:   "listen",
+			Usage:  "the address the recording proxy listens on",
+			EnvVar: "TRELLO2MD_RECORD_LISTEN",
+			Value:  "127.0.0.1:8090",
+		},
+		cli.StringFlag{
+			Name:   "dir",
+			Usage:  "the directory each request and its response is saved to as a json fixture",
+			EnvVar: "TRELLO2MD_RECORD_DIR",
+			Value:  "fixtures",
+		},
+	}
+
+	// fixtureCredentialParams are the query parameters trello takes credentials in
+	fixtureCredentialParams = []string{"key", "token"}
+)
+
+// fixture is a request made to the trello api and the response it got, with credentials redacted
+type fixture struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Query       string `json:"query,omitempty"`
+	RequestBody string `json:"request_body,omitempty"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	// Body is the response when it's json, BodyText holds any other response
+	Body     json.RawMessage `json:"body,omitempty"`
+	BodyText string          `json:"body_text,omitempty"`
+}
+
+// fixtureRecorder saves the requests passing through the proxy, numbered in the order they were answered
+type fixtureRecorder struct {
+	dir     string
+	secrets []string
+
+	mu       sync.Mutex
+	recorded int
+}
+
+// fixtureRequestBody carries the body of a proxied request to the recorder, the proxy consumes the original
+type fixtureRequestBody struct{}
+
+// record proxies requests to the trello api, or the --api-base-url, saving each request with its response to the
+// fixtures directory. exports pointed at the proxy with --api-base-url are answered as usual, the fixtures let a bug
+// be reproduced or a renderer developed offline against the shape of real boards
+func record(c *cli.Context) error {
+	upstream, err := getAPIBaseURL(c)
+	if err != nil {
+		return err
+	}
+
+	transport, err := newHTTPTransport(c, nil)
+	if err != nil {
+		return err
+	}
+
+	dir := c.String("dir")
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return errors.Wrap(err, "unable to create the fixtures directory")
+	}
+
+	var secrets []string
+	for _, name := range []string{"key", "token", "secret", "token-secret"} {
+		if value := c.GlobalString(name); value != "" {
+			secrets = append(secrets, value)
+		}
+	}
+	recorder := &fixtureRecorder{dir: dir, secrets: secrets}
+
+	proxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = upstream.Scheme
+			req.URL.Host = upstream.Host
+			req.Host = ""
+			// left to the transport so responses are decompressed before they're recorded
+			req.Header.Del("Accept-Encoding")
+		},
+		Transport:      transport,
+		ModifyResponse: recorder.save,
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		proxy.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), fixtureRequestBody{}, body)))
+	})
+
+	log.Printf("recording trello api requests to %s, point exports at --api-base-url http://%s%s", dir, c.String("listen"), upstream.Path)
+
+	return http.ListenAndServe(c.String("listen"), handler)
+}
+
+// save writes the response and the request it answers to the next fixture, the response is handed on unchanged
+func (r *fixtureRecorder) save(resp *http.Response) error {
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	req := resp.Request
+	secrets := append([]string{}, r.secrets...)
+	query := req.URL.Query()
+	for _, param := range fixtureCredentialParams {
+		for _, value := range query[param] {
+			if value != "" {
+				secrets = append(secrets, value)
+			}
+		}
+		if _, ok := query[param]; ok {
+			query.Set(param, redacted)
+		}
+	}
+	scrub := func(s string) string {
+		for _, secret := range secrets {
+			// trello keys and tokens are long, a short test value would redact every word containing it
+			if len(secret) < fixtureSecretMinLength {
+				continue
+			}
+
+			s = strings.Replace(s, secret, redacted, -1)
+		}
+
+		return s
+	}
+
+	requestBody, _ := req.Context().Value(fixtureRequestBody{}).([]byte)
+	recorded := fixture{
+		Method:      req.Method,
+		Path:        scrub(req.URL.Path),
+		Query:       scrub(query.Encode()),
+		RequestBody: scrub(string(requestBody)),
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+
+	responseBody := scrub(string(body))
+	if json.Valid([]byte(responseBody)) {
+		recorded.Body = json.RawMessage(responseBody)
+	} else {
+		recorded.BodyText = responseBody
+	}
+
+	// query strings are kept readable rather than escaped for html
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(recorded)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.recorded++
+	name := fmt.Sprintf("%04d-%s-%s.json", r.recorded, strings.ToLower(req.Method), slugify(recorded.Path))
+	r.mu.Unlock()
+
+	err = ioutil.WriteFile(filepath.Join(r.dir, name), data.Bytes(), 0644)
+	if err != nil {
+		// the export carries on, a missing fixture is noticed when the recording is used
+		log.Printf("unable to save fixture %s: %v", name, err)
+		return nil
+	}
+
+	log.Printf("recorded %s %s to %s", req.Method, recorded.Path, name)
+
+	return nil
+}
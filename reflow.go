@@ -0,0 +1,109 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// markdownBlockPrefix matches the markers which start a block, quotes, list items and task list items, along
+	// with their indentation
+	markdownBlockPrefix = regexp.MustCompile(`^(\s*(?:>\s?)*\s*(?:[-*+] (?:\[[ xX]\] )?|\d+[.)] )?)`)
+	// markdownUnflowed matches lines which are never wrapped or joined, headings, tables, horizontal rules and
+	// images
+	markdownUnflowed = regexp.MustCompile(`^\s*(#|\||---|\*\*\*|!\[|<)`)
+)
+
+// reflow hard wraps the prose of a markdown document at width columns, or joins the lines of each paragraph into
+// one when width is negative, fenced code, tables, headings and front matter are left untouched
+func reflow(markdown string, width int) string {
+	lines := strings.Split(markdown, "\n")
+
+	var out []string
+	fenced := false
+	frontMatter := len(lines) > 0 && lines[0] == "---"
+	for i, line := range lines {
+		switch {
+		case frontMatter:
+			out = append(out, line)
+			if i > 0 && line == "---" {
+				frontMatter = false
+			}
+			continue
+		case strings.HasPrefix(strings.TrimSpace(line), "```"):
+			fenced = !fenced
+			out = append(out, line)
+			continue
+		case fenced || strings.TrimSpace(line) == "" || markdownUnflowed.MatchString(line):
+			out = append(out, line)
+			continue
+		}
+
+		if width < 0 {
+			previous := len(out) - 1
+			if previous >= 0 && isPlainLine(out[previous]) && isPlainLine(line) && !isHardBreak(out[previous]) {
+				out[previous] = strings.TrimRight(out[previous], " ") + " " + strings.TrimSpace(line)
+				continue
+			}
+
+			out = append(out, line)
+			continue
+		}
+
+		out = append(out, wrapLine(line, width)...)
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// isPlainLine reports whether the line is paragraph text which can be joined with its neighbours
+func isPlainLine(line string) bool {
+	if strings.TrimSpace(line) == "" || markdownUnflowed.MatchString(line) || strings.HasPrefix(strings.TrimSpace(line), "```") {
+		return false
+	}
+
+	return markdownBlockPrefix.FindString(line) == ""
+}
+
+// isHardBreak reports whether the line ends with a markdown hard line break
+func isHardBreak(line string) bool {
+	return strings.HasSuffix(line, "  ") || strings.HasSuffix(line, `\`)
+}
+
+// wrapLine wraps a line at width columns, continuation lines keep quote markers and are indented under list items,
+// words longer than the width are never broken
+func wrapLine(line string, width int) []string {
+	if utf8.RuneCountInString(line) <= width {
+		return []string{line}
+	}
+
+	prefix := markdownBlockPrefix.FindString(line)
+	continuation := strings.Repeat(" ", utf8.RuneCountInString(prefix))
+	if quote := strings.LastIndex(prefix, ">"); quote >= 0 {
+		indent := utf8.RuneCountInString(prefix[quote+1:]) - 1
+		if indent < 0 {
+			indent = 0
+		}
+		continuation = prefix[:quote+1] + " " + strings.Repeat(" ", indent)
+	}
+
+	var wrapped []string
+	current := prefix
+	empty := true
+	for _, word := range strings.Fields(line[len(prefix):]) {
+		if !empty && utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > width {
+			wrapped = append(wrapped, current)
+			current = continuation
+			empty = true
+		}
+
+		if !empty {
+			current += " "
+		}
+		current += word
+		empty = false
+	}
+
+	return append(wrapped, current)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestReflow(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		width    int
+		want     string
+	}{
+		{
+			name:     "short lines",
+			markdown: "one two\nthree",
+			width:    20,
+			want:     "one two\nthree",
+		},
+		{
+			name:     "wrap paragraph",
+			markdown: "the quick brown fox jumps over the lazy dog",
+			width:    16,
+			want:     "the quick brown\nfox jumps over\nthe lazy dog",
+		},
+		{
+			name:     "long word",
+			markdown: "see https://example.com/a/very/long/link here",
+			width:    10,
+			want:     "see\nhttps://example.com/a/very/long/link\nhere",
+		},
+		{
+			name:     "list item",
+			markdown: "- the quick brown fox jumps",
+			width:    12,
+			want:     "- the quick\n  brown fox\n  jumps",
+		},
+		{
+			name:     "task list item",
+			markdown: "- [x] the quick brown fox",
+			width:    16,
+			want:     "- [x] the quick\n      brown fox",
+		},
+		{
+			name:     "ordered list item",
+			markdown: "12. the quick brown fox",
+			width:    14,
+			want:     "12. the quick\n    brown fox",
+		},
+		{
+			name:     "quote",
+			markdown: "> the quick brown fox jumps",
+			width:    12,
+			want:     "> the quick\n> brown fox\n> jumps",
+		},
+		{
+			name:     "untouched blocks",
+			markdown: "#### a heading longer than the width\n| a table | row |\n---\n![image](https://example.com/image.png)",
+			width:    10,
+			want:     "#### a heading longer than the width\n| a table | row |\n---\n![image](https://example.com/image.png)",
+		},
+		{
+			name:     "fenced code",
+			markdown: "```\nthe quick brown fox jumps\n```",
+			width:    10,
+			want:     "```\nthe quick brown fox jumps\n```",
+		},
+		{
+			name:     "front matter",
+			markdown: "---\ntitle: the quick brown fox jumps\n---\nthe quick brown fox",
+			width:    10,
+			want:     "---\ntitle: the quick brown fox jumps\n---\nthe quick\nbrown fox",
+		},
+		{
+			name:     "join paragraph",
+			markdown: "the quick\nbrown fox\n\njumps over",
+			width:    -1,
+			want:     "the quick brown fox\n\njumps over",
+		},
+		{
+			name:     "join keeps hard breaks and lists",
+			markdown: "the quick  \nbrown fox\n- jumps\n- over",
+			width:    -1,
+			want:     "the quick  \nbrown fox\n- jumps\n- over",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := reflow(tt.markdown, tt.width)
+			if got != tt.want {
+				t.Errorf("reflow(%q, %d) = %q, want %q", tt.markdown, tt.width, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	// defaultReleaseNotesTemplate renders a card as a single changelog entry, leaving out anything internal
+	defaultReleaseNotesTemplate = `- {{.Card.Name}}
+`
+
+	releaseNotesOtherGroup = "Other"
+)
+
+var (
+	releaseNotesArgs = []cli.Flag{
+		cli.StringSliceFlag{
+			Name:   "group-by-label",
+			Usage:  "group cards under a heading per label in the order given e.g. feature, cards without any of the labels are listed under Other",
+			EnvVar: "GROUP_BY_LABEL",
+		},
+	}
+)
+
+func releaseNotes(c *cli.Context) error {
+	notes, err := renderReleaseNotes(c)
+	if err != nil {
+		return err
+	}
+
+	if c.String("output") != "" {
+		return writeFileIfChanged(c.String("output"), notes)
+	}
+
+	_, err = os.Stdout.Write(notes)
+	if err != nil {
+		return errors.Wrap(err, "unable to write release notes")
+	}
+
+	return nil
+}
+
+// renderReleaseNotes renders the cards of every board as a single list of titles, the --template flag replaces the
+// release notes template rather than the card template
+func renderReleaseNotes(c *cli.Context) ([]byte, error) {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	err = applyProfile(c, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := loadCardTemplate(c.String("template"), defaultReleaseNotesTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	var transformer *cardTransformer
+	if c.String("transform") != "" {
+		transformer, err = loadTransformer(c.String("transform"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	exports, err := fetchExports(c, cfg, fetchOptions{
+		ListFilters:      listFilters(c),
+		AllLists:         c.Bool("all-lists"),
+		Sort:             c.String("sort"),
+		IncludeTemplates: c.Bool("include-templates"),
+		Transformer:      transformer,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var notes bytes.Buffer
+
+	groups := c.StringSlice("group-by-label")
+	groupOf := func(view *cardView) string {
+		for _, label := range view.Card.Labels {
+			if containsString(groups, label.Name) {
+				return label.Name
+			}
+		}
+
+		return releaseNotesOtherGroup
+	}
+
+	if len(groups) == 0 && c.Bool("sections") {
+		groups = cfg.Sections.names()
+		groupOf = func(view *cardView) string {
+			return cfg.Sections.sectionOf(&view.Card)
+		}
+	}
+
+	if len(groups) == 0 {
+		for _, view := range exportedCards(exports) {
+			err = tmpl.Execute(&notes, view)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return notes.Bytes(), nil
+	}
+
+	grouped := map[string][]*cardView{}
+	for _, view := range exportedCards(exports) {
+		group := groupOf(view)
+		grouped[group] = append(grouped[group], view)
+	}
+
+	if !containsString(groups, releaseNotesOtherGroup) {
+		groups = append(groups, releaseNotesOtherGroup)
+	}
+
+	for _, group := range groups {
+		if len(grouped[group]) == 0 {
+			continue
+		}
+
+		err = printReleaseNotesGroup(&notes, tmpl, group, grouped[group])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return notes.Bytes(), nil
+}
+
+func printReleaseNotesGroup(notes *bytes.Buffer, tmpl *template.Template, group string, views []*cardView) error {
+	if notes.Len() > 0 {
+		fmt.Fprintln(notes)
+	}
+	heading := group
+	if group == releaseNotesOtherGroup {
+		heading = tr("other")
+	}
+	fmt.Fprintf(notes, "### %s\n", heading)
+
+	for _, view := range views {
+		err := tmpl.Execute(notes, view)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportedCards flattens the cards of every list of every board in document order
+func exportedCards(exports []*boardExport) []*cardView {
+	var views []*cardView
+	for _, export := range exports {
+		for _, list := range export.lists {
+			views = append(views, list.cards...)
+		}
+	}
+
+	return views
+}
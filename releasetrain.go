@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var (
+	releaseTrainArgs = []cli.Flag{
+		cli.StringFlag{
+			Name:   "release",
+			Usage:  "the label marking the cards of the release e.g. release:2024.05, or custom-field:<name>=<value> for a custom field holding the release",
+			EnvVar: "TRELLO2MD_RELEASE",
+		},
+	}
+)
+
+// releaseMarker is how cards are marked as part of a release, either a label or the value of a custom field
+type releaseMarker struct {
+	label string
+	field string
+	value string
+}
+
+// parseReleaseMarker reads the --release flag, custom-field:<name>=<value> selects a custom field and anything
+// else is a label name
+func parseReleaseMarker(s string) (*releaseMarker, error) {
+	if s == "" {
+		return nil, errors.New("--release is required")
+	}
+
+	if !strings.HasPrefix(s, storyPointsCustomField) {
+		return &releaseMarker{label: s}, nil
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(s, storyPointsCustomField), "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, errors.Errorf("unsupported release %q, expected a label or custom-field:<name>=<value>", s)
+	}
+
+	return &releaseMarker{field: parts[0], value: parts[1]}, nil
+}
+
+func (m *releaseMarker) matches(view *cardView) bool {
+	if m.label != "" {
+		for _, label := range view.Card.Labels {
+			if label.Name == m.label {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for _, field := range view.CustomFields {
+		if field.Name == m.field && field.Value == m.value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *releaseMarker) String() string {
+	if m.label != "" {
+		return m.label
+	}
+
+	return m.value
+}
+
+// releaseTrainTeam is a team's share of the release, the release's cards on each of the team's boards
+type releaseTrainTeam struct {
+	name   string
+	boards []*releaseTrainBoard
+}
+
+type releaseTrainBoard struct {
+	name  string
+	cards []*cardView
+}
+
+func releaseTrain(c *cli.Context) error {
+	train, err := renderReleaseTrain(c)
+	if err != nil {
+		return err
+	}
+
+	if c.String("output") != "" {
+		return writeFileIfChanged(c.String("output"), train)
+	}
+
+	_, err = os.Stdout.Write(train)
+	if err != nil {
+		return errors.Wrap(err, "unable to write the release train")
+	}
+
+	return nil
+}
+
+// renderReleaseTrain collects the cards of the release from every board, those in the config when no board is given,
+// into a single document with a section per team and a heading per board within it. the cards of every list are
+// collected unless list filters are given, and the --template flag replaces the release notes template
+func renderReleaseTrain(c *cli.Context) ([]byte, error) {
+	marker, err := parseReleaseMarker(c.String("release"))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	err = applyProfile(c, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(c.StringSlice("board-id")) == 0 {
+		boardIds := cfg.configuredBoards()
+		if len(boardIds) == 0 {
+			return nil, errors.New("--board-id is required when the config has no boards")
+		}
+
+		for _, boardId := range boardIds {
+			err = c.Set("board-id", boardId)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	tmpl, err := loadCardTemplate(c.String("template"), defaultReleaseNotesTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	var transformer *cardTransformer
+	if c.String("transform") != "" {
+		transformer, err = loadTransformer(c.String("transform"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	exports, err := fetchExports(c, cfg, fetchOptions{
+		ListFilters:      c.StringSlice("list-filter"),
+		AllLists:         c.Bool("all-lists") || len(c.StringSlice("list-filter")) == 0,
+		Sort:             c.String("sort"),
+		IncludeTemplates: c.Bool("include-templates"),
+		Transformer:      transformer,
+		Show: showOptions{
+			CustomFields: marker.field != "",
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var teams []*releaseTrainTeam
+	for _, export := range exports {
+		board := &releaseTrainBoard{name: export.board.Name}
+		for _, list := range export.lists {
+			for _, view := range list.cards {
+				if marker.matches(view) {
+					board.cards = append(board.cards, view)
+				}
+			}
+		}
+
+		if len(board.cards) == 0 {
+			continue
+		}
+
+		name := cfg.board(export.board.Id).Team
+		if name == "" {
+			name = export.board.Name
+		}
+
+		var team *releaseTrainTeam
+		for _, t := range teams {
+			if t.name == name {
+				team = t
+			}
+		}
+		if team == nil {
+			team = &releaseTrainTeam{name: name}
+			teams = append(teams, team)
+		}
+
+		team.boards = append(team.boards, board)
+	}
+
+	var train bytes.Buffer
+	fmt.Fprintf(&train, "# %s\n\n", tr("releaseTrain", marker))
+	if len(teams) == 0 {
+		fmt.Fprintf(&train, "_%s_\n", tr("releaseNoCards", marker))
+		return train.Bytes(), nil
+	}
+
+	for i, team := range teams {
+		if i > 0 {
+			fmt.Fprintln(&train)
+		}
+		fmt.Fprintf(&train, "## %s\n", team.name)
+		for _, board := range team.boards {
+			// a board is its own team when it isn't given one, it needs no heading of its own
+			if board.name != team.name {
+				fmt.Fprintf(&train, "\n### %s\n", board.name)
+			}
+
+			for _, view := range board.cards {
+				err = tmpl.Execute(&train, view)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return train.Bytes(), nil
+}
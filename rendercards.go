@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var (
+	// trelloCardId matches card ids and short links given on their own
+	trelloCardId = regexp.MustCompile(`^\w+$`)
+)
+
+// renderCards renders the cards whose ids, short links or urls are read from stdin one per line, so trello2md can
+// render cards picked by other scripts. cards are rendered in the order they're read under the heading of their board,
+// a card which can't be rendered is warned about and the rest are still rendered
+func renderCards(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	err = applyProfile(c, cfg)
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := loadCardTemplate(c.String("template"), defaultCardTemplate)
+	if err != nil {
+		return err
+	}
+
+	ids, err := readCardIds(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	show := showOptions{
+		LabelsAndMembers: c.Bool("show-labels-and-members"),
+		Description:      c.Bool("show-description"),
+		Checklists:       c.Bool("show-checklists"),
+		Comments:         c.Bool("show-comments"),
+		Attachments:      c.Bool("show-attachments"),
+	}
+
+	var document bytes.Buffer
+	printDate(&document, time.Now())
+
+	boards := map[string]*trello.Board{}
+	var heading string
+	var failed []string
+	for _, id := range ids {
+		var card trello.Card
+		err := getJSON(client, "/cards/"+id, &card)
+		if err != nil {
+			warnings.add(WarningSkipped, id, fmt.Sprintf("unable to render card %s: %v", id, err))
+			failed = append(failed, id)
+			continue
+		}
+
+		board, ok := boards[card.IdBoard]
+		if !ok {
+			board, err = client.Board(card.IdBoard)
+			if err != nil {
+				return errors.Wrapf(err, "unable to fetch the board of card %s", id)
+			}
+			boards[card.IdBoard] = board
+		}
+
+		if board.Id != heading {
+			printBoard(&document, newBoard(*board))
+			heading = board.Id
+		}
+
+		view, err := newCardView(client, *board, &card, show)
+		if err != nil {
+			return err
+		}
+
+		err = tmpl.Execute(&document, view)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.String("output") != "" {
+		err = writeFileIfChanged(c.String("output"), document.Bytes())
+	} else {
+		_, err = os.Stdout.Write(document.Bytes())
+	}
+	if err != nil {
+		return errors.Wrap(err, "unable to write the cards")
+	}
+
+	if len(failed) > 0 {
+		return errors.Errorf("%d of %d cards couldn't be rendered: %s", len(failed), len(ids), strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// readCardIds reads a card id, short link or url from each line, blank lines are skipped
+func readCardIds(r io.Reader) ([]string, error) {
+	var ids []string
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if m := trelloCardUrl.FindStringSubmatch(line); m != nil {
+			ids = append(ids, m[1])
+			continue
+		}
+
+		if !trelloCardId.MatchString(line) {
+			return nil, errors.Errorf("line %d isn't a card id, short link or url: %q", n, line)
+		}
+
+		ids = append(ids, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "unable to read card ids from stdin")
+	}
+
+	if len(ids) == 0 {
+		return nil, errors.New("render-cards reads card ids, short links or urls from stdin, one per line, and none were given")
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+)
+
+const (
+	// reopenedFilter selects the board actions moving cards between lists and archiving them
+	reopenedFilter = "updateCard:idList,updateCard:closed"
+)
+
+// reopenedCards finds the cards moved back out of a done list, work which was thought finished but needed more
+type reopenedCards struct {
+	doneLists []string
+	// since is how far back the board's actions are searched
+	since time.Duration
+}
+
+// reopenedCard is a card whose last move took it out of a done list
+type reopenedCard struct {
+	name string
+	url  string
+	from string
+	to   string
+	date string
+	by   string
+}
+
+// find reads the board's recent list moves for the cards whose latest move took them from a done list to one which
+// isn't, cards archived since are left out
+func (r *reopenedCards) find(client *trello.Client, board *trello.Board) ([]reopenedCard, error) {
+	actions, err := getBoardActions(client, board, reopenedFilter, time.Now().Add(-r.since))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to find the reopened cards of board %s", board.Name)
+	}
+
+	var reopened []reopenedCard
+	decided := map[string]bool{}
+	// newest first so the latest move of each card decides whether it's still reopened
+	for _, action := range actions {
+		cardId := action.Data.Card.Id
+		if decided[cardId] {
+			continue
+		}
+
+		if action.Data.Card.Closed != nil {
+			// an archived card is no longer being worked on, one restored from the archive is decided by its moves
+			if *action.Data.Card.Closed {
+				decided[cardId] = true
+			}
+			continue
+		}
+
+		if action.Data.ListBefore == nil || action.Data.ListAfter == nil {
+			continue
+		}
+		decided[cardId] = true
+
+		if !containsString(r.doneLists, action.Data.ListBefore.Name) || containsString(r.doneLists, action.Data.ListAfter.Name) {
+			continue
+		}
+
+		reopened = append(reopened, reopenedCard{
+			name: action.Data.Card.Name,
+			url:  "https://trello.com/c/" + action.Data.Card.ShortLink,
+			from: action.Data.ListBefore.Name,
+			to:   action.Data.ListAfter.Name,
+			date: action.Date,
+			by:   action.MemberCreator.FullName,
+		})
+	}
+
+	return reopened, nil
+}
+
+// printReopened writes a section listing the board's reopened cards, most recently reopened first
+func printReopened(w io.Writer, cards []reopenedCard) {
+	if len(cards) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "### %s\n", tr("reopened"))
+	for _, card := range cards {
+		date, _ := formatDate(card.date)
+		fmt.Fprintf(w, "- [%s](%s) - %s\n", markdownEscaper.Replace(card.name), card.url, tr("reopenedMove", card.from, card.to, card.by, date))
+	}
+	fmt.Fprintln(w)
+}
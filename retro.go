@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/urfave/cli"
+)
+
+var (
+	retroArgs = []cli.Flag{
+		cli.StringFlag{
+			Name:   "actions-list",
+			Usage:  "the name of the list holding action items, its cards are rendered as a task list with their owners",
+			EnvVar: "ACTIONS_LIST",
+			Value:  "Actions",
+		},
+	}
+)
+
+// retro renders retrospective boards, every list becomes a section of cards ordered by votes and the action items
+// list becomes a task list
+func retro(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	err = applyProfile(c, cfg)
+	if err != nil {
+		return err
+	}
+
+	clients := newBoardClients(c, cfg)
+	boards, err := getBoards(clients, c.StringSlice("board-id"))
+	if err != nil {
+		return err
+	}
+
+	printDate(os.Stdout, time.Now())
+
+	for _, board := range *boards {
+		printBoard(os.Stdout, newBoard(board))
+
+		lists, err := getLists(&board)
+		if err != nil {
+			return err
+		}
+
+		client, err := clients.forBoard(board.Id)
+		if err != nil {
+			return err
+		}
+
+		for i := range lists {
+			err = printRetroList(os.Stdout, client, &lists[i], lists[i].Name == c.String("actions-list"))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func printRetroList(w io.Writer, client *trello.Client, list *trello.List, actions bool) error {
+	cards, err := getCards(client, list, cardSortPosition, false)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "#### %s\n", list.Name)
+
+	if actions {
+		for _, card := range *cards {
+			members, err := getCardMembers(client, &card)
+			if err != nil {
+				return err
+			}
+
+			var owners []string
+			for _, member := range members {
+				owners = append(owners, "@"+member.Username)
+			}
+
+			owner := tr("unassigned")
+			if len(owners) > 0 {
+				owner = strings.Join(owners, ", ")
+			}
+
+			fmt.Fprintf(w, "- [ ] %s - %s\n", card.Name, owner)
+		}
+
+		fmt.Fprintln(w)
+		return nil
+	}
+
+	// the most voted cards lead each section, cards with equal votes keep their position on the board
+	sort.SliceStable(*cards, func(i, j int) bool {
+		return (*cards)[i].Badges.Votes > (*cards)[j].Badges.Votes
+	})
+
+	for _, card := range *cards {
+		votes := tr("votes", card.Badges.Votes)
+		if card.Badges.Votes == 1 {
+			votes = tr("vote", card.Badges.Votes)
+		}
+
+		fmt.Fprintf(w, "- %s (%s)\n", card.Name, votes)
+	}
+
+	fmt.Fprintln(w)
+	return nil
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var (
+	exportMembersArgs = []cli.Flag{
+		cli.StringSliceFlag{
+			Name:   "board-id",
+			Usage:  "the ids or config aliases of the boards to list the members of",
+			EnvVar: "BOARD_ID",
+		},
+		cli.StringFlag{
+			Name:   "workspace",
+			Usage:  "the id or short name of a workspace to list the members of instead of boards, cards are counted across its open boards",
+			EnvVar: "TRELLO2MD_MEMBERS_WORKSPACE",
+		},
+		cli.StringFlag{
+			Name:   "output",
+			Usage:  "the file to write the roster to instead of stdout",
+			EnvVar: "TRELLO2MD_MEMBERS_OUTPUT",
+		},
+	}
+
+	// memberRoles name the message of each trello membership type
+	memberRoles = map[string]string{
+		"admin":    "roleAdmin",
+		"normal":   "roleNormal",
+		"observer": "roleObserver",
+	}
+)
+
+// membership is a member's role on a board or workspace
+type membership struct {
+	IdMember    string `json:"idMember"`
+	MemberType  string `json:"memberType"`
+	Deactivated bool   `json:"deactivated"`
+}
+
+// rosterMember is a row of the roster
+type rosterMember struct {
+	name     string
+	username string
+	role     string
+	cards    int
+}
+
+// exportMembers renders the members of boards or of a workspace along with their role and the number of open
+// cards each is assigned, as a table per board or for the workspace
+func exportMembers(c *cli.Context) error {
+	boardIds, workspace := c.StringSlice("board-id"), c.String("workspace")
+	if (len(boardIds) == 0) == (workspace == "") {
+		return errors.New("export-members lists the members of either --board-id boards or a --workspace")
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	var roster bytes.Buffer
+	printDate(&roster, time.Now())
+
+	if workspace != "" {
+		err = printWorkspaceRoster(&roster, c, workspace)
+	} else {
+		err = printBoardRosters(&roster, newBoardClients(c, cfg), boardIds)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.String("output") != "" {
+		return writeFileIfChanged(c.String("output"), roster.Bytes())
+	}
+
+	_, err = os.Stdout.Write(roster.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "unable to write the member roster")
+	}
+
+	return nil
+}
+
+func printBoardRosters(w io.Writer, clients *boardClients, boardIds []string) error {
+	boards, err := getBoards(clients, boardIds)
+	if err != nil {
+		return err
+	}
+
+	for _, board := range *boards {
+		client, err := clients.forBoard(board.Id)
+		if err != nil {
+			return err
+		}
+
+		members, err := board.Members()
+		if err != nil {
+			return errors.Wrapf(err, "unable to list the members of %s", board.Name)
+		}
+
+		memberships, err := getMemberships(client, "/boards/"+board.Id)
+		if err != nil {
+			return err
+		}
+
+		cards, err := board.Cards()
+		if err != nil {
+			return err
+		}
+
+		printBoard(w, newBoard(board))
+		printRoster(w, newRoster(members, memberships, cards))
+	}
+
+	return nil
+}
+
+func printWorkspaceRoster(w io.Writer, c *cli.Context, workspace string) error {
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	var organization trello.Organization
+	err = getJSON(client, "/organizations/"+workspace, &organization)
+	if err != nil {
+		return errors.Wrapf(err, "unable to find workspace %s", workspace)
+	}
+
+	var members []trello.Member
+	err = getJSON(client, "/organizations/"+organization.Id+"/members", &members)
+	if err != nil {
+		return errors.Wrapf(err, "unable to list the members of %s", organization.DisplayName)
+	}
+
+	memberships, err := getMemberships(client, "/organizations/"+organization.Id)
+	if err != nil {
+		return err
+	}
+
+	var boards []trello.Board
+	err = getJSON(client, "/organizations/"+organization.Id+"/boards?filter=open", &boards)
+	if err != nil {
+		return errors.Wrapf(err, "unable to list the boards of %s", organization.DisplayName)
+	}
+
+	var cards []trello.Card
+	for _, board := range boards {
+		var boardCards []trello.Card
+		err = getJSON(client, "/boards/"+board.Id+"/cards", &boardCards)
+		if err != nil {
+			return errors.Wrapf(err, "unable to list the cards of %s", board.Name)
+		}
+
+		cards = append(cards, boardCards...)
+	}
+
+	fmt.Fprintf(w, "### %s\n", organization.DisplayName)
+	printRoster(w, newRoster(members, memberships, cards))
+
+	return nil
+}
+
+func getJSON(client *trello.Client, path string, v interface{}) error {
+	body, err := client.Get(path)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+// getMemberships fetches the roles of the members of the board or workspace at path
+func getMemberships(client *trello.Client, path string) ([]membership, error) {
+	var memberships []membership
+	err := getJSON(client, path+"/memberships", &memberships)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list memberships")
+	}
+
+	return memberships, nil
+}
+
+// newRoster lists the members by name along with their role and how many of the cards they're assigned
+func newRoster(members []trello.Member, memberships []membership, cards []trello.Card) []rosterMember {
+	roles := map[string]membership{}
+	for _, m := range memberships {
+		roles[m.IdMember] = m
+	}
+
+	assigned := map[string]int{}
+	for _, card := range cards {
+		for _, id := range card.IdMembers {
+			assigned[id]++
+		}
+	}
+
+	var roster []rosterMember
+	for _, member := range members {
+		role := roles[member.Id].MemberType
+		if message, ok := memberRoles[role]; ok {
+			role = tr(message)
+		}
+		if roles[member.Id].Deactivated {
+			role = tr("roleDeactivated", role)
+		}
+
+		roster = append(roster, rosterMember{
+			name:     member.FullName,
+			username: member.Username,
+			role:     role,
+			cards:    assigned[member.Id],
+		})
+	}
+
+	sort.SliceStable(roster, func(i, j int) bool {
+		return collation.less(roster[i].name, roster[j].name)
+	})
+
+	return roster
+}
+
+func printRoster(w io.Writer, roster []rosterMember) {
+	fmt.Fprintf(w, "| %s | %s | %s | %s |\n", tr("name"), tr("username"), tr("role"), tr("cardCount"))
+	fmt.Fprintln(w, "| --- | --- | --- | --- |")
+	for _, member := range roster {
+		fmt.Fprintf(w, "| %s | %s | %s | %d |\n", tableCell(member.name), tableCell(member.username), tableCell(member.role), member.cards)
+	}
+	fmt.Fprintln(w)
+}
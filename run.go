@@ -0,0 +1,201 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	// profileCommandKey is the profile key naming the command the profile runs, it isn't a flag
+	profileCommandKey = "command"
+
+	defaultProfileCommand = "export-boards"
+)
+
+var (
+	runArgs = []cli.Flag{
+		cli.BoolFlag{
+			Name:  "all",
+			Usage: "run every profile in the config file",
+		},
+	}
+
+	// processFlags are the global flags which apply to the whole process rather than to a profile's run
+	processFlags = []string{"cpuprofile", "memprofile", "trace"}
+)
+
+// runProfiles runs the command bound to each profile with the profile's flag values, so a single profile name can
+// produce anything from the weekly export to release notes, api responses are shared between the profiles so
+// boards they have in common are only fetched once
+func runProfiles(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	names := []string(c.Args())
+	if c.Bool("all") {
+		if len(names) > 0 {
+			return errors.New("--all can't be combined with profile names")
+		}
+		names = cfg.profileNames()
+	}
+
+	if len(names) == 0 {
+		return errors.New("a profile name or --all is required")
+	}
+
+	commands := map[string][]string{}
+	for _, name := range names {
+		p, ok := cfg.Profiles[name]
+		if !ok {
+			return errors.Errorf("unknown profile %q", name)
+		}
+
+		command := []string{defaultProfileCommand}
+		if value, ok := p[profileCommandKey]; ok {
+			command = strings.Fields(fmt.Sprint(value))
+		}
+
+		err = checkProfileCommand(c.App, command)
+		if err != nil {
+			return errors.Wrapf(err, "invalid command in profile %s", name)
+		}
+
+		commands[name] = command
+	}
+
+	if len(names) > 1 {
+		responseCache = newAPICache()
+		defer func() { responseCache = nil }()
+	}
+
+	var failed []string
+	for _, name := range names {
+		if len(names) > 1 {
+			log.Printf("running profile %s", name)
+		}
+
+		args := append([]string{c.App.Name}, globalFlagArgs(c)...)
+		args = append(args, commands[name]...)
+		args = append(args, "--profile", name)
+
+		err = c.App.Run(args)
+		if err != nil {
+			if len(names) == 1 {
+				return err
+			}
+
+			// carry on with the remaining profiles so one broken profile doesn't hold up the rest
+			log.Printf("profile %s failed: %v", name, err)
+			failed = append(failed, name)
+		}
+	}
+
+	if len(failed) > 0 {
+		return errors.Errorf("profiles failed: %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// checkProfileCommand verifies the command exists and takes a profile
+func checkProfileCommand(app *cli.App, command []string) error {
+	found, err := findCommand(app, command)
+	if err != nil {
+		return err
+	}
+
+	for _, flag := range found.Flags {
+		if flag.GetName() == "profile" {
+			return nil
+		}
+	}
+
+	return errors.Errorf("%s doesn't take a profile", strings.Join(command, " "))
+}
+
+// findCommand looks up a command of the app by its name followed by the names of its subcommands
+func findCommand(app *cli.App, command []string) (*cli.Command, error) {
+	if len(command) == 0 {
+		return nil, errors.New("no command given")
+	}
+
+	commands := app.Commands
+	var found *cli.Command
+	for _, name := range command {
+		found = nil
+		for i := range commands {
+			if commands[i].HasName(name) {
+				found = &commands[i]
+				break
+			}
+		}
+
+		if found == nil {
+			return nil, errors.Errorf("unknown command %q", strings.Join(command, " "))
+		}
+
+		commands = found.Subcommands
+	}
+
+	return found, nil
+}
+
+// globalFlagArgs are the global flags set for this invocation, passed on to the command a profile runs. the
+// profiling flags are left out, the outer run profiles the whole process and a nested run would start and stop its
+// profile again
+func globalFlagArgs(c *cli.Context) []string {
+	var names []string
+	for _, name := range c.GlobalFlagNames() {
+		if c.GlobalIsSet(name) && !containsString(processFlags, name) {
+			names = append(names, name)
+		}
+	}
+
+	return flagArgs(names, c.GlobalGeneric)
+}
+
+// commandFlagArgs are the flags of the command set for this invocation, leaving out those named in skip
+func commandFlagArgs(c *cli.Context, skip ...string) []string {
+	var names []string
+	for _, name := range c.FlagNames() {
+		if c.IsSet(name) && !containsString(skip, name) {
+			names = append(names, name)
+		}
+	}
+
+	return flagArgs(names, c.Generic)
+}
+
+// flagArgs turns the values of the named flags back into arguments
+func flagArgs(names []string, value func(name string) interface{}) []string {
+	var args []string
+	for _, name := range names {
+		// slice flags print as [a b], each value is passed as a flag of its own
+		switch value := value(name).(type) {
+		case *cli.StringSlice:
+			for _, v := range value.Value() {
+				args = append(args, "--"+name+"="+v)
+			}
+		case *cli.IntSlice:
+			for _, v := range value.Value() {
+				args = append(args, "--"+name+"="+strconv.Itoa(v))
+			}
+		case *cli.Int64Slice:
+			for _, v := range value.Value() {
+				args = append(args, "--"+name+"="+strconv.FormatInt(v, 10))
+			}
+		case flag.Value:
+			args = append(args, "--"+name+"="+value.String())
+		}
+	}
+
+	return args
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestGlobalFlagArgs(t *testing.T) {
+	var got []string
+	app := cli.NewApp()
+	app.Flags = globalArguments
+	app.Action = func(c *cli.Context) error {
+		got = globalFlagArgs(c)
+		return nil
+	}
+
+	err := app.Run([]string{appName, "--encrypt-to", "age1a", "--encrypt-to", "age1b", "--lang", "de",
+		"--cpuprofile", "cpu.out", "--trace", "trace.out"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"--encrypt-to=age1a", "--encrypt-to=age1b", "--lang=de"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("globalFlagArgs() = %q, want %q", got, want)
+	}
+}
+
+// TestRunProfiles runs every profile of a config file, each writing the recorded boards to its own output
+func TestRunProfiles(t *testing.T) {
+	server := replayFixtures(t)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "trello2md-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	profile := `    board-id: [b1, b2]
+    show-labels-and-members: true
+    show-description: true
+    show-checklists: true
+    show-comments: true
+    show-attachments: true
+    show-completion: true
+`
+	config := "profiles:\n" +
+		"  weekly:\n" + profile + "    output: " + filepath.Join(dir, "weekly.md") + "\n" +
+		"  monthly:\n" + profile + "    output: " + filepath.Join(dir, "monthly.md") + "\n"
+	configPath := filepath.Join(dir, "config.yaml")
+	err = ioutil.WriteFile(configPath, []byte(config), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = newApp().Run([]string{appName, "--api-base-url", server.URL + "/1", "--key", "k", "--token", "t",
+		"--config", configPath, "run", "--all"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := ioutil.ReadFile("testdata/export.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"weekly.md", "monthly.md"} {
+		got, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(withoutGenerationDate(got)) != string(withoutGenerationDate(want)) {
+			t.Errorf("the %s profile's export differs from the fixtures' export, got:\n%s", name, got)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	warnings = &warningLog{}
+)
+
+// WarningKind groups warnings by what went wrong, so they can be filtered or shown differently
+type WarningKind string
+
+const (
+	// WarningSkipped is a board or card left out of the export
+	WarningSkipped WarningKind = "skipped"
+	// WarningTruncated is a result trello cut short, such as the actions of a card with a long history
+	WarningTruncated WarningKind = "truncated"
+	// WarningUnresolvedLink is a link to a card which couldn't be looked up
+	WarningUnresolvedLink WarningKind = "unresolved-link"
+	// WarningLoginLink is a card linking to uploads readers need a trello login to open
+	WarningLoginLink WarningKind = "login-link"
+	// WarningMalformedDate is a date trello sent which couldn't be read
+	WarningMalformedDate WarningKind = "malformed-date"
+	// WarningImage is an image which couldn't be downloaded or embedded and is linked instead
+	WarningImage WarningKind = "image"
+	// WarningLookup is anything else which couldn't be looked up and is left out of the document
+	WarningLookup WarningKind = "lookup"
+	// WarningDegraded is an optional section trello refused to serve, summarised once per export
+	WarningDegraded WarningKind = "degraded"
+)
+
+// Warning is an issue which didn't stop the export but left something out of or wrong in the document
+type Warning struct {
+	Kind WarningKind `json:"kind"`
+	// Subject is the id, short link or url of what the warning is about, empty when it's about the whole export
+	Subject string `json:"subject,omitempty"`
+	Message string `json:"message"`
+}
+
+// warningLog collects the warnings logged during the lifetime of the process so they can be included in run reports
+// and handed to output plugins, which can show them in their own ui rather than leaving them in trello2md's log
+type warningLog struct {
+	mu       sync.Mutex
+	warnings []Warning
+}
+
+func (l *warningLog) add(kind WarningKind, subject string, message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.warnings = append(l.warnings, Warning{Kind: kind, Subject: subject, Message: message})
+	log.Printf("warning: %s", message)
+}
+
+// since returns the warnings logged after the first n
+func (l *warningLog) since(n int) []Warning {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]Warning{}, l.warnings[n:]...)
+}
+
+func (l *warningLog) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.warnings)
+}
+
+// runReport summarises what an export covered, so automation can assert it exported what was expected
+type runReport struct {
+	start             time.Time
+	startRequests     int64
+	startWarnings     int
+	startDegradations int
+
+	Boards      int      `json:"boards"`
+	Lists       int      `json:"lists"`
+	Cards       int      `json:"cards"`
+	APIRequests int64    `json:"api_requests"`
+	Warnings    []string `json:"warnings"`
+	// WarningDetails are the warnings with their kind and subject, Warnings has just their messages
+	WarningDetails []Warning `json:"warning_details"`
+	// Degraded are the optional sections trello refused to serve and which were left out
+	Degraded []Degradation `json:"degraded"`
+	Duration float64       `json:"duration_seconds"`
+}
+
+// startRunReport notes where the api request, warning and degradation counts stand as an export starts
+func startRunReport() *runReport {
+	return &runReport{
+		start:             time.Now(),
+		startRequests:     metrics.requests(),
+		startWarnings:     warnings.count(),
+		startDegradations: degradations.count(),
+	}
+}
+
+// finish totals the exported boards, lists and cards along with everything that happened since the report started
+func (r *runReport) finish(exports []*boardExport) {
+	for _, boardExport := range exports {
+		r.Boards++
+		for _, listExport := range boardExport.lists {
+			r.Lists++
+			r.Cards += len(listExport.cards) + listExport.streamed
+		}
+	}
+
+	r.APIRequests = metrics.requests() - r.startRequests
+	// each degraded section is warned about once rather than for every card it's left out of
+	r.Degraded = append([]Degradation{}, degradations.since(r.startDegradations)...)
+	for _, degradation := range r.Degraded {
+		warnings.add(WarningDegraded, "", degradation.String())
+	}
+	r.WarningDetails = warnings.since(r.startWarnings)
+	r.Warnings = []string{}
+	for _, warning := range r.WarningDetails {
+		r.Warnings = append(r.Warnings, warning.Message)
+	}
+	r.Duration = time.Since(r.start).Seconds()
+}
+
+func (r *runReport) String() string {
+	return fmt.Sprintf("exported %d boards, %d lists and %d cards with %d api requests and %d warnings in %s",
+		r.Boards, r.Lists, r.Cards, r.APIRequests, len(r.Warnings), time.Duration(r.Duration*float64(time.Second)).Round(time.Millisecond))
+}
+
+// write saves the report as json
+func (r *runReport) write(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = writeStateFile(path, append(data, '\n'))
+	if err != nil {
+		return errors.Wrap(err, "unable to write the run report")
+	}
+
+	return nil
+}
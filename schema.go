@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	// exportSchemaVersion is the version of the json export's structure, it's bumped when a field is removed, renamed
+	// or changes type. fields are only ever added within a version so consumers should ignore fields they don't know
+	exportSchemaVersion = 1
+)
+
+var (
+	schemaArgs = []cli.Flag{
+		cli.StringFlag{
+			Name:   "output",
+			Usage:  "the file to write the schema to instead of stdout",
+			EnvVar: "TRELLO2MD_SCHEMA_OUTPUT",
+		},
+	}
+)
+
+// exportSchemaId identifies the schema of the json export's current version, exports name it in their $schema field
+func exportSchemaId() string {
+	return fmt.Sprintf("urn:trello2md:export:v%d", exportSchemaVersion)
+}
+
+// schema prints the json schema of the export --json-output and snapshot save write, for validating exports
+func schema(c *cli.Context) error {
+	data, err := json.MarshalIndent(newExportSchema(), "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if c.String("output") != "" {
+		return writeFileIfChanged(c.String("output"), data)
+	}
+
+	_, err = os.Stdout.Write(data)
+	if err != nil {
+		return errors.Wrap(err, "unable to write the schema")
+	}
+
+	return nil
+}
+
+// validateExportVersion rejects exports written by a newer version of the format, exports from before it was
+// versioned have no version and are read as the first
+func validateExportVersion(path string, version int) error {
+	if version > exportSchemaVersion {
+		return errors.Errorf("%s is version %d of the export format but this trello2md only reads up to version %d, upgrade trello2md to read it", path, version, exportSchemaVersion)
+	}
+
+	return nil
+}
+
+// newExportSchema derives the json schema of the export from the types it's encoded from, so it can't drift from
+// what's written. named structs become definitions referenced wherever they're used
+func newExportSchema() map[string]interface{} {
+	definitions := map[string]interface{}{}
+	root := structSchema(reflect.TypeOf(queryDocument{}), definitions)
+
+	root["$schema"] = "http://json-schema.org/draft-07/schema#"
+	root["$id"] = exportSchemaId()
+	root["title"] = "trello2md export"
+	root["description"] = fmt.Sprintf("the boards, lists and cards written by --json-output and snapshot save, version %d of the format", exportSchemaVersion)
+	root["definitions"] = definitions
+
+	return root
+}
+
+// typeSchema returns the schema of the json encoding of t, adding the named structs it holds to definitions
+func typeSchema(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return nullable(typeSchema(t.Elem(), definitions))
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		// nil slices are encoded as null
+		return nullable(map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), definitions)})
+	case reflect.Map:
+		return nullable(map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), definitions)})
+	case reflect.Interface:
+		return map[string]interface{}{}
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			return map[string]interface{}{"type": "string", "format": "date-time"}
+		}
+
+		if t.Name() == "" {
+			return structSchema(t, definitions)
+		}
+
+		if _, ok := definitions[t.Name()]; !ok {
+			// reserved before the fields are walked so a type holding itself refers to its definition
+			definitions[t.Name()] = nil
+			definitions[t.Name()] = structSchema(t, definitions)
+		}
+
+		return map[string]interface{}{"$ref": "#/definitions/" + t.Name()}
+	}
+
+	panic(fmt.Sprintf("the export holds a %s which can't be described by its schema", t))
+}
+
+// structSchema describes the fields encoding/json writes for t, fields which aren't omitted when empty are required
+func structSchema(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	var addFields func(t reflect.Type)
+	addFields = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+
+			name, options := tag, ""
+			if comma := strings.Index(tag, ","); comma >= 0 {
+				name, options = tag[:comma], tag[comma+1:]
+			}
+
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				addFields(field.Type)
+				continue
+			}
+
+			if field.PkgPath != "" {
+				continue
+			}
+
+			if name == "" {
+				name = field.Name
+			}
+
+			properties[name] = typeSchema(field.Type, definitions)
+			if !strings.Contains(options, "omitempty") {
+				required = append(required, name)
+			}
+		}
+	}
+	addFields(t)
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// nullable allows null in place of the value schema describes, references can't carry a type so they're wrapped
+func nullable(schema map[string]interface{}) map[string]interface{} {
+	if kind, ok := schema["type"].(string); ok {
+		schema["type"] = []string{kind, "null"}
+		return schema
+	}
+
+	if _, ok := schema["$ref"]; ok {
+		return map[string]interface{}{"oneOf": []interface{}{schema, map[string]interface{}{"type": "null"}}}
+	}
+
+	return schema
+}
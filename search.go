@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	searchFormatText = "text"
+	searchFormatJSON = "json"
+
+	searchSortRelevance = "relevance"
+	searchSortActivity  = "activity"
+
+	// searchBoardFields are the fields of the boards found which are needed to filter and sort them
+	searchBoardFields = "name,idOrganization,starred,dateLastActivity"
+)
+
+var (
+	// trelloBoardUrl matches trello board urls, the board's short link follows /b/
+	trelloBoardUrl = regexp.MustCompile(`^https?://trello\.com/b/(\w+)`)
+)
+
+// searchBoard is a board in trello's search results
+type searchBoard struct {
+	Id               string `json:"id"`
+	Name             string `json:"name"`
+	IdOrganization   string `json:"idOrganization"`
+	Starred          bool   `json:"starred"`
+	DateLastActivity string `json:"dateLastActivity"`
+}
+
+// boardSearchResult is a board found by search-boards, the lists and members are only filled in for a board
+// resolved from its url
+type boardSearchResult struct {
+	Id      string   `json:"id"`
+	Name    string   `json:"name"`
+	Url     string   `json:"url,omitempty"`
+	Lists   []string `json:"lists,omitempty"`
+	Members int      `json:"members,omitempty"`
+	// LastActivity is when anything last happened on the board, as an RFC3339 date
+	LastActivity string `json:"last_activity,omitempty"`
+}
+
+func validateSearchFormat(format string) error {
+	if format != searchFormatText && format != searchFormatJSON {
+		return errors.Errorf("unsupported format %q, expected text or json", format)
+	}
+
+	return nil
+}
+
+func validateSearchSort(sortBy string) error {
+	if sortBy != searchSortRelevance && sortBy != searchSortActivity {
+		return errors.Errorf("unsupported sort %q, expected relevance or activity", sortBy)
+	}
+
+	return nil
+}
+
+// resolveWorkspace returns the id of the workspace with the id or short name
+func resolveWorkspace(c *cli.Context, workspace string) (string, error) {
+	client, err := newClient(c)
+	if err != nil {
+		return "", err
+	}
+
+	var organization trello.Organization
+	err = getJSON(client, "/organizations/"+workspace, &organization)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to find workspace %s", workspace)
+	}
+
+	return organization.Id, nil
+}
+
+// resolveBoardUrl looks up the board a pasted board url points at along with its open lists and member count
+func resolveBoardUrl(c *cli.Context, shortLink string) (*boardSearchResult, error) {
+	client, err := newClient(c)
+	if err != nil {
+		return nil, err
+	}
+
+	board, err := client.Board(shortLink)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to find board %s", shortLink)
+	}
+
+	lists, err := getLists(board)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := board.Members()
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to list the members of %s", board.Name)
+	}
+
+	result := &boardSearchResult{Id: board.Id, Name: board.Name, Url: board.Url, Members: len(members)}
+	for _, list := range lists {
+		result.Lists = append(result.Lists, list.Name)
+	}
+
+	return result, nil
+}
+
+func printBoardSearchResults(results []boardSearchResult, format string) error {
+	if format == searchFormatJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(results)
+	}
+
+	for _, result := range results {
+		fmt.Printf("%s - %s\n", result.Id, result.Name)
+		if result.Url == "" {
+			continue
+		}
+
+		fmt.Printf("  lists: %s\n", strings.Join(result.Lists, ", "))
+		fmt.Printf("  members: %d\n", result.Members)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"github.com/pkg/errors"
+)
+
+// reportSection collects the cards carrying any of its labels under a heading, e.g. bug cards under Fixes
+type reportSection struct {
+	Name   string   `yaml:"name"`
+	Labels []string `yaml:"labels"`
+}
+
+// reportSections are tried in order, a card goes into the first section it has a label of
+type reportSections []reportSection
+
+func (s reportSections) validate() error {
+	for i, section := range s {
+		if section.Name == "" {
+			return errors.Errorf("section %d has no name", i+1)
+		}
+
+		if len(section.Labels) == 0 {
+			return errors.Errorf("section %s has no labels", section.Name)
+		}
+	}
+
+	return nil
+}
+
+// names are the section names in order followed by the group of cards in no section
+func (s reportSections) names() []string {
+	var names []string
+	for _, section := range s {
+		names = append(names, section.Name)
+	}
+
+	return append(names, releaseNotesOtherGroup)
+}
+
+// sectionOf names the section a card belongs in, cards without any of the labels belong in Other
+func (s reportSections) sectionOf(card *Card) string {
+	for _, section := range s {
+		for _, label := range card.Labels {
+			if containsString(section.Labels, label.Name) {
+				return section.Name
+			}
+		}
+	}
+
+	return releaseNotesOtherGroup
+}
+
+// regroup replaces the lists of each board with a list per section holding the cards of every exported list, in
+// section order and leaving out empty sections
+func (s reportSections) regroup(exports []*boardExport) {
+	for _, export := range exports {
+		bySection := map[string][]*cardView{}
+		for _, list := range export.lists {
+			for _, view := range list.cards {
+				section := s.sectionOf(&view.Card)
+				bySection[section] = append(bySection[section], view)
+			}
+		}
+
+		var lists []*listExport
+		for _, name := range s.names() {
+			if len(bySection[name]) == 0 {
+				continue
+			}
+
+			heading := name
+			if name == releaseNotesOtherGroup {
+				heading = tr("other")
+			}
+
+			lists = append(lists, &listExport{
+				list:  List{Name: heading, IdBoard: export.board.Id},
+				cards: bySection[name],
+			})
+		}
+
+		export.lists = lists
+		export.headings = true
+	}
+}
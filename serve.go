@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+// renderedExport is an export kept to answer requests until the cache ttl passes
+type renderedExport struct {
+	document []byte
+	etag     string
+	rendered time.Time
+}
+
+func newRenderedExport(document []byte) *renderedExport {
+	return &renderedExport{
+		document: document,
+		etag:     fmt.Sprintf(`"%x"`, sha256.Sum256(document)),
+		rendered: time.Now(),
+	}
+}
+
+// matches reports whether the etag is one of those listed in an If-None-Match header
+func (e *renderedExport) matches(ifNoneMatch string) bool {
+	for _, etag := range strings.Split(ifNoneMatch, ",") {
+		etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+		if etag == e.etag || etag == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func serve(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	// the exports of other profiles start from the flags given on the command line, before the served profile's
+	// values are applied
+	commandArgs := commandFlagArgs(c, "profile")
+
+	err = applyProfile(c, cfg)
+	if err != nil {
+		return err
+	}
+
+	// responses are the rendered document, an export written to files would leave them empty
+	if c.String("output") != "" || c.String("split-by") != "" {
+		return errors.New("serve can't be combined with --output or --split-by")
+	}
+
+	// the readiness probe checks the boards without waiting for in-flight exports, which share the flag set
+	readyBoardIds := c.StringSlice("board-id")
+
+	// exports share the command's flag set so requests are rendered one at a time
+	var exportMu sync.Mutex
+	// cache holds the latest export for each set of allowed boards
+	cache := map[string]*renderedExport{}
+	// boardNames are the names of the boards the exports fetched, for the index
+	boardNames := map[string]string{}
+
+	// authorize returns the boards the request may see, nil when it may see every board. false is returned once the
+	// request has been refused
+	authorize := func(w http.ResponseWriter, r *http.Request) ([]string, bool) {
+		if len(cfg.Access) == 0 {
+			return nil, true
+		}
+
+		rule := cfg.authorize(r)
+		if rule == nil {
+			w.Header().Set("WWW-Authenticate", `Basic realm="`+appName+`"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return nil, false
+		}
+
+		return cfg.allowedBoards(rule), true
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+
+		// health checks, metrics and the version stay open, only the rendered boards are protected
+		allowed, ok := authorize(w, r)
+		if !ok {
+			return
+		}
+
+		exportMu.Lock()
+		defer exportMu.Unlock()
+
+		key := allBoards
+		if allowed != nil {
+			key = strings.Join(allowed, ",")
+		}
+
+		// another profile of the config file is rendered when it's picked from the index
+		exportContext := c
+		profile := r.URL.Query().Get("profile")
+		if profile != "" && profile != c.String("profile") {
+			if _, ok := cfg.Profiles[profile]; !ok {
+				http.NotFound(w, r)
+				return
+			}
+
+			var err error
+			exportContext, err = profileContext(c, cfg, commandArgs, profile)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// a single board of the export is rendered on its own when it's picked from the index
+		if board := r.URL.Query().Get("board"); board != "" {
+			err := applyProfile(exportContext, cfg)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			boardId := cfg.boardID(board)
+			boardIds, _, err := servedBoards(exportContext, cfg)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			if !containsString(boardIds, boardId) || (allowed != nil && !containsString(allowed, boardId)) {
+				http.NotFound(w, r)
+				return
+			}
+
+			key = boardId
+			allowed = []string{boardId}
+		}
+
+		if exportContext != c {
+			key = profile + ":" + key
+		}
+
+		rendered, ok := cache[key]
+		if !ok || time.Since(rendered.rendered) >= c.Duration("cache-ttl") {
+			var document bytes.Buffer
+			err := export(exportContext, &document, exportOptions{Allowed: allowed, BoardNames: boardNames})
+			if err != nil {
+				log.Printf("export failed: %v", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			rendered = newRenderedExport(document.Bytes())
+			cache[key] = rendered
+		}
+
+		w.Header().Set("ETag", rendered.etag)
+		w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(c.Duration("cache-ttl").Seconds())))
+		if rendered.matches(r.Header.Get("If-None-Match")) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		_, _ = w.Write(rendered.document)
+	})
+	mux.HandleFunc(serveIndexPath, func(w http.ResponseWriter, r *http.Request) {
+		allowed, ok := authorize(w, r)
+		if !ok {
+			return
+		}
+
+		exportMu.Lock()
+		defer exportMu.Unlock()
+
+		err := applyProfile(c, cfg)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var page bytes.Buffer
+		err = writeServeIndex(&page, c, cfg, allowed, cache, boardNames)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(page.Bytes())
+	})
+	mux.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(buildVersion())
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		err := checkReady(c, cfg, readyBoardIds)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		_, _ = fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		metrics.write(w)
+	})
+
+	server := &http.Server{
+		Addr:    c.String("listen"),
+		Handler: mux,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("serving on %s", server.Addr)
+		errs <- server.ListenAndServe()
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case err := <-errs:
+		return err
+	case sig := <-signals:
+		log.Printf("received %s, waiting up to %s for in-flight exports to finish", sig, c.Duration("shutdown-timeout"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.Duration("shutdown-timeout"))
+	defer cancel()
+
+	err = server.Shutdown(ctx)
+	if err != nil {
+		return errors.Wrap(err, "in-flight exports did not finish before the shutdown timeout")
+	}
+
+	return nil
+}
+
+// profileContext returns a context for the serve command with the values of another profile in place of the served
+// one's, args are the command's flags given on the command line which still win over the profile
+func profileContext(c *cli.Context, cfg *config, args []string, name string) (*cli.Context, error) {
+	set := flag.NewFlagSet(c.Command.Name, flag.ContinueOnError)
+	set.SetOutput(ioutil.Discard)
+	for _, f := range c.Command.Flags {
+		f.Apply(set)
+	}
+
+	err := set.Parse(append(args, "--profile="+name))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to render profile %s", name)
+	}
+
+	profileContext := cli.NewContext(c.App, set, c.Parent())
+	profileContext.Command = c.Command
+
+	return profileContext, applyProfile(profileContext, cfg)
+}
+
+// checkReady verifies the credentials are accepted and every served board can be read
+func checkReady(c *cli.Context, cfg *config, boardIds []string) error {
+	if len(boardIds) == 0 {
+		return errors.New("no boards configured")
+	}
+
+	clients := newBoardClients(c, cfg)
+	for _, boardId := range boardIds {
+		boardId = cfg.boardID(boardId)
+		client, err := clients.forBoard(boardId)
+		if err != nil {
+			return err
+		}
+
+		_, err = client.Board(boardId)
+		if err != nil {
+			return errors.Wrapf(err, "unable to access board %s", boardId)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+const (
+	// serveIndexPath is where the serve command lists the boards it serves
+	serveIndexPath = "/boards"
+)
+
+var (
+	serveIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 40em; }
+li { margin: 0.4em 0; }
+small { color: #666; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{with .Profiles}}<p>{{$.ProfilesLabel}}: {{range $i, $p := .}}{{if $i}}, {{end}}<a href="{{$p.URL}}">{{if $p.Served}}<strong>{{$p.Name}}</strong>{{else}}{{$p.Name}}{{end}}</a>{{end}}</p>{{end}}
+<ul>
+{{range .Entries}}<li><a href="{{.URL}}">{{.Name}}</a> <small>{{.Refreshed}}</small></li>
+{{end}}</ul>
+</body>
+</html>
+`))
+)
+
+// serveIndex is the page listing the exports a request may see
+type serveIndex struct {
+	Title         string
+	ProfilesLabel string
+	Profiles      []serveIndexProfile
+	Entries       []serveIndexEntry
+}
+
+// serveIndexProfile links to the export of a profile of the config file, the served one is highlighted
+type serveIndexProfile struct {
+	Name   string
+	URL    string
+	Served bool
+}
+
+// serveIndexEntry links to an export along with when it was last rendered
+type serveIndexEntry struct {
+	Name      string
+	URL       string
+	Refreshed string
+}
+
+// servedBoards returns the ids of the boards the serve command exports, picked as export picks them from --board-id,
+// the manifest or --from-trello-json, along with the names to show them by until they've been exported: the name in
+// the json export, or else their alias when they have one and otherwise their id
+func servedBoards(c *cli.Context, cfg *config) ([]string, map[string]string, error) {
+	var picked *manifest
+	if c.String("manifest") != "" {
+		var err error
+		picked, err = loadManifest(c.String("manifest"), cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var boardIds []string
+	names := map[string]string{}
+	if paths := c.StringSlice("from-trello-json"); len(paths) > 0 {
+		for _, path := range paths {
+			board, err := readTrelloJSONBoard(path)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if picked.keepsBoard(board.Id) {
+				boardIds = append(boardIds, board.Id)
+				names[board.Id] = board.Name
+			}
+		}
+
+		return boardIds, names, nil
+	}
+
+	for _, board := range picked.boards(c.StringSlice("board-id"), cfg) {
+		boardId := cfg.boardID(board)
+		boardIds = append(boardIds, boardId)
+		names[boardId] = board
+
+		for alias, aliased := range cfg.Aliases {
+			if board == boardId && aliased == boardId && (names[boardId] == boardId || alias < names[boardId]) {
+				names[boardId] = alias
+			}
+		}
+	}
+
+	return boardIds, names, nil
+}
+
+// writeServeIndex writes the index page linking to the export of every configured profile, of every allowed board and
+// of all of them together. boards are shown by the names their exports fetched, exports which haven't been
+// rendered yet are rendered when they're first opened
+func writeServeIndex(w io.Writer, c *cli.Context, cfg *config, allowed []string, cache map[string]*renderedExport,
+	boardNames map[string]string) error {
+	index := serveIndex{Title: tr("indexTitle"), ProfilesLabel: tr("indexProfiles")}
+	for _, name := range cfg.profileNames() {
+		profile := serveIndexProfile{Name: name, URL: "/?profile=" + url.QueryEscape(name)}
+		if name == c.String("profile") {
+			profile.Served = true
+			profile.URL = "/"
+		}
+
+		index.Profiles = append(index.Profiles, profile)
+	}
+
+	key := allBoards
+	if allowed != nil {
+		key = strings.Join(allowed, ",")
+	}
+	index.Entries = append(index.Entries, serveIndexEntry{
+		Name:      tr("indexAll"),
+		URL:       "/",
+		Refreshed: refreshedAt(cache[key]),
+	})
+
+	boardIds, names, err := servedBoards(c, cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, boardId := range boardIds {
+		if allowed != nil && !containsString(allowed, boardId) {
+			continue
+		}
+
+		name, ok := boardNames[boardId]
+		if !ok {
+			name = names[boardId]
+		}
+
+		index.Entries = append(index.Entries, serveIndexEntry{
+			Name:      name,
+			URL:       "/?board=" + url.QueryEscape(boardId),
+			Refreshed: refreshedAt(cache[boardId]),
+		})
+	}
+
+	return serveIndexTemplate.Execute(w, index)
+}
+
+func refreshedAt(rendered *renderedExport) string {
+	if rendered == nil {
+		return tr("indexNotRendered")
+	}
+
+	return tr("indexRefreshed", rendered.rendered.Format(time.RFC1123))
+}
@@ -0,0 +1,521 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	snapshotExtension = ".json"
+)
+
+var (
+	snapshotArgs = []cli.Flag{
+		cli.StringFlag{
+			Name:   "snapshot-dir",
+			Usage:  "the directory snapshots are kept in",
+			EnvVar: "TRELLO2MD_SNAPSHOT_DIR",
+			Value:  "snapshots",
+		},
+	}
+
+	snapshotSaveArgs = append([]cli.Flag{
+		cli.BoolFlag{
+			Name:   "force",
+			Usage:  "replace a snapshot which already has the name",
+			EnvVar: "TRELLO2MD_SNAPSHOT_FORCE",
+		},
+	}, snapshotArgs...)
+)
+
+// snapshotPath returns where the named snapshot is kept, names are plain file names without the extension
+func snapshotPath(c *cli.Context, name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", errors.Errorf("invalid snapshot name %q", name)
+	}
+
+	return filepath.Join(c.String("snapshot-dir"), name+snapshotExtension), nil
+}
+
+// snapshotSave exports the boards as json into the snapshot directory, named after today's date unless a name
+// is given
+func snapshotSave(c *cli.Context) error {
+	name := c.Args().First()
+	if name == "" {
+		name = time.Now().Format(dateFormat)
+	}
+
+	path, err := snapshotPath(c, name)
+	if err != nil {
+		return err
+	}
+
+	existing, err := findOutput(path)
+	if err != nil {
+		return err
+	}
+
+	if existing != "" && !c.Bool("force") {
+		return errors.Errorf("snapshot %s already exists, use --force to replace it", name)
+	}
+
+	err = c.Set("json-output", path)
+	if err != nil {
+		return err
+	}
+
+	err = export(c, ioutil.Discard, exportOptions{})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("saved snapshot %s", name)
+
+	return nil
+}
+
+// snapshotList prints the saved snapshots oldest first with the boards and cards each holds
+func snapshotList(c *cli.Context) error {
+	paths, err := filepath.Glob(filepath.Join(c.String("snapshot-dir"), "*"+snapshotExtension+"*"))
+	if err != nil {
+		return err
+	}
+
+	type savedSnapshot struct {
+		name   string
+		saved  time.Time
+		boards int
+		cards  int
+	}
+
+	var snapshots []savedSnapshot
+	for _, path := range paths {
+		// encrypted snapshots have the extension of the tool they were encrypted with appended
+		name := filepath.Base(path)
+		for _, tool := range []encryptionTool{ageTool, gpgTool} {
+			name = strings.TrimSuffix(name, tool.extension)
+		}
+		if !strings.HasSuffix(name, snapshotExtension) {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		document, err := loadExportJSON(filepath.Join(filepath.Dir(path), name))
+		if err != nil {
+			return err
+		}
+
+		snapshot := savedSnapshot{
+			name:   strings.TrimSuffix(name, snapshotExtension),
+			saved:  info.ModTime(),
+			boards: len(document.Boards),
+		}
+		for _, board := range document.Boards {
+			for _, list := range board.Lists {
+				snapshot.cards += len(list.Cards)
+			}
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].saved.Before(snapshots[j].saved)
+	})
+
+	for _, snapshot := range snapshots {
+		fmt.Printf("%s - saved %s - %d boards, %d cards\n", snapshot.name, snapshot.saved.Format("2006-01-02 15:04"), snapshot.boards, snapshot.cards)
+	}
+
+	return nil
+}
+
+// snapshotDiff prints what changed on the boards between two snapshots, or between a snapshot and the boards as
+// they are now when only one is named
+func snapshotDiff(c *cli.Context) error {
+	if c.NArg() < 1 || c.NArg() > 2 {
+		return errors.New("snapshot diff takes the snapshot to compare from and optionally the one to compare to")
+	}
+
+	fromName := c.Args().Get(0)
+	fromPath, err := snapshotPath(c, fromName)
+	if err != nil {
+		return err
+	}
+
+	from, err := loadExportJSON(fromPath)
+	if err != nil {
+		return err
+	}
+
+	var to queryDocument
+	title := tr("changesSince", fromName)
+	if c.NArg() == 2 {
+		toName := c.Args().Get(1)
+		toPath, err := snapshotPath(c, toName)
+		if err != nil {
+			return err
+		}
+
+		to, err = loadExportJSON(toPath)
+		if err != nil {
+			return err
+		}
+
+		title = tr("changesBetween", fromName, toName)
+	} else {
+		to, err = fetchExportJSON(c)
+		if err != nil {
+			return err
+		}
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	printDate(os.Stdout, time.Now())
+	fmt.Printf("### %s\n", title)
+	printSnapshotDiff(os.Stdout, cfg, from, to)
+
+	return nil
+}
+
+// loadExportJSON reads an export written by --json-output or snapshot save, decrypting it when it was encrypted
+func loadExportJSON(path string) (queryDocument, error) {
+	var document queryDocument
+	data, err := readOutput(path)
+	if err != nil {
+		return document, errors.Wrap(err, "unable to read snapshot")
+	}
+
+	err = json.Unmarshal(data, &document)
+	if err != nil {
+		return document, errors.Wrapf(err, "unable to parse snapshot %s", path)
+	}
+
+	return document, validateExportVersion(path, document.Version)
+}
+
+// fetchExportJSON exports the boards as they are now in the structure snapshots are saved in
+func fetchExportJSON(c *cli.Context) (queryDocument, error) {
+	dir, err := ioutil.TempDir("", "trello2md-snapshot")
+	if err != nil {
+		return queryDocument{}, err
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "now"+snapshotExtension)
+	err = c.Set("json-output", path)
+	if err != nil {
+		return queryDocument{}, err
+	}
+
+	// the export is read back and removed straight away so it's never encrypted
+	err = export(c, ioutil.Discard, exportOptions{PlainJSONOutput: true})
+	if err != nil {
+		return queryDocument{}, err
+	}
+
+	return loadExportJSON(path)
+}
+
+// snapshotCard is a card of a snapshot along with where it was
+type snapshotCard struct {
+	board queryBoard
+	list  string
+	view  *cardView
+}
+
+func snapshotCards(document queryDocument) ([]snapshotCard, map[string]snapshotCard) {
+	var cards []snapshotCard
+	byId := map[string]snapshotCard{}
+	for _, board := range document.Boards {
+		for _, list := range board.Lists {
+			for _, view := range list.Cards {
+				card := snapshotCard{board: board, list: list.Name, view: view}
+				cards = append(cards, card)
+				byId[view.Card.Id] = card
+			}
+		}
+	}
+
+	return cards, byId
+}
+
+// boardChanges are the changes to the cards of a board, each a line of the diff
+type boardChanges struct {
+	name    string
+	added   []string
+	removed []string
+	moved   []string
+	changed []string
+	// overLimit are the lists of the later snapshot holding more cards than their WIP limit
+	overLimit []string
+}
+
+// printSnapshotDiff writes the cards added, removed, moved between lists and changed between the snapshots,
+// grouped by board in the order the boards were exported, followed by the lists over their WIP limit
+func printSnapshotDiff(w io.Writer, cfg *config, from queryDocument, to queryDocument) {
+	fromCards, fromById := snapshotCards(from)
+	toCards, toById := snapshotCards(to)
+
+	var boards []*boardChanges
+	byBoard := map[string]*boardChanges{}
+	changesOf := func(board queryBoard) *boardChanges {
+		changes, ok := byBoard[board.Id]
+		if !ok {
+			changes = &boardChanges{name: board.Name}
+			boards = append(boards, changes)
+			byBoard[board.Id] = changes
+		}
+
+		return changes
+	}
+
+	for _, card := range toCards {
+		changes := changesOf(card.board)
+		before, ok := fromById[card.view.Card.Id]
+		if !ok {
+			changes.added = append(changes.added, fmt.Sprintf("%s - %s", snapshotCardLink(&card.view.Card), card.list))
+			continue
+		}
+
+		if before.list != card.list {
+			changes.moved = append(changes.moved, fmt.Sprintf("%s - %s → %s", snapshotCardLink(&card.view.Card), before.list, card.list))
+		}
+
+		if fields := changedCardFields(&before.view.Card, &card.view.Card); len(fields) > 0 {
+			changes.changed = append(changes.changed, fmt.Sprintf("%s - %s", snapshotCardLink(&card.view.Card), strings.Join(fields, ", ")))
+		}
+	}
+
+	for _, card := range fromCards {
+		if _, ok := toById[card.view.Card.Id]; !ok {
+			changes := changesOf(card.board)
+			changes.removed = append(changes.removed, fmt.Sprintf("%s - %s", snapshotCardLink(&card.view.Card), card.list))
+		}
+	}
+
+	for _, board := range to.Boards {
+		limits := cfg.board(board.Id).WIPLimits
+		for _, list := range board.Lists {
+			if violation := wipViolation(len(list.Cards), limits[list.Name]); violation != "" {
+				changes := changesOf(board)
+				changes.overLimit = append(changes.overLimit, fmt.Sprintf("%s - %d, %s", list.Name, len(list.Cards), violation))
+			}
+		}
+	}
+
+	for _, changes := range boards {
+		fmt.Fprintf(w, "#### %s\n", changes.name)
+
+		if len(changes.added)+len(changes.removed)+len(changes.moved)+len(changes.changed) == 0 {
+			fmt.Fprintf(w, "_%s_\n\n", tr("noChanges"))
+		}
+
+		for _, section := range []struct {
+			message string
+			lines   []string
+		}{
+			{"added", changes.added},
+			{"removed", changes.removed},
+			{"moved", changes.moved},
+			{"changed", changes.changed},
+			{"overWIPLimits", changes.overLimit},
+		} {
+			if len(section.lines) == 0 {
+				continue
+			}
+
+			fmt.Fprintf(w, "##### %s\n", tr(section.message))
+			for _, line := range section.lines {
+				fmt.Fprintf(w, "- %s\n", line)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+func snapshotCardLink(card *Card) string {
+	if card.Url == "" {
+		return card.Name
+	}
+
+	return fmt.Sprintf("[%s](%s)", card.Name, card.Url)
+}
+
+// changedCardFields names what changed on a card between snapshots
+func changedCardFields(before *Card, after *Card) []string {
+	var fields []string
+	if before.Name != after.Name {
+		fields = append(fields, tr("renamedFrom", before.Name))
+	}
+
+	if before.Due != after.Due {
+		fields = append(fields, tr("fieldDue"))
+	}
+
+	if strings.Join(before.IdMembers, ",") != strings.Join(after.IdMembers, ",") {
+		fields = append(fields, tr("fieldMembers"))
+	}
+
+	if labelNames(before) != labelNames(after) {
+		fields = append(fields, tr("fieldLabels"))
+	}
+
+	if before.Desc != after.Desc {
+		fields = append(fields, tr("fieldDesc"))
+	}
+
+	return fields
+}
+
+func labelNames(card *Card) string {
+	var names []string
+	for _, label := range card.Labels {
+		names = append(names, label.Color+":"+label.Name)
+	}
+
+	return strings.Join(names, ",")
+}
+
+// snapshotComparison is an earlier export the boards are compared to, for the changes noted under board headings
+type snapshotComparison struct {
+	saved time.Time
+	cards map[string]snapshotCard
+}
+
+// boardDeltas counts what changed on a board since the earlier export
+type boardDeltas struct {
+	done     int
+	added    int
+	removed  int
+	reopened int
+	comments int
+}
+
+// loadSnapshotComparison reads the earlier export or snapshot at path, nil when it doesn't exist yet so the previous
+// run's --json-output can be compared to without failing the first run
+func loadSnapshotComparison(path string) (*snapshotComparison, error) {
+	found, err := findOutput(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if found == "" {
+		return nil, nil
+	}
+
+	info, err := os.Stat(found)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := loadExportJSON(path)
+	if err != nil {
+		return nil, err
+	}
+
+	_, byId := snapshotCards(document)
+
+	return &snapshotComparison{saved: info.ModTime(), cards: byId}, nil
+}
+
+// deltas compares the board as it's exported now to the earlier export, cards entering a done list count as done
+// and cards leaving one as reopened
+func (s *snapshotComparison) deltas(boardExport *boardExport, doneLists []string) boardDeltas {
+	var deltas boardDeltas
+	exported := map[string]bool{}
+	for _, listExport := range boardExport.lists {
+		done := containsString(doneLists, listExport.list.Name)
+		for _, view := range listExport.cards {
+			exported[view.Card.Id] = true
+
+			before, ok := s.cards[view.Card.Id]
+			if !ok {
+				deltas.added++
+				if done {
+					deltas.done++
+				}
+				deltas.comments += len(view.Comments)
+				continue
+			}
+
+			wasDone := containsString(doneLists, before.list)
+			if done && !wasDone {
+				deltas.done++
+			}
+			if !done && wasDone {
+				deltas.reopened++
+			}
+
+			seen := map[string]bool{}
+			for _, comment := range before.view.Comments {
+				seen[comment.Id] = true
+			}
+			for _, comment := range view.Comments {
+				if !seen[comment.Id] {
+					deltas.comments++
+				}
+			}
+		}
+	}
+
+	for id, card := range s.cards {
+		if card.board.Id == boardExport.board.Id && !exported[id] {
+			deltas.removed++
+		}
+	}
+
+	return deltas
+}
+
+// printBoardDeltas writes what changed on the board since the earlier export under the board's heading
+func printBoardDeltas(w io.Writer, deltas boardDeltas, since time.Time) {
+	var changes []string
+	if deltas.done > 0 {
+		changes = append(changes, tr("deltaDone", deltas.done))
+	}
+
+	if deltas.added > 0 {
+		changes = append(changes, tr("deltaNew", deltas.added))
+	}
+
+	if deltas.comments == 1 {
+		changes = append(changes, tr("deltaNewComment", deltas.comments))
+	} else if deltas.comments > 1 {
+		changes = append(changes, tr("deltaNewComments", deltas.comments))
+	}
+
+	if deltas.reopened > 0 {
+		changes = append(changes, tr("deltaReopened", deltas.reopened))
+	}
+
+	if deltas.removed > 0 {
+		changes = append(changes, tr("deltaRemoved", deltas.removed))
+	}
+
+	if len(changes) == 0 {
+		fmt.Fprintf(w, "_%s_\n\n", tr("deltasNone", since.Format(dateFormat)))
+		return
+	}
+
+	fmt.Fprintf(w, "_%s_\n\n", tr("deltasSince", strings.Join(changes, ", "), since.Format(dateFormat)))
+}
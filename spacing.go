@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+func validateCardSeparator(separator string, split string) error {
+	if separator == "" {
+		return nil
+	}
+
+	if strings.Contains(separator, "\n") {
+		return errors.New("--card-separator must be a single line")
+	}
+
+	if split != splitNone && split != splitBoard && split != splitList {
+		return errors.Errorf("--card-separator can't be combined with --split-by %s, the cards of a list aren't written together", split)
+	}
+
+	return nil
+}
+
+// printCardSeparator writes the separator after a card, set apart by blank lines so a rule isn't read as the
+// underline of a heading
+func printCardSeparator(w io.Writer, card []byte, separator string) {
+	if !bytes.HasSuffix(card, []byte("\n")) {
+		fmt.Fprintln(w)
+	}
+	if !bytes.HasSuffix(card, []byte("\n\n")) {
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "%s\n\n", separator)
+}
+
+// normalizeBlankLines collapses runs of blank lines into one, sets headings apart from what comes before them with
+// a blank line and ends the document with a single line break, fenced code and front matter are left untouched
+func normalizeBlankLines(markdown string) string {
+	lines := strings.Split(strings.TrimRight(markdown, "\n"), "\n")
+
+	var out []string
+	fenced := false
+	frontMatter := len(lines) > 0 && lines[0] == "---"
+	for i, line := range lines {
+		blank := strings.TrimSpace(line) == ""
+		previousBlank := len(out) == 0 || strings.TrimSpace(out[len(out)-1]) == ""
+
+		switch {
+		case frontMatter:
+			if i > 0 && line == "---" {
+				frontMatter = false
+			}
+		case strings.HasPrefix(strings.TrimSpace(line), "```"):
+			fenced = !fenced
+		case fenced:
+		case blank && previousBlank:
+			continue
+		case blank:
+			line = ""
+		case strings.HasPrefix(line, "#") && !previousBlank:
+			out = append(out, "")
+		}
+
+		out = append(out, line)
+	}
+
+	return strings.Join(out, "\n") + "\n"
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+const (
+	// standupFilter selects the card activity a standup report covers
+	standupFilter = "commentCard,updateCard:idList"
+)
+
+var (
+	slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+	standupArgs = []cli.Flag{
+		cli.DurationFlag{
+			Name:   "window",
+			Usage:  "how far back to look for card activity",
+			EnvVar: "STANDUP_WINDOW",
+			Value:  24 * time.Hour,
+		},
+	}
+)
+
+// standupCard is a card a member worked on along with what they did to it
+type standupCard struct {
+	name     string
+	url      string
+	movedTo  string
+	comments int
+}
+
+// standup prints the cards moved or commented on recently across every board grouped by the member responsible,
+// formatted as slack mrkdwn
+func standup(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	err = applyProfile(c, cfg)
+	if err != nil {
+		return err
+	}
+
+	clients := newBoardClients(c, cfg)
+	boards, err := getBoards(clients, c.StringSlice("board-id"))
+	if err != nil {
+		return err
+	}
+
+	since := time.Now().Add(-c.Duration("window"))
+
+	var members []string
+	byMember := map[string][]*standupCard{}
+	cards := map[string]*standupCard{}
+	for _, board := range *boards {
+		client, err := clients.forBoard(board.Id)
+		if err != nil {
+			return err
+		}
+
+		actions, err := getBoardActions(client, &board, standupFilter, since)
+		if err != nil {
+			return err
+		}
+
+		// oldest first so the last move seen is where the card ended up
+		for i := len(actions) - 1; i >= 0; i-- {
+			action := actions[i]
+			member := action.MemberCreator.FullName
+
+			key := member + "/" + action.Data.Card.Id
+			card, ok := cards[key]
+			if !ok {
+				card = &standupCard{
+					name: action.Data.Card.Name,
+					url:  "https://trello.com/c/" + action.Data.Card.ShortLink,
+				}
+				cards[key] = card
+
+				if _, ok := byMember[member]; !ok {
+					members = append(members, member)
+				}
+				byMember[member] = append(byMember[member], card)
+			}
+
+			if action.Type == commentCardAction {
+				card.comments++
+			} else if action.Data.ListAfter != nil {
+				card.movedTo = action.Data.ListAfter.Name
+			}
+		}
+	}
+
+	collation.sort(members)
+
+	for i, member := range members {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("*%s*\n", member)
+
+		for _, card := range byMember[member] {
+			fmt.Printf("• <%s|%s>%s\n", card.url, slackEscape(card.name), card.activity())
+		}
+	}
+
+	return nil
+}
+
+// activity describes what was done to the card, e.g. moved to Done, 2 comments
+func (s *standupCard) activity() string {
+	activity := ""
+	if s.movedTo != "" {
+		activity = " - " + tr("movedTo", s.movedTo)
+	}
+
+	if s.comments > 0 {
+		if activity == "" {
+			activity = " -"
+		} else {
+			activity += ","
+		}
+
+		if s.comments == 1 {
+			activity += " " + tr("comment")
+		} else {
+			activity += " " + tr("comments", s.comments)
+		}
+	}
+
+	return activity
+}
+
+// slackEscape escapes the characters slack treats as control characters in mrkdwn
+func slackEscape(s string) string {
+	return slackEscaper.Replace(s)
+}
@@ -0,0 +1,566 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	statsFormatMarkdown = "markdown"
+	statsFormatCSV      = "csv"
+
+	// actionsPageSize is the most actions trello returns for a single request
+	actionsPageSize = 1000
+
+	// cardHistoryFilter selects the board actions which change which list a card is in, or whether it's on the
+	// board at all
+	cardHistoryFilter = "createCard,copyCard,convertToCardFromCheckItem,moveCardToBoard,moveCardFromBoard,deleteCard,updateCard:idList,updateCard:closed"
+	// checkItemHistoryFilter selects the board actions which check or uncheck checklist items
+	checkItemHistoryFilter = "updateCheckItemStateOnCard"
+
+	burndownUnitCards      = "cards"
+	burndownUnitCheckItems = "checklist-items"
+
+	checkItemComplete   = "complete"
+	checkItemIncomplete = "incomplete"
+)
+
+var (
+	statsArgs = []cli.Flag{
+		cli.StringSliceFlag{
+			Name:   "board-id",
+			Usage:  "the ids or config aliases of the boards to report on",
+			EnvVar: "BOARD_ID",
+		},
+		cli.StringFlag{
+			Name:   "since",
+			Usage:  "the first day to report on as YYYY-MM-DD, defaults to 30 days ago",
+			EnvVar: "TRELLO2MD_STATS_SINCE",
+		},
+		cli.StringFlag{
+			Name:   "until",
+			Usage:  "the last day to report on as YYYY-MM-DD, defaults to today",
+			EnvVar: "TRELLO2MD_STATS_UNTIL",
+		},
+		cli.StringFlag{
+			Name:   "format",
+			Usage:  "the format to write the report in, markdown or csv",
+			EnvVar: "STATS_FORMAT",
+			Value:  statsFormatMarkdown,
+		},
+	}
+
+	burndownArgs = append([]cli.Flag{
+		cli.StringSliceFlag{
+			Name:   "done-list",
+			Usage:  "the names of the lists holding finished cards, cards anywhere else remain, defaults to Done",
+			EnvVar: "DONE_LISTS",
+		},
+		cli.StringFlag{
+			Name:   "unit",
+			Usage:  "what remains, cards or checklist-items, checklist items added after the window started are counted from its start",
+			EnvVar: "BURNDOWN_UNIT",
+			Value:  burndownUnitCards,
+		},
+	}, statsArgs...)
+)
+
+// boardAction is a board action carrying the card and list details needed to replay a board's history
+type boardAction struct {
+	Id   string `json:"id"`
+	Type string `json:"type"`
+	Date string `json:"date"`
+	Data struct {
+		Card struct {
+			Id        string `json:"id"`
+			Name      string `json:"name"`
+			ShortLink string `json:"shortLink"`
+			IdList    string `json:"idList"`
+			Closed    *bool  `json:"closed"`
+		} `json:"card"`
+		List struct {
+			Id string `json:"id"`
+		} `json:"list"`
+		ListBefore *struct {
+			Id   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"listBefore"`
+		ListAfter *struct {
+			Id   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"listAfter"`
+		CheckItem struct {
+			Id    string `json:"id"`
+			State string `json:"state"`
+		} `json:"checkItem"`
+		Old struct {
+			Closed *bool `json:"closed"`
+		} `json:"old"`
+		Text string `json:"text"`
+	} `json:"data"`
+	MemberCreator struct {
+		FullName string `json:"fullName"`
+	} `json:"memberCreator"`
+}
+
+// listOf returns the list the action's card was in when the action happened
+func (a boardAction) listOf() string {
+	if a.Data.List.Id != "" {
+		return a.Data.List.Id
+	}
+
+	return a.Data.Card.IdList
+}
+
+// boardHistory is the state of a board's open cards which can be wound back one action at a time
+type boardHistory struct {
+	lists      []List
+	cards      map[string]string
+	checkItems map[string]string
+	actions    []boardAction
+}
+
+// dayCounts is the number of cards in each list and of unchecked checklist items at the end of a day
+type dayCounts struct {
+	date       time.Time
+	counts     map[string]int
+	incomplete int
+}
+
+func statsCFD(c *cli.Context) error {
+	since, until, err := statsRange(c)
+	if err != nil {
+		return err
+	}
+
+	format := c.String("format")
+	if format != statsFormatMarkdown && format != statsFormatCSV {
+		return errors.Errorf("unsupported format %q, expected markdown or csv", format)
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	clients := newBoardClients(c, cfg)
+	boards, err := getBoards(clients, c.StringSlice("board-id"))
+	if err != nil {
+		return err
+	}
+
+	var rows *csv.Writer
+	if format == statsFormatCSV {
+		rows = csv.NewWriter(os.Stdout)
+		err = rows.Write([]string{"date", "board", "list", "cards"})
+		if err != nil {
+			return err
+		}
+	} else {
+		printDate(os.Stdout, time.Now())
+	}
+
+	for _, board := range *boards {
+		client, err := clients.forBoard(board.Id)
+		if err != nil {
+			return err
+		}
+
+		history, err := loadBoardHistory(client, &board, since, false)
+		if err != nil {
+			return err
+		}
+
+		days := history.dailyCounts(since, until)
+
+		if format == statsFormatCSV {
+			for _, day := range days {
+				for _, list := range history.lists {
+					err = rows.Write([]string{day.date.Format(dateFormat), board.Name, list.Name, strconv.Itoa(day.counts[list.Id])})
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			continue
+		}
+
+		printBoard(os.Stdout, newBoard(board))
+		printCountsTable(os.Stdout, history.lists, days)
+	}
+
+	if rows != nil {
+		rows.Flush()
+		return rows.Error()
+	}
+
+	return nil
+}
+
+// statsRange parses the inclusive range of days to report on
+func statsRange(c *cli.Context) (time.Time, time.Time, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	until := today
+	if c.String("until") != "" {
+		var err error
+		until, err = time.Parse(dateFormat, c.String("until"))
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.Wrap(err, "invalid --until")
+		}
+	}
+
+	since := until.AddDate(0, 0, -30)
+	if c.String("since") != "" {
+		var err error
+		since, err = time.Parse(dateFormat, c.String("since"))
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.Wrap(err, "invalid --since")
+		}
+	}
+
+	if since.After(until) {
+		return time.Time{}, time.Time{}, errors.New("--since must not be after --until")
+	}
+
+	return since, until, nil
+}
+
+// loadBoardHistory fetches the board's open lists and cards as they are now along with every action since the
+// given day which changed where a card is, and the state of every checklist item when checkItems is set
+func loadBoardHistory(client *trello.Client, board *trello.Board, since time.Time, checkItems bool) (*boardHistory, error) {
+	lists, err := board.Lists()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(lists, func(i, j int) bool {
+		return lists[i].Pos < lists[j].Pos
+	})
+
+	cards, err := board.Cards()
+	if err != nil {
+		return nil, err
+	}
+
+	history := &boardHistory{
+		lists:      newLists(lists),
+		cards:      map[string]string{},
+		checkItems: map[string]string{},
+	}
+
+	for _, card := range cards {
+		history.cards[card.Id] = card.IdList
+	}
+
+	filter := cardHistoryFilter
+	if checkItems {
+		checklists, err := board.Checklists()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, checklist := range checklists {
+			for _, item := range checklist.CheckItems {
+				history.checkItems[item.Id] = item.State
+			}
+		}
+
+		filter += "," + checkItemHistoryFilter
+	}
+
+	history.actions, err = getBoardActions(client, board, filter, since)
+	if err != nil {
+		return nil, err
+	}
+
+	// actions are replayed newest first, trello returns them in that order but a stable order is cheap to ensure
+	sort.SliceStable(history.actions, func(i, j int) bool {
+		return history.actions[i].Date > history.actions[j].Date
+	})
+
+	return history, nil
+}
+
+// getBoardActions pages through every action of the board matching the filter since the given time, newest first
+func getBoardActions(client *trello.Client, board *trello.Board, filter string, since time.Time) ([]boardAction, error) {
+	var actions []boardAction
+
+	before := ""
+	for {
+		query := url.Values{}
+		query.Set("filter", filter)
+		query.Set("since", since.Format(time.RFC3339))
+		query.Set("limit", strconv.Itoa(actionsPageSize))
+		if before != "" {
+			query.Set("before", before)
+		}
+
+		body, err := client.Get("/boards/" + board.Id + "/actions?" + query.Encode())
+		if err != nil {
+			return nil, err
+		}
+
+		var page []boardAction
+		err = json.Unmarshal(body, &page)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read the actions of board %s", board.Id)
+		}
+
+		actions = append(actions, page...)
+
+		if len(page) < actionsPageSize {
+			break
+		}
+		before = page[len(page)-1].Id
+	}
+
+	return actions, nil
+}
+
+// dailyCounts winds the board back from its current state to count the cards in each list at the end of every day
+// in the range, the counts are returned oldest day first
+func (h *boardHistory) dailyCounts(since, until time.Time) []dayCounts {
+	var days []dayCounts
+
+	next := 0
+	for day := until; !day.Before(since); day = day.AddDate(0, 0, -1) {
+		endOfDay := day.AddDate(0, 0, 1).Format(time.RFC3339)
+		for ; next < len(h.actions) && h.actions[next].Date >= endOfDay; next++ {
+			h.undo(h.actions[next])
+		}
+
+		counts := map[string]int{}
+		for _, list := range h.cards {
+			counts[list]++
+		}
+
+		incomplete := 0
+		for _, state := range h.checkItems {
+			if state == checkItemIncomplete {
+				incomplete++
+			}
+		}
+
+		days = append([]dayCounts{{date: day, counts: counts, incomplete: incomplete}}, days...)
+	}
+
+	return days
+}
+
+// undo reverts a single action, leaving the cards as they were immediately before it happened
+func (h *boardHistory) undo(action boardAction) {
+	cardId := action.Data.Card.Id
+
+	switch action.Type {
+	case "createCard", "copyCard", "convertToCardFromCheckItem", "moveCardToBoard":
+		delete(h.cards, cardId)
+	case "updateCheckItemStateOnCard":
+		itemId := action.Data.CheckItem.Id
+		if _, ok := h.checkItems[itemId]; !ok {
+			return
+		}
+
+		if action.Data.CheckItem.State == checkItemComplete {
+			h.checkItems[itemId] = checkItemIncomplete
+		} else {
+			h.checkItems[itemId] = checkItemComplete
+		}
+	case "deleteCard", "moveCardFromBoard":
+		h.cards[cardId] = action.listOf()
+	case "updateCard":
+		if action.Data.ListBefore != nil {
+			if _, ok := h.cards[cardId]; ok {
+				h.cards[cardId] = action.Data.ListBefore.Id
+			}
+			return
+		}
+
+		if action.Data.Old.Closed != nil {
+			if *action.Data.Old.Closed {
+				delete(h.cards, cardId)
+			} else {
+				h.cards[cardId] = action.listOf()
+			}
+		}
+	}
+}
+
+// printCountsTable writes a markdown table with a row per day and a column per list
+func printCountsTable(w io.Writer, lists []List, days []dayCounts) {
+	header := []string{tr("date")}
+	for _, list := range lists {
+		header = append(header, tableCell(list.Name))
+	}
+
+	fmt.Fprintf(w, "| %s |\n", strings.Join(header, " | "))
+	fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(header)))
+
+	for _, day := range days {
+		row := []string{day.date.Format(dateFormat)}
+		for _, list := range lists {
+			row = append(row, strconv.Itoa(day.counts[list.Id]))
+		}
+
+		fmt.Fprintf(w, "| %s |\n", strings.Join(row, " | "))
+	}
+
+	fmt.Fprintln(w)
+}
+
+func statsBurndown(c *cli.Context) error {
+	since, until, err := statsRange(c)
+	if err != nil {
+		return err
+	}
+
+	format := c.String("format")
+	if format != statsFormatMarkdown && format != statsFormatCSV {
+		return errors.Errorf("unsupported format %q, expected markdown or csv", format)
+	}
+
+	unit := c.String("unit")
+	if unit != burndownUnitCards && unit != burndownUnitCheckItems {
+		return errors.Errorf("unsupported unit %q, expected cards or checklist-items", unit)
+	}
+
+	doneLists := c.StringSlice("done-list")
+	if len(doneLists) == 0 {
+		doneLists = []string{"Done"}
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	clients := newBoardClients(c, cfg)
+	boards, err := getBoards(clients, c.StringSlice("board-id"))
+	if err != nil {
+		return err
+	}
+
+	var rows *csv.Writer
+	if format == statsFormatCSV {
+		rows = csv.NewWriter(os.Stdout)
+		err = rows.Write([]string{"date", "board", "remaining", "ideal"})
+		if err != nil {
+			return err
+		}
+	} else {
+		printDate(os.Stdout, time.Now())
+	}
+
+	for _, board := range *boards {
+		client, err := clients.forBoard(board.Id)
+		if err != nil {
+			return err
+		}
+
+		history, err := loadBoardHistory(client, &board, since, unit == burndownUnitCheckItems)
+		if err != nil {
+			return err
+		}
+
+		done := map[string]bool{}
+		for _, list := range history.lists {
+			if containsString(doneLists, list.Name) {
+				done[list.Id] = true
+			}
+		}
+
+		days := history.dailyCounts(since, until)
+
+		var remaining []int
+		for _, day := range days {
+			if unit == burndownUnitCheckItems {
+				remaining = append(remaining, day.incomplete)
+				continue
+			}
+
+			count := 0
+			for list, cards := range day.counts {
+				if !done[list] {
+					count += cards
+				}
+			}
+			remaining = append(remaining, count)
+		}
+
+		ideal := idealBurndown(remaining[0], len(days))
+
+		if format == statsFormatCSV {
+			for i, day := range days {
+				err = rows.Write([]string{day.date.Format(dateFormat), board.Name, strconv.Itoa(remaining[i]), strconv.FormatFloat(ideal[i], 'f', 1, 64)})
+				if err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		printBoard(os.Stdout, newBoard(board))
+		printBurndown(os.Stdout, newBoard(board), unit, days, remaining, ideal)
+	}
+
+	if rows != nil {
+		rows.Flush()
+		return rows.Error()
+	}
+
+	return nil
+}
+
+// idealBurndown is the straight line from the work remaining at the start of the window to nothing at its end
+func idealBurndown(start int, days int) []float64 {
+	ideal := make([]float64, days)
+	for i := range ideal {
+		if days == 1 {
+			break
+		}
+
+		ideal[i] = float64(start) - float64(start)*float64(i)/float64(days-1)
+	}
+
+	return ideal
+}
+
+// printBurndown writes the burndown as a markdown table followed by a mermaid line chart of the same data
+func printBurndown(w io.Writer, board Board, unit string, days []dayCounts, remaining []int, ideal []float64) {
+	fmt.Fprintf(w, "| %s | %s | %s |\n", tr("date"), tr("remaining"), tr("ideal"))
+	fmt.Fprintln(w, "| --- | --- | --- |")
+
+	var labels, remainingPoints, idealPoints []string
+	for i, day := range days {
+		fmt.Fprintf(w, "| %s | %d | %.1f |\n", day.date.Format(dateFormat), remaining[i], ideal[i])
+
+		labels = append(labels, strconv.Quote(day.date.Format("01-02")))
+		remainingPoints = append(remainingPoints, strconv.Itoa(remaining[i]))
+		idealPoints = append(idealPoints, strconv.FormatFloat(ideal[i], 'f', 1, 64))
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "```mermaid")
+	fmt.Fprintln(w, "xychart-beta")
+	fmt.Fprintf(w, "    title %s\n", strconv.Quote(tr("burndown", board.Name)))
+	fmt.Fprintf(w, "    x-axis [%s]\n", strings.Join(labels, ", "))
+	fmt.Fprintf(w, "    y-axis %s\n", strconv.Quote(tr("remainingUnit", tr(unit))))
+	fmt.Fprintf(w, "    line [%s]\n", strings.Join(remainingPoints, ", "))
+	fmt.Fprintf(w, "    line [%s]\n", strings.Join(idealPoints, ", "))
+	fmt.Fprintln(w, "```")
+	fmt.Fprintln(w)
+}
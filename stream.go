@@ -0,0 +1,100 @@
+package main
+
+import (
+	"io"
+
+	"github.com/urfave/cli"
+)
+
+// exportStream renders each card as soon as it's fetched rather than once every board has been, so the members,
+// checklists, comments and attachments of only one card are held at a time however long the lists are
+type exportStream struct {
+	out    *exportWriter
+	render func(w io.Writer, list List, number int, view *cardView) ([]byte, error)
+	// separator goes between the cards of a list, previous is the card rendered before the next one
+	separator string
+	previous  []byte
+	// loginLinks is the --login-links mode, applied card by card rather than to the whole export
+	loginLinks     string
+	imagesEmbedded bool
+
+	board *boardExport
+}
+
+// streamable is true when the export can be rendered as it's fetched: the cards keep their position in trello,
+// the boards are rendered in the order given and nothing rendered with the cards needs the rest of the export first
+func streamable(c *cli.Context, cfg *config, show showOptions) bool {
+	if c.String("sort") != cardSortPosition || len(c.StringSlice("from-trello-json")) > 0 {
+		return false
+	}
+
+	if c.String("board-order") != boardOrderGiven {
+		return false
+	}
+	for _, board := range cfg.Boards {
+		if board.Order != 0 {
+			return false
+		}
+	}
+
+	for _, name := range []string{"merge-boards", "sections", "verify", "due-calendar", "board-diagram", "show-custom-field-definitions", "show-reopened", "show-list-counts"} {
+		if c.Bool(name) {
+			return false
+		}
+	}
+
+	for _, name := range []string{"query", "json-output", "compare-snapshot", "story-points"} {
+		if c.String(name) != "" {
+			return false
+		}
+	}
+
+	return !show.Audit && c.String("link-style") != linkStyleWikilink && c.String("checklists") != checklistsAppendix
+}
+
+func (s *exportStream) startBoard(board *boardExport) error {
+	s.board = board
+	_, err := s.out.openBoard(board.board)
+
+	return err
+}
+
+func (s *exportStream) startList(list *listExport) error {
+	w, err := s.out.openList(s.board.board, list.list)
+	if err != nil {
+		return err
+	}
+
+	if s.board.headings {
+		printList(w, list, false)
+	}
+	s.previous = nil
+
+	return nil
+}
+
+// card renders the card to the list, it's counted on the list rather than kept
+func (s *exportStream) card(list *listExport, view *cardView) error {
+	w, err := s.out.openCard(s.board.board, list.list, view)
+	if err != nil {
+		return err
+	}
+
+	switch s.loginLinks {
+	case loginLinksAnnotate:
+		annotateCardLoginLinks(view, s.imagesEmbedded)
+	case loginLinksWarn:
+		if links := cardLoginLinks(view, s.imagesEmbedded); len(links) > 0 {
+			warnLoginLinks([]loginLinkCard{{view: view, links: links}})
+		}
+	}
+
+	if s.separator != "" && s.previous != nil {
+		printCardSeparator(w, s.previous, s.separator)
+	}
+
+	list.streamed++
+	s.previous, err = s.render(w, list.list, list.streamed, view)
+
+	return err
+}
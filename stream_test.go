@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestExportStreamed checks cards rendered as they're fetched come out as they do when the whole export is fetched
+// before it's rendered
+func TestExportStreamed(t *testing.T) {
+	server := replayFixtures(t)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "trello2md-stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	args := append([]string{appName, "--api-base-url", server.URL + "/1", "--key", "k", "--token", "t"}, fixtureExport...)
+	args = append(args, "--sort", "position", "--number-cards", "--card-separator", "***")
+
+	streamed := filepath.Join(dir, "streamed.md")
+	err = newApp().Run(append(args, "--output", streamed))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// writing the export as json as well needs every card fetched first
+	fetched := filepath.Join(dir, "fetched.md")
+	err = newApp().Run(append(args, "--output", fetched, "--json-output", filepath.Join(dir, "export.json")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadFile(streamed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadFile(fetched)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(withoutGenerationDate(got)) != string(withoutGenerationDate(want)) {
+		t.Errorf("the streamed export differs, got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	statsFormatJSON = "json"
+)
+
+var (
+	// agePercentiles are the card age percentiles reported by the stats summary
+	agePercentiles = []int{50, 90, 99}
+
+	statsSummaryArgs = []cli.Flag{
+		cli.StringSliceFlag{
+			Name:   "board-id",
+			Usage:  "the ids or config aliases of the boards to report on",
+			EnvVar: "BOARD_ID",
+		},
+		cli.StringFlag{
+			Name:   "format",
+			Usage:  "the format to write the summary in, markdown or json for a flat document of metrics",
+			EnvVar: "STATS_FORMAT",
+			Value:  statsFormatMarkdown,
+		},
+	}
+)
+
+// boardSummary counts the open cards of a board as they are now
+type boardSummary struct {
+	board Board
+	lists []List
+	// cards counts the cards in each list by list id
+	cards  map[string]int
+	labels map[string]int
+	// ages are the ages of every card in days, sorted
+	ages []float64
+	// limits are the WIP limits of the lists by list name
+	limits map[string]int
+}
+
+// statsSummary reports the number of cards in every list, how often each label is used and the age of cards
+func statsSummary(c *cli.Context) error {
+	format := c.String("format")
+	if format != statsFormatMarkdown && format != statsFormatJSON {
+		return errors.Errorf("unsupported format %q, expected markdown or json", format)
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	clients := newBoardClients(c, cfg)
+	boards, err := getBoards(clients, c.StringSlice("board-id"))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	var summaries []*boardSummary
+	for _, board := range *boards {
+		summary, err := summariseBoard(board, cfg.board(board.Id).WIPLimits, now)
+		if err != nil {
+			return err
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	if format == statsFormatJSON {
+		return printSummaryMetrics(os.Stdout, summaries, now)
+	}
+
+	printDate(os.Stdout, now)
+	for _, summary := range summaries {
+		printBoard(os.Stdout, summary.board)
+		summary.print(os.Stdout)
+	}
+
+	return nil
+}
+
+func summariseBoard(board trello.Board, limits map[string]int, now time.Time) (*boardSummary, error) {
+	lists, err := getLists(&board)
+	if err != nil {
+		return nil, err
+	}
+
+	cards, err := board.Cards()
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &boardSummary{
+		board:  newBoard(board),
+		lists:  newLists(lists),
+		cards:  map[string]int{},
+		labels: map[string]int{},
+		limits: limits,
+	}
+
+	for _, card := range cards {
+		summary.cards[card.IdList]++
+
+		for _, label := range card.Labels {
+			name := label.Name
+			if name == "" {
+				name = label.Color
+			}
+			summary.labels[name]++
+		}
+
+		created, err := cardCreated(&card)
+		if err != nil {
+			return nil, err
+		}
+		summary.ages = append(summary.ages, now.Sub(created).Hours()/24)
+	}
+
+	sort.Float64s(summary.ages)
+
+	return summary, nil
+}
+
+// percentile is the nearest rank percentile of the sorted values, zero when there are none
+func percentile(sorted []float64, p int) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(float64(p) / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+// sortedLabels names the labels most used first
+func (s *boardSummary) sortedLabels() []string {
+	var labels []string
+	for label := range s.labels {
+		labels = append(labels, label)
+	}
+
+	sort.Slice(labels, func(i, j int) bool {
+		if s.labels[labels[i]] != s.labels[labels[j]] {
+			return s.labels[labels[i]] > s.labels[labels[j]]
+		}
+
+		return collation.less(labels[i], labels[j])
+	})
+
+	return labels
+}
+
+func (s *boardSummary) print(w io.Writer) {
+	fmt.Fprintf(w, "| %s | %s |\n", tr("list"), tr("cardCount"))
+	fmt.Fprintln(w, "| --- | --- |")
+	for _, list := range s.lists {
+		count := strconv.Itoa(s.cards[list.Id])
+		if violation := wipViolation(s.cards[list.Id], s.limits[list.Name]); violation != "" {
+			count += " ⚠️ " + violation
+		}
+		fmt.Fprintf(w, "| %s | %s |\n", tableCell(list.Name), count)
+	}
+	fmt.Fprintln(w)
+
+	if labels := s.sortedLabels(); len(labels) > 0 {
+		fmt.Fprintf(w, "| %s | %s |\n", tr("label"), tr("cardCount"))
+		fmt.Fprintln(w, "| --- | --- |")
+		for _, label := range labels {
+			fmt.Fprintf(w, "| %s | %d |\n", tableCell(label), s.labels[label])
+		}
+		fmt.Fprintln(w)
+	}
+
+	var ages []string
+	for _, p := range agePercentiles {
+		ages = append(ages, fmt.Sprintf("p%d %s", p, strconv.FormatFloat(math.Round(percentile(s.ages, p)), 'f', -1, 64)))
+	}
+	fmt.Fprintf(w, "_%s_\n\n", tr("cardAge", strings.Join(ages, ", ")))
+}
+
+// printSummaryMetrics writes the summaries as a single flat json object, keys are dotted paths of slugified board,
+// list and label names so every value can be shipped as its own time series
+func printSummaryMetrics(w io.Writer, summaries []*boardSummary, now time.Time) error {
+	metrics := map[string]interface{}{
+		"timestamp": now.Unix(),
+		"boards":    len(summaries),
+	}
+
+	lists, cards := 0, 0
+	for _, summary := range summaries {
+		prefix := "board." + slugify(summary.board.Name)
+		lists += len(summary.lists)
+		cards += len(summary.ages)
+
+		metrics[prefix+".lists"] = len(summary.lists)
+		metrics[prefix+".cards"] = len(summary.ages)
+
+		for _, list := range summary.lists {
+			metrics[prefix+".list."+slugify(list.Name)+".cards"] = summary.cards[list.Id]
+
+			if limit, ok := summary.limits[list.Name]; ok {
+				metrics[prefix+".list."+slugify(list.Name)+".wip_limit"] = limit
+			}
+		}
+
+		for label, count := range summary.labels {
+			metrics[prefix+".label."+slugify(label)+".cards"] = count
+		}
+
+		for _, p := range agePercentiles {
+			metrics[fmt.Sprintf("%s.age_days.p%d", prefix, p)] = math.Round(percentile(summary.ages, p)*10) / 10
+		}
+	}
+
+	metrics["lists"] = lists
+	metrics["cards"] = cards
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(metrics)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	layoutCards    = "cards"
+	layoutTasklist = "tasklist"
+
+	// tasklistCardTemplate renders a card as a task, checked when it's in a done list, with its checklist items as
+	// sub-tasks
+	tasklistCardTemplate = `- [{{if .Done}}x{{else}} {{end}}] {{if .Number}}{{.Number}}. {{end}}{{if .Card.Url}}[{{.Card.Name}}]({{.Card.Url}}){{else}}{{.Card.Name}}{{end}}
+{{range .Checklists}}{{range .CheckItems}}  - [{{if eq .State "complete"}}x{{else}} {{end}}] {{.Name}}
+{{end}}{{end -}}
+`
+)
+
+// layoutCardTemplate returns the built in card template of a layout, a custom template replaces the card layout
+func layoutCardTemplate(layout string, customTemplate string) (string, error) {
+	switch layout {
+	case layoutCards:
+		return defaultCardTemplate, nil
+	case layoutTasklist:
+		if customTemplate != "" {
+			return "", errors.New("--layout tasklist can't be combined with --template")
+		}
+
+		return tasklistCardTemplate, nil
+	default:
+		return "", errors.Errorf("unsupported layout %q, expected cards or tasklist", layout)
+	}
+}
@@ -0,0 +1,783 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+)
+
+const (
+	cardTemplateName = "card"
+	partialExtension = ".tmpl"
+
+	commentStyleQuote  = "quote"
+	commentStyleBullet = "bullet"
+	commentStyleTable  = "table"
+	commentStylePlain  = "plain"
+
+	// defaultCardTemplate renders a single card, sections only appear when the matching show flag is set. the title,
+	// labels, description, attachments, checklist, dependencies and comment blocks can each be overridden by a partial
+	defaultCardTemplate = `{{/* card title, the date is the last activity on the card */ -}}
+{{block "title" .}}#### **{{date .Card.DateLastActivity}}** {{if .Number}}{{.Number}}. {{end}}{{if .Card.Url}}[{{.Title}}]({{.Card.Url}}){{else}}{{.Title}}{{end}}{{if .Merged}} _{{join .Boards ", "}}_{{end}}{{if .Points}} ` + "`{{tr \"points\" .PointsText}}`" + `{{end}}{{end}}
+{{if .TitleTruncated -}}
+**{{.Card.Name}}**
+
+{{end -}}
+{{if .Show.Completion}}{{with .Completion -}}
+_{{if .By}}{{tr "completedBy" (date .Date) .By}}{{else}}{{tr "completed" (date .Date)}}{{end}}_
+{{end}}{{end -}}
+{{with .Creator -}}
+_{{if .By}}{{tr "createdBy" (date .Date) .By}}{{else}}{{tr "created" (date .Date)}}{{end}}_
+{{end -}}
+{{if .Show.Age -}}
+_{{tr "daysInList" .DaysInList}}_
+{{end -}}
+{{if .Show.DataviewFields -}}
+{{with .Card.Due}}due:: {{date .}}
+{{end -}}
+{{with .MemberNames}}assignee:: {{join . ", "}}
+{{end -}}
+{{with .LabelNames}}labels:: {{join . ", "}}
+{{end -}}
+{{with .Card.Url}}trello:: {{.}}
+{{end}}
+{{end -}}
+{{if .Show.LabelsAndMembers -}}
+{{block "labels" .}}##### {{range .Card.Labels}}` + "`{{.Name}}`" + ` {{end}}- {{if .WikiLinks}}{{range $i, $m := .MemberNames}}{{if $i}}, {{end}}[[{{$m}}]]{{end}}{{else if .LinkMembers}}**{{join .MemberLinks ", "}}**{{else}}**[{{join .MemberNames ", "}}]**{{end}}{{end}}
+{{end -}}
+{{if .Stickers -}}
+{{range $i, $s := .Stickers}}{{if $i}} {{end}}![{{$s.Image}}]({{$s.ImageUrl}} "{{$s.Image}}"){{end}}
+
+{{end -}}
+{{if .Show.Description -}}
+{{block "description" . -}}
+{{if .CollapseDescription -}}
+<details>
+<summary>{{tr "description"}}</summary>
+
+{{end -}}
+{{.Card.Desc}}
+
+{{if .CollapseDescription -}}
+</details>
+
+{{end -}}
+{{end -}}
+{{end -}}
+{{with .Location -}}
+📍 {{if .Name}}**{{.Name}}**{{end}}{{if and .Name .Address}} - {{end}}{{.Address}}{{if .MapUrl}} ([{{tr "map"}}]({{.MapUrl}})){{end}}
+
+{{end -}}
+{{block "attachments" . -}}
+{{range .Attachments -}}
+[{{.Name}}]({{.Url}}){{if .RequiresLogin}} _({{tr "trelloLogin"}})_{{end}}
+![{{altText .Name $.Card.Name}}]({{.Url}})
+{{with $.Caption . }}
+_{{.}}_
+{{end}}
+{{end -}}
+{{end -}}
+{{range .Checklists -}}
+{{block "checklist" . -}}
+{{.Name}}
+{{range .CheckItems -}}
+- [{{if eq .State "complete"}}x{{else}} {{end}}] {{.Name}}
+{{end}}
+{{end -}}
+{{end -}}
+{{with .Dependencies -}}
+{{block "dependencies" . -}}
+**{{tr "dependsOn"}}**
+{{range . -}}
+- [{{.Name}}]({{.Url}}) - _{{.Status}}_
+{{end}}
+{{end -}}
+{{end -}}
+{{range $name, $value := .Extracted -}}
+- **{{$name}}:** {{$value}}
+{{end -}}
+{{range .PluginData -}}
+` + "`{{.IdPlugin}}`" + `
+` + "```json" + `
+{{.Value}}
+` + "```" + `
+
+{{end -}}
+{{with .FieldChanges -}}
+##### {{tr "fieldChanges"}}
+{{range . -}}
+- **{{date .Date}}** - {{.Member}}: {{.Text}}
+{{end}}
+{{end -}}
+{{if .CollapseComments -}}
+<details>
+<summary>{{tr "comments" (len .Comments)}}</summary>
+
+{{end -}}
+{{if eq .Show.CommentStyle "table" -}}
+{{with .Comments -}}
+| {{tr "date"}} | {{tr "author"}} | {{tr "commentText"}} |
+| --- | --- | --- |
+{{range . -}}
+| {{date .Date}} | {{tableCell .MemberCreator.FullName}} | {{tableCell .Data.Text}}{{range .Reactions}} {{.Emoji.Native}} {{.Count}}{{end}} |
+{{end}}
+{{end -}}
+{{else if eq .Show.CommentStyle "bullet" -}}
+{{with .Comments -}}
+{{range . -}}
+- **{{date .Date}}** {{.MemberCreator.FullName}}: {{indent 2 .Data.Text}}{{range .Reactions}} {{.Emoji.Native}} {{.Count}}{{end}}
+{{end}}
+{{end -}}
+{{else -}}
+{{range .Comments -}}
+{{if eq $.Show.CommentStyle "plain" -}}
+**{{.MemberCreator.FullName}}** - {{date .Date}}
+
+{{.Data.Text}}
+{{if .Reactions}}
+{{range $i, $r := .Reactions}}{{if $i}}  {{end}}{{$r.Emoji.Native}} {{$r.Count}}{{end}}
+{{end}}
+{{else -}}
+{{block "comment" . -}}
+> **{{date .Date}}** - **{{.MemberCreator.FullName}}:**
+> {{quote .Data.Text}}
+{{if .Reactions -}}
+>
+> {{range $i, $r := .Reactions}}{{if $i}}  {{end}}{{$r.Emoji.Native}} {{$r.Count}}{{end}}
+{{end}}
+{{end -}}
+{{end -}}
+{{end -}}
+{{end -}}
+{{if .CollapseComments -}}
+</details>
+
+{{end -}}
+`
+)
+
+var (
+	// labelColors maps trello label color names to the hex values trello renders them with
+	labelColors = map[string]string{
+		"green":  "#61bd4f",
+		"yellow": "#f2d600",
+		"orange": "#ff9f1a",
+		"red":    "#eb5a46",
+		"purple": "#c377e0",
+		"blue":   "#0079bf",
+		"sky":    "#00c2e0",
+		"lime":   "#51e898",
+		"pink":   "#ff78cb",
+		"black":  "#344563",
+	}
+
+	// altTextEscaper escapes the characters that would end image alt text early
+	altTextEscaper = strings.NewReplacer(
+		`\`, `\\`,
+		"[", `\[`,
+		"]", `\]`,
+	)
+
+	markdownEscaper = strings.NewReplacer(
+		`\`, `\\`,
+		"`", "\\`",
+		"*", `\*`,
+		"_", `\_`,
+		"{", `\{`,
+		"}", `\}`,
+		"[", `\[`,
+		"]", `\]`,
+		"(", `\(`,
+		")", `\)`,
+		"#", `\#`,
+		"+", `\+`,
+		"!", `\!`,
+		"|", `\|`,
+		"<", `\<`,
+		">", `\>`,
+	)
+)
+
+// cardView is the data handed to the card template, members, attachments, checklists and comments are only
+// fetched when the matching show flag is set
+type cardView struct {
+	Board       Board
+	Card        Card
+	Members     []Member
+	Attachments []Attachment
+	Checklists  []Checklist
+	Comments    []Comment
+	// Completion records when and by whom the card was completed, set when completion is shown
+	Completion *cardCompletion
+	// Creator records when and by whom the card was created, set when audit fields are shown
+	Creator *cardCreator
+	// ListEntered is when the card entered its current list, set when age is shown or stale cards are filtered
+	ListEntered time.Time
+	// Points is the card's story point estimate, nil unless story points are enabled and the card has an estimate
+	Points *float64
+	// Stickers are the stickers placed on the card, set when stickers are shown
+	Stickers []sticker
+	// Location is where the card is on the map power-up, set when locations are shown
+	Location *cardLocation
+	// PluginData is the data power-ups stored on the card, set when plugin data is shown
+	PluginData []pluginData
+	// CustomFields are the custom fields set on the card, set when custom fields are shown
+	CustomFields []customFieldValue
+	// Activity is everything done to the card other than comments, set when activity is shown
+	Activity []activityView
+	// FieldChanges are the changes to the tracked fields oldest first, set when fields are tracked
+	FieldChanges []fieldChange
+	// Cover is the attachment shown as the card's cover, set for digests
+	Cover *Attachment
+	// Dependencies are the cards linked from the card's checklist items, set when dependencies are shown
+	Dependencies []cardDependency
+	// Extracted holds the values plugin extractors found on the card, e.g. story points
+	Extracted map[string]string
+	// Fields holds custom values derived by a transform script
+	Fields map[string]interface{}
+	// Merged is set when cards of every board are rendered as one list
+	Merged bool
+	// Boards names the boards the card belongs to in a merged list, deduplicated cards list every board
+	Boards []string
+	// WikiLinks is set when links to exported cards and members are rendered as [[wiki links]]
+	WikiLinks bool
+	// LinkMembers is set when members are rendered as links to their trello profiles
+	LinkMembers bool
+	// Done is set when the card is in one of the done lists
+	Done bool
+	// Number is the card's position in its list counting from 1, set when cards are numbered
+	Number int
+	Show   showOptions
+}
+
+type showOptions struct {
+	LabelsAndMembers bool
+	Description      bool
+	Checklists       bool
+	Comments         bool
+	Reactions        bool
+	Completion       bool
+	Age              bool
+	PluginData       bool
+	Location         bool
+	Stickers         bool
+	Attachments      bool
+	DataviewFields   bool
+	Dependencies     bool
+	// Audit renders who created each card and who can see the board, for compliance archives
+	Audit bool
+	// CustomFields and Activity are only fetched for full fidelity exports
+	CustomFields bool
+	Activity     bool
+	// CompletionDates fetches when each card was completed without rendering it, month and quarter splits file cards
+	// by it
+	CompletionDates bool
+	// ImageCaptions renders a caption beneath each attachment's image
+	ImageCaptions bool
+	// CommentStyle is how comments are rendered, quote, bullet, table or plain
+	CommentStyle string
+	// TrackFields are the fields whose changes are rendered as a timeline under each card, due, labels, members,
+	// name or list
+	TrackFields []string
+	// CollapseDescription folds descriptions longer than this many lines into a details block, zero never folds
+	CollapseDescription int
+	// CollapseComments folds comment threads longer than this many comments into a details block, zero never folds
+	CollapseComments int
+	// TitleMaxLength shortens card names longer than this many characters in headings, zero never shortens them
+	TitleMaxLength int
+}
+
+// Title is the card's name as shown in its heading, shortened with an ellipsis when it's too long for one
+func (v cardView) Title() string {
+	if !v.TitleTruncated() {
+		return v.Card.Name
+	}
+
+	name := []rune(v.Card.Name)
+
+	return strings.TrimSpace(string(name[:v.Show.TitleMaxLength-1])) + "…"
+}
+
+// TitleTruncated reports whether the card's name is too long for its heading, the full name is then rendered
+// under the heading
+func (v cardView) TitleTruncated() bool {
+	return v.Show.TitleMaxLength > 0 && utf8.RuneCountInString(v.Card.Name) > v.Show.TitleMaxLength
+}
+
+// CollapseDescription reports whether the description is long enough to be folded away
+func (v cardView) CollapseDescription() bool {
+	return v.Show.CollapseDescription > 0 && strings.Count(strings.TrimSpace(v.Card.Desc), "\n")+1 > v.Show.CollapseDescription
+}
+
+// CollapseComments reports whether the comment thread is long enough to be folded away
+func (v cardView) CollapseComments() bool {
+	return v.Show.CollapseComments > 0 && len(v.Comments) > v.Show.CollapseComments
+}
+
+// PointsText formats the card's story points without trailing zeros
+func (v cardView) PointsText() string {
+	if v.Points == nil {
+		return ""
+	}
+
+	return strconv.FormatFloat(*v.Points, 'f', -1, 64)
+}
+
+// DaysInList is the number of whole days the card has been in its current list
+func (v cardView) DaysInList() int {
+	return int(time.Since(v.ListEntered).Hours() / 24)
+}
+
+// LabelNames names the card's labels, unnamed labels are named by their color
+func (v cardView) LabelNames() []string {
+	var labelNames []string
+	for _, label := range v.Card.Labels {
+		if label.Name == "" {
+			labelNames = append(labelNames, label.Color)
+			continue
+		}
+
+		labelNames = append(labelNames, label.Name)
+	}
+
+	return labelNames
+}
+
+// Summary is the first paragraph of the card's description
+func (v cardView) Summary() string {
+	return strings.TrimSpace(strings.SplitN(strings.TrimSpace(v.Card.Desc), "\n\n", 2)[0])
+}
+
+func (v cardView) MemberNames() []string {
+	var memberNames []string
+	for _, member := range v.Members {
+		memberNames = append(memberNames, member.FullName)
+	}
+
+	return memberNames
+}
+
+// MemberLinks returns markdown links to the profiles of the members on the card
+func (v cardView) MemberLinks() []string {
+	var memberLinks []string
+	for _, member := range v.Members {
+		url := member.Url
+		if url == "" {
+			url = "https://trello.com/" + member.Username
+		}
+
+		memberLinks = append(memberLinks, "["+member.FullName+"]("+url+")")
+	}
+
+	return memberLinks
+}
+
+func validateCommentStyle(style string) error {
+	switch style {
+	case commentStyleQuote, commentStyleBullet, commentStyleTable, commentStylePlain:
+		return nil
+	default:
+		return errors.Errorf("unsupported comment style %q, expected quote, bullet, table or plain", style)
+	}
+}
+
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"date":           formatDate,
+		"dateFormat":     formatDateLayout,
+		"join":           strings.Join,
+		"quote":          quote,
+		"indent":         indent,
+		"truncate":       truncate,
+		"slugify":        slugify,
+		"escapeMarkdown": markdownEscaper.Replace,
+		"tableCell":      tableCell,
+		"labelColor":     labelColor,
+		"altText":        altText,
+		"upper":          strings.ToUpper,
+		"lower":          strings.ToLower,
+		"trim":           strings.TrimSpace,
+		"tr":             tr,
+		"htmlToMarkdown": htmlToMarkdown,
+	}
+}
+
+// loadCardTemplate parses the template at path over defaultText, a template which only defines blocks such as
+// {{define "title"}} overrides just those blocks of the default and a complete template replaces it
+func loadCardTemplate(path string, defaultText string) (*template.Template, error) {
+	tmpl, err := template.New(cardTemplateName).Funcs(templateFuncs()).Parse(defaultText)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse template")
+	}
+
+	if path == "" {
+		return tmpl, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read template")
+	}
+
+	_, err = tmpl.Parse(string(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse template")
+	}
+
+	return tmpl, nil
+}
+
+// loadTemplatePartials overrides blocks of the template with the partials in dir, each is named after the block it
+// replaces e.g. title.tmpl, a single trailing newline is dropped so editors adding one don't add a blank line
+func loadTemplatePartials(tmpl *template.Template, dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+partialExtension))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), partialExtension)
+		if name == cardTemplateName || tmpl.Lookup(name) == nil {
+			blocks := templateBlocks(tmpl)
+			if len(blocks) == 0 {
+				return errors.Errorf("partial %s can't be used, the card template has no blocks to override", path)
+			}
+
+			return errors.Errorf("partial %s doesn't match a block of the card template, expected one of %s", path, strings.Join(blocks, ", "))
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrap(err, "unable to read partial")
+		}
+
+		_, err = tmpl.New(name).Parse(strings.TrimSuffix(string(data), "\n"))
+		if err != nil {
+			return errors.Wrapf(err, "unable to parse partial %s", path)
+		}
+	}
+
+	return nil
+}
+
+// templateBlocks names the blocks of the template which can be overridden
+func templateBlocks(tmpl *template.Template) []string {
+	var names []string
+	for _, block := range tmpl.Templates() {
+		if block.Name() != cardTemplateName {
+			names = append(names, block.Name())
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func newCardView(client *trello.Client, board trello.Board, card *trello.Card, show showOptions) (*cardView, error) {
+	view := &cardView{
+		Board: newBoard(board),
+		Card:  newCard(*card),
+		Show:  show,
+	}
+
+	if show.LabelsAndMembers || show.DataviewFields {
+		members, err := getCardMembers(client, card)
+		if err != nil {
+			return nil, err
+		}
+
+		view.Members = newMembers(members)
+	}
+
+	if show.Attachments {
+		attachments, err := getCardAttachments(client, card)
+		if err != nil {
+			return nil, err
+		}
+
+		view.Attachments = newAttachments(*attachments)
+		if show.ImageCaptions {
+			setUploaders(client, view.Attachments, view.Members)
+		}
+	}
+
+	if show.Checklists {
+		checklists, err := getCardCheckLists(client, card)
+		if err != nil {
+			return nil, err
+		}
+
+		view.Checklists = newChecklists(*checklists)
+	}
+
+	if show.Dependencies {
+		checklists := view.Checklists
+		if !show.Checklists {
+			fetched, err := getCardCheckLists(client, card)
+			if err != nil {
+				return nil, err
+			}
+
+			checklists = newChecklists(*fetched)
+		}
+
+		view.Dependencies = getCardDependencies(client, checklists)
+	}
+
+	if show.Completion || show.CompletionDates {
+		completion, err := getCardCompletion(client, card)
+		if err != nil {
+			return nil, err
+		}
+
+		view.Completion = completion
+	}
+
+	if show.Audit {
+		creator, err := getCardCreator(client, card)
+		if err != nil {
+			return nil, err
+		}
+
+		view.Creator = creator
+	}
+
+	if show.Age {
+		entered, err := getCardListEntered(client, card)
+		if err != nil {
+			return nil, err
+		}
+
+		view.ListEntered = entered
+	}
+
+	if show.Stickers {
+		stickers, err := getCardStickers(client, card)
+		err = optional(degradedStickers, err)
+		if err != nil {
+			return nil, err
+		}
+
+		view.Stickers = stickers
+	}
+
+	if show.Location {
+		location, err := getCardLocation(client, card)
+		if err != nil {
+			return nil, err
+		}
+
+		view.Location = location
+	}
+
+	if show.PluginData {
+		data, err := getCardPluginData(client, card)
+		err = optional(degradedPluginData, err)
+		if err != nil {
+			return nil, err
+		}
+
+		view.PluginData = data
+	}
+
+	if show.CustomFields {
+		fields, err := getCardCustomFields(client, card)
+		err = optional(degradedCustomFields, err)
+		if err != nil {
+			return nil, err
+		}
+
+		view.CustomFields = fields
+	}
+
+	if show.Activity {
+		activity, err := getCardActivity(client, card)
+		if err != nil {
+			return nil, err
+		}
+
+		view.Activity = activity
+	}
+
+	if len(show.TrackFields) > 0 {
+		changes, err := getCardFieldChanges(client, card, show.TrackFields)
+		if err != nil {
+			return nil, err
+		}
+
+		view.FieldChanges = changes
+	}
+
+	if show.Comments {
+		comments, err := getCardComments(client, card)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, comment := range *comments {
+			commentView := newComment(comment)
+			if show.Reactions {
+				reactions, err := getCommentReactions(client, &comment.Action)
+				err = optional(degradedReactions, err)
+				if err != nil {
+					return nil, err
+				}
+
+				commentView.Reactions = reactions
+			}
+
+			view.Comments = append(view.Comments, commentView)
+		}
+	}
+
+	return view, nil
+}
+
+func formatDate(date string) (string, error) {
+	// malformed dates were warned about when the card was fetched
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return "", nil
+	}
+
+	return t.Format(dateFormat), nil
+}
+
+// formatDateLayout formats an RFC3339 trello date with a go time layout, empty dates render as an empty string
+func formatDateLayout(layout string, date string) (string, error) {
+	if date == "" {
+		return "", nil
+	}
+
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return "", nil
+	}
+
+	return t.Format(layout), nil
+}
+
+// truncate shortens s to at most length runes, marking the cut with an ellipsis
+func truncate(length int, s string) string {
+	runes := []rune(s)
+	if len(runes) <= length {
+		return s
+	}
+
+	if length < 1 {
+		return ""
+	}
+
+	return string(runes[:length-1]) + "…"
+}
+
+// slugify lower cases s and replaces every run of characters which aren't letters or digits with a single dash
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+
+		if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// labelColor returns the hex value for a trello label color name, unknown colors are returned unchanged
+func labelColor(color string) string {
+	if hex, ok := labelColors[color]; ok {
+		return hex
+	}
+
+	return color
+}
+
+// altText describes an image attachment by its name and the card it's on, renderers hide images with empty alt text
+func altText(name string, cardName string) string {
+	text := cardName
+	if name != "" && name != cardName {
+		text = name + " - " + cardName
+	}
+
+	return altTextEscaper.Replace(strings.Join(strings.Fields(text), " "))
+}
+
+// quote continues text over the lines of a blockquote whose first line the template has already started. blank lines
+// keep the quote going so paragraphs and lists stay inside it, code blocks are quoted verbatim and a code block the
+// text leaves open is closed so it can't swallow what follows the quote
+func quote(text string) string {
+	lines := strings.Split(strings.TrimRight(strings.Replace(text, "\r\n", "\n", -1), "\n"), "\n")
+
+	var fence string
+	for i, line := range lines {
+		if marker := codeFence(line); marker != "" {
+			switch {
+			case fence == "":
+				fence = marker
+			case strings.HasPrefix(marker, fence) && strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), fence[:1])) == "":
+				fence = ""
+			}
+		}
+
+		if i == 0 {
+			continue
+		}
+
+		if line == "" {
+			lines[i] = ">"
+			continue
+		}
+
+		lines[i] = "> " + line
+	}
+
+	if fence != "" {
+		lines = append(lines, "> "+fence)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// codeFence returns the backticks or tildes opening or closing a fenced code block on the line, empty when the line
+// isn't a fence
+func codeFence(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return ""
+	}
+
+	for _, char := range []string{"`", "~"} {
+		marker := trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, char))]
+		if len(marker) >= 3 {
+			return marker
+		}
+	}
+
+	return ""
+}
+
+// indent indents every line of text after the first by n spaces, keeping multi-line text inside a list item
+func indent(n int, text string) string {
+	return strings.Replace(text, "\n", "\n"+strings.Repeat(" ", n), -1)
+}
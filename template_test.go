@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Fix login bug":       "fix-login-bug",
+		"  Release: 2024.05 ": "release-2024-05",
+		"Über größe":          "über-größe",
+		"a -- b":              "a-b",
+		"***":                 "",
+		"":                    "",
+		"Q3/Q4 plans!":        "q3-q4-plans",
+	}
+
+	for s, want := range tests {
+		if got := slugify(s); got != want {
+			t.Errorf("slugify(%q) = %q, want %q", s, got, want)
+		}
+	}
+}
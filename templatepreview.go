@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	// previewWatchInterval is how often the files of a watched preview are checked for changes
+	previewWatchInterval = 500 * time.Millisecond
+
+	// sampleCardViews is the bundled board data templates are previewed against, every section of the default
+	// template has something to render
+	sampleCardViews = `[
+  {
+    "Board": {"id": "sample", "name": "Sample Board", "url": "https://trello.com/b/sample/sample-board"},
+    "Card": {
+      "id": "card1",
+      "name": "Ship the login page",
+      "shortLink": "card1",
+      "url": "https://trello.com/c/card1/ship-the-login-page",
+      "dateLastActivity": "2024-04-08T10:00:00.000Z",
+      "due": "2024-04-12T17:00:00.000Z",
+      "desc": "Rolled out the **new** login page to everyone.",
+      "idMembers": ["member1"],
+      "labels": [{"name": "feature", "color": "green"}, {"name": "frontend", "color": "blue"}]
+    },
+    "Members": [{"id": "member1", "fullName": "Jane Doe", "username": "jane"}],
+    "Attachments": [{"name": "screenshot.png", "url": "https://example.com/screenshot.png"}],
+    "Checklists": [
+      {"name": "Rollout", "checkItems": [{"name": "Feature flag", "state": "complete"}, {"name": "Announce", "state": "incomplete"}]}
+    ],
+    "Comments": [
+      {
+        "date": "2024-04-08T09:00:00.000Z",
+        "memberCreator": {"fullName": "John Smith"},
+        "data": {"text": "Looks good\nship it"},
+        "Reactions": [{"count": 2, "emoji": {"native": "👍", "shortName": "+1"}}]
+      }
+    ],
+    "Completion": {"Date": "2024-04-08T10:00:00.000Z", "By": "Jane Doe"},
+    "Stickers": [{"id": "sticker1", "image": "check", "imageUrl": "https://trello.com/stickers/check.png"}]
+  },
+  {
+    "Board": {"id": "sample", "name": "Sample Board", "url": "https://trello.com/b/sample/sample-board"},
+    "Card": {
+      "id": "card2",
+      "name": "Fix the flaky export test",
+      "shortLink": "card2",
+      "url": "https://trello.com/c/card2/fix-the-flaky-export-test",
+      "dateLastActivity": "2024-04-07T10:00:00.000Z",
+      "desc": "Retries were hiding a race, see https://trello.com/c/card1",
+      "labels": [{"name": "bug", "color": "red"}]
+    }
+  }
+]`
+)
+
+var (
+	templatePreviewArgs = []cli.Flag{
+		cli.StringFlag{
+			Name:   "template",
+			Usage:  "the card template to preview, the default template is used when unset",
+			EnvVar: "TRELLO2MD_PREVIEW_TEMPLATE",
+		},
+		cli.StringFlag{
+			Name:   "partials-dir",
+			Usage:  "a directory of partials overriding single blocks of the card template",
+			EnvVar: "TRELLO2MD_PREVIEW_PARTIALS_DIR",
+		},
+		cli.StringFlag{
+			Name:  "snapshot",
+			Usage: "render a saved json list of cards, or a --state-file checkpoint, instead of the bundled sample board",
+		},
+		cli.StringFlag{
+			Name:   "output",
+			Usage:  "the file to write the preview to instead of stdout, e.g. one open in a markdown viewer while watching",
+			EnvVar: "TRELLO2MD_PREVIEW_OUTPUT",
+		},
+		cli.BoolFlag{
+			Name:  "watch",
+			Usage: "keep running and render the preview again whenever the template, a partial or the snapshot changes on disk",
+		},
+	}
+)
+
+// templatePreview renders a card template against sample or saved data without calling the trello api
+func templatePreview(c *cli.Context) error {
+	if !c.Bool("watch") {
+		return writePreview(c)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	rendered := ""
+	for {
+		stamp := previewStamp(c)
+		if stamp != rendered {
+			rendered = stamp
+
+			err := writePreview(c)
+			if err != nil {
+				// a template saved half edited is reported and rendered again once it's saved, rather than ending the watch
+				log.Printf("unable to render the preview: %v", err)
+			} else {
+				log.Printf("rendered the preview")
+			}
+		}
+
+		select {
+		case sig := <-signals:
+			log.Printf("received %s, stopping", sig)
+			return nil
+		case <-time.After(previewWatchInterval):
+		}
+	}
+}
+
+// previewStamp describes the size and modification time of every file the preview is rendered from, it changes
+// whenever one of them is saved
+func previewStamp(c *cli.Context) string {
+	paths := []string{c.String("template"), c.String("snapshot")}
+	if c.String("partials-dir") != "" {
+		partials, _ := filepath.Glob(filepath.Join(c.String("partials-dir"), "*"+partialExtension))
+		paths = append(paths, partials...)
+	}
+
+	var stamp strings.Builder
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Fprintf(&stamp, "%s missing\n", path)
+			continue
+		}
+
+		fmt.Fprintf(&stamp, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+	}
+
+	return stamp.String()
+}
+
+// writePreview renders the preview to --output, or stdout when unset
+func writePreview(c *cli.Context) error {
+	var preview bytes.Buffer
+	err := renderPreview(c, &preview)
+	if err != nil {
+		return err
+	}
+
+	if c.String("output") != "" {
+		return writeFileIfChanged(c.String("output"), preview.Bytes())
+	}
+
+	_, err = os.Stdout.Write(preview.Bytes())
+	return err
+}
+
+// renderPreview renders every card of the snapshot, or of the bundled sample, with the template
+func renderPreview(c *cli.Context, w io.Writer) error {
+	tmpl, err := loadCardTemplate(c.String("template"), defaultCardTemplate)
+	if err != nil {
+		return err
+	}
+
+	err = loadTemplatePartials(tmpl, c.String("partials-dir"))
+	if err != nil {
+		return err
+	}
+
+	views, err := loadSnapshot(c.String("snapshot"))
+	if err != nil {
+		return err
+	}
+
+	// sample data shows every section so all of the template is exercised
+	show := showOptions{
+		LabelsAndMembers: true,
+		Description:      true,
+		Checklists:       true,
+		Comments:         true,
+		Reactions:        true,
+		Completion:       true,
+		Attachments:      true,
+		Stickers:         true,
+	}
+
+	printDate(w, time.Now())
+
+	boardId := ""
+	for _, view := range views {
+		if c.String("snapshot") == "" {
+			view.Show = show
+		}
+
+		if view.Board.Id != boardId {
+			printBoard(w, view.Board)
+			boardId = view.Board.Id
+		}
+
+		err = tmpl.Execute(w, view)
+		if err != nil {
+			return errors.Wrapf(err, "unable to render %s", view.Card.Name)
+		}
+	}
+
+	return nil
+}
+
+// loadSnapshot reads the card views to preview, the bundled sample is used when no path is given
+func loadSnapshot(path string) ([]*cardView, error) {
+	data := []byte(sampleCardViews)
+	if path != "" {
+		var err error
+		data, err = ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read snapshot")
+		}
+	}
+
+	var views []*cardView
+	err := json.Unmarshal(data, &views)
+	if err == nil {
+		return views, nil
+	}
+
+	var checkpoint exportCheckpoint
+	if json.Unmarshal(data, &checkpoint) != nil {
+		return nil, errors.Wrap(err, "unable to parse snapshot")
+	}
+
+	for _, view := range checkpoint.Cards {
+		views = append(views, view)
+	}
+
+	// checkpoints hold cards keyed by id, order them by board and then as trello would
+	sort.SliceStable(views, func(i, j int) bool {
+		if views[i].Board.Name != views[j].Board.Name {
+			return views[i].Board.Name < views[j].Board.Name
+		}
+
+		return views[i].Card.Pos < views[j].Card.Pos
+	})
+
+	return views, nil
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	ansiAltScreen      = "\x1b[?1049h"
+	ansiMainScreen     = "\x1b[?1049l"
+	ansiHideCursor     = "\x1b[?25l"
+	ansiShowCursor     = "\x1b[?25h"
+	ansiReverse        = "\x1b[7m"
+	ansiClearScreen    = "\x1b[H\x1b[2J"
+	ansiClearToLineEnd = "\x1b[K"
+
+	keyUp       = "up"
+	keyDown     = "down"
+	keyLeft     = "left"
+	keyRight    = "right"
+	keyPageUp   = "pgup"
+	keyPageDown = "pgdn"
+	keyEnter    = "enter"
+	keyBack     = "backspace"
+	keySpace    = "space"
+
+	// defaultTerminalRows and defaultTerminalColumns are used when stty can't tell the size of the terminal
+	defaultTerminalRows    = 24
+	defaultTerminalColumns = 80
+)
+
+// terminal is the controlling terminal switched to raw mode, so keys are read as they're pressed, with stty rather
+// than a terminal library
+type terminal struct {
+	tty   *os.File
+	keys  *bufio.Reader
+	saved string
+}
+
+// openTerminal switches the controlling terminal to raw mode and the alternate screen, close restores it
+func openTerminal() (*terminal, error) {
+	if _, err := exec.LookPath("stty"); err != nil {
+		return nil, errors.New("browse needs a terminal which can be configured with stty")
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "browse needs a terminal")
+	}
+
+	t := &terminal{tty: tty, keys: bufio.NewReader(tty)}
+	t.saved, err = t.stty("-g")
+	if err != nil {
+		tty.Close()
+		return nil, err
+	}
+
+	_, err = t.stty("raw", "-echo")
+	if err != nil {
+		tty.Close()
+		return nil, err
+	}
+
+	_, err = tty.WriteString(ansiAltScreen + ansiHideCursor)
+
+	return t, err
+}
+
+// close restores the terminal to how it was before it was opened
+func (t *terminal) close() error {
+	_, _ = t.tty.WriteString(ansiShowCursor + ansiMainScreen)
+	_, err := t.stty(t.saved)
+	if closeErr := t.tty.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+func (t *terminal) stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = t.tty
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to run stty %s", strings.Join(args, " "))
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// size returns the rows and columns of the terminal, it's read before every redraw so resizes are picked up
+func (t *terminal) size() (int, int) {
+	out, err := t.stty("size")
+	if err != nil {
+		return defaultTerminalRows, defaultTerminalColumns
+	}
+
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return defaultTerminalRows, defaultTerminalColumns
+	}
+
+	rows, rowsErr := strconv.Atoi(fields[0])
+	columns, columnsErr := strconv.Atoi(fields[1])
+	if rowsErr != nil || columnsErr != nil || rows <= 0 || columns <= 0 {
+		return defaultTerminalRows, defaultTerminalColumns
+	}
+
+	return rows, columns
+}
+
+// readKey returns the next key pressed, the named keys are returned as their key constant and any other as the
+// character typed
+func (t *terminal) readKey() (string, error) {
+	r, _, err := t.keys.ReadRune()
+	if err != nil {
+		return "", err
+	}
+
+	switch r {
+	case '\r', '\n':
+		return keyEnter, nil
+	case 127, '\b':
+		return keyBack, nil
+	case ' ':
+		return keySpace, nil
+	case 3:
+		// ctrl-c doesn't interrupt in raw mode
+		return "q", nil
+	case 0x1b:
+		return t.readEscape()
+	}
+
+	return string(r), nil
+}
+
+// readEscape reads the rest of an escape sequence, the arrow and page keys send csi sequences
+func (t *terminal) readEscape() (string, error) {
+	if t.keys.Buffered() == 0 {
+		return "", nil
+	}
+
+	r, _, err := t.keys.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return "", err
+	}
+
+	r, _, err = t.keys.ReadRune()
+	if err != nil {
+		return "", err
+	}
+
+	switch r {
+	case 'A':
+		return keyUp, nil
+	case 'B':
+		return keyDown, nil
+	case 'C':
+		return keyRight, nil
+	case 'D':
+		return keyLeft, nil
+	case '5', '6':
+		// page up and down end with a tilde
+		_, _, err = t.keys.ReadRune()
+		if r == '5' {
+			return keyPageUp, err
+		}
+
+		return keyPageDown, err
+	}
+
+	return "", nil
+}
+
+// readLine reads a line of text with the terminal back in cooked mode so it's echoed and can be edited
+func (t *terminal) readLine(prompt string) (string, error) {
+	_, err := t.stty(t.saved)
+	if err != nil {
+		return "", err
+	}
+
+	_, _ = t.tty.WriteString(ansiShowCursor + prompt)
+	line, readErr := t.keys.ReadString('\n')
+
+	_, _ = t.tty.WriteString(ansiHideCursor)
+	_, err = t.stty("raw", "-echo")
+	if readErr != nil {
+		return "", readErr
+	}
+
+	return strings.TrimSpace(line), err
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	// asciiPunctuation replaces the typographic punctuation word processors substitute as text is typed, which
+	// turns up in cards pasted from them
+	asciiPunctuation = strings.NewReplacer(
+		"‘", "'", "’", "'", "‚", "'", "‛", "'",
+		"“", `"`, "”", `"`, "„", `"`, "‟", `"`,
+		"′", "'", "″", `"`,
+		"–", "-", "—", "--", "―", "--", "−", "-",
+		"…", "...",
+		"\u00a0", " ", "\u2009", " ", "\u202f", " ",
+	)
+)
+
+// cleanText normalizes text to NFC so the same characters always render the same bytes, drops control characters
+// other than tabs and line breaks, and replaces typographic punctuation with ascii when ascii is set
+func cleanText(text string, ascii bool) string {
+	text = strings.Replace(text, "\r\n", "\n", -1)
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+
+		return r
+	}, norm.NFC.String(text))
+
+	if ascii {
+		text = asciiPunctuation.Replace(text)
+	}
+
+	return text
+}
+
+// cleanCardText cleans the text of the card and everything rendered with it
+func cleanCardText(view *cardView, ascii bool) {
+	view.Card.Name = cleanText(view.Card.Name, ascii)
+	view.Card.Desc = cleanText(view.Card.Desc, ascii)
+
+	for i := range view.Card.Labels {
+		view.Card.Labels[i].Name = cleanText(view.Card.Labels[i].Name, ascii)
+	}
+
+	for i := range view.Attachments {
+		view.Attachments[i].Name = cleanText(view.Attachments[i].Name, ascii)
+	}
+
+	for i := range view.Checklists {
+		checklist := &view.Checklists[i]
+		checklist.Name = cleanText(checklist.Name, ascii)
+		for j := range checklist.CheckItems {
+			checklist.CheckItems[j].Name = cleanText(checklist.CheckItems[j].Name, ascii)
+		}
+	}
+
+	for i := range view.Comments {
+		view.Comments[i].Data.Text = cleanText(view.Comments[i].Data.Text, ascii)
+	}
+}
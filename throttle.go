@@ -0,0 +1,154 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+// throttlePreset bundles settings that keep an export within trello's rate limits, 100 requests every 10 seconds
+// for each token and 300 for each key
+type throttlePreset struct {
+	// concurrency is the number of boards fetched in parallel unless --concurrency is given
+	concurrency int
+	// interval is the least time between the start of two requests across every client of the process
+	interval time.Duration
+	// retries is how many times a rate limited or failed request is retried
+	retries int
+	// backoff is the wait before the first retry, doubled for each retry after it
+	backoff time.Duration
+}
+
+var (
+	throttlePresets = map[string]throttlePreset{
+		"conservative": {concurrency: 1, interval: 200 * time.Millisecond, retries: 5, backoff: 2 * time.Second},
+		"normal":       {concurrency: 4, interval: 100 * time.Millisecond, retries: 3, backoff: time.Second},
+		"aggressive":   {concurrency: 8, interval: 35 * time.Millisecond, retries: 2, backoff: 500 * time.Millisecond},
+	}
+
+	// throttle is the preset chosen with --throttle, requests are neither paced nor retried without one
+	throttle *throttlePreset
+)
+
+func setThrottle(name string) error {
+	if name == "" {
+		return nil
+	}
+
+	preset, ok := throttlePresets[name]
+	if !ok {
+		return errors.Errorf("unsupported throttle %q, expected conservative, normal or aggressive", name)
+	}
+	throttle = &preset
+
+	return nil
+}
+
+// boardConcurrency is the number of boards to fetch in parallel, an explicit --concurrency wins over the preset
+func boardConcurrency(c *cli.Context) int {
+	if throttle != nil && !c.IsSet("concurrency") {
+		return throttle.concurrency
+	}
+
+	return c.Int("concurrency")
+}
+
+// requestPacer spaces out the start of requests, it's shared so the pace holds however many boards are fetched in
+// parallel
+type requestPacer struct {
+	mu   sync.Mutex
+	next time.Time
+}
+
+var pacer = &requestPacer{}
+
+func (p *requestPacer) wait(interval time.Duration) {
+	p.mu.Lock()
+	now := time.Now()
+	start := p.next
+	if start.Before(now) {
+		start = now
+	}
+	p.next = start.Add(interval)
+	p.mu.Unlock()
+
+	time.Sleep(start.Sub(now))
+}
+
+// throttleTransport paces requests and retries those trello rate limited or failed on its side, a request with a
+// body is only retried when it can be sent again
+type throttleTransport struct {
+	delegate http.RoundTripper
+}
+
+func (t *throttleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	preset := throttle
+	if preset == nil {
+		return t.delegate.RoundTrip(req)
+	}
+
+	backoff := preset.backoff
+	for attempt := 0; ; attempt++ {
+		pacer.wait(preset.interval)
+
+		resp, err := t.delegate.RoundTrip(req)
+		if attempt >= preset.retries || !retryable(req, resp, err) {
+			return resp, err
+		}
+
+		if !retryBudget.take() {
+			log.Printf("not retrying %s %s, the retry budget is spent", req.Method, req.URL.Path)
+			return resp, err
+		}
+
+		wait := backoff
+		if resp != nil {
+			if after, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && after > 0 {
+				wait = time.Duration(after) * time.Second
+			}
+			resp.Body.Close()
+		}
+		log.Printf("retrying %s %s in %s", req.Method, req.URL.Path, wait)
+
+		if req.Body != nil {
+			if req.GetBody == nil {
+				return nil, errors.Errorf("unable to retry %s %s", req.Method, req.URL.Path)
+			}
+
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		backoff *= 2
+	}
+}
+
+// retryable reports whether a request is worth sending again, rate limited requests were never acted on so are
+// always retried, other failures only for requests that change nothing
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		if _, ok := err.(*circuitBreakerError); ok {
+			return false
+		}
+
+		return req.Method == http.MethodGet && req.Context().Err() == nil
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+
+	return req.Method == http.MethodGet && resp.StatusCode >= http.StatusInternalServerError
+}
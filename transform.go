@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"go.starlark.net/starlark"
+)
+
+const (
+	transformFunction = "transform"
+)
+
+// cardTransformer runs a starlark script's transform(card) function against every card before it is rendered,
+// the function returns the (possibly modified) card dict or None to drop the card from the export
+type cardTransformer struct {
+	path string
+	fn   starlark.Callable
+}
+
+func loadTransformer(path string) (*cardTransformer, error) {
+	thread := &starlark.Thread{Name: "load"}
+
+	globals, err := starlark.ExecFile(thread, path, nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load transform script")
+	}
+
+	// frozen globals can be shared by the threads transforming cards of different boards concurrently
+	globals.Freeze()
+
+	fn, ok := globals[transformFunction].(starlark.Callable)
+	if !ok {
+		return nil, errors.Errorf("%s does not define a %s(card) function", path, transformFunction)
+	}
+
+	return &cardTransformer{
+		path: path,
+		fn:   fn,
+	}, nil
+}
+
+// transform passes the card to the script and applies the result back onto the view, false is returned when
+// the script dropped the card
+func (t *cardTransformer) transform(view *cardView) (bool, error) {
+	card, err := toStarlark(cardToMap(view))
+	if err != nil {
+		return false, err
+	}
+
+	thread := &starlark.Thread{Name: "transform"}
+
+	result, err := starlark.Call(thread, t.fn, starlark.Tuple{card}, nil)
+	if err != nil {
+		return false, errors.Wrapf(err, "transform failed for card %s", view.Card.Id)
+	}
+
+	if result == starlark.None {
+		return false, nil
+	}
+
+	transformed, ok := fromStarlark(result).(map[string]interface{})
+	if !ok {
+		return false, errors.Errorf("transform must return a dict or None, got %s", result.Type())
+	}
+
+	mapToCard(transformed, view)
+
+	return true, nil
+}
+
+func cardToMap(view *cardView) map[string]interface{} {
+	var labels []interface{}
+	for _, label := range view.Card.Labels {
+		labels = append(labels, map[string]interface{}{
+			"name":  label.Name,
+			"color": label.Color,
+		})
+	}
+
+	var members []interface{}
+	for _, member := range view.Members {
+		members = append(members, member.FullName)
+	}
+
+	var comments []interface{}
+	for _, comment := range view.Comments {
+		comments = append(comments, map[string]interface{}{
+			"author": comment.MemberCreator.FullName,
+			"date":   comment.Date,
+			"text":   comment.Data.Text,
+		})
+	}
+
+	fields := map[string]interface{}{}
+	for key, value := range view.Fields {
+		fields[key] = value
+	}
+
+	return map[string]interface{}{
+		"id":                 view.Card.Id,
+		"name":               view.Card.Name,
+		"desc":               view.Card.Desc,
+		"url":                view.Card.Url,
+		"short_url":          view.Card.ShortUrl,
+		"due":                view.Card.Due,
+		"date_last_activity": view.Card.DateLastActivity,
+		"labels":             labels,
+		"members":            members,
+		"comments":           comments,
+		"fields":             fields,
+	}
+}
+
+func mapToCard(card map[string]interface{}, view *cardView) {
+	getString := func(key string) string {
+		s, _ := card[key].(string)
+		return s
+	}
+
+	view.Card.Name = getString("name")
+	view.Card.Desc = getString("desc")
+	view.Card.Url = getString("url")
+	view.Card.ShortUrl = getString("short_url")
+	view.Card.Due = getString("due")
+	view.Card.DateLastActivity = getString("date_last_activity")
+
+	labels, _ := card["labels"].([]interface{})
+	view.Card.Labels = nil
+	for _, l := range labels {
+		label, _ := l.(map[string]interface{})
+		name, _ := label["name"].(string)
+		color, _ := label["color"].(string)
+		view.Card.Labels = append(view.Card.Labels, Label{Color: color, Name: name})
+	}
+
+	members, _ := card["members"].([]interface{})
+	view.Members = nil
+	for _, m := range members {
+		view.Members = append(view.Members, Member{FullName: fmt.Sprint(m)})
+	}
+
+	comments, _ := card["comments"].([]interface{})
+	var transformedComments []Comment
+	for i, c := range comments {
+		comment, _ := c.(map[string]interface{})
+
+		var action Comment
+		if i < len(view.Comments) {
+			action.Reactions = view.Comments[i].Reactions
+		}
+		action.Type = commentCardAction
+		action.Date, _ = comment["date"].(string)
+		action.MemberCreator.FullName, _ = comment["author"].(string)
+		action.Data.Text, _ = comment["text"].(string)
+		transformedComments = append(transformedComments, action)
+	}
+	view.Comments = transformedComments
+
+	fields, _ := card["fields"].(map[string]interface{})
+	view.Fields = fields
+}
+
+func toStarlark(v interface{}) (starlark.Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case string:
+		return starlark.String(v), nil
+	case bool:
+		return starlark.Bool(v), nil
+	case int:
+		return starlark.MakeInt(v), nil
+	case int64:
+		return starlark.MakeInt64(v), nil
+	case float64:
+		return starlark.Float(v), nil
+	case []interface{}:
+		var values []starlark.Value
+		for _, e := range v {
+			value, err := toStarlark(e)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, value)
+		}
+
+		return starlark.NewList(values), nil
+	case map[string]interface{}:
+		var keys []string
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		dict := starlark.NewDict(len(v))
+		for _, key := range keys {
+			value, err := toStarlark(v[key])
+			if err != nil {
+				return nil, err
+			}
+
+			err = dict.SetKey(starlark.String(key), value)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return dict, nil
+	default:
+		return nil, errors.Errorf("unable to convert %T to starlark", v)
+	}
+}
+
+func fromStarlark(v starlark.Value) interface{} {
+	switch v := v.(type) {
+	case starlark.NoneType:
+		return nil
+	case starlark.String:
+		return string(v)
+	case starlark.Bool:
+		return bool(v)
+	case starlark.Int:
+		if i, ok := v.Int64(); ok {
+			return i
+		}
+		return v.String()
+	case starlark.Float:
+		return float64(v)
+	case *starlark.List:
+		var values []interface{}
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, fromStarlark(v.Index(i)))
+		}
+		return values
+	case starlark.Tuple:
+		var values []interface{}
+		for _, e := range v {
+			values = append(values, fromStarlark(e))
+		}
+		return values
+	case *starlark.Dict:
+		values := map[string]interface{}{}
+		for _, item := range v.Items() {
+			key, ok := starlark.AsString(item[0])
+			if !ok {
+				key = item[0].String()
+			}
+			values[key] = fromStarlark(item[1])
+		}
+		return values
+	default:
+		return v.String()
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var (
+	// trelloJSONExcludedFlags need data trello leaves out of its json export, they can only be used with the api
+	trelloJSONExcludedFlags = []string{"show-reactions", "show-stickers", "show-location", "show-plugin-data", "board-diagram", "verify", "skip-missing-boards", "show-reopened"}
+)
+
+// trelloJSONBoard is the json written by the Export as JSON item of trello's board menu, the board along with
+// everything on it and its latest actions
+type trelloJSONBoard struct {
+	trello.Board
+	Lists      []trello.List      `json:"lists"`
+	Cards      []trelloJSONCard   `json:"cards"`
+	Checklists []trello.Checklist `json:"checklists"`
+	Members    []trello.Member    `json:"members"`
+	Actions    json.RawMessage    `json:"actions"`
+	// IdEnterprise and EnterpriseOwned are the audit fields the board client leaves out
+	IdEnterprise    string `json:"idEnterprise"`
+	EnterpriseOwned bool   `json:"enterpriseOwned"`
+
+	comments []cardComment
+	updates  []cardUpdateAction
+	activity []cardAction
+}
+
+// trelloJSONCard is a card of a json export, which inlines its attachments
+type trelloJSONCard struct {
+	trello.Card
+	IsTemplate  bool                `json:"isTemplate"`
+	Attachments []trello.Attachment `json:"attachments"`
+}
+
+func validateTrelloJSON(c *cli.Context, opts fetchOptions) error {
+	if len(c.StringSlice("board-id")) > 0 {
+		return errors.New("--from-trello-json reads the boards from the files and can't be combined with --board-id")
+	}
+
+	for _, name := range trelloJSONExcludedFlags {
+		if c.Bool(name) {
+			return errors.Errorf("--from-trello-json can't be combined with --%s, trello's json export doesn't include what it needs", name)
+		}
+	}
+
+	if c.String("track-fields") != "" {
+		return errors.New("--from-trello-json can't be combined with --track-fields, trello's json export only includes the board's latest actions")
+	}
+
+	if c.String("fidelity") == fidelityFull {
+		return errors.New("--from-trello-json can't be combined with --fidelity full, trello's json export doesn't include custom fields")
+	}
+
+	if opts.StoryPoints != nil && opts.StoryPoints.fieldName != "" {
+		return errors.New("--from-trello-json reads story points from card titles only, trello's json export doesn't include custom fields")
+	}
+
+	return nil
+}
+
+// loadTrelloJSONExports builds the exports of the boards in trello json export files without calling the api
+func loadTrelloJSONExports(paths []string, opts fetchOptions) ([]*boardExport, error) {
+	var exports []*boardExport
+	for _, path := range paths {
+		board, err := readTrelloJSONBoard(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if (opts.AllowedBoards != nil && !containsString(opts.AllowedBoards, board.Id)) || !opts.Manifest.keepsBoard(board.Id) {
+			continue
+		}
+
+		export, err := board.export(opts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to export %s", path)
+		}
+		opts.Progress.board(board.Name, len(paths))
+
+		exports = append(exports, export)
+	}
+
+	return exports, nil
+}
+
+func readTrelloJSONBoard(path string) (*trelloJSONBoard, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read trello json export")
+	}
+
+	var board trelloJSONBoard
+	err = json.Unmarshal(data, &board)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse trello json export %s", path)
+	}
+
+	return &board, nil
+}
+
+// export renders the board as fetchBoard would from the api, comments and history are limited to the actions
+// trello included in the file
+func (b *trelloJSONBoard) export(opts fetchOptions) (*boardExport, error) {
+	err := json.Unmarshal(b.Actions, &b.comments)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read actions")
+	}
+
+	err = json.Unmarshal(b.Actions, &b.updates)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read actions")
+	}
+
+	err = json.Unmarshal(b.Actions, &b.activity)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read actions")
+	}
+
+	var open []trello.List
+	for _, list := range b.Lists {
+		if !list.Closed {
+			open = append(open, list)
+		}
+	}
+	sort.SliceStable(open, func(i, j int) bool {
+		return open[i].Pos < open[j].Pos
+	})
+
+	lists, err := selectLists(b.Name, open, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateCardSort(opts.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &boardExport{
+		board:    newBoard(b.Board),
+		headings: opts.AllLists || len(lists) > 1,
+	}
+
+	if opts.Show.Audit {
+		export.audit = &boardAudit{
+			Visibility:      b.Prefs.PermissionLevel,
+			IdEnterprise:    b.IdEnterprise,
+			EnterpriseOwned: b.EnterpriseOwned,
+		}
+	}
+
+	for _, list := range lists {
+		listExport := &listExport{list: newList(list)}
+		for i := range b.Cards {
+			card := &b.Cards[i]
+			if card.Closed || card.IdList != list.Id || (card.IsTemplate && !opts.IncludeTemplates) || !opts.Manifest.keepsCard(&card.Card) {
+				continue
+			}
+
+			view, err := b.cardView(card, opts)
+			if err != nil {
+				return nil, err
+			}
+
+			view, err = finishCardView(view, opts)
+			if err != nil {
+				return nil, err
+			}
+
+			if view != nil {
+				listExport.cards = append(listExport.cards, view)
+			}
+		}
+
+		sort.SliceStable(listExport.cards, func(i, j int) bool {
+			return cardLess(&listExport.cards[i].Card, &listExport.cards[j].Card, opts.Sort)
+		})
+
+		export.lists = append(export.lists, listExport)
+	}
+
+	return export, nil
+}
+
+// cardView builds the view of a card from the file as newCardView would from the api
+func (b *trelloJSONBoard) cardView(card *trelloJSONCard, opts fetchOptions) (*cardView, error) {
+	show := opts.Show
+	view := &cardView{
+		Board: newBoard(b.Board),
+		Card:  newCard(card.Card),
+		Show:  show,
+	}
+
+	if show.LabelsAndMembers || show.DataviewFields || (opts.Filter != nil && opts.Filter.members) {
+		var members []trello.Member
+		for _, member := range b.Members {
+			if containsString(card.IdMembers, member.Id) {
+				members = append(members, member)
+			}
+		}
+
+		view.Members = newMembers(members)
+	}
+
+	if show.Attachments {
+		view.Attachments = newAttachments(card.Attachments)
+		if show.ImageCaptions {
+			setUploaders(nil, view.Attachments, newMembers(b.Members))
+		}
+	}
+
+	if show.Checklists {
+		var checklists []trello.Checklist
+		for _, checklist := range b.Checklists {
+			if checklist.IdCard == card.Id {
+				sort.SliceStable(checklist.CheckItems, func(i, j int) bool {
+					return checklist.CheckItems[i].Pos < checklist.CheckItems[j].Pos
+				})
+				checklists = append(checklists, checklist)
+			}
+		}
+		sort.SliceStable(checklists, func(i, j int) bool {
+			return checklists[i].Pos < checklists[j].Pos
+		})
+
+		view.Checklists = newChecklists(checklists)
+	}
+
+	var updates []cardUpdateAction
+	for _, action := range b.updates {
+		if action.Data.Card.Id == card.Id {
+			updates = append(updates, action)
+		}
+	}
+
+	if show.Completion || show.CompletionDates {
+		view.Completion = cardCompletionOf(updates)
+	}
+
+	if show.Audit {
+		view.Creator = cardCreatorOf(&card.Card, updates)
+	}
+
+	if show.Age || opts.StaleAfter > 0 {
+		entered, err := cardListEntered(&card.Card, updates)
+		if err != nil {
+			return nil, err
+		}
+
+		view.ListEntered = entered
+	}
+
+	if show.Comments {
+		var comments []cardComment
+		for _, action := range b.comments {
+			if action.Type == commentCardAction && action.Data.Card.Id == card.Id {
+				comments = append(comments, action)
+			}
+		}
+		sortCommentsByDate(comments)
+
+		for _, comment := range comments {
+			view.Comments = append(view.Comments, newComment(comment))
+		}
+	}
+
+	if opts.ConvertHTML {
+		view.Card.Desc = htmlToMarkdown(view.Card.Desc)
+	}
+
+	if opts.HumanActivity != nil {
+		var activity []cardAction
+		for _, action := range b.activity {
+			if action.Data.Card.Id == card.Id {
+				activity = append(activity, action)
+			}
+		}
+
+		date, err := opts.HumanActivity.lastHumanActivity(activity)
+		if err != nil {
+			return nil, err
+		}
+
+		if date != "" {
+			view.Card.DateLastActivity = date
+		}
+	}
+
+	if opts.StoryPoints != nil {
+		points, err := opts.StoryPoints.points(nil, &card.Card)
+		if err != nil {
+			return nil, err
+		}
+
+		view.Points = points
+	}
+
+	return view, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jakekeeys/go-trello"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var (
+	// verifyExcludedFlags leave cards out of the export on purpose so its counts can't be checked against trello's
+	verifyExcludedFlags = []string{"filter", "stale-after", "transform", "merge-boards"}
+)
+
+func validateVerify(c *cli.Context) error {
+	if !c.Bool("verify") {
+		return nil
+	}
+
+	for _, name := range verifyExcludedFlags {
+		if c.IsSet(name) {
+			return errors.Errorf("--verify compares every card of the exported lists and can't be combined with --%s", name)
+		}
+	}
+
+	return nil
+}
+
+// verifyExports cross checks the export against counts fetched from trello separately, the number of cards in
+// every list and of comments on every card, and fails listing every mismatch
+func verifyExports(clients *boardClients, exports []*boardExport, show showOptions) error {
+	var mismatches []string
+	for _, boardExport := range exports {
+		client, err := clients.forBoard(boardExport.board.Id)
+		if err != nil {
+			return err
+		}
+
+		for _, listExport := range boardExport.lists {
+			body, err := client.Get("/lists/" + listExport.list.Id + "/cards?fields=id")
+			if err != nil {
+				return errors.Wrapf(err, "unable to count the cards of %s", listExport.list.Name)
+			}
+
+			var cards []trello.Card
+			err = json.Unmarshal(body, &cards)
+			if err != nil {
+				return errors.Wrapf(err, "unable to count the cards of %s", listExport.list.Name)
+			}
+
+			if len(cards) != len(listExport.cards) {
+				mismatches = append(mismatches, fmt.Sprintf("%s/%s has %d cards but %d were exported", boardExport.board.Name, listExport.list.Name, len(cards), len(listExport.cards)))
+			}
+
+			if !show.Comments {
+				continue
+			}
+
+			for _, view := range listExport.cards {
+				if view.Card.Badges.Comments != len(view.Comments) {
+					mismatches = append(mismatches, fmt.Sprintf("%s has %d comments but %d were exported", view.Card.Name, view.Card.Badges.Comments, len(view.Comments)))
+				}
+			}
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return errors.Errorf("the export doesn't match trello:\n%s", strings.Join(mismatches, "\n"))
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+)
+
+const (
+	unknownVersion = "dev"
+)
+
+var (
+	// buildDate and commit can be set at build time with -ldflags "-X main.buildDate=... -X main.commit=..."
+	buildDate string
+	commit    string
+)
+
+// versionInfo describes the build of trello2md for --version, serve's /version endpoint and the export footer
+type versionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"build_date,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// buildVersion reads the version from the revision set at build time, falling back to the module version embedded
+// by the go toolchain when trello2md was installed with go install
+func buildVersion() versionInfo {
+	info := versionInfo{
+		Version:   revision,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok && info.Version == "" && build.Main.Version != "(devel)" {
+		info.Version = build.Main.Version
+	}
+
+	if info.Version == "" {
+		info.Version = unknownVersion
+	}
+
+	return info
+}
+
+// short is the version along with the abbreviated commit
+func (v versionInfo) short() string {
+	if len(v.Commit) < 7 {
+		return v.Version
+	}
+
+	return fmt.Sprintf("%s (%s)", v.Version, v.Commit[:7])
+}
+
+func (v versionInfo) print(w io.Writer) {
+	fmt.Fprintf(w, "%s %s\n", appName, v.Version)
+	if v.Commit != "" {
+		fmt.Fprintf(w, "commit: %s\n", v.Commit)
+	}
+	if v.BuildDate != "" {
+		fmt.Fprintf(w, "built: %s\n", v.BuildDate)
+	}
+	fmt.Fprintf(w, "go: %s\n", v.GoVersion)
+}
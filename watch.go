@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var (
+	watchArgs = []cli.Flag{
+		cli.DurationFlag{
+			Name:   "interval",
+			Usage:  "how often the boards are checked for new cards",
+			EnvVar: "TRELLO2MD_WATCH_INTERVAL",
+			Value:  5 * time.Minute,
+		},
+		cli.StringFlag{
+			Name:   "slack-webhook-url",
+			Usage:  "the slack incoming webhook new cards are posted to, they are printed when unset",
+			EnvVar: "TRELLO2MD_SLACK_WEBHOOK_URL",
+		},
+		cli.StringFlag{
+			Name:   "seen-file",
+			Usage:  "a file recording the cards already seen so a restarted watch only posts cards which arrived since",
+			EnvVar: "TRELLO2MD_SEEN_FILE",
+		},
+	}
+)
+
+// cardWatcher remembers the cards seen in the watched lists so only new arrivals are announced
+type cardWatcher struct {
+	seen map[string]bool
+	path string
+}
+
+func loadCardWatcher(path string) (*cardWatcher, bool, error) {
+	w := &cardWatcher{seen: map[string]bool{}, path: path}
+	if path == "" {
+		return w, false, nil
+	}
+
+	data, err := readOutput(path)
+	if os.IsNotExist(err) {
+		return w, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrap(err, "unable to read seen file")
+	}
+
+	var ids []string
+	err = json.Unmarshal(data, &ids)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "unable to parse seen file")
+	}
+
+	for _, id := range ids {
+		w.seen[id] = true
+	}
+
+	return w, true, nil
+}
+
+// diff returns the boards with only the cards not seen before, the cards now in the lists replace those seen so a
+// card which leaves and comes back is announced again
+func (w *cardWatcher) diff(exports []*boardExport) []*boardExport {
+	seen := map[string]bool{}
+	var changed []*boardExport
+	for _, current := range exports {
+		added := &boardExport{board: current.board}
+		for _, list := range current.lists {
+			var cards []*cardView
+			for _, view := range list.cards {
+				seen[view.Card.Id] = true
+				if !w.seen[view.Card.Id] {
+					cards = append(cards, view)
+				}
+			}
+
+			if len(cards) > 0 {
+				added.lists = append(added.lists, &listExport{list: list.list, cards: cards})
+			}
+		}
+
+		if len(added.lists) > 0 {
+			changed = append(changed, added)
+		}
+	}
+	w.seen = seen
+
+	return changed
+}
+
+func (w *cardWatcher) save() error {
+	if w.path == "" {
+		return nil
+	}
+
+	var ids []string
+	for id := range w.seen {
+		ids = append(ids, id)
+	}
+
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+
+	return writeStateFile(w.path, data)
+}
+
+// watch polls the boards and announces the cards which entered the exported lists since the last check, the
+// first check only records the cards already there unless a seen file from a previous run is loaded
+func watch(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	err = applyProfile(c, cfg)
+	if err != nil {
+		return err
+	}
+
+	var filter *cardFilter
+	if c.String("filter") != "" {
+		filter, err = newCardFilter(c.String("filter"))
+		if err != nil {
+			return err
+		}
+	}
+
+	watcher, seeded, err := loadCardWatcher(c.String("seen-file"))
+	if err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	for {
+		exports, err := fetchExports(c, cfg, fetchOptions{
+			ListFilters:      listFilters(c),
+			AllLists:         c.Bool("all-lists"),
+			Sort:             c.String("sort"),
+			IncludeTemplates: c.Bool("include-templates"),
+			Filter:           filter,
+		})
+		if err != nil {
+			// a failed check is retried at the next interval rather than ending the watch
+			log.Printf("unable to check boards: %v", err)
+		} else {
+			added := watcher.diff(exports)
+			if seeded && len(added) > 0 {
+				err = announceCards(c, added)
+				if err != nil {
+					log.Printf("unable to announce new cards: %v", err)
+				}
+			}
+			seeded = true
+
+			err = watcher.save()
+			if err != nil {
+				return err
+			}
+		}
+
+		select {
+		case sig := <-signals:
+			log.Printf("received %s, stopping", sig)
+			return nil
+		case <-time.After(c.Duration("interval")):
+		}
+	}
+}
+
+// announceCards posts the new cards to slack as a short message, or prints the message without a webhook
+func announceCards(c *cli.Context, exports []*boardExport) error {
+	var message strings.Builder
+	for i, boardExport := range exports {
+		if i > 0 {
+			message.WriteString("\n")
+		}
+
+		for _, listExport := range boardExport.lists {
+			fmt.Fprintf(&message, "*%s* - %s\n", slackEscape(boardExport.board.Name), slackEscape(listExport.list.Name))
+			for _, view := range listExport.cards {
+				fmt.Fprintf(&message, "• <%s|%s>\n", view.Card.Url, slackEscape(view.Card.Name))
+			}
+		}
+	}
+
+	if c.String("slack-webhook-url") == "" {
+		fmt.Print(message.String())
+		return nil
+	}
+
+	return postToSlack(c.String("slack-webhook-url"), message.String())
+}
+
+// postToSlack posts the message to a slack incoming webhook
+func postToSlack(webhookURL string, message string) error {
+	body, err := json.Marshal(map[string]string{"text": message})
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("slack responded with %s", resp.Status)
+	}
+
+	return nil
+}
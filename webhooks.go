@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var (
+	webhooksCreateArgs = []cli.Flag{
+		cli.StringFlag{
+			Name:   "board-id",
+			Usage:  "the id or config alias of the board to watch",
+			EnvVar: "TRELLO2MD_WEBHOOK_BOARD_ID",
+		},
+		cli.StringFlag{
+			Name:  "callback-url",
+			Usage: "the url trello posts board changes to, it must answer a HEAD request when the webhook is created",
+		},
+		cli.StringFlag{
+			Name:  "description",
+			Usage: "a description to recognise the webhook by",
+			Value: appName,
+		},
+	}
+
+	webhooksDeleteArgs = []cli.Flag{
+		cli.BoolFlag{
+			Name:  "inactive",
+			Usage: "delete every webhook trello has deactivated after its callback kept failing",
+		},
+	}
+)
+
+// webhook is a trello webhook registered by the token
+type webhook struct {
+	Id          string `json:"id"`
+	Description string `json:"description"`
+	IdModel     string `json:"idModel"`
+	CallbackURL string `json:"callbackURL"`
+	Active      bool   `json:"active"`
+}
+
+func getWebhooks(c *cli.Context) ([]webhook, error) {
+	client, err := newClient(c)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := client.Get("/tokens/" + c.GlobalString("token") + "/webhooks")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list webhooks")
+	}
+
+	var webhooks []webhook
+	err = json.Unmarshal(body, &webhooks)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list webhooks")
+	}
+
+	return webhooks, nil
+}
+
+func listWebhooks(c *cli.Context) error {
+	webhooks, err := getWebhooks(c)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range webhooks {
+		status := "active"
+		if !w.Active {
+			status = "inactive"
+		}
+
+		fmt.Printf("%s - %s - %s - %s - %s\n", w.Id, w.IdModel, w.CallbackURL, status, w.Description)
+	}
+
+	return nil
+}
+
+func createWebhook(c *cli.Context) error {
+	if c.String("board-id") == "" || c.String("callback-url") == "" {
+		return errors.New("--board-id and --callback-url are required")
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	body, err := client.Post("/webhooks", url.Values{
+		"idModel":     {cfg.boardID(c.String("board-id"))},
+		"callbackURL": {c.String("callback-url")},
+		"description": {c.String("description")},
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to create webhook")
+	}
+
+	var created webhook
+	err = json.Unmarshal(body, &created)
+	if err != nil {
+		return errors.Wrap(err, "unable to create webhook")
+	}
+
+	fmt.Println(created.Id)
+
+	return nil
+}
+
+func deleteWebhooks(c *cli.Context) error {
+	ids := []string(c.Args())
+	if c.Bool("inactive") {
+		webhooks, err := getWebhooks(c)
+		if err != nil {
+			return err
+		}
+
+		for _, w := range webhooks {
+			if !w.Active {
+				ids = append(ids, w.Id)
+			}
+		}
+	} else if len(ids) == 0 {
+		return errors.New("webhook ids or --inactive are required")
+	}
+
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		_, err = client.Delete("/webhooks/" + id)
+		if err != nil {
+			return errors.Wrapf(err, "unable to delete webhook %s", id)
+		}
+
+		log.Printf("deleted webhook %s", id)
+	}
+
+	return nil
+}
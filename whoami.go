@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var (
+	whoamiArgs = []cli.Flag{
+		cli.StringFlag{
+			Name:  "credentials",
+			Usage: "check the named credentials entry of the config instead of the global key and token",
+		},
+	}
+)
+
+// whoamiMember is the member the token acts as
+type whoamiMember struct {
+	Id       string `json:"id"`
+	FullName string `json:"fullName"`
+	Username string `json:"username"`
+}
+
+// whoamiWorkspace is a workspace the member belongs to
+type whoamiWorkspace struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// whoami prints who the credentials authenticate as, when the token expires, what it was granted access to, the
+// workspaces it can see and how much of the rate limit is left, so credentials can be checked without an export
+func whoami(c *cli.Context) error {
+	creds := globalCredentials(c)
+	name := c.String("credentials")
+	if name != "" {
+		cfg, err := loadConfig(c)
+		if err != nil {
+			return err
+		}
+
+		var ok bool
+		creds, ok = cfg.Credentials[name]
+		if !ok {
+			return errors.Errorf("the config has no credentials named %q", name)
+		}
+	}
+
+	if creds.Token == "" {
+		return errors.New("--token is required, create one at " + appKeyURL)
+	}
+
+	client, err := newClientWithCredentials(c, creds)
+	if err != nil {
+		return err
+	}
+
+	var member whoamiMember
+	err = getJSON(client, "/tokens/"+creds.Token+"/member?fields=fullName,username", &member)
+	if err != nil {
+		return tokenError(name, err)
+	}
+
+	token, err := getTokenInfo(client, creds.Token)
+	if err != nil {
+		return tokenError(name, err)
+	}
+
+	var workspaces []whoamiWorkspace
+	err = getJSON(client, "/members/me/organizations?fields=name,displayName", &workspaces)
+	if err != nil {
+		return errors.Wrap(err, "unable to list the workspaces")
+	}
+
+	fmt.Printf("member: %s (@%s) %s\n", member.FullName, member.Username, member.Id)
+	fmt.Printf("token expires: %s\n", tokenExpiry(token.DateExpires))
+	fmt.Printf("token scopes: %s\n", tokenScopes(token))
+	for _, permission := range token.Permissions {
+		fmt.Printf("  - %s: %s\n", permissionModel(permission, workspaces), permissionAccess(permission))
+	}
+
+	fmt.Printf("workspaces: %d\n", len(workspaces))
+	for _, workspace := range workspaces {
+		fmt.Printf("  - %s (%s) %s\n", workspace.DisplayName, workspace.Name, workspace.Id)
+	}
+
+	limit, ok := metrics.latestRateLimit()
+	if !ok {
+		fmt.Println("rate limit: unknown, trello didn't report it")
+		return nil
+	}
+
+	fmt.Printf("rate limit: %s left for the token, %s left for the api key\n",
+		rateLimitHeadroom(limit.TokenRemaining, limit.TokenMax, limit.TokenInterval),
+		rateLimitHeadroom(limit.KeyRemaining, limit.KeyMax, limit.KeyInterval))
+
+	return nil
+}
+
+func tokenExpiry(dateExpires string) string {
+	if dateExpires == "" {
+		return "never"
+	}
+
+	expires, err := time.Parse(time.RFC3339, dateExpires)
+	if err != nil {
+		return dateExpires
+	}
+
+	if expires.Before(time.Now()) {
+		return fmt.Sprintf("%s, it has expired, create a new one at %s", expires.Format(dateFormat), appKeyURL)
+	}
+
+	return expires.Format(dateFormat)
+}
+
+// tokenScopes names the scopes the token was granted, read and write, from the access its permissions give
+func tokenScopes(token *tokenInfo) string {
+	var read, write bool
+	for _, permission := range token.Permissions {
+		read = read || permission.Read
+		write = write || permission.Write
+	}
+
+	var scopes []string
+	if read {
+		scopes = append(scopes, "read")
+	}
+	if write {
+		scopes = append(scopes, "write")
+	}
+	if len(scopes) == 0 {
+		return "none"
+	}
+
+	return strings.Join(scopes, ", ")
+}
+
+// permissionModel names what a permission applies to, workspaces by their display name when the member belongs to
+// them
+func permissionModel(permission tokenPermission, workspaces []whoamiWorkspace) string {
+	model := strings.ToLower(permission.ModelType)
+	if permission.ModelType == "Organization" {
+		model = "workspace"
+		for _, workspace := range workspaces {
+			if workspace.Id == permission.IdModel {
+				return model + " " + workspace.DisplayName
+			}
+		}
+	}
+
+	if permission.IdModel == "*" {
+		return "every " + model
+	}
+
+	return model + " " + permission.IdModel
+}
+
+func permissionAccess(permission tokenPermission) string {
+	switch {
+	case permission.Read && permission.Write:
+		return "read and write"
+	case permission.Read:
+		return "read"
+	case permission.Write:
+		return "write"
+	default:
+		return "none"
+	}
+}
+
+// rateLimitHeadroom describes the requests left of a rate limit, e.g. 95 of 100 requests per 10s
+func rateLimitHeadroom(remaining int, max int, interval time.Duration) string {
+	if remaining < 0 {
+		return "unknown"
+	}
+
+	headroom := fmt.Sprintf("%d", remaining)
+	if max >= 0 {
+		headroom += fmt.Sprintf(" of %d", max)
+	}
+	headroom += " requests"
+	if interval > 0 {
+		headroom += " per " + interval.String()
+	}
+
+	return headroom
+}